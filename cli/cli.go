@@ -2,12 +2,16 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"database/sql"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/proullon/ramsql/engine/agnostic"
+	"github.com/proullon/ramsql/engine/executor"
 	"github.com/proullon/ramsql/engine/log"
 )
 
@@ -63,6 +67,61 @@ func query(db *sql.DB, query string) {
 	}
 }
 
+// generate fills a relation with random rows
+//
+//	GENERATE table rows [seed]
+func generate(db *sql.DB, stmt string) {
+	fields := strings.Fields(stmt)
+	if len(fields) < 3 || len(fields) > 4 {
+		fmt.Printf("ERROR : usage : GENERATE table rows [seed]\n")
+		return
+	}
+
+	var schema string
+	relation := fields[1]
+	if s, r, ok := strings.Cut(relation, "."); ok {
+		schema, relation = s, r
+	}
+
+	n, err := strconv.Atoi(fields[2])
+	if err != nil {
+		fmt.Printf("ERROR : invalid number of rows : %s\n", err)
+		return
+	}
+
+	var opts agnostic.GenerateOptions
+	if len(fields) == 4 {
+		opts.Seed, err = strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			fmt.Printf("ERROR : invalid seed : %s\n", err)
+			return
+		}
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		fmt.Printf("ERROR : cannot get connection : %s\n", err)
+		return
+	}
+	defer conn.Close()
+
+	var rowsAffected int64
+	err = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(interface{ Engine() *executor.Engine })
+		if !ok {
+			return fmt.Errorf("driver does not support generation")
+		}
+		rowsAffected, err = c.Engine().Generate(schema, relation, n, opts)
+		return err
+	})
+	if err != nil {
+		fmt.Printf("ERROR : cannot generate : %s\n", err)
+		return
+	}
+
+	fmt.Printf("Query OK. %d rows affected\n", rowsAffected)
+}
+
 func prettyPrintHeader(row []string) {
 	var line string
 
@@ -130,6 +189,8 @@ func Run(db *sql.DB) {
 			query(db, stmt)
 		} else if strings.HasPrefix(stmt, "DESCRIBE") {
 			query(db, stmt)
+		} else if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "GENERATE") {
+			generate(db, stmt)
 		} else {
 			exec(db, stmt)
 		}
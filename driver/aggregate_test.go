@@ -0,0 +1,345 @@
+package ramsql
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestAggregate")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, name TEXT, country TEXT)`,
+		`CREATE TABLE payments (id BIGSERIAL PRIMARY KEY, user_id BIGINT, amount INT, fee NUMERIC(10,2), rate FLOAT, method TEXT)`,
+		`INSERT INTO account (name, country) VALUES ('alice', 'fr')`,
+		`INSERT INTO account (name, country) VALUES ('bob', 'fr')`,
+		`INSERT INTO account (name, country) VALUES ('carol', 'uk')`,
+		`INSERT INTO payments (user_id, amount, fee, rate, method) VALUES (1, 80, 1.50, 0.5, 'card')`,
+		`INSERT INTO payments (user_id, amount, fee, rate, method) VALUES (1, 40, 2.25, 1.5, 'cash')`,
+		`INSERT INTO payments (user_id, amount, fee, rate, method) VALUES (2, 30, NULL, NULL, 'card')`,
+		`INSERT INTO payments (user_id, amount, fee, rate, method) VALUES (2, NULL, 0.75, 2.5, 'card')`,
+		`INSERT INTO payments (user_id, amount, fee, rate, method) VALUES (3, 200, 3.00, 1, 'cash')`,
+		`INSERT INTO payments (user_id, amount, fee, rate, method) VALUES (4, NULL, NULL, NULL, NULL)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	valueTests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT SUM(amount) FROM payments`, "350"},
+		{`SELECT COUNT(*) FROM payments`, "6"},
+		{`SELECT COUNT(amount) FROM payments`, "4"},
+		{`SELECT MIN(amount) FROM payments`, "30"},
+		{`SELECT MAX(amount) FROM payments WHERE user_id < 3`, "80"},
+		{`SELECT AVG(amount) FROM payments`, "87.5000000000000000"},
+		{`SELECT SUM(fee) FROM payments`, "7.50"},
+		{`SELECT AVG(fee) FROM payments WHERE user_id = 1`, "1.8750000000000000"},
+		{`SELECT AVG(rate) FROM payments`, "1.375"},
+		{`SELECT MAX(method) FROM payments`, "cash"},
+		{`SELECT SUM(amount) * 2 + COUNT(*) FROM payments WHERE user_id = 1`, "242"},
+		{`SELECT SUM(amount) FROM payments WHERE user_id = $1`, "30"},
+	}
+	for _, tc := range valueTests {
+		var s string
+		var err error
+		if strings.Contains(tc.query, "$1") {
+			err = db.QueryRow(tc.query, 2).Scan(&s)
+		} else {
+			err = db.QueryRow(tc.query).Scan(&s)
+		}
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if s != tc.expected {
+			t.Fatalf("expected '%s' with '%s', got '%s'", tc.expected, tc.query, s)
+		}
+	}
+
+	// aggregates of no value are NULL, except COUNT
+	var null sql.NullInt64
+	err = db.QueryRow(`SELECT SUM(amount) FROM payments WHERE user_id = 4`).Scan(&null)
+	if err != nil {
+		t.Fatalf("cannot sum NULL values: %s", err)
+	}
+	if null.Valid {
+		t.Fatalf("expected NULL sum, got %d", null.Int64)
+	}
+	var sum, avg, max sql.NullString
+	var count int64
+	err = db.QueryRow(`SELECT SUM(amount), AVG(amount), MAX(amount), COUNT(*) FROM payments WHERE user_id > 100`).Scan(&sum, &avg, &max, &count)
+	if err != nil {
+		t.Fatalf("cannot aggregate no row: %s", err)
+	}
+	if sum.Valid || avg.Valid || max.Valid || count != 0 {
+		t.Fatalf("expected NULL aggregates and no row counted, got %v, %v, %v, %d", sum, avg, max, count)
+	}
+
+	type group struct {
+		key   string
+		value string
+	}
+	groupTests := []struct {
+		query    string
+		expected []group
+	}{
+		{
+			`SELECT user_id, SUM(amount) FROM payments GROUP BY user_id HAVING SUM(amount) > 100 ORDER BY user_id`,
+			[]group{{"1", "120"}, {"3", "200"}},
+		},
+		{
+			`SELECT user_id, COUNT(*) FROM payments GROUP BY user_id HAVING COUNT(*) > 1 AND MIN(fee) < 1 ORDER BY user_id`,
+			[]group{{"2", "2"}},
+		},
+		{
+			`SELECT method, COUNT(amount) FROM payments WHERE user_id < 4 GROUP BY method ORDER BY COUNT(amount) DESC, method`,
+			[]group{{"card", "2"}, {"cash", "2"}},
+		},
+		{
+			`SELECT method, MAX(amount) FROM payments GROUP BY method HAVING method IS NULL OR MAX(amount) > 100`,
+			[]group{{"cash", "200"}, {"", ""}},
+		},
+		{
+			`SELECT amount % 20, COUNT(*) FROM payments WHERE amount IS NOT NULL GROUP BY amount % 20 ORDER BY COUNT(*)`,
+			[]group{{"10", "1"}, {"0", "3"}},
+		},
+		{
+			`SELECT CASE WHEN amount > 50 THEN 'big' ELSE 'small' END, SUM(amount) FROM payments WHERE amount IS NOT NULL GROUP BY 1 ORDER BY SUM(amount)`,
+			[]group{{"small", "70"}, {"big", "280"}},
+		},
+		{
+			`SELECT account.country, SUM(payments.amount) FROM payments JOIN account ON payments.user_id = account.id GROUP BY account.country ORDER BY account.country`,
+			[]group{{"fr", "150"}, {"uk", "200"}},
+		},
+		{
+			`SELECT account.name, COUNT(*) FROM account JOIN payments ON payments.user_id = account.id GROUP BY account.id ORDER BY account.name`,
+			[]group{{"alice", "2"}, {"bob", "2"}, {"carol", "1"}},
+		},
+		{
+			`SELECT method, SUM(amount) FROM payments GROUP BY method HAVING method = 'card'`,
+			[]group{{"card", "110"}},
+		},
+	}
+	for _, tc := range groupTests {
+		rows, err := db.Query(tc.query)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		var res []group
+		for rows.Next() {
+			var key, value sql.NullString
+			if err := rows.Scan(&key, &value); err != nil {
+				t.Fatalf("cannot scan '%s': %s", tc.query, err)
+			}
+			res = append(res, group{key.String, value.String})
+		}
+		rows.Close()
+		if len(res) != len(tc.expected) {
+			t.Fatalf("expected %v with '%s', got %v", tc.expected, tc.query, res)
+		}
+		for i := range res {
+			if res[i] != tc.expected[i] {
+				t.Fatalf("expected %v with '%s', got %v", tc.expected, tc.query, res)
+			}
+		}
+	}
+
+	// grouping on several columns
+	rows, err := db.Query(`SELECT user_id, method, COUNT(*) FROM payments GROUP BY user_id, method ORDER BY user_id, method`)
+	if err != nil {
+		t.Fatalf("cannot group on several columns: %s", err)
+	}
+	var n int
+	for rows.Next() {
+		var userID, c int64
+		var method sql.NullString
+		if err := rows.Scan(&userID, &method, &c); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		if c != 1 && !(userID == 2 && method.String == "card" && c == 2) {
+			t.Fatalf("unexpected count %d for %d, %v", c, userID, method)
+		}
+		n++
+	}
+	rows.Close()
+	if n != 5 {
+		t.Fatalf("expected 5 groups, got %d", n)
+	}
+
+	// GROUP BY without matching rows gives no group, HAVING without GROUP BY filters the single group
+	for _, q := range []string{
+		`SELECT user_id, SUM(amount) FROM payments WHERE user_id > 100 GROUP BY user_id`,
+		`SELECT COUNT(*) FROM payments HAVING COUNT(*) > 10`,
+	} {
+		rows, err := db.Query(q)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", q, err)
+		}
+		if rows.Next() {
+			t.Fatalf("expected no row with '%s'", q)
+		}
+		rows.Close()
+	}
+
+	invalid := []struct {
+		query string
+		err   string
+	}{
+		{`SELECT user_id, SUM(amount) FROM payments`, `column "payments.user_id" must appear in the GROUP BY clause or be used in an aggregate function`},
+		{`SELECT method, COUNT(*) FROM payments GROUP BY user_id`, `column "payments.method" must appear in the GROUP BY clause`},
+		{`SELECT user_id FROM payments GROUP BY user_id HAVING amount > 1`, `column "payments.amount" must appear in the GROUP BY clause`},
+		{`SELECT user_id FROM payments GROUP BY user_id ORDER BY amount`, `column "payments.amount" must appear in the GROUP BY clause`},
+		{`SELECT SUM(amount) FROM payments GROUP BY SUM(amount)`, `aggregate functions are not allowed in GROUP BY`},
+		{`SELECT user_id FROM payments GROUP BY 3`, `GROUP BY position 3 is not in select list`},
+		{`SELECT SUM(method) FROM payments`, `function sum(text) does not exist`},
+		{`SELECT id FROM payments WHERE SUM(amount) > 1`, `aggregate function sum is not allowed here`},
+	}
+	for _, tc := range invalid {
+		rows, err := db.Query(tc.query)
+		if err == nil {
+			rows.Close()
+			t.Fatalf("expected error with '%s'", tc.query)
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("expected error '%s' with '%s', got '%s'", tc.err, tc.query, err)
+		}
+	}
+}
+
+func TestAggregateDistinctFilter(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestAggregateDistinctFilter")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE payments (id BIGSERIAL PRIMARY KEY, user_id BIGINT, amount INT, fee NUMERIC(10,2), method TEXT)`,
+		`INSERT INTO payments (user_id, amount, fee, method) VALUES (1, 80, 1.50, 'card')`,
+		`INSERT INTO payments (user_id, amount, fee, method) VALUES (1, 80, 1.5, 'cash')`,
+		`INSERT INTO payments (user_id, amount, fee, method) VALUES (1, 40, 2.25, 'card')`,
+		`INSERT INTO payments (user_id, amount, fee, method) VALUES (2, 30, NULL, 'card')`,
+		`INSERT INTO payments (user_id, amount, fee, method) VALUES (2, NULL, 0.75, 'card')`,
+		`INSERT INTO payments (user_id, amount, fee, method) VALUES (3, 200, 3.00, 'cash')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	valueTests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT COUNT(DISTINCT user_id) FROM payments`, "3"},
+		{`SELECT COUNT(DISTINCT amount) FROM payments`, "4"},
+		{`SELECT SUM(DISTINCT amount) FROM payments`, "350"},
+		{`SELECT COUNT(DISTINCT fee) FROM payments`, "4"},
+		{`SELECT AVG(DISTINCT amount) FROM payments WHERE user_id = 1`, "60.0000000000000000"},
+		{`SELECT COUNT(*) FILTER (WHERE method = 'card') FROM payments`, "4"},
+		{`SELECT SUM(amount) FILTER (WHERE method = 'card' AND user_id = 1) FROM payments`, "120"},
+		{`SELECT COUNT(DISTINCT user_id) FILTER (WHERE amount > 35) FROM payments`, "2"},
+		{`SELECT COUNT(*) FILTER (WHERE method = 'cash') * 100 / COUNT(*) FROM payments`, "33"},
+	}
+	for _, tc := range valueTests {
+		var s string
+		err := db.QueryRow(tc.query).Scan(&s)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if s != tc.expected {
+			t.Fatalf("expected '%s' with '%s', got '%s'", tc.expected, tc.query, s)
+		}
+	}
+
+	// filtering out all rows gives NULL, except for COUNT
+	var sum sql.NullInt64
+	var count int64
+	err = db.QueryRow(`SELECT SUM(amount) FILTER (WHERE method = 'wire'), COUNT(*) FILTER (WHERE method = 'wire') FROM payments`).Scan(&sum, &count)
+	if err != nil {
+		t.Fatalf("cannot filter out all rows: %s", err)
+	}
+	if sum.Valid || count != 0 {
+		t.Fatalf("expected NULL sum and no row counted, got %v and %d", sum, count)
+	}
+
+	type group struct {
+		userID int64
+		card   int64
+		cash   int64
+	}
+	groupTests := []struct {
+		query    string
+		expected []group
+	}{
+		{
+			`SELECT user_id, COUNT(*) FILTER (WHERE method = 'card'), COUNT(*) FILTER (WHERE method = 'cash') FROM payments GROUP BY user_id ORDER BY user_id`,
+			[]group{{1, 2, 1}, {2, 2, 0}, {3, 0, 1}},
+		},
+		{
+			`SELECT user_id, COUNT(DISTINCT amount), COUNT(DISTINCT method) FROM payments GROUP BY user_id HAVING COUNT(DISTINCT method) > 1`,
+			[]group{{1, 2, 2}},
+		},
+		{
+			`SELECT user_id, COUNT(DISTINCT amount) FILTER (WHERE method = 'card'), COUNT(*) FROM payments GROUP BY user_id HAVING SUM(amount) FILTER (WHERE method = 'card') > 100`,
+			[]group{{1, 2, 3}},
+		},
+	}
+	for _, tc := range groupTests {
+		rows, err := db.Query(tc.query)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		var res []group
+		for rows.Next() {
+			var g group
+			if err := rows.Scan(&g.userID, &g.card, &g.cash); err != nil {
+				t.Fatalf("cannot scan '%s': %s", tc.query, err)
+			}
+			res = append(res, g)
+		}
+		rows.Close()
+		if len(res) != len(tc.expected) {
+			t.Fatalf("expected %v with '%s', got %v", tc.expected, tc.query, res)
+		}
+		for i := range res {
+			if res[i] != tc.expected[i] {
+				t.Fatalf("expected %v with '%s', got %v", tc.expected, tc.query, res)
+			}
+		}
+	}
+
+	invalid := []struct {
+		query string
+		err   string
+	}{
+		{`SELECT lower(DISTINCT method) FROM payments`, `DISTINCT specified, but lower is not an aggregate function`},
+		{`SELECT lower(method) FILTER (WHERE amount > 1) FROM payments`, `FILTER specified, but lower is not an aggregate function`},
+		{`SELECT user_id, COUNT(*) FILTER (WHERE amount > 1) FROM payments`, `column "payments.user_id" must appear in the GROUP BY clause`},
+		{`SELECT id FROM payments WHERE COUNT(DISTINCT method) > 1`, `aggregate function count is not allowed here`},
+	}
+	for _, tc := range invalid {
+		rows, err := db.Query(tc.query)
+		if err == nil {
+			rows.Close()
+			t.Fatalf("expected error with '%s'", tc.query)
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("expected error '%s' with '%s', got '%s'", tc.err, tc.query, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package ramsql
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestArray(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestArray")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE article (id BIGSERIAL PRIMARY KEY, title TEXT, tags TEXT[] DEFAULT '{}', scores INT[])`,
+		`INSERT INTO article (title, tags, scores) VALUES ('go', '{go,"hello world",sql}', '{1, 2, 3}')`,
+		`INSERT INTO article (title, tags, scores) VALUES ('rust', '{rust,NULL,"NULL"}', '{10}')`,
+		`INSERT INTO article (title, scores) VALUES ('empty', NULL)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// slices and text format arguments, as pq.Array produces
+	_, err = db.Exec(`INSERT INTO article (title, tags, scores) VALUES ($1, $2, $3)`, "sql", []string{"sql", `quote"d`}, `{4,5}`)
+	if err != nil {
+		t.Fatalf("cannot insert array arguments: %s", err)
+	}
+
+	invalid := []string{
+		`INSERT INTO article (title, scores) VALUES ('bad', '{1,a}')`,
+		`INSERT INTO article (title, scores) VALUES ('bad', '{{1},{2}}')`,
+		`INSERT INTO article (title, tags) VALUES ('bad', 'go')`,
+	}
+	for _, q := range invalid {
+		_, err = db.Exec(q)
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+
+	// values are returned in text format
+	rows, err := db.Query(`SELECT tags, scores FROM article ORDER BY id`)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	var got [][2]sql.NullString
+	for rows.Next() {
+		var tags, scores sql.NullString
+		if err := rows.Scan(&tags, &scores); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		got = append(got, [2]sql.NullString{tags, scores})
+	}
+	rows.Close()
+	expected := [][2]string{
+		{`{go,"hello world",sql}`, `{1,2,3}`},
+		{`{rust,NULL,"NULL"}`, `{10}`},
+		{`{}`, ``},
+		{`{sql,"quote\"d"}`, `{4,5}`},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), len(got))
+	}
+	for i := range expected {
+		if got[i][0].String != expected[i][0] || got[i][1].String != expected[i][1] {
+			t.Fatalf("row %d: expected %v, got %v", i, expected[i], got[i])
+		}
+	}
+
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM article WHERE id = ANY($1)`, []any{[]int64{1, 3, 42}}, 2},
+		{`SELECT COUNT(*) FROM article WHERE id = ANY($1)`, []any{`{2}`}, 1},
+		{`SELECT COUNT(*) FROM article WHERE 'go' = ANY(tags)`, nil, 1},
+		{`SELECT COUNT(*) FROM article WHERE 5 < ANY(scores)`, nil, 1},
+		{`SELECT COUNT(*) FROM article WHERE id <> ALL('{1,2}')`, nil, 2},
+		{`SELECT COUNT(*) FROM article WHERE 0 < ALL(scores)`, nil, 3},
+		{`SELECT COUNT(*) FROM article WHERE tags @> '{sql}'`, nil, 2},
+		{`SELECT COUNT(*) FROM article WHERE tags @> $1`, []any{[]string{"go", "sql"}}, 1},
+		{`SELECT COUNT(*) FROM article WHERE scores @> '{3,1}'`, nil, 1},
+		{`SELECT COUNT(*) FROM article WHERE '{10}' <@ scores`, nil, 1},
+		{`SELECT COUNT(*) FROM article WHERE tags && '{rust,sql}'`, nil, 3},
+		{`SELECT COUNT(*) FROM article WHERE scores && $1`, []any{[]int{2, 5}}, 2},
+		{`SELECT COUNT(*) FROM article WHERE tags = '{}'`, nil, 1},
+		{`SELECT COUNT(*) FROM article WHERE scores = '{1,2,3}'`, nil, 1},
+		{`SELECT COUNT(*) FROM article WHERE scores > '{4}'`, nil, 2},
+		{`SELECT COUNT(*) FROM article WHERE array_length(tags, 1) = 3`, nil, 2},
+		{`SELECT COUNT(*) FROM article WHERE array_length(tags, 1) IS NULL`, nil, 1},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	// || operator
+	_, err = db.Exec(`UPDATE article SET tags = tags || 'new' WHERE id = 3`)
+	if err != nil {
+		t.Fatalf("cannot append to array: %s", err)
+	}
+	_, err = db.Exec(`UPDATE article SET scores = scores || '{6,7}' WHERE id = 4`)
+	if err != nil {
+		t.Fatalf("cannot concatenate arrays: %s", err)
+	}
+	var tags, scores, title string
+	err = db.QueryRow(`SELECT tags, scores, title || '!' FROM article WHERE id = 4`).Scan(&tags, &scores, &title)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if scores != `{4,5,6,7}` || title != `sql!` {
+		t.Fatalf("unexpected concatenation results %s and %s", scores, title)
+	}
+	err = db.QueryRow(`SELECT tags FROM article WHERE id = 3`).Scan(&tags)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if tags != `{new}` {
+		t.Fatalf("expected {new}, got %s", tags)
+	}
+
+	// unnest
+	rows, err = db.Query(`SELECT id, unnest(scores) FROM article WHERE id < 3`)
+	if err != nil {
+		t.Fatalf("cannot unnest: %s", err)
+	}
+	var elems []int64
+	for rows.Next() {
+		var id, e int64
+		if err := rows.Scan(&id, &e); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		elems = append(elems, e)
+	}
+	rows.Close()
+	if len(elems) != 4 || elems[0] != 1 || elems[3] != 10 {
+		t.Fatalf("expected 1 2 3 10, got %v", elems)
+	}
+
+	// array_agg
+	var ids string
+	err = db.QueryRow(`SELECT array_agg(id) FROM article WHERE 'sql' = ANY(tags)`).Scan(&ids)
+	if err != nil {
+		t.Fatalf("cannot aggregate: %s", err)
+	}
+	if ids != `{1,4}` {
+		t.Fatalf("expected {1,4}, got %s", ids)
+	}
+	var none sql.NullString
+	err = db.QueryRow(`SELECT array_agg(title) FROM article WHERE id > 10`).Scan(&none)
+	if err != nil {
+		t.Fatalf("cannot aggregate: %s", err)
+	}
+	if none.Valid {
+		t.Fatalf("expected NULL aggregating no rows, got %s", none.String)
+	}
+}
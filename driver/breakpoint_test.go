@@ -0,0 +1,211 @@
+package ramsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/proullon/ramsql/engine/executor"
+)
+
+func TestBreakpoint(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestBreakpoint")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	if err = db.Ping(); err != nil {
+		t.Fatalf("cannot ping: %s", err)
+	}
+
+	e, ok := db.Driver().(*Driver).Engine("TestBreakpoint")
+	if !ok {
+		t.Fatalf("cannot find engine")
+	}
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, email TEXT)`,
+		`CREATE TABLE audit (id BIGSERIAL PRIMARY KEY, msg TEXT)`,
+		`INSERT INTO account (email) VALUES ('foo@bar.com')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	bp := &executor.Breakpoint{
+		Statement: regexp.MustCompile(`^UPDATE`),
+		Table:     "account",
+		C:         make(chan *executor.Break),
+	}
+	e.AddBreakpoint(bp)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("cannot begin: %s", err)
+	}
+	_, err = tx.Exec(`INSERT INTO audit (msg) VALUES ('before update')`)
+	if err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := tx.Exec(`UPDATE account SET email = $1 WHERE id = $2`, "bar@bar.com", 1)
+		done <- err
+	}()
+
+	b := <-bp.C
+	if len(b.Args) != 2 || b.Args[0].Value != "bar@bar.com" {
+		t.Fatalf("unexpected breakpoint arguments: %v", b.Args)
+	}
+	if len(b.Tables) != 1 || b.Tables[0] != "account" {
+		t.Fatalf("unexpected breakpoint tables: %v", b.Tables)
+	}
+	if c := b.State.Changes(); c != 1 {
+		t.Fatalf("expected 1 pending change, got %d", c)
+	}
+	if r := b.State.Relations(); len(r) != 1 || r[0] != "audit" {
+		t.Fatalf("expected audit to be locked, got %v", r)
+	}
+	_, rows, err := b.State.Query(`SELECT msg FROM audit`)
+	if err != nil {
+		t.Fatalf("cannot query paused transaction: %s", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected paused transaction to see 1 audit row, got %d", len(rows))
+	}
+	_, _, err = b.State.Query(`DELETE FROM audit`)
+	if err == nil {
+		t.Fatalf("expected error running DELETE in paused transaction")
+	}
+
+	// concurrent operation while update is paused
+	_, err = db.Exec(`INSERT INTO account (email) VALUES ('baz@bar.com')`)
+	if err != nil {
+		t.Fatalf("cannot insert concurrently: %s", err)
+	}
+
+	b.Resume()
+	if err = <-done; err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	// resuming twice has no effect
+	b.Resume()
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+	e.RemoveBreakpoint(bp)
+
+	// on error
+	var failed string
+	e.AddBreakpoint(&executor.Breakpoint{
+		OnError: true,
+		Handler: func(b *executor.Break) {
+			failed = b.Query
+			if b.Err == nil {
+				t.Errorf("expected breakpoint error to be set")
+			}
+		},
+	})
+
+	_, err = db.Exec(`UPDATE account SET email = 'qux@bar.com' WHERE id = 2`)
+	if err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	if failed != "" {
+		t.Fatalf("expected error breakpoint not to fire, got %s", failed)
+	}
+
+	_, err = db.Exec(`INSERT INTO nope (email) VALUES ('foo@bar.com')`)
+	if err == nil {
+		t.Fatalf("expected error inserting into non existing relation")
+	}
+	if failed != `INSERT INTO nope (email) VALUES ('foo@bar.com')` {
+		t.Fatalf("expected error breakpoint to fire, got '%s'", failed)
+	}
+
+	_, err = db.Exec(`INSERT INTO account (email VALUES`)
+	if err == nil {
+		t.Fatalf("expected parsing error")
+	}
+	if failed != `INSERT INTO account (email VALUES` {
+		t.Fatalf("expected error breakpoint to fire on parsing error, got '%s'", failed)
+	}
+
+	e.ClearBreakpoints()
+}
+
+func TestBreakpointContext(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestBreakpointContext")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, email TEXT)`)
+	if err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+
+	e, ok := db.Driver().(*Driver).Engine("TestBreakpointContext")
+	if !ok {
+		t.Fatalf("cannot find engine")
+	}
+	bp := &executor.Breakpoint{
+		Statement: regexp.MustCompile(`^INSERT`),
+		C:         make(chan *executor.Break),
+	}
+	e.AddBreakpoint(bp)
+	defer e.ClearBreakpoints()
+
+	// nobody receives the break
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = db.ExecContext(ctx, `INSERT INTO account (email) VALUES ('foo@bar.com')`)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded on unread breakpoint, got %v", err)
+	}
+
+	// break is received but never resumed
+	go func() {
+		<-bp.C
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = db.ExecContext(ctx, `INSERT INTO account (email) VALUES ('foo@bar.com')`)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded on breakpoint not resumed, got %v", err)
+	}
+	e.RemoveBreakpoint(bp)
+
+	// handler does not return in time, paused transaction cannot be queried once statement failed
+	release := make(chan struct{})
+	queried := make(chan error)
+	e.AddBreakpoint(&executor.Breakpoint{
+		Statement: regexp.MustCompile(`^INSERT`),
+		Handler: func(b *executor.Break) {
+			<-release
+			_, _, err := b.State.Query(`SELECT email FROM account`)
+			queried <- err
+		},
+	})
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = db.ExecContext(ctx, `INSERT INTO account (email) VALUES ('foo@bar.com')`)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded on handler not returning, got %v", err)
+	}
+	close(release)
+	if err := <-queried; err == nil {
+		t.Fatalf("expected error querying transaction not paused anymore")
+	}
+}
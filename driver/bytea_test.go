@@ -0,0 +1,112 @@
+package ramsql
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+)
+
+func TestBytea(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestBytea")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE secret (id BIGSERIAL PRIMARY KEY, hash BYTEA UNIQUE, payload BLOB)`,
+		`INSERT INTO secret (hash, payload) VALUES ('\xDEADBEEF', 'abc\000\\def')`,
+		`INSERT INTO secret (hash, payload) VALUES ('\x00ff', '\x')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	_, err = db.Exec(`INSERT INTO secret (hash, payload) VALUES ($1, $2)`, []byte{0x01, 0x02}, []byte("raw\\bytes"))
+	if err != nil {
+		t.Fatalf("cannot insert []byte arguments: %s", err)
+	}
+
+	// invalid literals are rejected
+	invalid := []string{
+		`INSERT INTO secret (hash) VALUES ('\xDEADBEE')`,
+		`INSERT INTO secret (hash) VALUES ('\xZZ')`,
+		`INSERT INTO secret (hash) VALUES ('abc\9')`,
+	}
+	for _, q := range invalid {
+		_, err = db.Exec(q)
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+
+	// unicity is checked on bytes
+	_, err = db.Exec(`INSERT INTO secret (hash) VALUES ($1)`, []byte{0xde, 0xad, 0xbe, 0xef})
+	if err == nil {
+		t.Fatalf("expected unique violation")
+	}
+
+	// values are returned as []byte
+	expected := [][]byte{
+		{0xde, 0xad, 0xbe, 0xef}, []byte("abc\x00\\def"),
+		{0x00, 0xff}, {},
+		{0x01, 0x02}, []byte("raw\\bytes"),
+	}
+	rows, err := db.Query(`SELECT hash, payload FROM secret ORDER BY id`)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	var got [][]byte
+	for rows.Next() {
+		var hash, payload []byte
+		if err := rows.Scan(&hash, &payload); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		got = append(got, hash, payload)
+	}
+	rows.Close()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d values, got %d", len(expected), len(got))
+	}
+	for i := range expected {
+		if !bytes.Equal(got[i], expected[i]) {
+			t.Fatalf("value %d: expected %x, got %x", i, expected[i], got[i])
+		}
+	}
+
+	// comparison is byte-wise, through hash index or not
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM secret WHERE hash = '\xdeadbeef'`, nil, 1},
+		{`SELECT COUNT(*) FROM secret WHERE hash = $1`, []any{[]byte{0x00, 0xff}}, 1},
+		{`SELECT COUNT(*) FROM secret WHERE hash = $1`, []any{[]byte{0x00}}, 0},
+		{`SELECT COUNT(*) FROM secret WHERE payload = $1`, []any{[]byte("raw\\bytes")}, 1},
+		{`SELECT COUNT(*) FROM secret WHERE hash > '\x01'`, nil, 2},
+		{`SELECT COUNT(*) FROM secret WHERE hash < $1`, []any{[]byte{0x01, 0x02, 0x00}}, 2},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	var id int64
+	err = db.QueryRow(`SELECT id FROM secret ORDER BY hash DESC LIMIT 1`).Scan(&id)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if id != 1 {
+		t.Fatalf("expected greatest hash in row 1, got %d", id)
+	}
+}
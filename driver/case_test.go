@@ -0,0 +1,160 @@
+package ramsql
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestCase(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestCase")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE task (id BIGSERIAL PRIMARY KEY, name TEXT, status TEXT, priority INT, done BOOLEAN DEFAULT false)`,
+		`INSERT INTO task (name, status, priority) VALUES ('write', 'open', 3)`,
+		`INSERT INTO task (name, status, priority) VALUES ('review', 'closed', 1)`,
+		`INSERT INTO task (name, status, priority) VALUES ('test', 'open', NULL)`,
+		`INSERT INTO task (name, status, priority) VALUES ('deploy', NULL, 2)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	valueTests := []struct {
+		query    string
+		args     []any
+		expected string
+	}{
+		{`SELECT CASE WHEN status = 'open' THEN 'todo' ELSE 'other' END FROM task WHERE id = 1`, nil, "todo"},
+		{`SELECT CASE WHEN status = 'open' THEN 'todo' ELSE 'other' END FROM task WHERE id = 2`, nil, "other"},
+		{`SELECT CASE status WHEN 'open' THEN 'todo' WHEN 'closed' THEN 'done' END FROM task WHERE id = 2`, nil, "done"},
+		{`SELECT CASE status WHEN 'open' THEN 'todo' ELSE 'unknown' END FROM task WHERE id = 4`, nil, "unknown"},
+		{`SELECT CASE WHEN priority > 2 THEN 'high' WHEN priority > 1 THEN 'medium' ELSE 'low' END FROM task WHERE id = 4`, nil, "medium"},
+		{`SELECT CASE WHEN priority IS NULL THEN 'none' ELSE 'some' END FROM task WHERE id = 3`, nil, "none"},
+		{`SELECT CASE WHEN status = 'open' AND priority >= 3 THEN 'urgent' ELSE 'later' END FROM task WHERE id = 1`, nil, "urgent"},
+		{`SELECT CASE WHEN status = 'closed' OR (priority < 2 AND NOT name = 'x') THEN 'yes' ELSE 'no' END FROM task WHERE id = 2`, nil, "yes"},
+		{`SELECT CASE WHEN status = 'open' THEN CASE WHEN priority IS NULL THEN 'open-none' ELSE 'open-set' END ELSE 'closed' END FROM task WHERE id = 3`, nil, "open-none"},
+		{`SELECT CASE WHEN name IN ('write', 'test') THEN upper(name) ELSE name END FROM task WHERE id = 1`, nil, "WRITE"},
+		{`SELECT CASE WHEN priority > $1 THEN 'above' ELSE 'below' END FROM task WHERE id = 1`, []any{2}, "above"},
+		{`SELECT name || ':' || CASE WHEN done THEN 'done' ELSE 'pending' END FROM task WHERE id = 1`, nil, "write:pending"},
+	}
+	for _, tc := range valueTests {
+		var s string
+		err = db.QueryRow(tc.query, tc.args...).Scan(&s)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if s != tc.expected {
+			t.Fatalf("expected '%s' with '%s', got '%s'", tc.expected, tc.query, s)
+		}
+	}
+
+	var v int64
+	err = db.QueryRow(`SELECT CASE WHEN status = 'open' THEN 1 ELSE 0 END * 2 + priority FROM task WHERE id = 1`).Scan(&v)
+	if err != nil {
+		t.Fatalf("cannot compute CASE in expression: %s", err)
+	}
+	if v != 5 {
+		t.Fatalf("expected 5, got %d", v)
+	}
+
+	// no ELSE gives NULL, and NULL never matches in simple CASE
+	nullTests := []string{
+		`SELECT CASE WHEN status = 'closed' THEN 'done' END FROM task WHERE id = 1`,
+		`SELECT CASE WHEN priority > 2 THEN 'high' END FROM task WHERE id = 3`,
+		`SELECT CASE status WHEN NULL THEN 'null' END FROM task WHERE id = 4`,
+	}
+	for _, q := range nullTests {
+		var s sql.NullString
+		err = db.QueryRow(q).Scan(&s)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", q, err)
+		}
+		if s.Valid {
+			t.Fatalf("expected NULL with '%s', got %s", q, s.String)
+		}
+	}
+
+	countTests := []struct {
+		query string
+		count int
+	}{
+		{`SELECT COUNT(*) FROM task WHERE CASE WHEN status = 'open' THEN 1 ELSE 0 END = 1`, 2},
+		{`SELECT COUNT(*) FROM task WHERE CASE status WHEN 'closed' THEN priority ELSE 0 END > 0`, 1},
+		{`SELECT COUNT(*) FROM task WHERE priority = CASE WHEN name = 'write' THEN 3 ELSE 2 END`, 2},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	rows, err := db.Query(`SELECT name FROM task ORDER BY CASE status WHEN 'open' THEN 0 WHEN 'closed' THEN 1 ELSE 2 END, name`)
+	if err != nil {
+		t.Fatalf("cannot order by CASE: %s", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	expected := []string{"test", "write", "review", "deploy"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, names)
+		}
+	}
+
+	_, err = db.Exec(`UPDATE task SET done = CASE WHEN status = 'closed' THEN true ELSE false END, priority = CASE WHEN priority IS NULL THEN 0 ELSE priority + 1 END`)
+	if err != nil {
+		t.Fatalf("cannot update with CASE: %s", err)
+	}
+	var done bool
+	err = db.QueryRow(`SELECT done, priority FROM task WHERE id = 2`).Scan(&done, &v)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if !done || v != 2 {
+		t.Fatalf("expected done with priority 2, got %v, %d", done, v)
+	}
+	err = db.QueryRow(`SELECT done, priority FROM task WHERE id = 3`).Scan(&done, &v)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if done || v != 0 {
+		t.Fatalf("expected not done with priority 0, got %v, %d", done, v)
+	}
+
+	invalid := []string{
+		`SELECT CASE END FROM task`,
+		`SELECT CASE WHEN status = 'open' THEN 1 FROM task`,
+		`SELECT CASE WHEN status = 'open' 1 END FROM task`,
+		`SELECT CASE WHEN nosuchcolumn = 1 THEN 1 END FROM task`,
+	}
+	for _, q := range invalid {
+		rows, err := db.Query(q)
+		if err == nil {
+			rows.Close()
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+}
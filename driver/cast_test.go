@@ -0,0 +1,192 @@
+package ramsql
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCast(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestCast")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TYPE mood AS ENUM ('sad', 'ok', 'happy')`,
+		`CREATE TABLE account (id UUID PRIMARY KEY, name TEXT, age TEXT, score FLOAT, balance NUMERIC(10,2), created_at TIMESTAMP, current mood)`,
+		`INSERT INTO account (id, name, age, score, balance, created_at, current) VALUES ('5f2e5f3a-3c5e-4b7e-9a1e-0d2f2b3c4d5e'::uuid, 'alice', ' 42 ', 3.7, '12.345'::numeric(10,2), '2024-03-05 10:20:30'::timestamp, 'ok')`,
+		`INSERT INTO account (id, name, age, score, balance, created_at, current) VALUES (CAST($1 AS UUID), 'bob', 'n/a', -1.5, 7, $2::timestamp, CAST('happy' AS mood))`,
+		`CREATE TABLE doc (id BIGSERIAL PRIMARY KEY, b JSONB, n INT)`,
+		`INSERT INTO doc (b, n) VALUES ('{"y": 2}', 3)`,
+		`INSERT INTO doc (b, n) VALUES ('{"y": 1}', 4)`,
+	}
+	for _, b := range batch {
+		var err error
+		if strings.Contains(b, "$1") {
+			_, err = db.Exec(b, "8a7b6c5d-4e3f-4a1b-8c2d-3e4f5a6b7c8d", "2024-01-01 00:00:00")
+		} else {
+			_, err = db.Exec(b)
+		}
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	intTests := []struct {
+		query    string
+		args     []any
+		expected int64
+	}{
+		{`SELECT CAST(age AS BIGINT) FROM account WHERE name = 'alice'`, nil, 42},
+		{`SELECT age::int + 1 FROM account WHERE name = 'alice'`, nil, 43},
+		{`SELECT score::int FROM account WHERE name = 'alice'`, nil, 4},
+		{`SELECT CAST(score AS SMALLINT) FROM account WHERE name = 'bob'`, nil, -2},
+		{`SELECT balance::bigint FROM account WHERE name = 'alice'`, nil, 12},
+		{`SELECT $1::integer * 2 FROM account WHERE name = 'alice'`, []any{"21"}, 42},
+		{`SELECT -'5'::int FROM account WHERE name = 'alice'`, nil, -5},
+		{`SELECT (1 + 2)::bigint FROM account WHERE name = 'alice'`, nil, 3},
+		{`SELECT CAST(true AS INT) FROM account WHERE name = 'alice'`, nil, 1},
+		{`SELECT length(CAST(score AS TEXT)) FROM account WHERE name = 'alice'`, nil, 3},
+	}
+	for _, tc := range intTests {
+		var v int64
+		err = db.QueryRow(tc.query, tc.args...).Scan(&v)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if v != tc.expected {
+			t.Fatalf("expected %d with '%s', got %d", tc.expected, tc.query, v)
+		}
+	}
+
+	textTests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT id::text FROM account WHERE name = 'alice'`, "5f2e5f3a-3c5e-4b7e-9a1e-0d2f2b3c4d5e"},
+		{`SELECT CAST(balance AS TEXT) FROM account WHERE name = 'alice'`, "12.35"},
+		{`SELECT balance::numeric(10,1)::text FROM account WHERE name = 'alice'`, "12.4"},
+		{`SELECT score::text FROM account WHERE name = 'bob'`, "-1.5"},
+		{`SELECT name::varchar(3) FROM account WHERE name = 'alice'`, "ali"},
+		{`SELECT CAST(name AS CHAR(7)) || '|' FROM account WHERE name = 'bob'`, "bob|"},
+		{`SELECT created_at::date::text FROM account WHERE name = 'alice'`, "2024-03-05"},
+		{`SELECT created_at::text FROM account WHERE name = 'alice'`, "2024-03-05 10:20:30"},
+		{`SELECT current::text FROM account WHERE name = 'bob'`, "happy"},
+		{`SELECT 't'::boolean::text FROM account WHERE name = 'bob'`, "true"},
+		{`SELECT '{"a": 1}'::jsonb->>'a' FROM account WHERE name = 'bob'`, "1"},
+		{`SELECT name FROM account WHERE id = $1::uuid`, "bob"},
+		{`SELECT name FROM account WHERE created_at::date = '2024-03-05'::date`, "alice"},
+		{`SELECT name FROM account WHERE CAST(age AS TEXT) = ' 42 '`, "alice"},
+		{`SELECT name FROM account ORDER BY score::int LIMIT 1`, "bob"},
+	}
+	for _, tc := range textTests {
+		var s string
+		var err error
+		if strings.Contains(tc.query, "$1") {
+			err = db.QueryRow(tc.query, "8a7b6c5d-4e3f-4a1b-8c2d-3e4f5a6b7c8d").Scan(&s)
+		} else {
+			err = db.QueryRow(tc.query).Scan(&s)
+		}
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if s != tc.expected {
+			t.Fatalf("expected '%s' with '%s', got '%s'", tc.expected, tc.query, s)
+		}
+	}
+
+	// cast of parenthesized expressions in predicates
+	countTests := []struct {
+		query string
+		count int
+	}{
+		{`SELECT COUNT(*) FROM doc WHERE (b->>'y')::numeric > 1`, 1},
+		{`SELECT COUNT(*) FROM doc WHERE (n*2)::text = '6'`, 1},
+		{`SELECT COUNT(*) FROM doc WHERE (n + 1)::text = '5' OR (b->>'y')::int = 2`, 2},
+		{`SELECT COUNT(*) FROM doc WHERE n > 0 AND ((n*2)::text = '8')`, 1},
+		{`SELECT COUNT(*) FROM doc WHERE (n)::bigint = 3`, 1},
+		{`SELECT COUNT(*) FROM doc WHERE '6' = (n*2)::text`, 1},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	var d time.Time
+	err = db.QueryRow(`SELECT '2024-01-31'::date + interval '1 month' FROM account WHERE name = 'bob'`).Scan(&d)
+	if err != nil {
+		t.Fatalf("cannot add interval to date: %s", err)
+	}
+	if d.Year() != 2024 || d.Month() != time.February || d.Day() != 29 {
+		t.Fatalf("expected 2024-02-29, got %s", d)
+	}
+
+	var f float64
+	err = db.QueryRow(`SELECT CAST('1.5e2' AS DOUBLE PRECISION) FROM account WHERE name = 'bob'`).Scan(&f)
+	if err != nil {
+		t.Fatalf("cannot cast to double precision: %s", err)
+	}
+	if f != 150 {
+		t.Fatalf("expected 150, got %f", f)
+	}
+
+	var null sql.NullInt64
+	err = db.QueryRow(`SELECT CAST(NULL AS INT) FROM account WHERE name = 'bob'`).Scan(&null)
+	if err != nil {
+		t.Fatalf("cannot cast NULL: %s", err)
+	}
+	if null.Valid {
+		t.Fatalf("expected NULL, got %d", null.Int64)
+	}
+
+	_, err = db.Exec(`UPDATE account SET score = age::float * 2 WHERE name = 'alice'`)
+	if err != nil {
+		t.Fatalf("cannot update with cast: %s", err)
+	}
+	err = db.QueryRow(`SELECT score FROM account WHERE name = 'alice'`).Scan(&f)
+	if err != nil {
+		t.Fatalf("cannot select score: %s", err)
+	}
+	if f != 84 {
+		t.Fatalf("expected 84, got %f", f)
+	}
+
+	invalid := []struct {
+		query string
+		err   string
+	}{
+		{`SELECT age::int FROM account WHERE name = 'bob'`, `invalid input syntax for type integer: "n/a"`},
+		{`SELECT CAST(name AS BIGINT) FROM account WHERE name = 'bob'`, `invalid input syntax for type bigint: "bob"`},
+		{`SELECT 'nope'::uuid FROM account`, `invalid input syntax for type uuid: "nope"`},
+		{`SELECT 'maybe'::boolean FROM account`, `invalid input syntax for type boolean: "maybe"`},
+		{`SELECT '2024-13-45'::date FROM account`, `invalid input syntax for type date: "2024-13-45"`},
+		{`SELECT 'abc'::numeric FROM account`, `invalid input syntax for type numeric: "abc"`},
+		{`SELECT '1.5'::float::text::int FROM account`, `invalid input syntax for type integer: "1.5"`},
+		{`SELECT '70000'::smallint FROM account`, `smallint out of range`},
+		{`SELECT 'angry'::mood FROM account`, `invalid input value for enum mood: "angry"`},
+		{`SELECT balance::numeric(3,2) FROM account WHERE name = 'alice'`, `numeric field overflow`},
+		{`SELECT created_at::uuid FROM account`, `cannot cast type timestamp to uuid`},
+		{`SELECT name::nosuchtype FROM account`, `type "nosuchtype" does not exist`},
+		{`SELECT CAST(name) FROM account`, `Syntax error`},
+	}
+	for _, tc := range invalid {
+		rows, err := db.Query(tc.query)
+		if err == nil {
+			rows.Close()
+			t.Fatalf("expected error with '%s'", tc.query)
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("expected error '%s' with '%s', got '%s'", tc.err, tc.query, err)
+		}
+	}
+}
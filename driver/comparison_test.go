@@ -0,0 +1,221 @@
+package ramsql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestBetween(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestBetween")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE product (id BIGSERIAL PRIMARY KEY, name TEXT, price INT, added_at TIMESTAMP)`,
+		`INSERT INTO product (name, price, added_at) VALUES ('apple', 3, '2024-01-10 08:00:00')`,
+		`INSERT INTO product (name, price, added_at) VALUES ('bread', 5, '2024-01-20 09:30:00')`,
+		`INSERT INTO product (name, price, added_at) VALUES ('cheese', 12, '2024-02-05 17:00:00')`,
+		`INSERT INTO product (name, price, added_at) VALUES ('dates', NULL, NULL)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM product WHERE price BETWEEN 3 AND 5`, nil, 2},
+		{`SELECT COUNT(*) FROM product WHERE price BETWEEN 5 AND 3`, nil, 0},
+		{`SELECT COUNT(*) FROM product WHERE price BETWEEN SYMMETRIC 5 AND 3`, nil, 2},
+		{`SELECT COUNT(*) FROM product WHERE price NOT BETWEEN 3 AND 5`, nil, 1},
+		{`SELECT COUNT(*) FROM product WHERE price BETWEEN $1 AND $2 AND name <> 'apple'`, []any{1, 20}, 2},
+		{`SELECT COUNT(*) FROM product WHERE price BETWEEN 1 AND 4 OR price BETWEEN 10 AND 20`, nil, 2},
+		{`SELECT COUNT(*) FROM product WHERE added_at BETWEEN '2024-01-01' AND '2024-01-31'`, nil, 2},
+		{`SELECT COUNT(*) FROM product WHERE added_at BETWEEN $1 AND $2`, []any{time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, 2},
+		{`SELECT COUNT(*) FROM product WHERE name BETWEEN 'b' AND 'd'`, nil, 2},
+		{`SELECT COUNT(*) FROM product WHERE price + 1 BETWEEN 4 AND 6`, nil, 2},
+		{`SELECT COUNT(*) FROM product WHERE price BETWEEN NULL AND 5`, nil, 0},
+		{`SELECT COUNT(*) FROM product WHERE price NOT BETWEEN NULL AND 4`, nil, 2},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	_, err = db.Exec(`SELECT name FROM product WHERE price BETWEEN 3`)
+	if err == nil {
+		t.Fatalf("expected error with BETWEEN without upper bound")
+	}
+}
+
+func TestDistinctFrom(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestDistinctFrom")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, email TEXT, backup_email TEXT, manager_id INT)`,
+		`INSERT INTO account (email, backup_email, manager_id) VALUES ('a@example.com', 'a@example.com', NULL)`,
+		`INSERT INTO account (email, backup_email, manager_id) VALUES ('b@example.com', NULL, 1)`,
+		`INSERT INTO account (email, backup_email, manager_id) VALUES (NULL, NULL, 1)`,
+		`INSERT INTO account (email, backup_email, manager_id) VALUES ('d@example.com', 'x@example.com', 2)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		// comparing NULL is unknown, and unknown is not selected, negated or not
+		{`SELECT COUNT(*) FROM account WHERE backup_email = 'a@example.com'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE backup_email <> 'a@example.com'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE manager_id = NULL`, nil, 0},
+		{`SELECT COUNT(*) FROM account WHERE manager_id <> NULL`, nil, 0},
+		{`SELECT COUNT(*) FROM account WHERE manager_id = $1`, []any{nil}, 0},
+		{`SELECT COUNT(*) FROM account WHERE manager_id NOT IN (1, 2)`, nil, 0},
+		{`SELECT COUNT(*) FROM account WHERE email NOT LIKE 'a%'`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE manager_id = 1 OR backup_email = 'a@example.com'`, nil, 3},
+		{`SELECT COUNT(*) FROM account WHERE email IS NULL`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE backup_email IS NOT NULL`, nil, 2},
+		// IS DISTINCT FROM treats NULL as a comparable value
+		{`SELECT COUNT(*) FROM account WHERE email IS DISTINCT FROM backup_email`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE email IS NOT DISTINCT FROM backup_email`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE manager_id IS DISTINCT FROM 1`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE manager_id IS NOT DISTINCT FROM $1`, []any{nil}, 1},
+		{`SELECT COUNT(*) FROM account WHERE manager_id IS DISTINCT FROM NULL AND email IS NOT NULL`, nil, 2},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+}
+
+func TestRowComparison(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestRowComparison")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE post (id BIGSERIAL PRIMARY KEY, title TEXT, created_at TIMESTAMP, rank INT)`,
+		`INSERT INTO post (title, created_at, rank) VALUES ('first', '2024-01-01 10:00:00', 1)`,
+		`INSERT INTO post (title, created_at, rank) VALUES ('second', '2024-01-01 10:00:00', 2)`,
+		`INSERT INTO post (title, created_at, rank) VALUES ('third', '2024-01-01 10:00:00', NULL)`,
+		`INSERT INTO post (title, created_at, rank) VALUES ('fourth', '2024-01-02 08:00:00', 1)`,
+		`INSERT INTO post (title, created_at, rank) VALUES ('fifth', '2024-01-03 08:00:00', 3)`,
+		`CREATE TABLE comment (id BIGSERIAL PRIMARY KEY, post_id BIGINT, score INT)`,
+		`INSERT INTO comment (post_id, score) VALUES (1, 10)`,
+		`INSERT INTO comment (post_id, score) VALUES (1, 20)`,
+		`INSERT INTO comment (post_id, score) VALUES (2, 10)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// keyset pagination
+	var titles []string
+	lastCreatedAt, lastID := time.Time{}, int64(0)
+	for page := 0; page < 5; page++ {
+		rows, err := db.Query(`SELECT id, title, created_at FROM post WHERE (created_at, id) > ($1, $2) ORDER BY created_at, id LIMIT 2`, lastCreatedAt, lastID)
+		if err != nil {
+			t.Fatalf("cannot query page %d: %s", page, err)
+		}
+		n := 0
+		for rows.Next() {
+			var title string
+			if err := rows.Scan(&lastID, &title, &lastCreatedAt); err != nil {
+				t.Fatalf("cannot scan: %s", err)
+			}
+			titles = append(titles, title)
+			n++
+		}
+		rows.Close()
+		if n == 0 {
+			break
+		}
+	}
+	expected := []string{"first", "second", "third", "fourth", "fifth"}
+	if len(titles) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, titles)
+	}
+	for i := range expected {
+		if titles[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, titles)
+		}
+	}
+
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM post WHERE (created_at, rank) = ('2024-01-01 10:00:00', 2)`, nil, 1},
+		{`SELECT COUNT(*) FROM post WHERE (created_at, rank) <> ('2024-01-01 10:00:00', 2)`, nil, 3},
+		{`SELECT COUNT(*) FROM post WHERE (created_at, rank) >= ('2024-01-01 10:00:00', 2)`, nil, 3},
+		{`SELECT COUNT(*) FROM post WHERE (created_at, rank) < ('2024-01-02 08:00:00', 1)`, nil, 3},
+		{`SELECT COUNT(*) FROM post WHERE (created_at, rank) <= ($1, $2)`, []any{"2024-01-02 08:00:00", 1}, 4},
+		{`SELECT COUNT(*) FROM post WHERE (rank, title) IS NOT DISTINCT FROM (NULL, 'third')`, nil, 1},
+		{`SELECT COUNT(*) FROM post WHERE (rank, title) IS DISTINCT FROM (NULL, 'third')`, nil, 4},
+		{`SELECT COUNT(*) FROM post WHERE ((created_at, rank) > ('2024-01-02 00:00:00', 0))`, nil, 2},
+		{`SELECT COUNT(*) FROM post JOIN comment ON post.id = comment.post_id WHERE (post.rank, comment.score) = (1, 10)`, nil, 1},
+		{`SELECT COUNT(*) FROM post, comment WHERE post.id = comment.post_id AND (post.rank, comment.score) = (1, 10)`, nil, 1},
+		{`SELECT COUNT(*) FROM post AS p, comment AS c WHERE (p.id, c.score) = (c.post_id, 20)`, nil, 1},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	invalid := []string{
+		`SELECT title FROM post WHERE (created_at, id) > ($1)`,
+		`SELECT title FROM post WHERE (created_at, id) > (1, 2, 3)`,
+		`SELECT title FROM post WHERE (created_at, id) > 1`,
+	}
+	for _, q := range invalid {
+		_, err = db.Exec(q, time.Now())
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+}
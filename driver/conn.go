@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"reflect"
 
+	"github.com/proullon/ramsql/engine/agnostic"
 	"github.com/proullon/ramsql/engine/executor"
 	"github.com/proullon/ramsql/engine/log"
 )
@@ -30,12 +32,14 @@ import (
 // https://pkg.go.dev/database/sql/driver#ConnPrepareContext
 // https://pkg.go.dev/database/sql/driver#ConnBeginTx
 type Conn struct {
-	e  *executor.Engine
-	tx *executor.Tx
+	e       *executor.Engine
+	tx      *executor.Tx
+	id      int64
+	session *executor.Session
 }
 
-func newConn(e *executor.Engine) *Conn {
-	return &Conn{e: e}
+func newConn(e *executor.Engine, id int64) *Conn {
+	return &Conn{e: e, id: id, session: executor.NewSession()}
 }
 
 // Ping
@@ -64,6 +68,47 @@ func (c *Conn) IsValid() bool {
 	return true
 }
 
+// Engine returns the RamSQL engine of the connection, available with sql.Conn.Raw:
+//
+//	conn.Raw(func(driverConn any) error {
+//		e := driverConn.(*ramsql.Conn).Engine()
+//		...
+//	})
+func (c *Conn) Engine() *executor.Engine {
+	return c.e
+}
+
+// CheckNamedValue accepts 16 bytes arrays as UUID arguments,
+// and slices other than []byte as array arguments.
+// Other arguments are converted with the default sql/driver rules.
+//
+// Implemented for NamedValueChecker interface
+func (c *Conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(driver.Valuer); ok {
+		return driver.ErrSkip
+	}
+
+	rv := reflect.ValueOf(nv.Value)
+	if rv.Kind() == reflect.Array && rv.Len() == 16 && rv.Type().Elem().Kind() == reflect.Uint8 {
+		u, err := agnostic.ToUUID(nv.Value)
+		if err != nil {
+			return err
+		}
+		nv.Value = u
+		return nil
+	}
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		a, err := agnostic.ToArray(nv.Value)
+		if err != nil {
+			return err
+		}
+		nv.Value = a
+		return nil
+	}
+
+	return driver.ErrSkip
+}
+
 // Prepare returns a prepared statement, bound to this connection.
 //
 // Implemented for Conn interface
@@ -103,6 +148,8 @@ func (c *Conn) Begin() (driver.Tx, error) {
 	if err != nil {
 		return nil, err
 	}
+	tx.SetConnection(c.id)
+	tx.SetSession(c.session)
 	c.tx = tx
 	log.Debug("%p BEGIN", c.tx)
 	return c, nil
@@ -120,6 +167,8 @@ func (c *Conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, e
 	if err != nil {
 		return nil, err
 	}
+	tx.SetConnection(c.id)
+	tx.SetSession(c.session)
 	c.tx = tx
 	log.Debug("%p BEGIN", c.tx)
 	return c, nil
@@ -162,6 +211,8 @@ func (c *Conn) QueryContext(ctx context.Context, query string, args []driver.Nam
 		if err != nil {
 			return nil, err
 		}
+		tx.SetConnection(c.id)
+		tx.SetSession(c.session)
 		defer tx.Rollback()
 	}
 
@@ -203,6 +254,8 @@ func (c *Conn) ExecContext(ctx context.Context, query string, args []driver.Name
 		if err != nil {
 			return nil, err
 		}
+		tx.SetConnection(c.id)
+		tx.SetSession(c.session)
 		defer tx.Rollback()
 	}
 
@@ -0,0 +1,61 @@
+package ramsql
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestNotNullAndReferences(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestNotNullAndReferences")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, name TEXT NOT NULL, bio TEXT)`,
+		`CREATE TABLE payment (id BIGSERIAL PRIMARY KEY, account_id INT REFERENCES account, parent_id BIGINT REFERENCES payment (id))`,
+		`INSERT INTO account (name, bio) VALUES ('alice', 'hi')`,
+		`INSERT INTO account (name, bio) VALUES ('bob', NULL)`,
+		`INSERT INTO payment (account_id, parent_id) VALUES (1, NULL)`,
+		`INSERT INTO payment (account_id, parent_id) VALUES (NULL, NULL)`,
+		`INSERT INTO payment (account_id, parent_id) VALUES (2, 1)`,
+		`INSERT INTO payment (id, account_id, parent_id) VALUES (10, 2, 10)`,
+		`UPDATE payment SET account_id = 2 WHERE id = 1`,
+		`UPDATE account SET bio = NULL WHERE id = 1`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	invalid := []struct {
+		query    string
+		expected string
+	}{
+		{`INSERT INTO account (name, bio) VALUES (NULL, 'foo')`, `null value in column "name" of relation "account" violates not-null constraint`},
+		{`UPDATE account SET name = NULL WHERE id = 1`, `null value in column "name" of relation "account" violates not-null constraint`},
+		{`INSERT INTO payment (account_id, parent_id) VALUES (999, NULL)`, `key (account_id)=(999) is not present in table "account"`},
+		{`INSERT INTO payment (id, account_id, parent_id) VALUES (11, NULL, 12)`, `key (parent_id)=(12) is not present in table "payment"`},
+		{`UPDATE payment SET account_id = 999 WHERE id = 1`, `key (account_id)=(999) is not present in table "account"`},
+	}
+	for _, tc := range invalid {
+		_, err = db.Exec(tc.query)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Fatalf("expected error '%s' with '%s', got %v", tc.expected, tc.query, err)
+		}
+	}
+
+	var n int
+	err = db.QueryRow(`SELECT COUNT(*) FROM payment WHERE account_id = 2`).Scan(&n)
+	if err != nil {
+		t.Fatalf("cannot query payments: %s", err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 payments of account 2, got %d", n)
+	}
+}
@@ -0,0 +1,102 @@
+package ramsql
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestDomain(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestDomain")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE DOMAIN username AS TEXT CHECK (VALUE <> '' AND VALUE <> 'root') NOT NULL`,
+		`CREATE DOMAIN percent INT DEFAULT 100 CHECK (VALUE >= 0) CHECK (VALUE <= 100)`,
+		`CREATE DOMAIN amount AS NUMERIC(6, 2) CHECK (VALUE > 0)`,
+		`CREATE DOMAIN color AS TEXT CHECK (VALUE IN ('red', 'green', 'blue'))`,
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, name username, progress percent, balance amount, favorite color)`,
+		`INSERT INTO account (name, progress, balance, favorite) VALUES ('alice', 42, 10.5, 'red')`,
+		`INSERT INTO account (name, balance, favorite) VALUES ('bob', NULL, NULL)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	invalid := []string{
+		`CREATE DOMAIN username AS TEXT`,
+		`CREATE DOMAIN bad AS TEXT CHECK (unknown > 0)`,
+		`INSERT INTO account (name, progress, balance, favorite) VALUES ('', 1, 1, NULL)`,
+		`INSERT INTO account (name, progress, balance, favorite) VALUES ('root', 1, 1, NULL)`,
+		`INSERT INTO account (name, progress, balance, favorite) VALUES (NULL, 1, 1, NULL)`,
+		`INSERT INTO account (name, progress, balance, favorite) VALUES ('carol', 101, 1, NULL)`,
+		`INSERT INTO account (name, progress, balance, favorite) VALUES ('carol', -1, 1, NULL)`,
+		`INSERT INTO account (name, progress, balance, favorite) VALUES ('carol', 1, 0, NULL)`,
+		`INSERT INTO account (name, progress, balance, favorite) VALUES ('carol', 1, 12345.67, NULL)`,
+		`INSERT INTO account (name, progress, balance, favorite) VALUES ('carol', 1, 1, 'pink')`,
+		`UPDATE account SET progress = 200 WHERE name = 'alice'`,
+		`UPDATE account SET name = NULL WHERE name = 'alice'`,
+		`DROP DOMAIN percent`,
+	}
+	for _, q := range invalid {
+		_, err = db.Exec(q)
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+
+	_, err = db.Exec(`INSERT INTO account (name, progress, balance, favorite) VALUES ($1, $2, NULL, NULL)`, "carol", 500)
+	if err == nil {
+		t.Fatalf("expected check violation with argument")
+	}
+	_, err = db.Exec(`UPDATE account SET progress = $1, favorite = $2 WHERE name = $3`, 0, "blue", "bob")
+	if err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+
+	// domain default value applies, checks did not write anything
+	rows, err := db.Query(`SELECT name, progress, favorite FROM account ORDER BY id`)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	var got []string
+	for rows.Next() {
+		var name, favorite string
+		var progress int64
+		if err := rows.Scan(&name, &progress, &favorite); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		got = append(got, name, favorite)
+		if name == "bob" && progress != 0 {
+			t.Fatalf("expected bob progress to be updated to 0, got %d", progress)
+		}
+		if name == "alice" && progress != 42 {
+			t.Fatalf("expected alice progress to be 42, got %d", progress)
+		}
+	}
+	rows.Close()
+	if len(got) != 4 || got[0] != "alice" || got[1] != "red" || got[2] != "bob" || got[3] != "blue" {
+		t.Fatalf("unexpected rows %v", got)
+	}
+
+	// domain cannot be dropped while in use
+	batch = []string{
+		`DROP TABLE account`,
+		`DROP DOMAIN percent`,
+		`DROP DOMAIN IF EXISTS percent`,
+		`CREATE TABLE score (value INT)`,
+		`INSERT INTO score (value) VALUES (101)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+}
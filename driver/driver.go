@@ -6,6 +6,7 @@ import (
 	"errors"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/proullon/ramsql/engine/executor"
@@ -28,6 +29,8 @@ type Driver struct {
 	sync.Mutex
 	// Holds all matching sql.DB instances of RamSQL engine
 	engines map[string]*executor.Engine
+	// Last connection identifier given
+	lastConn atomic.Int64
 }
 
 // NewDriver creates a driver object
@@ -68,10 +71,25 @@ func (rs *Driver) Open(dsn string) (conn driver.Conn, err error) {
 
 		rs.engines[dsn] = e
 
-		return newConn(e), nil
+		return newConn(e, rs.lastConn.Add(1)), nil
 	}
 
-	return newConn(dsnengine), err
+	return newConn(dsnengine, rs.lastConn.Add(1)), err
+}
+
+// Engine returns the RamSQL engine tied to given data source name.
+//
+// Engine is available once a connection has been opened with this data source name:
+//
+//	db, _ := sql.Open("ramsql", "TestFoo")
+//	db.Ping()
+//	e, ok := db.Driver().(*ramsql.Driver).Engine("TestFoo")
+func (rs *Driver) Engine(dsn string) (*executor.Engine, bool) {
+	rs.Lock()
+	defer rs.Unlock()
+
+	e, ok := rs.engines[dsn]
+	return e, ok
 }
 
 // The uri need to have the following syntax:
@@ -510,7 +510,9 @@ func TestDate(t *testing.T) {
 		t.Fatalf("Cannot select date: %s", err)
 	}
 
-	if fmt.Sprintf("%v", date) != "2015-09-10 14:03:09.444695269 +0200 CEST" {
+	// stored with microsecond precision, displayed in session time zone
+	expected := time.Date(2015, time.September, 10, 12, 3, 9, 444695000, time.UTC)
+	if !date.Equal(expected) || date.Location() != time.Local {
 		t.Fatalf("Expected specific date, got %v", date)
 	}
 }
@@ -0,0 +1,171 @@
+package ramsql
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestEnum(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestEnum")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TYPE mood AS ENUM ('sad', 'ok', 'happy')`,
+		`CREATE TABLE person (id BIGSERIAL PRIMARY KEY, name TEXT, current_mood mood DEFAULT 'ok')`,
+		`INSERT INTO person (name, current_mood) VALUES ('alice', 'happy')`,
+		`INSERT INTO person (name, current_mood) VALUES ('bob', 'sad')`,
+		`INSERT INTO person (name) VALUES ('carol')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	invalid := []string{
+		`CREATE TYPE mood AS ENUM ('a')`,
+		`CREATE TYPE dup AS ENUM ('a', 'a')`,
+		`CREATE TABLE bad (m mood DEFAULT 'angry')`,
+		`INSERT INTO person (name, current_mood) VALUES ('dave', 'angry')`,
+		`INSERT INTO person (name, current_mood) VALUES ('dave', 'Happy')`,
+		`UPDATE person SET current_mood = 'angry' WHERE name = 'bob'`,
+		`ALTER TYPE mood ADD VALUE 'ok'`,
+		`ALTER TYPE mood ADD VALUE 'meh' BEFORE 'unknown'`,
+		`DROP TYPE mood`,
+		`DROP TYPE unknown`,
+	}
+	for _, q := range invalid {
+		_, err = db.Exec(q)
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+
+	_, err = db.Exec(`INSERT INTO person (name, current_mood) VALUES ($1, $2)`, "dave", "angry")
+	if err == nil {
+		t.Fatalf("expected error inserting value outside of enum")
+	}
+	_, err = db.Exec(`UPDATE person SET current_mood = $1 WHERE name = $2`, "ok", "bob")
+	if err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+
+	// ordering follows declaration order
+	checkOrder := func(expected ...string) {
+		t.Helper()
+		rows, err := db.Query(`SELECT name, current_mood FROM person ORDER BY current_mood, id`)
+		if err != nil {
+			t.Fatalf("cannot select: %s", err)
+		}
+		defer rows.Close()
+		var got []string
+		for rows.Next() {
+			var name, m string
+			if err := rows.Scan(&name, &m); err != nil {
+				t.Fatalf("cannot scan: %s", err)
+			}
+			got = append(got, name+":"+m)
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Fatalf("expected %v, got %v", expected, got)
+			}
+		}
+	}
+	checkOrder("bob:ok", "carol:ok", "alice:happy")
+
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM person WHERE current_mood = 'ok'`, nil, 2},
+		{`SELECT COUNT(*) FROM person WHERE current_mood = $1`, []any{"happy"}, 1},
+		{`SELECT COUNT(*) FROM person WHERE current_mood > 'sad'`, nil, 3},
+		{`SELECT COUNT(*) FROM person WHERE current_mood < 'happy'`, nil, 2},
+		{`SELECT COUNT(*) FROM person WHERE current_mood >= 'happy'`, nil, 1},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	// comparing to a text which is not a label fails, like assigning it
+	for _, q := range []string{
+		`SELECT COUNT(*) FROM person WHERE current_mood > 'zzz'`,
+		`SELECT COUNT(*) FROM person WHERE current_mood = 'angry'`,
+		`SELECT COUNT(*) FROM person WHERE 'zzz' <= current_mood`,
+		`SELECT COUNT(*) FROM person WHERE current_mood BETWEEN 'sad' AND 'zzz'`,
+	} {
+		var count int
+		err = db.QueryRow(q).Scan(&count)
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+		if !strings.Contains(err.Error(), `invalid input value for enum mood: "`) {
+			t.Fatalf("expected invalid input value error with '%s', got '%s'", q, err)
+		}
+	}
+
+	// new values take their place in sort order
+	batch = []string{
+		`ALTER TYPE mood ADD VALUE 'ecstatic'`,
+		`ALTER TYPE mood ADD VALUE 'meh' BEFORE 'ok'`,
+		`ALTER TYPE mood ADD VALUE 'content' AFTER 'ok'`,
+		`ALTER TYPE mood ADD VALUE IF NOT EXISTS 'meh'`,
+		`INSERT INTO person (name, current_mood) VALUES ('erin', 'ecstatic')`,
+		`INSERT INTO person (name, current_mood) VALUES ('frank', 'meh')`,
+		`UPDATE person SET current_mood = 'content' WHERE name = 'carol'`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	checkOrder("frank:meh", "bob:ok", "carol:content", "alice:happy", "erin:ecstatic")
+
+	// type changes are transactional
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("cannot begin: %s", err)
+	}
+	_, err = tx.Exec(`ALTER TYPE mood ADD VALUE 'angry'`)
+	if err != nil {
+		t.Fatalf("cannot add value: %s", err)
+	}
+	tx.Rollback()
+	_, err = db.Exec(`INSERT INTO person (name, current_mood) VALUES ('dave', 'angry')`)
+	if err == nil {
+		t.Fatalf("expected error inserting rolled back value")
+	}
+
+	// type cannot be dropped while in use
+	batch = []string{
+		`DROP TABLE person`,
+		`DROP TYPE mood`,
+		`DROP TYPE IF EXISTS mood`,
+		`CREATE TYPE mood AS ENUM ('low', 'high')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+}
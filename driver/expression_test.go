@@ -0,0 +1,348 @@
+package ramsql
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpression(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestExpression")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE item (id BIGSERIAL PRIMARY KEY, name TEXT, price INT, qty INT, weight FLOAT, counter INT DEFAULT 0)`,
+		`INSERT INTO item (name, price, qty, weight) VALUES ('apple', 3, 10, 0.2)`,
+		`INSERT INTO item (name, price, qty, weight) VALUES ('bread', 5, 2, 0.5)`,
+		`INSERT INTO item (name, price, qty, weight) VALUES ('cheese', 12, 1, 1.5)`,
+		`INSERT INTO item (name, price, qty, weight) VALUES ('dates', NULL, 4, 0.25)`,
+		`INSERT INTO item (name, price, qty, weight) VALUES ('eggs', 2*3, (1+2)*2, -0.5)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	valueTests := []struct {
+		query    string
+		args     []any
+		expected int64
+	}{
+		{`SELECT price * qty FROM item WHERE name = 'apple'`, nil, 30},
+		{`SELECT price*qty+1 FROM item WHERE name = 'bread'`, nil, 11},
+		{`SELECT price + qty * 2 FROM item WHERE name = 'apple'`, nil, 23},
+		{`SELECT (price + qty) * 2 FROM item WHERE name = 'apple'`, nil, 26},
+		{`SELECT price - qty - 1 FROM item WHERE name = 'cheese'`, nil, 10},
+		{`SELECT price / qty FROM item WHERE name = 'bread'`, nil, 2},
+		{`SELECT price % qty FROM item WHERE name = 'bread'`, nil, 1},
+		{`SELECT -price FROM item WHERE name = 'cheese'`, nil, -12},
+		{`SELECT price * $1 FROM item WHERE name = 'cheese'`, []any{3}, 36},
+		{`SELECT price FROM item WHERE name = 'eggs'`, nil, 6},
+		{`SELECT qty FROM item WHERE name = 'eggs'`, nil, 6},
+	}
+	for _, tc := range valueTests {
+		var v int64
+		err = db.QueryRow(tc.query, tc.args...).Scan(&v)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if v != tc.expected {
+			t.Fatalf("expected %d with '%s', got %d", tc.expected, tc.query, v)
+		}
+	}
+
+	var f float64
+	err = db.QueryRow(`SELECT weight * qty FROM item WHERE name = 'dates'`).Scan(&f)
+	if err != nil {
+		t.Fatalf("cannot compute float expression: %s", err)
+	}
+	if f != 1.0 {
+		t.Fatalf("expected 1.0, got %f", f)
+	}
+
+	var s string
+	err = db.QueryRow(`SELECT name || '-' || id FROM item WHERE name = 'bread'`).Scan(&s)
+	if err != nil {
+		t.Fatalf("cannot concatenate: %s", err)
+	}
+	if s != "bread-2" {
+		t.Fatalf("expected bread-2, got %s", s)
+	}
+
+	var null sql.NullInt64
+	err = db.QueryRow(`SELECT price * qty FROM item WHERE name = 'dates'`).Scan(&null)
+	if err != nil {
+		t.Fatalf("cannot compute expression on NULL: %s", err)
+	}
+	if null.Valid {
+		t.Fatalf("expected NULL, got %d", null.Int64)
+	}
+
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM item WHERE price * qty > 10`, nil, 3},
+		{`SELECT COUNT(*) FROM item WHERE price + qty > 10`, nil, 3},
+		{`SELECT COUNT(*) FROM item WHERE (price + qty) * 2 >= 26`, nil, 2},
+		{`SELECT COUNT(*) FROM item WHERE qty = price - 4`, nil, 0},
+		{`SELECT COUNT(*) FROM item WHERE price > qty`, nil, 2},
+		{`SELECT COUNT(*) FROM item WHERE price = qty`, nil, 1},
+		{`SELECT COUNT(*) FROM item WHERE price <> qty AND name <> 'apple'`, nil, 2},
+		{`SELECT COUNT(*) FROM item WHERE -price < -5`, nil, 2},
+		{`SELECT COUNT(*) FROM item WHERE price % 2 = 0`, nil, 2},
+		{`SELECT COUNT(*) FROM item WHERE price * qty >= $1 AND name <> 'apple'`, []any{10}, 3},
+		{`SELECT COUNT(*) FROM item WHERE (price * qty > 20)`, nil, 2},
+		{`SELECT COUNT(*) FROM item WHERE now() - interval '1 hour' * 2 < now() - interval '90 minutes'`, nil, 5},
+		{`SELECT COUNT(*) FROM item WHERE now() - interval '1 hour' / 2 < now() - interval '90 minutes'`, nil, 0},
+		{`SELECT COUNT(*) FROM item WHERE $1 * 2 > 10`, []any{3}, 0},
+		{`SELECT COUNT(*) FROM item WHERE price > 10 OR $1 * 2 > 10`, []any{3}, 1},
+		{`SELECT COUNT(*) FROM item WHERE price > 10 OR $1 * 2 > 10`, []any{6}, 5},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	rows, err := db.Query(`SELECT name FROM item WHERE price IS NOT NULL ORDER BY price * qty DESC`)
+	if err != nil {
+		t.Fatalf("cannot order by expression: %s", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	expected := []string{"eggs", "apple", "cheese", "bread"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, names)
+		}
+	}
+
+	_, err = db.Exec(`UPDATE item SET counter = counter + 1`)
+	if err != nil {
+		t.Fatalf("cannot increment counter: %s", err)
+	}
+	_, err = db.Exec(`UPDATE item SET counter = counter * 10 + qty WHERE name = 'apple'`)
+	if err != nil {
+		t.Fatalf("cannot update with expression: %s", err)
+	}
+	_, err = db.Exec(`UPDATE item SET qty = -qty WHERE name = 'bread'`)
+	if err != nil {
+		t.Fatalf("cannot negate attribute: %s", err)
+	}
+
+	var counter, qty int64
+	err = db.QueryRow(`SELECT counter FROM item WHERE name = 'apple'`).Scan(&counter)
+	if err != nil {
+		t.Fatalf("cannot select counter: %s", err)
+	}
+	if counter != 20 {
+		t.Fatalf("expected counter 20, got %d", counter)
+	}
+	err = db.QueryRow(`SELECT counter FROM item WHERE name = 'cheese'`).Scan(&counter)
+	if err != nil {
+		t.Fatalf("cannot select counter: %s", err)
+	}
+	if counter != 1 {
+		t.Fatalf("expected counter 1, got %d", counter)
+	}
+	err = db.QueryRow(`SELECT qty FROM item WHERE name = 'bread'`).Scan(&qty)
+	if err != nil {
+		t.Fatalf("cannot select qty: %s", err)
+	}
+	if qty != -2 {
+		t.Fatalf("expected qty -2, got %d", qty)
+	}
+
+	_, err = db.Exec(`UPDATE item SET counter = price, name = 'brie' WHERE name = 'cheese'`)
+	if err != nil {
+		t.Fatalf("cannot assign attribute: %s", err)
+	}
+	err = db.QueryRow(`SELECT counter FROM item WHERE name = 'brie'`).Scan(&counter)
+	if err != nil {
+		t.Fatalf("cannot select counter: %s", err)
+	}
+	if counter != 12 {
+		t.Fatalf("expected counter 12, got %d", counter)
+	}
+
+	_, err = db.Query(`SELECT price / 0 FROM item`)
+	if err == nil {
+		t.Fatalf("expected division by zero error")
+	}
+
+	// constants are selected as computed columns, once per row
+	constantTests := []struct {
+		query    string
+		args     []any
+		cols     string
+		expected string
+	}{
+		{`SELECT name, 5 FROM item WHERE id < 3`, nil, "[name ?column?]", "[apple 5 bread 5]"},
+		{`SELECT 1 FROM item WHERE name = 'apple'`, nil, "[?column?]", "[1]"},
+		{`SELECT 5 FROM item`, nil, "[?column?]", "[5 5 5 5 5]"},
+		{`SELECT 'x', name, NULL FROM item WHERE id = 2`, nil, "[?column? name ?column?]", "[x bread <nil>]"},
+		{`SELECT 2.5, $1 FROM item WHERE id = 1`, []any{7}, "[?column? ?column?]", "[2.5 7]"},
+		{`SELECT id, interval '1 day' FROM item WHERE id = 1`, nil, "[id interval]", "[1 1 day]"},
+		{`SELECT current_schema() FROM item WHERE id = 1`, nil, "[current_schema]", "[public]"},
+	}
+	for _, tc := range constantTests {
+		rows, err := db.Query(tc.query, tc.args...)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		cols, err := rows.Columns()
+		if err != nil {
+			t.Fatalf("cannot get columns of '%s': %s", tc.query, err)
+		}
+		var res []any
+		for rows.Next() {
+			values := make([]any, len(cols))
+			dest := make([]any, len(cols))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				t.Fatalf("cannot scan '%s': %s", tc.query, err)
+			}
+			res = append(res, values...)
+		}
+		rows.Close()
+		if fmt.Sprint(cols) != tc.cols {
+			t.Fatalf("expected columns %s with '%s', got %v", tc.cols, tc.query, cols)
+		}
+		if fmt.Sprint(res) != tc.expected {
+			t.Fatalf("expected %s with '%s', got %v", tc.expected, tc.query, res)
+		}
+	}
+
+	var now time.Time
+	var id string
+	err = db.QueryRow(`SELECT now(), gen_random_uuid() FROM item WHERE id = 1`).Scan(&now, &id)
+	if err != nil {
+		t.Fatalf("cannot select now() and gen_random_uuid(): %s", err)
+	}
+	if time.Since(now) > time.Minute || len(id) != 36 {
+		t.Fatalf("expected current time and a uuid, got %s and %s", now, id)
+	}
+}
+
+func TestNumericExpression(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestNumericExpression")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE payment (id BIGSERIAL PRIMARY KEY, amount NUMERIC(10,2), qty BIGINT, rate FLOAT)`,
+		`INSERT INTO payment (amount, qty, rate) VALUES (0.10, 3, 0.5)`,
+		`INSERT INTO payment (amount, qty, rate) VALUES (0.20, 9223372036854775807, 0.5)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// NUMERIC arithmetic is exact
+	valueTests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT amount * 3 FROM payment WHERE id = 1`, "0.30"},
+		{`SELECT amount * qty FROM payment WHERE id = 1`, "0.30"},
+		{`SELECT amount + 0.05 FROM payment WHERE id = 1`, "0.15"},
+		{`SELECT amount - 0.3 FROM payment WHERE id = 1`, "-0.20"},
+		{`SELECT amount * 1.5 FROM payment WHERE id = 1`, "0.150"},
+		{`SELECT amount / 3 FROM payment WHERE id = 1`, "0.03333333333333333333"},
+		{`SELECT 10 / amount FROM payment WHERE id = 1`, "100.0000000000000000"},
+		{`SELECT amount % 0.03 FROM payment WHERE id = 1`, "0.01"},
+		{`SELECT -amount FROM payment WHERE id = 1`, "-0.10"},
+		{`SELECT amount * rate FROM payment WHERE id = 1`, "0.05"},
+	}
+	for _, tc := range valueTests {
+		var v string
+		err = db.QueryRow(tc.query).Scan(&v)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if v != tc.expected {
+			t.Fatalf("expected %s with '%s', got %s", tc.expected, tc.query, v)
+		}
+	}
+
+	countTests := []struct {
+		query string
+		count int
+	}{
+		{`SELECT COUNT(*) FROM payment WHERE amount * 3 = 0.30`, 1},
+		{`SELECT COUNT(*) FROM payment WHERE amount = 0.30 - 0.10`, 1},
+		{`SELECT COUNT(*) FROM payment WHERE amount + 0.20 = 0.30`, 1},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	// integer arithmetic does not wrap around
+	invalid := []string{
+		`SELECT qty + 1 FROM payment WHERE id = 2`,
+		`SELECT qty * 2 FROM payment WHERE id = 2`,
+		`SELECT -qty - 2 FROM payment WHERE id = 2`,
+		`SELECT 1 - qty - 3 FROM payment WHERE id = 2`,
+		`UPDATE payment SET qty = qty + 1 WHERE id = 2`,
+	}
+	for _, q := range invalid {
+		rows, err := db.Query(q)
+		if err == nil {
+			rows.Close()
+			t.Fatalf("expected error with '%s'", q)
+		}
+		if !strings.Contains(err.Error(), "bigint out of range") {
+			t.Fatalf("expected bigint out of range with '%s', got %s", q, err)
+		}
+	}
+
+	var qty int64
+	err = db.QueryRow(`SELECT -qty - 1 FROM payment WHERE id = 2`).Scan(&qty)
+	if err != nil {
+		t.Fatalf("cannot compute smallest bigint: %s", err)
+	}
+	if qty != math.MinInt64 {
+		t.Fatalf("expected %d, got %d", int64(math.MinInt64), qty)
+	}
+}
@@ -0,0 +1,210 @@
+package ramsql
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestScalarFunctions(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestScalarFunctions")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, email TEXT, nickname TEXT, score INT, ratio FLOAT, balance NUMERIC(10,2), code TEXT DEFAULT upper('abc'))`,
+		`INSERT INTO account (email, nickname, score, ratio, balance) VALUES ('  Alice@Example.com ', 'alice', -12, 2.5, '-10.25')`,
+		`INSERT INTO account (email, nickname, score, ratio, balance) VALUES ('bob@example.com', NULL, 7, -1.5, '3.50')`,
+		`INSERT INTO account (email, nickname, score, ratio, balance) VALUES (NULL, 'carol', NULL, NULL, NULL)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	textTests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT lower(email) FROM account WHERE id = 2`, "bob@example.com"},
+		{`SELECT UPPER(nickname) FROM account WHERE id = 1`, "ALICE"},
+		{`SELECT trim(email) FROM account WHERE id = 1`, "Alice@Example.com"},
+		{`SELECT TRIM(LEADING FROM email) FROM account WHERE id = 1`, "Alice@Example.com "},
+		{`SELECT trim(BOTH 'b' FROM nickname) FROM account WHERE id = 1`, "alice"},
+		{`SELECT rtrim(nickname, 'lo') FROM account WHERE id = 3`, "car"},
+		{`SELECT ltrim(nickname, 'ca') FROM account WHERE id = 3`, "rol"},
+		{`SELECT substring(nickname, 2, 3) FROM account WHERE id = 1`, "lic"},
+		{`SELECT SUBSTRING(nickname FROM 3) FROM account WHERE id = 1`, "ice"},
+		{`SELECT substring(nickname FROM 0 FOR 3) FROM account WHERE id = 1`, "al"},
+		{`SELECT replace(email, 'example', 'test') FROM account WHERE id = 2`, "bob@test.com"},
+		{`SELECT concat(nickname, '-', score, NULL) FROM account WHERE id = 1`, "alice--12"},
+		{`SELECT coalesce(nickname, email) FROM account WHERE id = 2`, "bob@example.com"},
+		{`SELECT coalesce(email, nickname, 'none') FROM account WHERE id = 3`, "carol"},
+		{`SELECT code FROM account WHERE id = 1`, "ABC"},
+		{`SELECT greatest('b', 'a', 'c') FROM account WHERE id = 1`, "c"},
+	}
+	for _, tc := range textTests {
+		var s string
+		err = db.QueryRow(tc.query).Scan(&s)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if s != tc.expected {
+			t.Fatalf("expected '%s' with '%s', got '%s'", tc.expected, tc.query, s)
+		}
+	}
+
+	intTests := []struct {
+		query    string
+		expected int64
+	}{
+		{`SELECT length(nickname) FROM account WHERE id = 1`, 5},
+		{`SELECT char_length('héllo') FROM account WHERE id = 1`, 5},
+		{`SELECT abs(score) FROM account WHERE id = 1`, 12},
+		{`SELECT mod(score, 5) FROM account WHERE id = 2`, 2},
+		{`SELECT greatest(score, 0, 3) FROM account WHERE id = 1`, 3},
+		{`SELECT least(score, 0, NULL) FROM account WHERE id = 1`, -12},
+		{`SELECT coalesce(score, 0) FROM account WHERE id = 3`, 0},
+		{`SELECT round(score, -1) FROM account WHERE id = 1`, -10},
+		{`SELECT abs(score) + length(nickname) FROM account WHERE id = 1`, 17},
+	}
+	for _, tc := range intTests {
+		var v int64
+		err = db.QueryRow(tc.query).Scan(&v)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if v != tc.expected {
+			t.Fatalf("expected %d with '%s', got %d", tc.expected, tc.query, v)
+		}
+	}
+
+	floatTests := []struct {
+		query    string
+		expected float64
+	}{
+		{`SELECT abs(ratio) FROM account WHERE id = 2`, 1.5},
+		{`SELECT round(ratio) FROM account WHERE id = 1`, 3},
+		{`SELECT ceil(ratio) FROM account WHERE id = 2`, -1},
+		{`SELECT floor(ratio) FROM account WHERE id = 2`, -2},
+		{`SELECT round(ratio * 1.2345, 2) FROM account WHERE id = 1`, 3.09},
+	}
+	for _, tc := range floatTests {
+		var v float64
+		err = db.QueryRow(tc.query).Scan(&v)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if v != tc.expected {
+			t.Fatalf("expected %f with '%s', got %f", tc.expected, tc.query, v)
+		}
+	}
+
+	numericTests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT abs(balance) FROM account WHERE id = 1`, "10.25"},
+		{`SELECT round(balance, 1) FROM account WHERE id = 1`, "-10.3"},
+		{`SELECT round(balance) FROM account WHERE id = 2`, "4"},
+		{`SELECT ceil(balance) FROM account WHERE id = 1`, "-10"},
+		{`SELECT floor(balance) FROM account WHERE id = 1`, "-11"},
+		{`SELECT ceil(balance) FROM account WHERE id = 2`, "4"},
+	}
+	for _, tc := range numericTests {
+		var s string
+		err = db.QueryRow(tc.query).Scan(&s)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if s != tc.expected {
+			t.Fatalf("expected %s with '%s', got %s", tc.expected, tc.query, s)
+		}
+	}
+
+	// NULL arguments give NULL
+	nullTests := []string{
+		`SELECT lower(nickname) FROM account WHERE id = 2`,
+		`SELECT length(email) FROM account WHERE id = 3`,
+		`SELECT abs(score) FROM account WHERE id = 3`,
+		`SELECT substring(nickname, 1, 2) FROM account WHERE id = 2`,
+		`SELECT nullif(nickname, 'alice') FROM account WHERE id = 1`,
+		`SELECT greatest(score, NULL) FROM account WHERE id = 3`,
+		`SELECT replace(email, NULL, 'x') FROM account WHERE id = 2`,
+	}
+	for _, q := range nullTests {
+		var v sql.NullString
+		err = db.QueryRow(q).Scan(&v)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", q, err)
+		}
+		if v.Valid {
+			t.Fatalf("expected NULL with '%s', got %s", q, v.String)
+		}
+	}
+
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM account WHERE lower(trim(email)) = 'alice@example.com'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE upper(nickname) LIKE 'A%'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE coalesce(score, 0) >= 0`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE abs(score) > $1`, []any{10}, 1},
+		{`SELECT COUNT(*) FROM account WHERE length(nickname) = 5`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE nullif(nickname, 'alice') IS NULL`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE random() < 1`, nil, 3},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	_, err = db.Exec(`UPDATE account SET email = lower(trim(email)), nickname = coalesce(nickname, 'anonymous') WHERE id <> 3`)
+	if err != nil {
+		t.Fatalf("cannot update with functions: %s", err)
+	}
+	var email, nickname string
+	err = db.QueryRow(`SELECT email, nickname FROM account WHERE id = 1`).Scan(&email, &nickname)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if email != "alice@example.com" || nickname != "alice" {
+		t.Fatalf("unexpected values after update: %s, %s", email, nickname)
+	}
+	err = db.QueryRow(`SELECT nickname FROM account WHERE id = 2`).Scan(&nickname)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if nickname != "anonymous" {
+		t.Fatalf("expected anonymous, got %s", nickname)
+	}
+
+	invalid := []string{
+		`SELECT lower(email, nickname) FROM account`,
+		`SELECT lower(score) FROM account WHERE id = 1`,
+		`SELECT abs(email) FROM account WHERE id = 1`,
+		`SELECT substring(nickname, 1, -1) FROM account WHERE id = 1`,
+		`SELECT mod(score, 0) FROM account WHERE id = 1`,
+		`SELECT coalesce() FROM account`,
+		`SELECT nosuchfunction(email) FROM account`,
+	}
+	for _, q := range invalid {
+		rows, err := db.Query(q)
+		if err == nil {
+			rows.Close()
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package ramsql
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/proullon/ramsql/engine/agnostic"
+)
+
+func TestGenerate(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestGenerate")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, email TEXT UNIQUE NOT NULL, name TEXT, age INT, role TEXT)`,
+		`CREATE TABLE post (id BIGSERIAL PRIMARY KEY, account_id BIGINT NOT NULL REFERENCES account(id), reviewer_id BIGINT REFERENCES account, title TEXT NOT NULL, created_at TIMESTAMP)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	e, ok := db.Driver().(*Driver).Engine("TestGenerate")
+	if !ok {
+		t.Fatalf("cannot find engine")
+	}
+
+	// referenced relation is empty
+	_, err = e.Generate("", "post", 10, agnostic.GenerateOptions{})
+	if err == nil {
+		t.Fatalf("expected error generating rows referencing empty relation")
+	}
+
+	opts := agnostic.GenerateOptions{
+		Seed:      42,
+		NullRatio: 0.5,
+		Generators: map[string]agnostic.Generator{
+			"role": func(rnd *rand.Rand, row int) any {
+				return fmt.Sprintf("role_%d", row%3)
+			},
+		},
+	}
+	n, err := e.Generate("", "account", 100, opts)
+	if err != nil {
+		t.Fatalf("cannot generate accounts: %s", err)
+	}
+	if n != 100 {
+		t.Fatalf("expected 100 accounts, got %d", n)
+	}
+
+	_, err = e.Generate("", "post", 500, agnostic.GenerateOptions{Seed: 42, NullRatio: 0.5})
+	if err != nil {
+		t.Fatalf("cannot generate posts: %s", err)
+	}
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM account WHERE email IS NULL`).Scan(&count)
+	if err != nil {
+		t.Fatalf("cannot count: %s", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected no NULL email, got %d", count)
+	}
+
+	err = db.QueryRow(`SELECT COUNT(*) FROM account WHERE role = 'role_1'`).Scan(&count)
+	if err != nil {
+		t.Fatalf("cannot count: %s", err)
+	}
+	if count != 33 {
+		t.Fatalf("expected 33 accounts with overridden role, got %d", count)
+	}
+
+	ids := make(map[int64]bool)
+	rows, err := db.Query(`SELECT id FROM account`)
+	if err != nil {
+		t.Fatalf("cannot query accounts: %s", err)
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		ids[id] = true
+	}
+	rows.Close()
+
+	var nullReviewers int
+	rows, err = db.Query(`SELECT account_id, reviewer_id FROM post`)
+	if err != nil {
+		t.Fatalf("cannot query posts: %s", err)
+	}
+	for rows.Next() {
+		var accountID int64
+		var reviewerID sql.NullInt64
+		if err := rows.Scan(&accountID, &reviewerID); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		if !ids[accountID] {
+			t.Fatalf("post references unknown account %d", accountID)
+		}
+		if !reviewerID.Valid {
+			nullReviewers++
+			continue
+		}
+		if !ids[reviewerID.Int64] {
+			t.Fatalf("post references unknown reviewer %d", reviewerID.Int64)
+		}
+	}
+	rows.Close()
+	if nullReviewers == 0 {
+		t.Fatalf("expected some nullable reviewers to be NULL")
+	}
+
+	// same seed generates same data
+	_, err = db.Exec(`CREATE TABLE other (id BIGSERIAL PRIMARY KEY, email TEXT UNIQUE NOT NULL, name TEXT, age INT, role TEXT)`)
+	if err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+	_, err = e.Generate("", "other", 100, opts)
+	if err != nil {
+		t.Fatalf("cannot generate: %s", err)
+	}
+	var email, otherEmail string
+	err = db.QueryRow(`SELECT email FROM account WHERE id = 50`).Scan(&email)
+	if err != nil {
+		t.Fatalf("cannot query: %s", err)
+	}
+	err = db.QueryRow(`SELECT email FROM other WHERE id = 50`).Scan(&otherEmail)
+	if err != nil {
+		t.Fatalf("cannot query: %s", err)
+	}
+	if email != otherEmail {
+		t.Fatalf("expected same seed to generate same data, got %s and %s", email, otherEmail)
+	}
+
+	// domain check constraints are satisfied by retrying values
+	batch = []string{
+		`CREATE DOMAIN even AS INT CHECK (VALUE % 2 = 0)`,
+		`CREATE DOMAIN tag AS TEXT CHECK (VALUE LIKE '%o%')`,
+		`CREATE DOMAIN never AS INT CHECK (VALUE < 0)`,
+		`CREATE TABLE checked (id BIGSERIAL PRIMARY KEY, score even NOT NULL, label tag)`,
+		`CREATE TABLE unchecked (id BIGSERIAL PRIMARY KEY, score never)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	_, err = e.Generate("", "checked", 100, agnostic.GenerateOptions{Seed: 7})
+	if err != nil {
+		t.Fatalf("cannot generate rows with domain check: %s", err)
+	}
+	err = db.QueryRow(`SELECT COUNT(*) FROM checked WHERE score % 2 = 0 AND label LIKE '%o%'`).Scan(&count)
+	if err != nil {
+		t.Fatalf("cannot query: %s", err)
+	}
+	if count != 100 {
+		t.Fatalf("expected 100 rows satisfying domain checks, got %d", count)
+	}
+	_, err = e.Generate("", "unchecked", 1, agnostic.GenerateOptions{})
+	if err == nil || !strings.Contains(err.Error(), "domain never") {
+		t.Fatalf("expected error generating values never satisfying domain check, got %v", err)
+	}
+
+	// unique constraint cannot be satisfied
+	_, err = e.Generate("", "account", 2, agnostic.GenerateOptions{
+		Generators: map[string]agnostic.Generator{
+			"email": func(rnd *rand.Rand, row int) any { return "same@example.com" },
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error generating duplicated unique values")
+	}
+}
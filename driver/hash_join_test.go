@@ -0,0 +1,326 @@
+package ramsql
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+func TestHashJoin(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestHashJoin")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, code CHAR(8))`,
+		`CREATE TABLE payments (id BIGSERIAL PRIMARY KEY, user_id BIGINT, code TEXT, amount INT)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// accounts 1 to 300, payments of accounts 0 to 399, every tenth without account
+	accounts, payments := 300, 600
+	for i := 1; i <= accounts; i++ {
+		_, err = db.Exec(`INSERT INTO account (code) VALUES ($1)`, fmt.Sprintf("c%d", i))
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	inner, first, codes, matched := 0, 0, 0, make(map[int]bool)
+	for i := 0; i < payments; i++ {
+		u := i % 400
+		if u >= 1 && u <= accounts {
+			codes++
+		}
+		var userID any
+		if i%10 != 0 {
+			userID = u
+			if u >= 1 && u <= accounts {
+				inner++
+				matched[u] = true
+			}
+			if u >= 1 && u <= 10 {
+				first++
+			}
+		}
+		_, err = db.Exec(`INSERT INTO payments (user_id, code, amount) VALUES ($1, $2, $3)`, userID, fmt.Sprintf("c%d", i%400), i)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	testCases := []struct {
+		query    string
+		expected int
+	}{
+		{
+			`SELECT COUNT(payments.id) FROM account JOIN payments ON payments.user_id = account.id`,
+			inner,
+		},
+		{
+			`SELECT COUNT(account.id) FROM account LEFT JOIN payments ON payments.user_id = account.id`,
+			inner + accounts - len(matched),
+		},
+		{
+			`SELECT COUNT(payments.id) FROM account RIGHT JOIN payments ON payments.user_id = account.id`,
+			payments,
+		},
+		{
+			`SELECT COUNT(*) FROM account FULL JOIN payments ON payments.user_id = account.id`,
+			payments + accounts - len(matched),
+		},
+		{
+			`SELECT COUNT(*) FROM account JOIN payments ON payments.code = account.code`,
+			codes,
+		},
+		{
+			`SELECT COUNT(*) FROM account JOIN payments ON payments.user_id = account.id WHERE account.id <= 10`,
+			first,
+		},
+	}
+	for _, tc := range testCases {
+		var n int
+		err := db.QueryRow(tc.query).Scan(&n)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if n != tc.expected {
+			t.Fatalf("expected %d with '%s', got %d", tc.expected, tc.query, n)
+		}
+	}
+
+	// rows are produced in nested loop order
+	query := `SELECT account.id, payments.amount FROM account JOIN payments ON payments.user_id = account.id WHERE account.id < 3`
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("cannot query '%s': %s", query, err)
+	}
+	defer rows.Close()
+	var res []int
+	for rows.Next() {
+		var id, amount int
+		if err := rows.Scan(&id, &amount); err != nil {
+			t.Fatalf("cannot scan '%s': %s", query, err)
+		}
+		res = append(res, id, amount)
+	}
+	if expected := "[1 1 1 401 2 2 2 402]"; fmt.Sprint(res) != expected {
+		t.Fatalf("expected %s with '%s', got %v", expected, query, res)
+	}
+}
+
+func TestMergeJoin(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestMergeJoin")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE profile (id BIGSERIAL PRIMARY KEY, bio TEXT)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// both relations stored in id order, every fifth account without profile
+	accounts := 300
+	for i := 1; i <= accounts; i++ {
+		_, err = db.Exec(`INSERT INTO account (name) VALUES ($1)`, fmt.Sprintf("a%d", i))
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+		_, err = db.Exec(`INSERT INTO profile (bio) VALUES ($1)`, fmt.Sprintf("b%d", i))
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	_, err = db.Exec(`DELETE FROM profile WHERE id % 5 = 0`)
+	if err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+	profiles := accounts - accounts/5
+
+	testCases := []struct {
+		query    string
+		expected int
+	}{
+		{
+			`SELECT COUNT(*) FROM account JOIN profile ON profile.id = account.id`,
+			profiles,
+		},
+		{
+			`SELECT COUNT(*) FROM account JOIN profile ON profile.id = account.id AND profile.bio = 'b1'`,
+			1,
+		},
+		{
+			`SELECT COUNT(account.id) FROM account LEFT JOIN profile ON profile.id = account.id`,
+			accounts,
+		},
+		{
+			`SELECT COUNT(profile.id) FROM account LEFT JOIN profile ON profile.id = account.id`,
+			profiles,
+		},
+	}
+	for _, tc := range testCases {
+		var n int
+		err := db.QueryRow(tc.query).Scan(&n)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if n != tc.expected {
+			t.Fatalf("expected %d with '%s', got %d", tc.expected, tc.query, n)
+		}
+	}
+
+	// rows are produced in nested loop order
+	query := `SELECT account.name, profile.bio FROM account JOIN profile ON profile.id = account.id`
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("cannot query '%s': %s", query, err)
+	}
+	defer rows.Close()
+	i, n := 0, 0
+	for rows.Next() {
+		var name, bio string
+		if err := rows.Scan(&name, &bio); err != nil {
+			t.Fatalf("cannot scan '%s': %s", query, err)
+		}
+		n++
+		i++
+		if i%5 == 0 {
+			i++
+		}
+		if name != fmt.Sprintf("a%d", i) || bio != fmt.Sprintf("b%d", i) {
+			t.Fatalf("expected a%d and b%d with '%s', got %s and %s", i, i, query, name, bio)
+		}
+	}
+	if n != profiles {
+		t.Fatalf("expected %d rows with '%s', got %d", profiles, query, n)
+	}
+}
+
+func TestMultiKeyJoin(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestMultiKeyJoin")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE stock (id BIGSERIAL PRIMARY KEY, shop INT, item INT, qty INT)`,
+		`CREATE TABLE price (id BIGSERIAL PRIMARY KEY, shop INT, item INT, amount INT)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// stock of items 0 to 19 in shops 0 to 9, prices of items 0 to 14 in shops 0 to 11
+	for shop := 0; shop < 10; shop++ {
+		for item := 0; item < 20; item++ {
+			_, err = db.Exec(`INSERT INTO stock (shop, item, qty) VALUES ($1, $2, $3)`, shop, item, item)
+			if err != nil {
+				t.Fatalf("sql.Exec: Error: %s\n", err)
+			}
+		}
+	}
+	for shop := 0; shop < 12; shop++ {
+		for item := 0; item < 15; item++ {
+			_, err = db.Exec(`INSERT INTO price (shop, item, amount) VALUES ($1, $2, $3)`, shop, item, shop*100+item)
+			if err != nil {
+				t.Fatalf("sql.Exec: Error: %s\n", err)
+			}
+		}
+	}
+
+	testCases := []struct {
+		query    string
+		expected int
+	}{
+		{
+			`SELECT COUNT(*) FROM stock JOIN price ON price.shop = stock.shop AND price.item = stock.item`,
+			150,
+		},
+		{
+			`SELECT COUNT(*) FROM stock JOIN price USING (shop, item)`,
+			150,
+		},
+		{
+			`SELECT COUNT(*) FROM stock, price WHERE price.shop = stock.shop AND price.item = stock.item`,
+			150,
+		},
+		{
+			`SELECT COUNT(*) FROM stock CROSS JOIN price WHERE stock.item = price.item AND stock.shop = price.shop`,
+			150,
+		},
+		{
+			`SELECT COUNT(*) FROM stock JOIN price ON price.shop = stock.shop WHERE price.item = stock.item`,
+			150,
+		},
+		{
+			`SELECT COUNT(*) FROM stock, price WHERE price.shop = stock.shop AND price.item = stock.item AND stock.qty < 5`,
+			50,
+		},
+		{
+			`SELECT COUNT(*) FROM stock JOIN price ON price.shop = stock.shop AND price.item = stock.item AND price.amount >= 500`,
+			75,
+		},
+		{
+			`SELECT COUNT(*) FROM stock LEFT JOIN price ON price.shop = stock.shop AND price.item = stock.item AND price.amount >= 500`,
+			200,
+		},
+		{
+			`SELECT COUNT(price.id) FROM stock LEFT JOIN price ON price.shop = stock.shop AND price.item = stock.item AND price.amount >= 500`,
+			75,
+		},
+		{
+			`SELECT COUNT(*) FROM stock LEFT JOIN price ON price.shop = stock.shop WHERE price.item = stock.item`,
+			150,
+		},
+	}
+	for _, tc := range testCases {
+		var n int
+		err := db.QueryRow(tc.query).Scan(&n)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if n != tc.expected {
+			t.Fatalf("expected %d with '%s', got %d", tc.expected, tc.query, n)
+		}
+	}
+
+	// rows are produced in nested loop order
+	query := `SELECT stock.id, price.amount FROM stock, price WHERE price.shop = stock.shop AND price.item = stock.item AND stock.qty < 2 AND stock.shop < 2`
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("cannot query '%s': %s", query, err)
+	}
+	defer rows.Close()
+	var res []int
+	for rows.Next() {
+		var id, amount int
+		if err := rows.Scan(&id, &amount); err != nil {
+			t.Fatalf("cannot scan '%s': %s", query, err)
+		}
+		res = append(res, id, amount)
+	}
+	if expected := "[1 0 2 1 21 100 22 101]"; fmt.Sprint(res) != expected {
+		t.Fatalf("expected %s with '%s', got %v", expected, query, res)
+	}
+}
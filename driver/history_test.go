@@ -0,0 +1,201 @@
+package ramsql
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestHistory")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	if err = db.Ping(); err != nil {
+		t.Fatalf("cannot ping: %s", err)
+	}
+
+	e, ok := db.Driver().(*Driver).Engine("TestHistory")
+	if !ok {
+		t.Fatalf("cannot find engine")
+	}
+	err = e.EnableHistory(10)
+	if err != nil {
+		t.Fatalf("cannot enable history: %s", err)
+	}
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, email TEXT)`,
+		`INSERT INTO account (email) VALUES ('foo@bar.com')`,
+		`INSERT INTO account (email) VALUES ('bar@bar.com')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	_, err = db.Exec(`UPDATE account SET email = $1 WHERE id = $2`, "baz@bar.com", 2)
+	if err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO nope (email) VALUES ('foo@bar.com')`)
+	if err == nil {
+		t.Fatalf("expected error inserting into non existing relation")
+	}
+
+	h := e.History()
+	if len(h) != 5 {
+		t.Fatalf("expected 5 entries in history, got %d", len(h))
+	}
+
+	var updates int
+	for _, entry := range h {
+		if strings.HasPrefix(entry.Query, "UPDATE") {
+			updates++
+			if entry.RowsAffected != 1 {
+				t.Fatalf("expected 1 row affected by update, got %d", entry.RowsAffected)
+			}
+			if len(entry.Args) != 2 || entry.Args[0].Value != "baz@bar.com" {
+				t.Fatalf("unexpected update arguments: %v", entry.Args)
+			}
+		}
+		if entry.Tx == 0 || entry.Conn == 0 {
+			t.Fatalf("expected transaction and connection to be set: %s", entry)
+		}
+	}
+	if updates != 1 {
+		t.Fatalf("expected exactly 1 update, got %d", updates)
+	}
+	if h[4].Err == nil {
+		t.Fatalf("expected last entry to have an error")
+	}
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM ramsql_history WHERE rows_affected = 1`).Scan(&count)
+	if err != nil {
+		t.Fatalf("cannot query history: %s", err)
+	}
+	if count != 4 {
+		t.Fatalf("expected 4 statements with 1 row affected, got %d", count)
+	}
+
+	// queries report no row affected, like sql.Result
+	h = e.History()
+	if entry := h[len(h)-1]; !strings.HasPrefix(entry.Query, "SELECT") || entry.RowsAffected != 0 {
+		t.Fatalf("expected SELECT with 0 row affected, got %s", entry)
+	}
+
+	_, err = db.Exec(`DELETE FROM ramsql_history WHERE id = 1`)
+	if err == nil {
+		t.Fatalf("expected error deleting from history")
+	}
+
+	e.ClearHistory()
+	err = e.EnableHistory(2)
+	if err != nil {
+		t.Fatalf("cannot enable history: %s", err)
+	}
+	for i := 0; i < 3; i++ {
+		_, err = db.Exec(`INSERT INTO account (email) VALUES ('foo@bar.com')`)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	if l := len(e.History()); l != 2 {
+		t.Fatalf("expected history to be bounded to 2 entries, got %d", l)
+	}
+
+	e.DisableHistory()
+	_, err = db.Exec(`INSERT INTO account (email) VALUES ('foo@bar.com')`)
+	if err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+	if l := len(e.History()); l != 2 {
+		t.Fatalf("expected no new entry in history, got %d", l)
+	}
+	_, err = db.Exec(`SELECT COUNT(*) FROM ramsql_history`)
+	if err == nil {
+		t.Fatalf("expected ramsql_history to be removed when history is disabled")
+	}
+}
+
+func TestHistoryRelationName(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestHistoryRelationName")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	// history is disabled, the name is free
+	_, err = db.Exec(`CREATE TABLE ramsql_history (id BIGSERIAL PRIMARY KEY, query TEXT)`)
+	if err != nil {
+		t.Fatalf("cannot create ramsql_history table: %s", err)
+	}
+	_, err = db.Exec(`INSERT INTO ramsql_history (query) VALUES ('foo')`)
+	if err != nil {
+		t.Fatalf("cannot insert into ramsql_history table: %s", err)
+	}
+
+	e, ok := db.Driver().(*Driver).Engine("TestHistoryRelationName")
+	if !ok {
+		t.Fatalf("cannot find engine")
+	}
+	err = e.EnableHistory(10)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected error enabling history with existing ramsql_history relation, got %v", err)
+	}
+}
+
+func TestHistoryToggle(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestHistoryToggle")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	if err = db.Ping(); err != nil {
+		t.Fatalf("cannot ping: %s", err)
+	}
+
+	e, ok := db.Driver().(*Driver).Engine("TestHistoryToggle")
+	if !ok {
+		t.Fatalf("cannot find engine")
+	}
+
+	// ramsql_history is scanned while registered and removed
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			var n int
+			err := db.QueryRow(`SELECT COUNT(*) FROM ramsql_history`).Scan(&n)
+			if err != nil && !strings.Contains(err.Error(), "does not exist") {
+				t.Errorf("cannot query ramsql_history: %s", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		if err := e.EnableHistory(10); err != nil {
+			t.Fatalf("cannot enable history: %s", err)
+		}
+		e.DisableHistory()
+	}
+	<-done
+
+	if err := e.EnableHistory(10); err != nil {
+		t.Fatalf("cannot enable history: %s", err)
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ramsql_history`).Scan(&n); err != nil {
+		t.Fatalf("cannot query ramsql_history: %s", err)
+	}
+}
@@ -0,0 +1,70 @@
+package ramsql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestIndexPredicate(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestIndexPredicate")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, name TEXT UNIQUE, age INT)`,
+		`INSERT INTO account (name, age) VALUES ('alice', 30)`,
+		`INSERT INTO account (name, age) VALUES ('bob', 40)`,
+		`INSERT INTO account (name, age) VALUES ('carol', 50)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// an indexed condition under OR does not restrict rows
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT id FROM account WHERE id = 1`, "[1]"},
+		{`SELECT id FROM account WHERE id = 1 OR name = 'carol' ORDER BY id`, "[1 3]"},
+		{`SELECT id FROM account WHERE name = 'alice' OR age = 50 ORDER BY id`, "[1 3]"},
+		{`SELECT id FROM account WHERE age > 35 AND id = 2`, "[2]"},
+		{`SELECT id FROM account WHERE name = 'bob' AND age = 40`, "[2]"},
+	}
+	for _, tc := range testCases {
+		rows, err := db.Query(tc.query)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		var res []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("cannot scan '%s': %s", tc.query, err)
+			}
+			res = append(res, id)
+		}
+		rows.Close()
+		if fmt.Sprint(res) != tc.expected {
+			t.Fatalf("expected %s with '%s', got %v", tc.expected, tc.query, res)
+		}
+	}
+
+	// unique values are checked against the index
+	_, err = db.Exec(`INSERT INTO account (name, age) VALUES ('dave', 30)`)
+	if err != nil {
+		t.Fatalf("cannot insert unique value: %s", err)
+	}
+	_, err = db.Exec(`INSERT INTO account (name, age) VALUES ('bob', 60)`)
+	if err == nil || !strings.Contains(err.Error(), "unicity") {
+		t.Fatalf("expected unicity violation, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package ramsql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestInterval(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestInterval")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE session (id BIGSERIAL PRIMARY KEY, name TEXT, created_at TIMESTAMP, ttl INTERVAL DEFAULT '1 hour', expires_at TIMESTAMP DEFAULT now() + interval '1 day')`,
+		`INSERT INTO session (name, created_at, ttl) VALUES ('old', now() - interval '3 days', '2 days')`,
+		`INSERT INTO session (name, created_at, ttl) VALUES ('recent', now() - interval '2 hours', '1 day 02:30:00')`,
+		`INSERT INTO session (name, created_at) VALUES ('new', now())`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	invalid := []string{
+		`INSERT INTO session (name, ttl) VALUES ('bad', '1 fortnight')`,
+		`SELECT name FROM session WHERE created_at > now() - interval 'one day'`,
+		`SELECT name FROM session WHERE created_at + created_at > now()`,
+	}
+	for _, q := range invalid {
+		_, err = db.Exec(q)
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM session WHERE created_at > now() - interval '1 day'`, nil, 2},
+		{`SELECT COUNT(*) FROM session WHERE created_at > now() - interval '1 hour'`, nil, 1},
+		{`SELECT COUNT(*) FROM session WHERE now() - interval '1 week' < created_at`, nil, 3},
+		{`SELECT COUNT(*) FROM session WHERE created_at + ttl > now()`, nil, 2},
+		{`SELECT COUNT(*) FROM session WHERE expires_at > now() + interval '23 hours'`, nil, 3},
+		{`SELECT COUNT(*) FROM session WHERE ttl = '48 hours'`, nil, 1},
+		{`SELECT COUNT(*) FROM session WHERE ttl = $1`, []any{"1 day 2.5 hours"}, 1},
+		{`SELECT COUNT(*) FROM session WHERE ttl > interval '1 day'`, nil, 2},
+		{`SELECT COUNT(*) FROM session WHERE ttl < '90 min'`, nil, 1},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	_, err = db.Exec(`UPDATE session SET ttl = ttl + interval '1 mon', expires_at = created_at + interval '1 year' WHERE name = 'old'`)
+	if err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+
+	var ttl string
+	var createdAt, expiresAt time.Time
+	err = db.QueryRow(`SELECT ttl, created_at, expires_at FROM session WHERE name = 'old'`).Scan(&ttl, &createdAt, &expiresAt)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if ttl != "1 mon 2 days" {
+		t.Fatalf("expected 1 mon 2 days, got %s", ttl)
+	}
+	if !expiresAt.Equal(createdAt.AddDate(1, 0, 0)) {
+		t.Fatalf("expected %v, got %v", createdAt.AddDate(1, 0, 0), expiresAt)
+	}
+
+	formatTests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT ttl FROM session WHERE name = 'recent'`, "1 day 02:30:00"},
+		{`SELECT ttl FROM session WHERE name = 'new'`, "01:00:00"},
+		{`SELECT make_interval(1, 2, 0, 3, 4, 5, 6.5) FROM session WHERE name = 'new'`, "1 year 2 mons 3 days 04:05:06.5"},
+		{`SELECT make_interval(0, 0, 2) FROM session WHERE name = 'new'`, "14 days"},
+		{`SELECT age('2024-03-15', '2023-01-31 12:00:00') FROM session WHERE name = 'new'`, "1 year 1 mon 14 days 12:00:00"},
+		{`SELECT age('2023-01-31', '2024-03-15') FROM session WHERE name = 'new'`, "-1 years -1 mons -15 days"},
+		{`SELECT interval '1 day' - interval '36 hours' FROM session WHERE name = 'new'`, "1 day -36:00:00"},
+	}
+	for _, tc := range formatTests {
+		var got string
+		err = db.QueryRow(tc.query).Scan(&got)
+		if err != nil {
+			t.Fatalf("cannot select with '%s': %s", tc.query, err)
+		}
+		if got != tc.expected {
+			t.Fatalf("expected %q with '%s', got %q", tc.expected, tc.query, got)
+		}
+	}
+}
+
+func TestDateFunctions(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestDateFunctions")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE event (id BIGSERIAL PRIMARY KEY, name TEXT, at TIMESTAMP, day DATE DEFAULT date_trunc('day', now()))`,
+		`INSERT INTO event (name, at) VALUES ('launch', '2024-02-29 14:35:07.25')`,
+		`INSERT INTO event (name, at) VALUES ('review', '2024-03-04 09:05:00')`,
+		`INSERT INTO event (name, at) VALUES ('release', '2024-05-17 23:59:59')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	truncTests := []struct {
+		query    string
+		expected time.Time
+	}{
+		{`SELECT date_trunc('month', at) FROM event WHERE name = 'launch'`, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{`SELECT date_trunc('hour', at) FROM event WHERE name = 'launch'`, time.Date(2024, time.February, 29, 14, 0, 0, 0, time.UTC)},
+		{`SELECT date_trunc('week', at) FROM event WHERE name = 'launch'`, time.Date(2024, time.February, 26, 0, 0, 0, 0, time.UTC)},
+		{`SELECT date_trunc('quarter', at) FROM event WHERE name = 'release'`, time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{`SELECT date_trunc('year', at) + interval '1 month' FROM event WHERE name = 'release'`, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{`SELECT at + interval '1 month' FROM event WHERE name = 'launch'`, time.Date(2024, time.March, 29, 14, 35, 7, 250000000, time.UTC)},
+	}
+	for _, tc := range truncTests {
+		var got time.Time
+		err = db.QueryRow(tc.query).Scan(&got)
+		if err != nil {
+			t.Fatalf("cannot select with '%s': %s", tc.query, err)
+		}
+		if !got.Equal(tc.expected) {
+			t.Fatalf("expected %v with '%s', got %v", tc.expected, tc.query, got)
+		}
+	}
+
+	partTests := []struct {
+		query    string
+		expected float64
+	}{
+		{`SELECT extract(year FROM at) FROM event WHERE name = 'launch'`, 2024},
+		{`SELECT EXTRACT(DOY FROM at) FROM event WHERE name = 'launch'`, 60},
+		{`SELECT date_part('second', at) FROM event WHERE name = 'launch'`, 7.25},
+		{`SELECT date_part('dow', at) FROM event WHERE name = 'launch'`, 4},
+		{`SELECT date_part('quarter', at) FROM event WHERE name = 'release'`, 2},
+		{`SELECT extract(epoch FROM at) FROM event WHERE name = 'review'`, 1709543100},
+		{`SELECT extract(hour FROM interval '1 day 05:30:00') FROM event WHERE name = 'review'`, 5},
+		{`SELECT extract(epoch FROM at - date_trunc('day', at)) FROM event WHERE name = 'review'`, 32700},
+	}
+	for _, tc := range partTests {
+		var got float64
+		err = db.QueryRow(tc.query).Scan(&got)
+		if err != nil {
+			t.Fatalf("cannot select with '%s': %s", tc.query, err)
+		}
+		if got != tc.expected {
+			t.Fatalf("expected %v with '%s', got %v", tc.expected, tc.query, got)
+		}
+	}
+
+	charTests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT to_char(at, 'YYYY-MM-DD HH24:MI:SS.MS') FROM event WHERE name = 'launch'`, "2024-02-29 14:35:07.250"},
+		{`SELECT to_char(at, 'FMDay, DD Mon YYYY HH12:MI AM') FROM event WHERE name = 'launch'`, "Thursday, 29 Feb 2024 02:35 PM"},
+		{`SELECT to_char(at, 'Month|MON|"Q"Q|DDD') FROM event WHERE name = 'review'`, "March    |MAR|Q1|064"},
+	}
+	for _, tc := range charTests {
+		var got string
+		err = db.QueryRow(tc.query).Scan(&got)
+		if err != nil {
+			t.Fatalf("cannot select with '%s': %s", tc.query, err)
+		}
+		if got != tc.expected {
+			t.Fatalf("expected %q with '%s', got %q", tc.expected, tc.query, got)
+		}
+	}
+
+	countTests := []struct {
+		query string
+		count int
+	}{
+		{`SELECT COUNT(*) FROM event WHERE date_trunc('month', at) = '2024-03-01'`, 1},
+		{`SELECT COUNT(*) FROM event WHERE extract(month FROM at) = 2`, 1},
+		{`SELECT COUNT(*) FROM event WHERE age(at, '2024-01-01') < interval '2 months'`, 1},
+		{`SELECT COUNT(*) FROM event WHERE day = date_trunc('day', now())`, 3},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	invalid := []string{
+		`SELECT date_trunc('fortnight', at) FROM event`,
+		`SELECT extract(fortnight FROM at) FROM event`,
+		`SELECT to_char(at) FROM event`,
+		`SELECT make_interval(1.5) FROM event`,
+	}
+	for _, q := range invalid {
+		rows, err := db.Query(q)
+		if err == nil {
+			for rows.Next() {
+			}
+			err = rows.Err()
+			rows.Close()
+		}
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+}
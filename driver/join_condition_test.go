@@ -0,0 +1,229 @@
+package ramsql
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestJoinCondition(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestJoinCondition")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, name TEXT, region TEXT)`,
+		`CREATE TABLE payments (id BIGSERIAL PRIMARY KEY, user_id BIGINT, region TEXT, amount INT)`,
+		`CREATE TABLE tier (name TEXT, low INT, high INT)`,
+		`INSERT INTO account (name, region) VALUES ('alice', 'eu')`,
+		`INSERT INTO account (name, region) VALUES ('bob', 'us')`,
+		`INSERT INTO account (name, region) VALUES ('carol', 'eu')`,
+		`INSERT INTO payments (user_id, region, amount) VALUES (1, 'eu', 40)`,
+		`INSERT INTO payments (user_id, region, amount) VALUES (1, 'us', 30)`,
+		`INSERT INTO payments (user_id, region, amount) VALUES (2, 'us', 50)`,
+		`INSERT INTO tier (name, low, high) VALUES ('small', 0, 35)`,
+		`INSERT INTO tier (name, low, high) VALUES ('big', 36, 100)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// each query returns 2 columns, NULL printed as empty string
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{
+			`SELECT account.name, payments.id FROM account JOIN payments ON payments.user_id = account.id AND payments.region = account.region ORDER BY payments.id`,
+			"[alice 1 bob 3]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account LEFT JOIN payments ON payments.user_id = account.id AND payments.region = account.region ORDER BY account.id`,
+			"[alice 1 bob 3 carol ]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account RIGHT JOIN payments ON payments.user_id = account.id AND payments.amount > 35 ORDER BY payments.id`,
+			"[alice 1  2 bob 3]",
+		},
+		{
+			`SELECT account.name, COUNT(payments.id) FROM account JOIN payments ON payments.user_id = account.id OR payments.amount = 50 GROUP BY account.name ORDER BY account.name`,
+			"[alice 3 bob 1 carol 1]",
+		},
+		{
+			`SELECT payments.id, tier.name FROM payments JOIN tier ON payments.amount BETWEEN tier.low AND tier.high ORDER BY payments.id`,
+			"[1 big 2 small 3 big]",
+		},
+		{
+			`SELECT payments.id, tier.name FROM payments JOIN tier ON payments.amount >= tier.low AND payments.amount <= tier.high ORDER BY payments.id`,
+			"[1 big 2 small 3 big]",
+		},
+		{
+			`SELECT account.name, COUNT(*) FROM account JOIN payments USING (region) GROUP BY account.name ORDER BY account.name`,
+			"[alice 1 bob 2 carol 1]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account JOIN payments USING (id, region) ORDER BY payments.id`,
+			"[alice 1 bob 2]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account CROSS JOIN payments WHERE account.id = 3 ORDER BY payments.id`,
+			"[carol 1 carol 2 carol 3]",
+		},
+		{
+			`SELECT account.name, COUNT(*) FROM account CROSS JOIN payments GROUP BY account.name ORDER BY account.name`,
+			"[alice 3 bob 3 carol 3]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account, payments WHERE payments.user_id = account.id AND payments.amount > 35 ORDER BY payments.id`,
+			"[alice 1 bob 3]",
+		},
+		{
+			`SELECT a.name, p.id FROM account AS a, payments AS p WHERE p.user_id = a.id ORDER BY p.id`,
+			"[alice 1 alice 2 bob 3]",
+		},
+		{
+			`SELECT a.name, p.id FROM account AS a JOIN payments AS p ON p.user_id = a.id AND p.amount < 45 ORDER BY p.id`,
+			"[alice 1 alice 2]",
+		},
+		{
+			`SELECT account.name, tier.name FROM account, payments JOIN tier ON payments.amount BETWEEN tier.low AND tier.high WHERE payments.user_id = account.id ORDER BY payments.id`,
+			"[alice big alice small bob big]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account JOIN payments ON payments.user_id = account.id WHERE account.id = 2 OR payments.amount = 40 ORDER BY payments.id`,
+			"[alice 1 bob 3]",
+		},
+	}
+	for _, tc := range testCases {
+		rows, err := db.Query(tc.query)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		var res []string
+		for rows.Next() {
+			var a, b sql.NullString
+			if err := rows.Scan(&a, &b); err != nil {
+				t.Fatalf("cannot scan '%s': %s", tc.query, err)
+			}
+			res = append(res, a.String, b.String)
+		}
+		rows.Close()
+		if fmt.Sprint(res) != tc.expected {
+			t.Fatalf("expected %s with '%s', got %v", tc.expected, tc.query, res)
+		}
+	}
+
+	invalid := []struct {
+		query string
+		err   string
+	}{
+		{`SELECT account.name FROM account JOIN payments USING (amount)`, `column amount specified in USING clause does not exist in left table`},
+		{`SELECT account.name FROM account JOIN payments USING (name)`, `column name specified in USING clause does not exist in right table`},
+	}
+	for _, tc := range invalid {
+		rows, err := db.Query(tc.query)
+		if err == nil {
+			rows.Close()
+			t.Fatalf("expected error with '%s'", tc.query)
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("expected error '%s' with '%s', got '%s'", tc.err, tc.query, err)
+		}
+	}
+}
+
+func TestJoinStar(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestJoinStar")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, name TEXT, region TEXT)`,
+		`CREATE TABLE payments (id BIGSERIAL PRIMARY KEY, user_id BIGINT, region TEXT, amount INT)`,
+		`INSERT INTO account (name, region) VALUES ('alice', 'eu')`,
+		`INSERT INTO account (name, region) VALUES ('bob', 'us')`,
+		`INSERT INTO account (name, region) VALUES ('carol', 'eu')`,
+		`INSERT INTO payments (user_id, region, amount) VALUES (1, 'eu', 40)`,
+		`INSERT INTO payments (user_id, region, amount) VALUES (1, 'us', 30)`,
+		`INSERT INTO payments (user_id, region, amount) VALUES (2, 'us', 50)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// columns of USING are listed once, first, followed by other columns of both relations. NULL printed as empty string
+	testCases := []struct {
+		query   string
+		columns string
+		rows    []string
+	}{
+		{
+			`SELECT * FROM account JOIN payments USING (region) WHERE payments.id = 1 ORDER BY account.id`,
+			"[region id name id user_id amount]",
+			[]string{"[eu 1 alice 1 1 40]", "[eu 3 carol 1 1 40]"},
+		},
+		{
+			`SELECT * FROM account LEFT JOIN payments USING (id, region) ORDER BY account.id`,
+			"[id region name user_id amount]",
+			[]string{"[1 eu alice 1 40]", "[2 us bob 1 30]", "[3 eu carol  ]"},
+		},
+		{
+			`SELECT * FROM account RIGHT JOIN payments USING (id, region) ORDER BY payments.id`,
+			"[id region name user_id amount]",
+			[]string{"[1 eu alice 1 40]", "[2 us bob 1 30]", "[3 us  2 50]"},
+		},
+		{
+			`SELECT * FROM account FULL JOIN payments USING (id, region)`,
+			"[id region name user_id amount]",
+			[]string{"[1 eu alice 1 40]", "[2 us bob 1 30]", "[3 eu carol  ]", "[3 us  2 50]"},
+		},
+	}
+	for _, tc := range testCases {
+		rows, err := db.Query(tc.query)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		columns, err := rows.Columns()
+		if err != nil {
+			t.Fatalf("cannot get columns of '%s': %s", tc.query, err)
+		}
+		if fmt.Sprint(columns) != tc.columns {
+			t.Fatalf("expected columns %s with '%s', got %v", tc.columns, tc.query, columns)
+		}
+		var res []string
+		for rows.Next() {
+			values := make([]sql.NullString, len(columns))
+			dest := make([]any, len(columns))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				t.Fatalf("cannot scan '%s': %s", tc.query, err)
+			}
+			row := make([]string, len(values))
+			for i, v := range values {
+				row[i] = v.String
+			}
+			res = append(res, fmt.Sprint(row))
+		}
+		rows.Close()
+		sort.Strings(res)
+		if fmt.Sprint(res) != fmt.Sprint(tc.rows) {
+			t.Fatalf("expected %v with '%s', got %v", tc.rows, tc.query, res)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package ramsql
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestJSONB(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestJSONB")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE event (id BIGSERIAL PRIMARY KEY, stream TEXT, data JSONB)`,
+		`INSERT INTO event (stream, data) VALUES ('order', '{"type": "created", "total": 12.50, "customer": {"name": "Joe", "tier": 2}, "tags": ["new", "web"]}')`,
+		`INSERT INTO event (stream, data) VALUES ('order', '{"type":"paid","total":12.5,"customer":{"name":"Joe","tier":2},"tags":["web"]}')`,
+		`INSERT INTO event (stream, data) VALUES ('user', '{"type": "registered", "customer": {"name": "Jane", "tier": 1}, "tags": []}')`,
+		`INSERT INTO event (stream, data) VALUES ('user', NULL)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// invalid documents are rejected
+	_, err = db.Exec(`INSERT INTO event (stream, data) VALUES ('order', '{"type": ')`)
+	if err == nil {
+		t.Fatalf("expected error inserting invalid JSON")
+	}
+	_, err = db.Exec(`INSERT INTO event (stream, data) VALUES ($1, $2)`, "order", "not json")
+	if err == nil {
+		t.Fatalf("expected error inserting invalid JSON argument")
+	}
+
+	// documents are normalized
+	var data string
+	err = db.QueryRow(`SELECT data FROM event WHERE id = 1`).Scan(&data)
+	if err != nil {
+		t.Fatalf("cannot select data: %s", err)
+	}
+	expected := `{"customer":{"name":"Joe","tier":2},"tags":["new","web"],"total":12.50,"type":"created"}`
+	if data != expected {
+		t.Fatalf("expected normalized document %s, got %s", expected, data)
+	}
+
+	// documents compare by JSON semantics
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM event WHERE data = $1`, `{"tags": ["web"], "type": "paid", "total": 12.50, "customer": {"tier": 2, "name": "Joe"}}`).Scan(&count)
+	if err != nil {
+		t.Fatalf("cannot count: %s", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 equal document, got %d", count)
+	}
+
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM event WHERE data->>'type' = 'paid'`, nil, 1},
+		{`SELECT COUNT(*) FROM event WHERE data->'customer'->>'name' = 'Joe'`, nil, 2},
+		{`SELECT COUNT(*) FROM event WHERE data->'customer'->'tier' = 2`, nil, 2},
+		{`SELECT COUNT(*) FROM event WHERE data->'customer'->'tier' > 1`, nil, 2},
+		{`SELECT COUNT(*) FROM event WHERE data->'total' = '12.5'`, nil, 2},
+		{`SELECT COUNT(*) FROM event WHERE data #>> '{customer,name}' = 'Jane'`, nil, 1},
+		{`SELECT COUNT(*) FROM event WHERE data->'tags'->>0 = 'new'`, nil, 1},
+		{`SELECT COUNT(*) FROM event WHERE data->'tags'->>-1 = 'web'`, nil, 2},
+		{`SELECT COUNT(*) FROM event WHERE data @> '{"customer": {"tier": 2}}'`, nil, 2},
+		{`SELECT COUNT(*) FROM event WHERE data@>'{"tags":["web"]}'`, nil, 2},
+		{`SELECT COUNT(*) FROM event WHERE '{"type":"registered"}' <@ data`, nil, 1},
+		{`SELECT COUNT(*) FROM event WHERE data @> $1`, []any{`{"type": "paid"}`}, 1},
+		{`SELECT COUNT(*) FROM event WHERE data ? 'total'`, nil, 2},
+		{`SELECT COUNT(*) FROM event WHERE data->'tags' ? 'new'`, nil, 1},
+		{`SELECT COUNT(*) FROM event WHERE data ? 'total' AND stream = ?`, []any{"order"}, 2},
+		{`SELECT COUNT(*) FROM event WHERE data->>'total' IS NULL`, nil, 2},
+		{`SELECT COUNT(*) FROM event WHERE data->'customer' = jsonb_build_object('tier', 1, 'name', 'Jane')`, nil, 1},
+	}
+	for _, tc := range countTests {
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	// operators in selectors
+	var name, tier string
+	err = db.QueryRow(`SELECT data->'customer'->>'name', data #> '{customer,tier}' FROM event WHERE id = 3`).Scan(&name, &tier)
+	if err != nil {
+		t.Fatalf("cannot select fields: %s", err)
+	}
+	if name != "Jane" || tier != "1" {
+		t.Fatalf("expected Jane and 1, got %s and %s", name, tier)
+	}
+
+	var obj string
+	err = db.QueryRow(`SELECT jsonb_build_object('id', id, 'name', data->'customer'->>'name', 'tags', data->'tags') FROM event WHERE id = $1`, 2).Scan(&obj)
+	if err != nil {
+		t.Fatalf("cannot build object: %s", err)
+	}
+	if obj != `{"id":2,"name":"Joe","tags":["web"]}` {
+		t.Fatalf("unexpected built object %s", obj)
+	}
+
+	// set returning function
+	rows, err := db.Query(`SELECT id, jsonb_array_elements_text(data->'tags') FROM event WHERE stream = 'order'`)
+	if err != nil {
+		t.Fatalf("cannot select array elements: %s", err)
+	}
+	var tags []string
+	for rows.Next() {
+		var id int64
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		tags = append(tags, tag)
+	}
+	rows.Close()
+	if len(tags) != 3 || tags[0] != "new" || tags[1] != "web" || tags[2] != "web" {
+		t.Fatalf("expected tags [new web web], got %v", tags)
+	}
+
+	// jsonb_set
+	res, err := db.Exec(`UPDATE event SET data = jsonb_set(data, '{customer,tier}', '3') WHERE stream = 'order'`)
+	if err != nil {
+		t.Fatalf("cannot update with jsonb_set: %s", err)
+	}
+	if n, _ := res.RowsAffected(); n != 2 {
+		t.Fatalf("expected 2 updated rows, got %d", n)
+	}
+	_, err = db.Exec(`UPDATE event SET data = jsonb_set(data, '{status}', $1) WHERE id = 1`, `"archived"`)
+	if err != nil {
+		t.Fatalf("cannot update with jsonb_set: %s", err)
+	}
+	err = db.QueryRow(`SELECT data FROM event WHERE id = 1`).Scan(&data)
+	if err != nil {
+		t.Fatalf("cannot select data: %s", err)
+	}
+	expected = `{"customer":{"name":"Joe","tier":3},"status":"archived","tags":["new","web"],"total":12.50,"type":"created"}`
+	if data != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+	err = db.QueryRow(`SELECT COUNT(*) FROM event WHERE data->'customer'->'tier' = 3`).Scan(&count)
+	if err != nil {
+		t.Fatalf("cannot count: %s", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 updated documents, got %d", count)
+	}
+
+	// functions in inserted values
+	_, err = db.Exec(`INSERT INTO event (stream, data) VALUES ('user', jsonb_build_object('type', 'deleted', 'at', $1))`, 42)
+	if err != nil {
+		t.Fatalf("cannot insert built object: %s", err)
+	}
+	err = db.QueryRow(`SELECT data FROM event WHERE data->>'type' = 'deleted'`).Scan(&data)
+	if err != nil {
+		t.Fatalf("cannot select data: %s", err)
+	}
+	if data != `{"at":42,"type":"deleted"}` {
+		t.Fatalf("unexpected inserted object %s", data)
+	}
+}
+
+func TestJSONText(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestJSONText")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	doc := `{"b": 1,  "a": 1.0, "a": 2, "big": 12345678901234567890.123456789, "e": 1e2}`
+	batch := []string{
+		`CREATE TABLE doc (id BIGSERIAL PRIMARY KEY, raw JSON, data JSONB)`,
+		`INSERT INTO doc (raw, data) VALUES ('` + doc + `', '` + doc + `')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	_, err = db.Exec(`INSERT INTO doc (raw) VALUES ('{"a": ')`)
+	if err == nil {
+		t.Fatalf("expected error inserting invalid JSON")
+	}
+
+	// json keeps text as written, jsonb is normalized with exact numbers
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT raw FROM doc WHERE id = 1`, doc},
+		{`SELECT data FROM doc WHERE id = 1`, `{"a":2,"b":1,"big":12345678901234567890.123456789,"e":100}`},
+		{`SELECT raw->>'big' FROM doc WHERE id = 1`, `12345678901234567890.123456789`},
+		{`SELECT data->>'big' FROM doc WHERE id = 1`, `12345678901234567890.123456789`},
+		{`SELECT data->>'a' FROM doc WHERE id = 1`, `2`},
+		{`SELECT '{"x": 1.0,  "y": [1.50]}'::json FROM doc`, `{"x": 1.0,  "y": [1.50]}`},
+		{`SELECT '{"x": 1.0,  "y": [1.50]}'::jsonb FROM doc`, `{"x":1.0,"y":[1.50]}`},
+		{`SELECT COUNT(*) FROM doc WHERE data->'big' = '12345678901234567890.1234567890'`, `1`},
+		{`SELECT COUNT(*) FROM doc WHERE data->'big' = '12345678901234567890.123456788'`, `0`},
+		{`SELECT COUNT(*) FROM doc WHERE data @> '{"a": 2.00}'`, `1`},
+	}
+	for _, tc := range testCases {
+		var v string
+		err := db.QueryRow(tc.query).Scan(&v)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		if v != tc.expected {
+			t.Fatalf("expected %s with '%s', got %s", tc.expected, tc.query, v)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package ramsql
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestLike(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestLike")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, email TEXT, code TEXT, tags TEXT[])`,
+		`INSERT INTO account (email, code, tags) VALUES ('alice@example.com', 'AB-100%', '{"admin","dev"}')`,
+		`INSERT INTO account (email, code, tags) VALUES ('Bob@Example.org', 'AB_200', '{"dev"}')`,
+		`INSERT INTO account (email, code, tags) VALUES ('carol@test.net', 'CD-300', '{}')`,
+		`INSERT INTO account (email, code, tags) VALUES (NULL, 'abc', '{}')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM account WHERE email LIKE '%@example.com'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE email LIKE '%@EXAMPLE%'`, nil, 0},
+		{`SELECT COUNT(*) FROM account WHERE email ILIKE '%@EXAMPLE%'`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE code NOT LIKE 'AB%'`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE email NOT ILIKE '%example%' AND email IS NOT NULL`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE email LIKE '_ob@%'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE email LIKE 'alice@example.com'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE email LIKE 'alice'`, nil, 0},
+		{`SELECT COUNT(*) FROM account WHERE email LIKE $1`, []any{"carol%"}, 1},
+		{`SELECT COUNT(*) FROM account WHERE code LIKE '%\%'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE code LIKE 'AB\_%'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE code LIKE 'AB!_%' ESCAPE '!'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE code LIKE 'AB\_%' ESCAPE ''`, nil, 0},
+		{`SELECT COUNT(*) FROM account WHERE code LIKE 'AB%' AND email LIKE '%.com'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE email LIKE '%' || code || '%'`, nil, 0},
+		{`SELECT COUNT(*) FROM account WHERE email ~ '^[a-z]+@'`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE email ~* '^[a-z]+@'`, nil, 3},
+		{`SELECT COUNT(*) FROM account WHERE email !~ 'example'`, nil, 2},
+		{`SELECT COUNT(*) FROM account WHERE email !~* 'EXAMPLE'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE code ~ '\d{3}'`, nil, 3},
+		{`SELECT COUNT(*) FROM account WHERE code SIMILAR TO '(AB|CD)-[0-9]+'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE code SIMILAR TO '(AB|CD)%'`, nil, 3},
+		{`SELECT COUNT(*) FROM account WHERE code NOT SIMILAR TO '[A-Z]{2}%'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE code SIMILAR TO 'AB#_%' ESCAPE '#'`, nil, 1},
+		{`SELECT COUNT(*) FROM account WHERE email LIKE ANY($1)`, []any{[]string{"alice%", "carol%"}}, 2},
+		{`SELECT COUNT(*) FROM account WHERE 'dev' ~ ANY(tags)`, nil, 2},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	invalid := []string{
+		`SELECT email FROM account WHERE email LIKE 'abc\'`,
+		`SELECT email FROM account WHERE email LIKE 'a%' ESCAPE '!!'`,
+		`SELECT email FROM account WHERE email ~ '(unclosed'`,
+		`SELECT email FROM account WHERE id LIKE '1%'`,
+	}
+	for _, q := range invalid {
+		rows, err := db.Query(q)
+		if err == nil {
+			for rows.Next() {
+			}
+			err = rows.Err()
+			rows.Close()
+		}
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package ramsql
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestNumeric(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestNumeric")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE invoice (id BIGSERIAL PRIMARY KEY, ref NUMERIC(8) UNIQUE, amount NUMERIC(12,2), rate DECIMAL(5, 4) DEFAULT 0.2, raw NUMERIC)`,
+		`INSERT INTO invoice (ref, amount, raw) VALUES (1001, 12.345, 0.1)`,
+		`INSERT INTO invoice (ref, amount, raw) VALUES (1002, '-0.005', '123456789012345678901234567890.123456789')`,
+		`INSERT INTO invoice (ref, amount, rate, raw) VALUES (1003, 0.1, 0.33333, 1.50)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	_, err = db.Exec(`INSERT INTO invoice (ref, amount, raw) VALUES ($1, $2, $3)`, 1004, 1999.999, "2.000")
+	if err != nil {
+		t.Fatalf("cannot insert with arguments: %s", err)
+	}
+
+	// values are rounded half away from zero to declared scale, and kept exact otherwise
+	rows, err := db.Query(`SELECT amount, rate, raw FROM invoice ORDER BY id`)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	var got [][]string
+	for rows.Next() {
+		var amount, rate, raw string
+		if err := rows.Scan(&amount, &rate, &raw); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		got = append(got, []string{amount, rate, raw})
+	}
+	rows.Close()
+	expected := [][]string{
+		{"12.35", "0.2000", "0.1"},
+		{"-0.01", "0.2000", "123456789012345678901234567890.123456789"},
+		{"0.10", "0.3333", "1.50"},
+		{"2000.00", "0.2000", "2.000"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), len(got))
+	}
+	for i := range expected {
+		for j := range expected[i] {
+			if got[i][j] != expected[i][j] {
+				t.Fatalf("row %d: expected %v, got %v", i, expected[i], got[i])
+			}
+		}
+	}
+
+	// overflow is an error
+	overflows := []string{
+		`INSERT INTO invoice (ref, amount) VALUES (1005, 12345678901.00)`,
+		`INSERT INTO invoice (ref, amount) VALUES (1005, 9999999999.995)`,
+		`INSERT INTO invoice (ref, amount, rate) VALUES (1005, 1, 10)`,
+		`UPDATE invoice SET rate = 12.5 WHERE ref = 1001`,
+	}
+	for _, q := range overflows {
+		_, err = db.Exec(q)
+		if err == nil {
+			t.Fatalf("expected numeric field overflow with '%s'", q)
+		}
+	}
+	_, err = db.Exec(`INSERT INTO invoice (ref, amount) VALUES (1005, 'abc')`)
+	if err == nil {
+		t.Fatalf("expected error inserting invalid numeric")
+	}
+
+	// comparisons are exact and ignore scale
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM invoice WHERE amount = 12.35`, nil, 1},
+		{`SELECT COUNT(*) FROM invoice WHERE amount = '0.1'`, nil, 1},
+		{`SELECT COUNT(*) FROM invoice WHERE amount = $1`, []any{0.1}, 1},
+		{`SELECT COUNT(*) FROM invoice WHERE amount = 2000`, nil, 1},
+		{`SELECT COUNT(*) FROM invoice WHERE raw = 1.5`, nil, 1},
+		{`SELECT COUNT(*) FROM invoice WHERE amount > 0.1`, nil, 2},
+		{`SELECT COUNT(*) FROM invoice WHERE amount >= 0.1`, nil, 3},
+		{`SELECT COUNT(*) FROM invoice WHERE amount < 0`, nil, 1},
+		{`SELECT COUNT(*) FROM invoice WHERE raw > 123456789012345678901234567890.12`, nil, 1},
+		{`SELECT COUNT(*) FROM invoice WHERE raw > $1`, []any{"123456789012345678901234567890.1234567889"}, 1},
+		{`SELECT COUNT(*) FROM invoice WHERE ref = $1`, []any{"1003.0"}, 1},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	// ordering is exact
+	rows, err = db.Query(`SELECT raw FROM invoice ORDER BY raw DESC`)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	var raws []string
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		raws = append(raws, raw)
+	}
+	rows.Close()
+	if len(raws) != 4 || raws[0] != "123456789012345678901234567890.123456789" || raws[1] != "2.000" || raws[2] != "1.50" || raws[3] != "0.1" {
+		t.Fatalf("unexpected order %v", raws)
+	}
+
+	// updated values are rounded too
+	_, err = db.Exec(`UPDATE invoice SET amount = $1 WHERE ref = 1001`, "100.125")
+	if err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	var amount float64
+	err = db.QueryRow(`SELECT amount FROM invoice WHERE ref = 1001`).Scan(&amount)
+	if err != nil {
+		t.Fatalf("cannot select amount: %s", err)
+	}
+	if amount != 100.13 {
+		t.Fatalf("expected 100.13, got %v", amount)
+	}
+}
@@ -0,0 +1,97 @@
+package ramsql
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+func TestOuterJoin(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestOuterJoin")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE payments (id BIGSERIAL PRIMARY KEY, user_id BIGINT, amount INT)`,
+		`CREATE TABLE refunds (id BIGSERIAL PRIMARY KEY, payment_id BIGINT)`,
+		`INSERT INTO account (name) VALUES ('alice')`,
+		`INSERT INTO account (name) VALUES ('bob')`,
+		`INSERT INTO account (name) VALUES ('carol')`,
+		`INSERT INTO payments (user_id, amount) VALUES (1, 40)`,
+		`INSERT INTO payments (user_id, amount) VALUES (1, 30)`,
+		`INSERT INTO payments (user_id, amount) VALUES (2, 50)`,
+		`INSERT INTO payments (user_id, amount) VALUES (9, 20)`,
+		`INSERT INTO refunds (payment_id) VALUES (2)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// each query returns an account name and a payment id, NULL printed as empty string
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{
+			`SELECT account.name, payments.id FROM account LEFT JOIN payments ON payments.user_id = account.id ORDER BY payments.id`,
+			"[carol  alice 1 alice 2 bob 3]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account LEFT OUTER JOIN payments ON account.id = payments.user_id ORDER BY payments.id`,
+			"[carol  alice 1 alice 2 bob 3]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account LEFT JOIN payments ON payments.user_id = account.id WHERE payments.id IS NULL`,
+			"[carol ]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account LEFT JOIN payments ON payments.user_id = account.id WHERE payments.amount > 35 ORDER BY payments.id`,
+			"[alice 1 bob 3]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account RIGHT JOIN payments ON payments.user_id = account.id ORDER BY payments.id`,
+			"[alice 1 alice 2 bob 3  4]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account FULL JOIN payments ON payments.user_id = account.id ORDER BY payments.id`,
+			"[carol  alice 1 alice 2 bob 3  4]",
+		},
+		{
+			`SELECT account.name, COUNT(payments.id) FROM account LEFT JOIN payments ON payments.user_id = account.id GROUP BY account.id, account.name ORDER BY account.id`,
+			"[alice 2 bob 1 carol 0]",
+		},
+		{
+			`SELECT account.name, refunds.id FROM account LEFT JOIN payments ON payments.user_id = account.id LEFT JOIN refunds ON refunds.payment_id = payments.id ORDER BY payments.id`,
+			"[carol  alice  alice 1 bob ]",
+		},
+		{
+			`SELECT account.name, payments.id FROM account LEFT JOIN payments ON payments.user_id = account.id WHERE payments.amount = 50 OR account.name = 'carol' ORDER BY account.id`,
+			"[bob 3 carol ]",
+		},
+	}
+	for _, tc := range testCases {
+		rows, err := db.Query(tc.query)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		var res []string
+		for rows.Next() {
+			var a, b sql.NullString
+			if err := rows.Scan(&a, &b); err != nil {
+				t.Fatalf("cannot scan '%s': %s", tc.query, err)
+			}
+			res = append(res, a.String, b.String)
+		}
+		rows.Close()
+		if fmt.Sprint(res) != tc.expected {
+			t.Fatalf("expected %s with '%s', got %v", tc.expected, tc.query, res)
+		}
+	}
+}
@@ -63,6 +63,12 @@ func (r *Rows) Next(dest []driver.Value) (err error) {
 	}
 
 	for i, v := range values {
+		if valuer, ok := v.(driver.Valuer); ok {
+			v, err = valuer.Value()
+			if err != nil {
+				return err
+			}
+		}
 		dest[i] = v
 	}
 
@@ -0,0 +1,224 @@
+package ramsql
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestCharacterLength(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestCharacterLength")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE country (id BIGSERIAL PRIMARY KEY, code CHAR(3) UNIQUE, flag CHAR, name VARCHAR(10), official CHARACTER VARYING(20) DEFAULT 'none')`,
+		`INSERT INTO country (code, flag, name) VALUES ('fr', 'y', 'France')`,
+		`INSERT INTO country (code, flag, name) VALUES ('deu', 'n', 'Germany   ')`,
+		`INSERT INTO country (code, flag, name) VALUES ('ita', 'y', 'Italia     ')`,
+		`INSERT INTO country (code, flag, name) VALUES ('日本', 'y', '日本国')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	invalid := []string{
+		`CREATE TABLE bad (name VARCHAR(0))`,
+		`INSERT INTO country (code, flag, name) VALUES ('abcd', 'y', 'x')`,
+		`INSERT INTO country (code, flag, name) VALUES ('esp', 'yes', 'x')`,
+		`INSERT INTO country (code, flag, name) VALUES ('esp', 'y', 'Espagne, Spain')`,
+		`INSERT INTO country (code, flag, name) VALUES ('fr ', 'y', 'x')`,
+		`UPDATE country SET name = 'Deutschland!' WHERE code = 'deu'`,
+	}
+	for _, q := range invalid {
+		_, err = db.Exec(q)
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+	_, err = db.Exec(`INSERT INTO country (code, flag, name, official) VALUES ($1, $2, $3, $4)`, "esp", "y", "Espana", "Reino de España, Spain")
+	if err == nil {
+		t.Fatalf("expected error with too long argument")
+	}
+
+	// CHAR is padded, excess spaces are truncated
+	rows, err := db.Query(`SELECT code, flag, name, official FROM country ORDER BY id`)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	var got []string
+	for rows.Next() {
+		var code, flag, name, official string
+		if err := rows.Scan(&code, &flag, &name, &official); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		got = append(got, code+"|"+flag+"|"+name+"|"+official)
+	}
+	rows.Close()
+	expected := []string{
+		"fr |y|France|none",
+		"deu|n|Germany   |none",
+		"ita|y|Italia    |none",
+		"日本 |y|日本国|none",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %q, got %q", expected[i], got[i])
+		}
+	}
+
+	// trailing spaces of CHAR are not significant
+	countTests := []struct {
+		query string
+		args  []any
+		count int
+	}{
+		{`SELECT COUNT(*) FROM country WHERE code = 'fr'`, nil, 1},
+		{`SELECT COUNT(*) FROM country WHERE code = 'fr '`, nil, 1},
+		{`SELECT COUNT(*) FROM country WHERE code = $1`, []any{"fr"}, 1},
+		{`SELECT COUNT(*) FROM country WHERE code < 'fra'`, nil, 2},
+		{`SELECT COUNT(*) FROM country WHERE name = 'Germany'`, nil, 0},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.args...).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	var name string
+	err = db.QueryRow(`SELECT code || '/' || name FROM country WHERE id = 1`).Scan(&name)
+	if err != nil {
+		t.Fatalf("cannot concatenate: %s", err)
+	}
+	if name != "fr/France" {
+		t.Fatalf("expected fr/France, got %q", name)
+	}
+
+	// numbers assigned to character types keep their text form
+	_, err = db.Exec(`CREATE TABLE note (id BIGSERIAL PRIMARY KEY, body TEXT, label VARCHAR(4))`)
+	if err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+	_, err = db.Exec(`INSERT INTO note (body, label) VALUES (42, 42)`)
+	if err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+	_, err = db.Exec(`INSERT INTO note (body, label) VALUES ($1, $2)`, 42, 3.5)
+	if err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+	_, err = db.Exec(`UPDATE note SET body = $1 WHERE id = 1`, int64(7))
+	if err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	rows, err = db.Query(`SELECT body, label FROM note ORDER BY id`)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	got = got[:0]
+	for rows.Next() {
+		var body, label string
+		if err := rows.Scan(&body, &label); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		got = append(got, body+"|"+label)
+	}
+	rows.Close()
+	if len(got) != 2 || got[0] != "7|42" || got[1] != "42|3.5" {
+		t.Fatalf("expected [7|42 42|3.5], got %v", got)
+	}
+}
+
+func TestIntegerRange(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestIntegerRange")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE measure (id SERIAL PRIMARY KEY, s SMALLINT, i INTEGER, b BIGINT, r REAL, d DOUBLE PRECISION)`,
+		`INSERT INTO measure (s, i, b, r, d) VALUES (32767, 2147483647, 9223372036854775807, 1.5, 1000000.125)`,
+		`INSERT INTO measure (s, i, b, r, d) VALUES (-32768, -2147483648, -9223372036854775808, -1.5, -1000000.125)`,
+		`INSERT INTO measure (s, i, b, r, d) VALUES ('12', 2.5, '-3', '0.25', 12)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	invalid := []struct {
+		query string
+		err   string
+	}{
+		{`INSERT INTO measure (s, i, b, r, d) VALUES (32768, 0, 0, 0, 0)`, `smallint out of range`},
+		{`INSERT INTO measure (s, i, b, r, d) VALUES (-32769, 0, 0, 0, 0)`, `smallint out of range`},
+		{`INSERT INTO measure (s, i, b, r, d) VALUES (0, 2147483648, 0, 0, 0)`, `integer out of range`},
+		{`INSERT INTO measure (s, i, b, r, d) VALUES (0, 0, 9223372036854775808, 0, 0)`, `bigint out of range`},
+		{`INSERT INTO measure (s, i, b, r, d) VALUES ('a', 0, 0, 0, 0)`, `invalid input syntax for type smallint: "a"`},
+		{`UPDATE measure SET s = 40000 WHERE id = 1`, `smallint out of range`},
+	}
+	for _, tc := range invalid {
+		_, err = db.Exec(tc.query)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("expected error '%s' with '%s', got %v", tc.err, tc.query, err)
+		}
+	}
+
+	argTests := []struct {
+		args []any
+		err  string
+	}{
+		{[]any{int64(1) << 15, 0, 0, 0, 0}, `smallint out of range`},
+		{[]any{0, int64(1) << 31, 0, 0, 0}, `integer out of range`},
+		{[]any{0, 0, float64(1e19), 0, 0}, `bigint out of range`},
+		{[]any{0, 0, 0, float64(1e39), 0}, `value out of range: overflow`},
+	}
+	for _, tc := range argTests {
+		_, err = db.Exec(`INSERT INTO measure (s, i, b, r, d) VALUES ($1, $2, $3, $4, $5)`, tc.args...)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("expected error '%s' with %v, got %v", tc.err, tc.args, err)
+		}
+	}
+
+	_, err = db.Exec(`INSERT INTO measure (s, i, b, r, d) VALUES ($1, $2, $3, $4, $5)`, 0, 0, 0, float32(3.4e38), 1e300)
+	if err != nil {
+		t.Fatalf("cannot insert floats: %s", err)
+	}
+
+	var s, i, b int64
+	var r, d float64
+	err = db.QueryRow(`SELECT s, i, b, r, d FROM measure WHERE id = 3`).Scan(&s, &i, &b, &r, &d)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if s != 12 || i != 3 || b != -3 || r != 0.25 || d != 12 {
+		t.Fatalf("unexpected values %d %d %d %f %f", s, i, b, r, d)
+	}
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM measure WHERE b > 0`).Scan(&count)
+	if err != nil {
+		t.Fatalf("cannot count: %s", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 row, got %d", count)
+	}
+}
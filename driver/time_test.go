@@ -0,0 +1,294 @@
+package ramsql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestTimestampPrecision(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestTimestampPrecision")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE document (id BIGSERIAL PRIMARY KEY, title TEXT, updated_at TIMESTAMP WITH TIME ZONE, created_at TIMESTAMP)`)
+	if err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+
+	updatedAt := time.Date(2024, time.March, 1, 10, 0, 0, 123456789, time.UTC)
+	_, err = db.Exec(`INSERT INTO document (title, updated_at, created_at) VALUES ($1, $2, $3)`, "draft", updatedAt, updatedAt)
+	if err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	// optimistic locking: a timestamp a few milliseconds apart is a different version
+	res, err := db.Exec(`UPDATE document SET title = $1 WHERE id = 1 AND updated_at = $2`, "stale", updatedAt.Add(3*time.Millisecond))
+	if err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	if n, _ := res.RowsAffected(); n != 0 {
+		t.Fatalf("expected no row updated with stale version, got %d", n)
+	}
+
+	countTests := []struct {
+		query string
+		arg   time.Time
+		count int
+	}{
+		{`SELECT COUNT(*) FROM document WHERE updated_at = $1`, updatedAt, 1},
+		{`SELECT COUNT(*) FROM document WHERE updated_at = $1`, updatedAt.Add(200 * time.Nanosecond), 1},
+		{`SELECT COUNT(*) FROM document WHERE updated_at = $1`, updatedAt.Add(time.Millisecond), 0},
+		{`SELECT COUNT(*) FROM document WHERE updated_at <> $1`, updatedAt.Add(time.Millisecond), 1},
+		{`SELECT COUNT(*) FROM document WHERE updated_at < $1`, updatedAt.Add(time.Millisecond), 1},
+		{`SELECT COUNT(*) FROM document WHERE updated_at >= $1`, updatedAt.Add(time.Millisecond), 0},
+		{`SELECT COUNT(*) FROM document WHERE updated_at = $1`, updatedAt.In(time.FixedZone("", 3600)), 1},
+		{`SELECT COUNT(*) FROM document WHERE created_at = $1`, updatedAt, 1},
+		{`SELECT COUNT(*) FROM document WHERE created_at <= $1`, updatedAt.Add(-time.Millisecond), 0},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query, tc.arg).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s' and %v, got %d", tc.count, tc.query, tc.arg, count)
+		}
+	}
+
+	res, err = db.Exec(`UPDATE document SET title = $1 WHERE id = 1 AND updated_at = $2`, "final", updatedAt)
+	if err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Fatalf("expected 1 row updated, got %d", n)
+	}
+
+	// stored with microsecond precision
+	var got time.Time
+	err = db.QueryRow(`SELECT updated_at FROM document WHERE id = 1`).Scan(&got)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if got.Nanosecond() != 123457000 {
+		t.Fatalf("expected timestamp rounded to microseconds, got %v", got)
+	}
+}
+
+func TestTimeZone(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestTimeZone")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	paris, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("cannot get connection: %s", err)
+	}
+	defer paris.Close()
+	utc, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("cannot get connection: %s", err)
+	}
+	defer utc.Close()
+
+	batch := []string{
+		`SET TIME ZONE '+02:00'`,
+		`CREATE TABLE meeting (id BIGSERIAL PRIMARY KEY, starts_at TIMESTAMPTZ, local_at TIMESTAMP WITHOUT TIME ZONE)`,
+		`INSERT INTO meeting (starts_at, local_at) VALUES ('2024-03-01 10:00:00', '2024-03-01 10:00:00')`,
+		`INSERT INTO meeting (starts_at, local_at) VALUES ('2024-03-01 10:00:00+00:00', '2024-03-01 10:00:00+00:00')`,
+	}
+	for _, b := range batch {
+		_, err = paris.ExecContext(ctx, b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	_, err = utc.ExecContext(ctx, `SET timezone TO 'UTC'`)
+	if err != nil {
+		t.Fatalf("cannot set time zone: %s", err)
+	}
+
+	// TIMESTAMPTZ literal is read, and displayed, in session time zone
+	var startsAt, localAt time.Time
+	err = paris.QueryRowContext(ctx, `SELECT starts_at, local_at FROM meeting WHERE id = 1`).Scan(&startsAt, &localAt)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if !startsAt.Equal(time.Date(2024, time.March, 1, 8, 0, 0, 0, time.UTC)) || startsAt.Hour() != 10 {
+		t.Fatalf("unexpected starts_at %v", startsAt)
+	}
+	if !localAt.Equal(time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected local_at %v", localAt)
+	}
+
+	err = utc.QueryRowContext(ctx, `SELECT starts_at, local_at FROM meeting WHERE id = 1`).Scan(&startsAt, &localAt)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if startsAt.Hour() != 8 || localAt.Hour() != 10 {
+		t.Fatalf("unexpected values %v and %v in UTC session", startsAt, localAt)
+	}
+
+	// TIMESTAMP ignores time zone of literal
+	err = utc.QueryRowContext(ctx, `SELECT starts_at, local_at FROM meeting WHERE id = 2`).Scan(&startsAt, &localAt)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if startsAt.Hour() != 10 || localAt.Hour() != 10 {
+		t.Fatalf("unexpected values %v and %v in UTC session", startsAt, localAt)
+	}
+
+	// same literal denotes different instants in each session
+	countTests := []struct {
+		conn  *sql.Conn
+		query string
+		count int
+	}{
+		{paris, `SELECT COUNT(*) FROM meeting WHERE starts_at = '2024-03-01 10:00:00'`, 1},
+		{utc, `SELECT COUNT(*) FROM meeting WHERE starts_at = '2024-03-01 10:00:00'`, 1},
+		{utc, `SELECT COUNT(*) FROM meeting WHERE starts_at = '2024-03-01 08:00:00'`, 1},
+		{paris, `SELECT COUNT(*) FROM meeting WHERE starts_at < '2024-03-01 11:00:00'`, 1},
+		{paris, `SELECT COUNT(*) FROM meeting WHERE local_at = '2024-03-01 10:00:00'`, 2},
+		{utc, `SELECT COUNT(*) FROM meeting WHERE local_at = '2024-03-01 10:00:00'`, 2},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = tc.conn.QueryRowContext(ctx, tc.query).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+
+	// setting is rolled back with transaction
+	tx, err := utc.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("cannot begin: %s", err)
+	}
+	_, err = tx.Exec(`SET TIME ZONE -5`)
+	if err != nil {
+		t.Fatalf("cannot set time zone: %s", err)
+	}
+	err = tx.QueryRow(`SELECT starts_at FROM meeting WHERE id = 1`).Scan(&startsAt)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if startsAt.Hour() != 3 {
+		t.Fatalf("expected starts_at at 3 in transaction, got %v", startsAt)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("cannot rollback: %s", err)
+	}
+	err = utc.QueryRowContext(ctx, `SELECT starts_at FROM meeting WHERE id = 1`).Scan(&startsAt)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if startsAt.Hour() != 8 {
+		t.Fatalf("expected starts_at at 8 after rollback, got %v", startsAt)
+	}
+
+	invalid := []string{
+		`SET TIME ZONE 'Nowhere/Somewhere'`,
+		`SET timezone 'UTC'`,
+	}
+	for _, q := range invalid {
+		_, err = utc.ExecContext(ctx, q)
+		if err == nil {
+			t.Fatalf("expected error with '%s'", q)
+		}
+	}
+}
+
+func TestDateAndTime(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestDateAndTime")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE opening (id BIGSERIAL PRIMARY KEY, day DATE, opens TIME, closes TIME WITHOUT TIME ZONE)`,
+		`INSERT INTO opening (day, opens, closes) VALUES ('2024-03-01 23:30:00', '08:30', '18:45:30.1234567')`,
+		`INSERT INTO opening (day, opens, closes) VALUES ('2024-03-02', '10:00:00', '12:00:00')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// date of a time is its date in its own time zone
+	_, err = db.Exec(`INSERT INTO opening (day, opens, closes) VALUES ($1, $2, $2)`, time.Date(2024, time.March, 3, 23, 30, 0, 0, time.FixedZone("", -5*3600)), time.Date(2024, time.March, 3, 9, 15, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO opening (day, opens, closes) VALUES ('2024-13-01', '08:00', '09:00')`)
+	if err == nil {
+		t.Fatalf("expected error with invalid date")
+	}
+	_, err = db.Exec(`INSERT INTO opening (day, opens, closes) VALUES ('2024-03-04', 'noon', '09:00')`)
+	if err == nil {
+		t.Fatalf("expected error with invalid time")
+	}
+
+	rows, err := db.Query(`SELECT day, opens, closes FROM opening ORDER BY id`)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	var got []string
+	for rows.Next() {
+		var day, opens, closes time.Time
+		if err := rows.Scan(&day, &opens, &closes); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		got = append(got, day.Format("2006-01-02 15:04:05")+"|"+opens.Format("15:04:05.999999")+"|"+closes.Format("15:04:05.999999"))
+	}
+	rows.Close()
+	expected := []string{
+		"2024-03-01 00:00:00|08:30:00|18:45:30.123457",
+		"2024-03-02 00:00:00|10:00:00|12:00:00",
+		"2024-03-03 00:00:00|09:15:00|09:15:00",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %q, got %q", expected[i], got[i])
+		}
+	}
+
+	countTests := []struct {
+		query string
+		count int
+	}{
+		{`SELECT COUNT(*) FROM opening WHERE day = '2024-03-01'`, 1},
+		{`SELECT COUNT(*) FROM opening WHERE day > '2024-03-01'`, 2},
+		{`SELECT COUNT(*) FROM opening WHERE opens >= '09:00'`, 2},
+		{`SELECT COUNT(*) FROM opening WHERE closes = '18:45:30.123457'`, 1},
+	}
+	for _, tc := range countTests {
+		var count int
+		err = db.QueryRow(tc.query).Scan(&count)
+		if err != nil {
+			t.Fatalf("cannot count with '%s': %s", tc.query, err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d rows with '%s', got %d", tc.count, tc.query, count)
+		}
+	}
+}
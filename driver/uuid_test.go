@@ -0,0 +1,157 @@
+package ramsql
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/proullon/ramsql/engine/agnostic"
+)
+
+func TestUUID(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestUUID")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), email TEXT)`,
+		`CREATE TABLE post (id UUID PRIMARY KEY, account_id UUID, title TEXT)`,
+		`INSERT INTO account (email) VALUES ('foo@bar.com')`,
+		`INSERT INTO account (id, email) VALUES ('A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11', 'bar@bar.com')`,
+		`INSERT INTO post (id, account_id, title) VALUES (gen_random_uuid(), 'a0eebc999c0b4ef8bb6d6bb9bd380a11', 'hello')`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	var id string
+	err = db.QueryRow(`SELECT id FROM account WHERE email = 'foo@bar.com'`).Scan(&id)
+	if err != nil {
+		t.Fatalf("cannot select generated uuid: %s", err)
+	}
+	u, err := agnostic.ParseUUID(id)
+	if err != nil {
+		t.Fatalf("invalid generated uuid '%s': %s", id, err)
+	}
+	if u.String() != id || u[6]>>4 != 4 {
+		t.Fatalf("expected canonical version 4 uuid, got %s", id)
+	}
+
+	// canonical text output and lookup with any representation
+	queries := []any{
+		"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+		"A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11",
+		"{a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11}",
+		[16]byte{0xa0, 0xee, 0xbc, 0x99, 0x9c, 0x0b, 0x4e, 0xf8, 0xbb, 0x6d, 0x6b, 0xb9, 0xbd, 0x38, 0x0a, 0x11},
+	}
+	for _, q := range queries {
+		var email string
+		err = db.QueryRow(`SELECT email FROM account WHERE id = $1`, q).Scan(&email)
+		if err != nil {
+			t.Fatalf("cannot select account with id %v: %s", q, err)
+		}
+		if email != "bar@bar.com" {
+			t.Fatalf("expected bar@bar.com, got %s", email)
+		}
+	}
+
+	err = db.QueryRow(`SELECT account_id FROM post WHERE title = 'hello'`).Scan(&id)
+	if err != nil {
+		t.Fatalf("cannot select post: %s", err)
+	}
+	if id != "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11" {
+		t.Fatalf("expected canonical uuid, got %s", id)
+	}
+
+	err = db.QueryRow(`SELECT title FROM post WHERE account_id = 'A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11'`).Scan(&id)
+	if err != nil {
+		t.Fatalf("cannot select post by literal uuid: %s", err)
+	}
+
+	// [16]byte argument round trip
+	raw := [16]byte{1, 2, 3, 4, 5, 6, 0x47, 8, 0x89, 10, 11, 12, 13, 14, 15, 16}
+	_, err = db.Exec(`INSERT INTO account (id, email) VALUES ($1, $2)`, raw, "raw@bar.com")
+	if err != nil {
+		t.Fatalf("cannot insert [16]byte uuid: %s", err)
+	}
+	err = db.QueryRow(`SELECT id FROM account WHERE email = $1`, "raw@bar.com").Scan(&id)
+	if err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if id != "01020304-0506-4708-890a-0b0c0d0e0f10" {
+		t.Fatalf("unexpected uuid %s", id)
+	}
+
+	// primary key violation with another representation
+	_, err = db.Exec(`INSERT INTO account (id, email) VALUES ($1, 'dup@bar.com')`, strings.ToUpper(id))
+	if err == nil {
+		t.Fatalf("expected primary key violation")
+	}
+
+	// malformed
+	malformed := []string{
+		`INSERT INTO post (id, title) VALUES ('not-a-uuid', 'foo')`,
+		`INSERT INTO post (id, title) VALUES ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a1', 'foo')`,
+		`INSERT INTO post (id, title) VALUES ('a0eebc99x9c0b-4ef8-bb6d-6bb9bd380a11', 'foo')`,
+		`UPDATE post SET account_id = 'foo' WHERE title = 'hello'`,
+	}
+	for _, m := range malformed {
+		_, err = db.Exec(m)
+		if err == nil {
+			t.Fatalf("expected error on malformed uuid: %s", m)
+		}
+	}
+
+	// seeded generator, per engine
+	other, err := sql.Open("ramsql", "TestUUIDOther")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer other.Close()
+
+	_, err = other.Exec(`CREATE TABLE account (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), email TEXT)`)
+	if err != nil {
+		t.Fatalf("cannot create table: %s", err)
+	}
+
+	seed := func(name string) {
+		e, ok := db.Driver().(*Driver).Engine(name)
+		if !ok {
+			t.Fatalf("cannot find engine %s", name)
+		}
+		e.SeedRandom(42)
+	}
+	generated := func(db *sql.DB) (string, float64) {
+		var id string
+		err := db.QueryRow(`INSERT INTO account (email) VALUES ('seed@bar.com') RETURNING id`).Scan(&id)
+		if err != nil {
+			t.Fatalf("cannot insert: %s", err)
+		}
+		var f float64
+		err = db.QueryRow(`SELECT random() FROM account WHERE email = 'seed@bar.com'`).Scan(&f)
+		if err != nil {
+			t.Fatalf("cannot query random(): %s", err)
+		}
+		_, err = db.Exec(`DELETE FROM account WHERE email = 'seed@bar.com'`)
+		if err != nil {
+			t.Fatalf("cannot delete: %s", err)
+		}
+		return id, f
+	}
+
+	seed("TestUUID")
+	a, fa := generated(db)
+	seed("TestUUID")
+	seed("TestUUIDOther")
+	generated(other)
+	b, fb := generated(db)
+	if a != b || fa != fb {
+		t.Fatalf("expected seeded generator to be deterministic, got %s, %v and %s, %v", a, fa, b, fb)
+	}
+}
@@ -0,0 +1,204 @@
+package ramsql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWindow(t *testing.T) {
+
+	db, err := sql.Open("ramsql", "TestWindow")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	batch := []string{
+		`CREATE TABLE account (id BIGSERIAL PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE payments (id BIGSERIAL PRIMARY KEY, user_id BIGINT, amount INT, method TEXT)`,
+		`INSERT INTO account (name) VALUES ('alice')`,
+		`INSERT INTO account (name) VALUES ('bob')`,
+		`INSERT INTO payments (user_id, amount, method) VALUES (1, 80, 'card')`,
+		`INSERT INTO payments (user_id, amount, method) VALUES (1, 40, 'cash')`,
+		`INSERT INTO payments (user_id, amount, method) VALUES (1, 80, 'card')`,
+		`INSERT INTO payments (user_id, amount, method) VALUES (2, 30, 'card')`,
+		`INSERT INTO payments (user_id, amount, method) VALUES (2, 10, 'card')`,
+		`CREATE TABLE score (id BIGSERIAL PRIMARY KEY, v INT)`,
+		`INSERT INTO score (v) VALUES (10)`,
+		`INSERT INTO score (v) VALUES (NULL)`,
+		`INSERT INTO score (v) VALUES (20)`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(b)
+		if err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	// each query returns payment id and a window function value, ordered by id
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{
+			`SELECT id, ROW_NUMBER() OVER (ORDER BY amount DESC) FROM payments ORDER BY id`,
+			[]string{"1", "3", "2", "4", "5"},
+		},
+		{
+			`SELECT id, RANK() OVER (PARTITION BY user_id ORDER BY amount DESC) FROM payments ORDER BY id`,
+			[]string{"1", "3", "1", "1", "2"},
+		},
+		{
+			`SELECT id, DENSE_RANK() OVER (ORDER BY amount DESC) FROM payments ORDER BY id`,
+			[]string{"1", "2", "1", "3", "4"},
+		},
+		{
+			`SELECT id, LAG(amount) OVER (ORDER BY id) FROM payments ORDER BY id`,
+			[]string{"", "80", "40", "80", "30"},
+		},
+		{
+			`SELECT id, LEAD(amount, 2, 0) OVER (PARTITION BY user_id ORDER BY id) FROM payments ORDER BY id`,
+			[]string{"80", "0", "0", "0", "0"},
+		},
+		{
+			`SELECT id, FIRST_VALUE(amount) OVER (PARTITION BY user_id ORDER BY amount) FROM payments ORDER BY id`,
+			[]string{"40", "40", "40", "10", "10"},
+		},
+		{
+			`SELECT id, LAST_VALUE(method) OVER (ORDER BY id ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING) FROM payments ORDER BY id`,
+			[]string{"card", "card", "card", "card", "card"},
+		},
+		{
+			`SELECT id, SUM(amount) OVER (ORDER BY id) FROM payments ORDER BY id`,
+			[]string{"80", "120", "200", "230", "240"},
+		},
+		{
+			// peers of current row are in default frame
+			`SELECT id, SUM(amount) OVER (ORDER BY amount) FROM payments ORDER BY id`,
+			[]string{"240", "80", "240", "40", "10"},
+		},
+		{
+			`SELECT id, SUM(amount) OVER (PARTITION BY user_id) FROM payments ORDER BY id`,
+			[]string{"200", "200", "200", "40", "40"},
+		},
+		{
+			`SELECT id, SUM(amount) OVER (ORDER BY id ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING) FROM payments ORDER BY id`,
+			[]string{"120", "200", "150", "120", "40"},
+		},
+		{
+			`SELECT id, MAX(amount) OVER (ORDER BY id ROWS 2 PRECEDING) FROM payments ORDER BY id`,
+			[]string{"80", "80", "80", "80", "80"},
+		},
+		{
+			`SELECT id, COUNT(*) OVER (ORDER BY id ROWS BETWEEN CURRENT ROW AND UNBOUNDED FOLLOWING) FROM payments ORDER BY id`,
+			[]string{"5", "4", "3", "2", "1"},
+		},
+		{
+			`SELECT id, COUNT(*) FILTER (WHERE method = 'card') OVER (PARTITION BY user_id) FROM payments ORDER BY id`,
+			[]string{"2", "2", "2", "2", "2"},
+		},
+		{
+			`SELECT id, NTILE(2) OVER (ORDER BY id) FROM payments ORDER BY id`,
+			[]string{"1", "1", "1", "2", "2"},
+		},
+		{
+			`SELECT id, amount * 100 / SUM(amount) OVER () FROM payments WHERE user_id = 1 ORDER BY id`,
+			[]string{"40", "20", "40"},
+		},
+	}
+	for _, tc := range testCases {
+		rows, err := db.Query(tc.query)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		var res []string
+		for i := 1; rows.Next(); i++ {
+			var id int64
+			var v sql.NullString
+			if err := rows.Scan(&id, &v); err != nil {
+				t.Fatalf("cannot scan '%s': %s", tc.query, err)
+			}
+			if id != int64(i) {
+				t.Fatalf("expected payment %d with '%s', got %d", i, tc.query, id)
+			}
+			res = append(res, v.String)
+		}
+		rows.Close()
+		if fmt.Sprint(res) != fmt.Sprint(tc.expected) {
+			t.Fatalf("expected %v with '%s', got %v", tc.expected, tc.query, res)
+		}
+	}
+
+	// ranking groups, paginating on a window function, and ranking joined rows
+	orderTests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT user_id, RANK() OVER (ORDER BY SUM(amount) DESC) FROM payments GROUP BY user_id ORDER BY user_id`, "[1 1 2 2]"},
+		{`SELECT id, amount FROM payments ORDER BY ROW_NUMBER() OVER (ORDER BY amount, id) DESC LIMIT 2`, "[3 80 1 80]"},
+		{`SELECT account.name, ROW_NUMBER() OVER (PARTITION BY account.id ORDER BY payments.amount) FROM payments JOIN account ON payments.user_id = account.id WHERE payments.amount < 50 ORDER BY account.name`, "[alice 1 bob 1 bob 2]"},
+		// NULL sorts last with ASC and first with DESC
+		{`SELECT id, ROW_NUMBER() OVER (ORDER BY v) FROM score ORDER BY id`, "[1 1 2 3 3 2]"},
+		{`SELECT id, ROW_NUMBER() OVER (ORDER BY v DESC) FROM score ORDER BY id`, "[1 3 2 1 3 2]"},
+	}
+	for _, tc := range orderTests {
+		rows, err := db.Query(tc.query)
+		if err != nil {
+			t.Fatalf("cannot query '%s': %s", tc.query, err)
+		}
+		var res []string
+		for rows.Next() {
+			var a, b string
+			if err := rows.Scan(&a, &b); err != nil {
+				t.Fatalf("cannot scan '%s': %s", tc.query, err)
+			}
+			res = append(res, a, b)
+		}
+		rows.Close()
+		if fmt.Sprint(res) != tc.expected {
+			t.Fatalf("expected %s with '%s', got %v", tc.expected, tc.query, res)
+		}
+	}
+
+	// window values are not selected by *
+	rows, err := db.Query(`SELECT *, ROW_NUMBER() OVER (ORDER BY id DESC) FROM payments`)
+	if err != nil {
+		t.Fatalf("cannot select * with window function: %s", err)
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		t.Fatalf("cannot get columns: %s", err)
+	}
+	rows.Close()
+	if len(cols) != 5 || cols[4] != "row_number" {
+		t.Fatalf("expected payments attributes and row_number, got %v", cols)
+	}
+
+	invalid := []struct {
+		query string
+		err   string
+	}{
+		{`SELECT id FROM payments WHERE ROW_NUMBER() OVER () > 1`, `window functions are not allowed in WHERE`},
+		{`SELECT ROW_NUMBER() FROM payments`, `window function row_number requires an OVER clause`},
+		{`SELECT lower(method) OVER () FROM payments`, `OVER specified, but lower is not a window function nor an aggregate function`},
+		{`SELECT COUNT(DISTINCT method) OVER () FROM payments`, `DISTINCT is not implemented for window functions`},
+		{`SELECT SUM(RANK() OVER ()) OVER () FROM payments`, `window function calls cannot be nested`},
+		{`SELECT user_id, RANK() OVER (ORDER BY amount) FROM payments GROUP BY user_id`, `column "payments.amount" must appear in the GROUP BY clause`},
+		{`SELECT RANK() OVER (ORDER BY user_id) FROM payments GROUP BY RANK() OVER (ORDER BY user_id)`, `window functions are not allowed in GROUP BY`},
+		{`SELECT SUM(amount) OVER (ORDER BY id ROWS BETWEEN CURRENT ROW AND 1 PRECEDING) FROM payments`, `frame starting from current row cannot have preceding rows`},
+		{`SELECT SUM(amount) OVER (ORDER BY id RANGE BETWEEN 1 PRECEDING AND CURRENT ROW) FROM payments`, `RANGE with offset PRECEDING/FOLLOWING is not supported`},
+		{`SELECT NTILE(0) OVER () FROM payments`, `argument of ntile must be greater than zero`},
+	}
+	for _, tc := range invalid {
+		rows, err := db.Query(tc.query)
+		if err == nil {
+			rows.Close()
+			t.Fatalf("expected error with '%s'", tc.query)
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("expected error '%s' with '%s', got '%s'", tc.err, tc.query, err)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package agnostic
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// avgScale is the minimum scale of numeric averages, as PostgreSQL displays avg of integers
+const avgScale = 16
+
+// AggregateValueFunctor computes an aggregate function, like sum(amount), over the rows of a group built by GroupBySorter
+type AggregateValueFunctor struct {
+	name     string
+	v        ValueFunctor
+	agg      func(values []any) (any, error)
+	distinct bool
+	filter   Predicate
+}
+
+// NewAggregateValueFunctor creates a ValueFunctor computing aggregate function name over values of v.
+// v is nil for count(*), which counts rows.
+func NewAggregateValueFunctor(name string, v ValueFunctor, functors ...func(*AggregateValueFunctor)) (*AggregateValueFunctor, error) {
+	agg, ok := aggregates[name]
+	if !ok {
+		return nil, fmt.Errorf("aggregate function %s does not exist", name)
+	}
+
+	f := &AggregateValueFunctor{
+		name: name,
+		v:    v,
+		agg:  agg,
+	}
+	for _, fn := range functors {
+		fn(f)
+	}
+	return f, nil
+}
+
+// WithDistinctValues makes aggregate function only consider distinct values, like count(DISTINCT user_id)
+func WithDistinctValues() func(*AggregateValueFunctor) {
+	return func(f *AggregateValueFunctor) {
+		f.distinct = true
+	}
+}
+
+// WithFilter makes aggregate function only consider rows for which p is true, like count(*) FILTER (WHERE paid)
+func WithFilter(p Predicate) func(*AggregateValueFunctor) {
+	return func(f *AggregateValueFunctor) {
+		f.filter = p
+	}
+}
+
+func (f *AggregateValueFunctor) Eval(cols []string, t *Tuple) (any, error) {
+	if t == nil || t.group == nil {
+		return nil, fmt.Errorf("aggregate function %s is not allowed here", f.name)
+	}
+
+	var seen map[string]struct{}
+	if f.distinct {
+		seen = make(map[string]struct{})
+	}
+
+	values := make([]any, 0, len(t.group))
+	for _, row := range t.group {
+		if f.filter != nil {
+			tr, err := evalTruth(f.filter, cols, row)
+			if err != nil {
+				return nil, err
+			}
+			if tr != truthTrue {
+				continue
+			}
+		}
+		// count(*) counts rows, whatever their values
+		if f.v == nil {
+			values = append(values, true)
+			continue
+		}
+		v, err := evalFunctor(f.v, cols, row)
+		if err != nil {
+			return nil, err
+		}
+		if f.distinct && v != nil {
+			k := valueKey(v)
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+		}
+		values = append(values, v)
+	}
+
+	return f.agg(values)
+}
+
+func (f *AggregateValueFunctor) Value(cols []string, t *Tuple) any {
+	v, err := f.Eval(cols, t)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func (f *AggregateValueFunctor) Relation() string {
+	if f.v == nil {
+		return ""
+	}
+	return f.v.Relation()
+}
+
+func (f *AggregateValueFunctor) Attribute() []string {
+	if f.v == nil {
+		return nil
+	}
+	return f.v.Attribute()
+}
+
+func (f AggregateValueFunctor) String() string {
+	var s string
+	switch {
+	case f.v == nil:
+		s = f.name + "(*)"
+	case f.distinct:
+		s = fmt.Sprintf("%s(DISTINCT %s)", f.name, f.v)
+	default:
+		s = fmt.Sprintf("%s(%s)", f.name, f.v)
+	}
+	if f.filter != nil {
+		s += fmt.Sprintf(" FILTER (WHERE %s)", f.filter)
+	}
+	return s
+}
+
+// count returns the number of non NULL values
+func count(values []any) (any, error) {
+	var n int64
+	for _, v := range values {
+		if v != nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// sum returns the sum of non NULL values, or NULL if there is none.
+// Integers sum to bigint, floats to double precision, numerics to numeric and intervals to interval.
+func sum(values []any) (any, error) {
+	var acc any
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		var err error
+		acc, err = addAggregate("sum", acc, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+// avg returns the mean of non NULL values, or NULL if there is none.
+// Integers and numerics average to numeric, floats to double precision and intervals to interval.
+func avg(values []any) (any, error) {
+	var acc any
+	var n int64
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		var err error
+		acc, err = addAggregate("avg", acc, v)
+		if err != nil {
+			return nil, err
+		}
+		n++
+	}
+
+	switch s := acc.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return s / float64(n), nil
+	case Interval:
+		return s.Mul(1 / float64(n)), nil
+	}
+
+	d, err := ToDecimal(acc)
+	if err != nil {
+		return nil, err
+	}
+	scale := avgScale
+	if d.Scale() > scale {
+		scale = d.Scale()
+	}
+	return d.QuoInt(n, scale), nil
+}
+
+// addAggregate returns acc + v for aggregate function name, acc being nil for the first value
+func addAggregate(name string, acc any, v any) (any, error) {
+	switch t := v.(type) {
+	case Interval:
+		if acc == nil {
+			return t, nil
+		}
+		if iv, ok := acc.(Interval); ok {
+			return iv.Add(t), nil
+		}
+	case Decimal:
+		if acc == nil {
+			return t, nil
+		}
+		if d, ok := acc.(Decimal); ok {
+			return d.Add(t), nil
+		}
+	case float32, float64:
+		f, _ := toFloat(t)
+		if acc == nil {
+			return f, nil
+		}
+		if s, ok := acc.(float64); ok {
+			return s + f, nil
+		}
+	default:
+		rv := reflect.ValueOf(v)
+		if !rv.CanInt() {
+			break
+		}
+		i := rv.Int()
+		if acc == nil {
+			return i, nil
+		}
+		if s, ok := acc.(int64); ok {
+			if (i > 0 && s > math.MaxInt64-i) || (i < 0 && s < math.MinInt64-i) {
+				return nil, fmt.Errorf("bigint out of range")
+			}
+			return s + i, nil
+		}
+	}
+	return nil, fmt.Errorf("function %s(%s) does not exist", name, valueTypeName(v))
+}
+
+// extremum returns an aggregate computing the greatest non NULL value if max is true, or else the least.
+// NULL is returned if there is no value.
+func extremum(max bool) func(values []any) (any, error) {
+	return func(values []any) (any, error) {
+		var res any
+		for _, v := range values {
+			if v == nil {
+				continue
+			}
+			if res == nil {
+				res = v
+				continue
+			}
+
+			var gt bool
+			var err error
+			if max {
+				gt, err = greater(v, res)
+			} else {
+				gt, err = greater(res, v)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if gt {
+				res = v
+			}
+		}
+		return res, nil
+	}
+}
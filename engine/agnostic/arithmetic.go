@@ -0,0 +1,328 @@
+package agnostic
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// ArithmeticValueFunctor implements +, -, *, / and % operators, on numbers, timestamps and intervals
+type ArithmeticValueFunctor struct {
+	op    byte
+	left  ValueFunctor
+	right ValueFunctor
+}
+
+// NewArithmeticValueFunctor creates a ValueFunctor computing left op right, op being '+', '-', '*', '/' or '%'
+func NewArithmeticValueFunctor(op byte, left, right ValueFunctor) (ValueFunctor, error) {
+	switch op {
+	case '+', '-', '*', '/', '%':
+	default:
+		return nil, fmt.Errorf("operator does not exist: %c", op)
+	}
+
+	f := &ArithmeticValueFunctor{
+		op:    op,
+		left:  left,
+		right: right,
+	}
+	return f, nil
+}
+
+func (f *ArithmeticValueFunctor) Eval(cols []string, t *Tuple) (any, error) {
+	vl, err := evalFunctor(f.left, cols, t)
+	if err != nil {
+		return nil, err
+	}
+	vr, err := evalFunctor(f.right, cols, t)
+	if err != nil {
+		return nil, err
+	}
+	return arithmetic(f.op, vl, vr)
+}
+
+func (f *ArithmeticValueFunctor) Value(cols []string, t *Tuple) any {
+	v, err := f.Eval(cols, t)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func (f *ArithmeticValueFunctor) Relation() string {
+	return commonRelation(f.left, f.right)
+}
+
+func (f *ArithmeticValueFunctor) Attribute() []string {
+	return append(f.left.Attribute(), f.right.Attribute()...)
+}
+
+func (f ArithmeticValueFunctor) String() string {
+	return fmt.Sprintf("%s %c %s", f.left, f.op, f.right)
+}
+
+// arithmetic computes vl op vr. Text operands are resolved against the other operand:
+// text added to a timestamp is an interval, text subtracted from a timestamp is an interval or a timestamp.
+func arithmetic(op byte, vl, vr any) (any, error) {
+	if vl == nil || vr == nil {
+		return nil, nil
+	}
+
+	switch op {
+	case '*', '/', '%':
+		return multiplicativeArithmetic(op, vl, vr)
+	}
+
+	switch l := vl.(type) {
+	case time.Time:
+		switch r := vr.(type) {
+		case Interval:
+			if op == '-' {
+				r = r.Neg()
+			}
+			return r.AddTo(l), nil
+		case time.Time:
+			if op == '-' {
+				return timeDiff(l, r), nil
+			}
+		case string:
+			if iv, err := ParseInterval(r); err == nil {
+				return arithmetic(op, l, iv)
+			}
+			if t, err := toTime(r, "timestamp"); err == nil && op == '-' {
+				return timeDiff(l, t), nil
+			}
+		default:
+			// date plus a number of days
+			if days, ok := toFloat(vr); ok && days == math.Trunc(days) {
+				if op == '-' {
+					days = -days
+				}
+				return l.AddDate(0, 0, int(days)), nil
+			}
+		}
+	case Interval:
+		switch r := vr.(type) {
+		case Interval:
+			if op == '-' {
+				r = r.Neg()
+			}
+			return l.Add(r), nil
+		case time.Time:
+			if op == '+' {
+				return l.AddTo(r), nil
+			}
+		case string:
+			if iv, err := ParseInterval(r); err == nil {
+				return arithmetic(op, l, iv)
+			}
+		}
+	case string:
+		switch vr.(type) {
+		case time.Time:
+			if t, err := toTime(l, "timestamp"); err == nil && op == '-' {
+				return timeDiff(t, vr.(time.Time)), nil
+			}
+			if iv, err := ParseInterval(l); err == nil && op == '+' {
+				return iv.AddTo(vr.(time.Time)), nil
+			}
+		case Interval:
+			if iv, err := ParseInterval(l); err == nil {
+				return arithmetic(op, iv, vr)
+			}
+		}
+	}
+
+	return numericArithmetic(op, vl, vr)
+}
+
+// multiplicativeArithmetic computes vl op vr for *, / and %, on numbers, and on an interval and a number
+func multiplicativeArithmetic(op byte, vl, vr any) (any, error) {
+	if iv, ok := vl.(Interval); ok && op != '%' {
+		if f, ok := toFloat(vr); ok {
+			if op == '/' {
+				if f == 0 {
+					return nil, fmt.Errorf("division by zero")
+				}
+				f = 1 / f
+			}
+			return iv.Mul(f), nil
+		}
+	}
+	if iv, ok := vr.(Interval); ok && op == '*' {
+		if f, ok := toFloat(vl); ok {
+			return iv.Mul(f), nil
+		}
+	}
+
+	if op != '*' {
+		if f, ok := toFloat(vr); ok && f == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+	}
+
+	return numericArithmetic(op, vl, vr)
+}
+
+// numericArithmetic computes vl op vr on integers, exactly on decimals and integers, or on floats if any operand
+// is a float. Integer division truncates toward zero. Divisor must not be zero.
+func numericArithmetic(op byte, vl, vr any) (any, error) {
+	l, r := reflect.ValueOf(vl), reflect.ValueOf(vr)
+	if l.CanInt() && r.CanInt() {
+		v, err := integerArithmetic(op, l.Int(), r.Int())
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	if dl, dr, ok := decimalOperands(vl, vr); ok {
+		switch op {
+		case '-':
+			return dl.Sub(dr), nil
+		case '*':
+			return dl.Mul(dr), nil
+		case '/':
+			return dl.Quo(dr), nil
+		case '%':
+			return dl.Rem(dr), nil
+		}
+		return dl.Add(dr), nil
+	}
+
+	_, lstr := vl.(string)
+	_, rstr := vr.(string)
+	if lstr && rstr {
+		return nil, fmt.Errorf("operator does not exist: %s %c %s", reflect.TypeOf(vl), op, reflect.TypeOf(vr))
+	}
+	fl, lok := toFloat(vl)
+	fr, rok := toFloat(vr)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator does not exist: %s %c %s", reflect.TypeOf(vl), op, reflect.TypeOf(vr))
+	}
+	switch op {
+	case '-':
+		return fl - fr, nil
+	case '*':
+		return fl * fr, nil
+	case '/':
+		return fl / fr, nil
+	case '%':
+		return math.Mod(fl, fr), nil
+	}
+	return fl + fr, nil
+}
+
+// integerArithmetic computes l op r, failing instead of wrapping around on overflow
+func integerArithmetic(op byte, l, r int64) (int64, error) {
+	var v int64
+	overflow := false
+	switch op {
+	case '-':
+		v = l - r
+		overflow = (r < 0 && v < l) || (r > 0 && v > l)
+	case '*':
+		v = l * r
+		overflow = (l == -1 && r == math.MinInt64) || (l != 0 && v/l != r)
+	case '/':
+		overflow = l == math.MinInt64 && r == -1
+		v = l / r
+	case '%':
+		v = l % r
+	default:
+		v = l + r
+		overflow = (r > 0 && v < l) || (r < 0 && v > l)
+	}
+	if overflow {
+		return 0, fmt.Errorf("bigint out of range")
+	}
+	return v, nil
+}
+
+// decimalOperands returns both operands as Decimal if one is a Decimal and the other is not a float,
+// so that NUMERIC arithmetic is exact
+func decimalOperands(vl, vr any) (Decimal, Decimal, bool) {
+	_, lok := vl.(Decimal)
+	_, rok := vr.(Decimal)
+	if !lok && !rok {
+		return Decimal{}, Decimal{}, false
+	}
+	for _, v := range []any{vl, vr} {
+		if rv := reflect.ValueOf(v); rv.CanFloat() {
+			return Decimal{}, Decimal{}, false
+		}
+	}
+	dl, err := ToDecimal(vl)
+	if err != nil {
+		return Decimal{}, Decimal{}, false
+	}
+	dr, err := ToDecimal(vr)
+	if err != nil {
+		return Decimal{}, Decimal{}, false
+	}
+	return dl, dr, true
+}
+
+// NegValueFunctor implements unary minus, on numbers and intervals
+type NegValueFunctor struct {
+	v ValueFunctor
+}
+
+// NewNegValueFunctor creates a ValueFunctor computing -v
+func NewNegValueFunctor(v ValueFunctor) ValueFunctor {
+	return &NegValueFunctor{v: v}
+}
+
+func (f *NegValueFunctor) Eval(cols []string, t *Tuple) (any, error) {
+	v, err := evalFunctor(f.v, cols, t)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	if iv, ok := v.(Interval); ok {
+		return iv.Neg(), nil
+	}
+	if d, ok := v.(Decimal); ok {
+		return d.Neg(), nil
+	}
+	if rv := reflect.ValueOf(v); rv.CanInt() {
+		if rv.Int() == math.MinInt64 {
+			return nil, fmt.Errorf("bigint out of range")
+		}
+		return -rv.Int(), nil
+	}
+	if _, ok := v.(string); !ok {
+		if fv, ok := toFloat(v); ok {
+			return -fv, nil
+		}
+	}
+	return nil, fmt.Errorf("operator does not exist: - %s", reflect.TypeOf(v))
+}
+
+func (f *NegValueFunctor) Value(cols []string, t *Tuple) any {
+	v, err := f.Eval(cols, t)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func (f *NegValueFunctor) Relation() string {
+	return f.v.Relation()
+}
+
+func (f *NegValueFunctor) Attribute() []string {
+	return f.v.Attribute()
+}
+
+func (f NegValueFunctor) String() string {
+	return fmt.Sprintf("-%s", f.v)
+}
+
+// timeDiff returns l - r as days and time, like PostgreSQL timestamp subtraction
+func timeDiff(l, r time.Time) Interval {
+	lm, rm := timeComparable(l, r)
+	diff := lm - rm
+	return Interval{days: int(diff / microsPerDay), micros: diff % microsPerDay}
+}
@@ -0,0 +1,503 @@
+package agnostic
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Array is a one dimensional SQL array, as stored in TEXT[] or INT[] attributes.
+// Elements are nil or values of the attribute element type.
+type Array []any
+
+// ParseArray parses an array in PostgreSQL text format, like {a,"b c",NULL}.
+// Elements are returned as text, to be converted to the element type.
+func ParseArray(s string) (Array, error) {
+	str := strings.TrimSpace(s)
+	if len(str) < 2 || str[0] != '{' || str[len(str)-1] != '}' {
+		return nil, fmt.Errorf("malformed array literal: \"%s\"", s)
+	}
+	body := str[1 : len(str)-1]
+
+	a := Array{}
+	if strings.TrimSpace(body) == "" {
+		return a, nil
+	}
+
+	i := 0
+	for {
+		for i < len(body) && body[i] == ' ' {
+			i++
+		}
+		if i == len(body) {
+			return nil, fmt.Errorf("malformed array literal: \"%s\"", s)
+		}
+
+		switch body[i] {
+		case '{':
+			return nil, fmt.Errorf("multidimensional arrays are not supported: \"%s\"", s)
+		case '"':
+			var b strings.Builder
+			i++
+			for i < len(body) && body[i] != '"' {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				b.WriteByte(body[i])
+				i++
+			}
+			if i == len(body) {
+				return nil, fmt.Errorf("malformed array literal: \"%s\"", s)
+			}
+			i++
+			a = append(a, b.String())
+		default:
+			j := i
+			for j < len(body) && body[j] != ',' {
+				j++
+			}
+			e := strings.TrimSpace(body[i:j])
+			if e == "" || strings.ContainsAny(e, "{}\"") {
+				return nil, fmt.Errorf("malformed array literal: \"%s\"", s)
+			}
+			if strings.EqualFold(e, "NULL") {
+				a = append(a, nil)
+			} else {
+				a = append(a, e)
+			}
+			i = j
+		}
+
+		for i < len(body) && body[i] == ' ' {
+			i++
+		}
+		if i == len(body) {
+			return a, nil
+		}
+		if body[i] != ',' {
+			return nil, fmt.Errorf("malformed array literal: \"%s\"", s)
+		}
+		i++
+	}
+}
+
+// ToArray converts given value to Array. Text is parsed as an array literal, Go slices are converted element-wise.
+func ToArray(v any) (Array, error) {
+	switch t := v.(type) {
+	case Array:
+		return t, nil
+	case string:
+		return ParseArray(t)
+	case []byte:
+		return ParseArray(string(t))
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		a := make(Array, rv.Len())
+		for i := range a {
+			a[i] = rv.Index(i).Interface()
+		}
+		return a, nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %v (type %T) to array", v, v)
+}
+
+// arrayElementType returns element type of array type name, like text for text[]
+func arrayElementType(typeName string) (string, bool) {
+	return strings.CutSuffix(strings.ToLower(typeName), "[]")
+}
+
+// String returns array in PostgreSQL text format
+func (a Array) String() string {
+	elems := make([]string, len(a))
+	for i, e := range a {
+		var s string
+		switch t := e.(type) {
+		case nil:
+			elems[i] = "NULL"
+			continue
+		case bool:
+			s = "f"
+			if t {
+				s = "t"
+			}
+		case time.Time:
+			s = t.Format("2006-01-02 15:04:05.999999999-07:00")
+		default:
+			s = fmt.Sprint(e)
+		}
+		if s == "" || strings.EqualFold(s, "NULL") || strings.ContainsAny(s, "{},\"\\ \t\n") {
+			s = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+		}
+		elems[i] = s
+	}
+	return "{" + strings.Join(elems, ",") + "}"
+}
+
+// Value implements driver.Valuer, returning array in text format as pq.Array expects
+func (a Array) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// resolveLike converts text v to the type of ref, as PostgreSQL resolves
+// literals of unknown type against the other operand
+func resolveLike(v, ref any) any {
+	s, ok := v.(string)
+	if !ok || ref == nil {
+		return v
+	}
+
+	var typeName string
+	switch ref.(type) {
+	case string:
+		return v
+	case bool:
+		typeName = "bool"
+	case time.Time:
+		typeName = "timestamp"
+	case Decimal:
+		typeName = "numeric"
+	case UUID:
+		typeName = "uuid"
+	case JSON:
+		typeName = "json"
+	case Bytea:
+		typeName = "bytea"
+	case Interval:
+		typeName = "interval"
+	default:
+		rv := reflect.ValueOf(ref)
+		switch {
+		case rv.CanInt():
+			typeName = "bigint"
+		case rv.CanUint():
+			typeName = "serial"
+		case rv.CanFloat():
+			typeName = "float"
+		default:
+			return v
+		}
+	}
+
+	r, err := ToInstance(s, typeName)
+	if err != nil {
+		return v
+	}
+	return r
+}
+
+// elementEqual compares two array elements. NULL elements are never equal.
+func elementEqual(a, b any) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	eq, err := equal(resolveLike(a, b), resolveLike(b, a))
+	return err == nil && eq
+}
+
+// arrayCompare compares a and b element by element, a shorter array being lower than its extensions
+func arrayCompare(a, b Array) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		l, r := resolveLike(a[i], b[i]), resolveLike(b[i], a[i])
+		if eq, err := equal(l, r); err == nil && eq {
+			continue
+		}
+		if gt, err := greater(l, r); err == nil && gt {
+			return 1
+		}
+		return -1
+	}
+
+	switch {
+	case len(a) > len(b):
+		return 1
+	case len(a) < len(b):
+		return -1
+	}
+	return 0
+}
+
+// arrayContains returns true if every element of b is an element of a, as @> does
+func arrayContains(a, b Array) bool {
+	for _, eb := range b {
+		found := false
+		for _, ea := range a {
+			if elementEqual(ea, eb) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayOverlap returns true if a and b have an element in common, as && does
+func arrayOverlap(a, b Array) bool {
+	for _, eb := range b {
+		for _, ea := range a {
+			if elementEqual(ea, eb) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// OverlapPredicate implements && operator: left and right arrays have an element in common
+type OverlapPredicate struct {
+	left  ValueFunctor
+	right ValueFunctor
+}
+
+func NewOverlapPredicate(left, right ValueFunctor) *OverlapPredicate {
+	p := &OverlapPredicate{
+		left:  left,
+		right: right,
+	}
+
+	return p
+}
+
+func (p *OverlapPredicate) Type() PredicateType {
+	return Overlap
+}
+
+func (p OverlapPredicate) String() string {
+	return fmt.Sprintf("%s && %s", p.left, p.right)
+}
+
+func (p *OverlapPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
+
+func (p *OverlapPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	return compareTruth(p.left, p.right, cols, t, p.compare)
+}
+
+func (p *OverlapPredicate) compare(vl, vr any) (bool, error) {
+	l, err := ToArray(vl)
+	if err != nil {
+		return false, err
+	}
+	r, err := ToArray(vr)
+	if err != nil {
+		return false, err
+	}
+
+	return arrayOverlap(l, r), nil
+}
+
+func (p *OverlapPredicate) Left() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *OverlapPredicate) Right() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *OverlapPredicate) Relation() string {
+	return commonRelation(p.left, p.right)
+}
+
+func (p *OverlapPredicate) Attribute() []string {
+	return append(p.left.Attribute(), p.right.Attribute()...)
+}
+
+// QuantifiedPredicate implements ANY and ALL: left value compared to each element of right array
+// matches at least one element, or all of them.
+type QuantifiedPredicate struct {
+	left  ValueFunctor
+	ptype PredicateType
+	right ValueFunctor
+	all   bool
+}
+
+// NewAnyPredicate creates a predicate true if left compared with ptype to any element of right is true,
+// like id = ANY($1)
+func NewAnyPredicate(left ValueFunctor, ptype PredicateType, right ValueFunctor) *QuantifiedPredicate {
+	return &QuantifiedPredicate{left: left, ptype: ptype, right: right}
+}
+
+// NewAllPredicate creates a predicate true if left compared with ptype to all elements of right is true,
+// like id <> ALL($1)
+func NewAllPredicate(left ValueFunctor, ptype PredicateType, right ValueFunctor) *QuantifiedPredicate {
+	return &QuantifiedPredicate{left: left, ptype: ptype, right: right, all: true}
+}
+
+func (p *QuantifiedPredicate) Type() PredicateType {
+	if p.all {
+		return All
+	}
+	return Any
+}
+
+func (p QuantifiedPredicate) String() string {
+	if p.all {
+		return fmt.Sprintf("%s %v ALL(%s)", p.left, p.ptype, p.right)
+	}
+	return fmt.Sprintf("%s %v ANY(%s)", p.left, p.ptype, p.right)
+}
+
+func (p *QuantifiedPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
+
+// truth of ANY is true if any comparison is true, unknown if none is but some are unknown.
+// truth of ALL is false if any comparison is false, unknown if none is but some are unknown.
+func (p *QuantifiedPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	vl, vr, err := evalOperands(p.left, p.right, cols, t)
+	if err != nil {
+		return truthFalse, err
+	}
+	if vl == nil || vr == nil {
+		return truthUnknown, nil
+	}
+
+	a, err := ToArray(vr)
+	if err != nil {
+		return truthFalse, err
+	}
+
+	result := truthOf(p.all)
+	for _, e := range a {
+		cmp, err := NewComparisonPredicate(NewConstValueFunctor(vl), p.ptype, NewConstValueFunctor(resolveLike(e, vl)))
+		if err != nil {
+			return truthFalse, err
+		}
+		tr, err := evalTruth(cmp, cols, t)
+		if err != nil {
+			return truthFalse, err
+		}
+		if tr == truthTrue && !p.all {
+			return truthTrue, nil
+		}
+		if tr == truthFalse && p.all {
+			return truthFalse, nil
+		}
+		if tr == truthUnknown {
+			result = truthUnknown
+		}
+	}
+
+	return result, nil
+}
+
+func (p *QuantifiedPredicate) Left() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *QuantifiedPredicate) Right() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *QuantifiedPredicate) Relation() string {
+	return commonRelation(p.left, p.right)
+}
+
+func (p *QuantifiedPredicate) Attribute() []string {
+	return append(p.left.Attribute(), p.right.Attribute()...)
+}
+
+// ConcatValueFunctor implements || operator, concatenating two arrays, an array and an element, or two texts
+type ConcatValueFunctor struct {
+	left  ValueFunctor
+	right ValueFunctor
+}
+
+func NewConcatValueFunctor(left, right ValueFunctor) ValueFunctor {
+	f := &ConcatValueFunctor{
+		left:  left,
+		right: right,
+	}
+	return f
+}
+
+func (f *ConcatValueFunctor) Value(cols []string, t *Tuple) any {
+	vl := f.left.Value(cols, t)
+	vr := f.right.Value(cols, t)
+
+	la, lok := vl.(Array)
+	ra, rok := vr.(Array)
+	switch {
+	case lok && rok:
+		return append(append(Array{}, la...), ra...)
+	case lok:
+		// text literal is an array literal if it parses as one
+		if s, ok := vr.(string); ok {
+			if a, err := ParseArray(s); err == nil {
+				return append(append(Array{}, la...), a...)
+			}
+		}
+		return append(append(Array{}, la...), vr)
+	case rok:
+		if s, ok := vl.(string); ok {
+			if a, err := ParseArray(s); err == nil {
+				return append(a, ra...)
+			}
+		}
+		return append(Array{vl}, ra...)
+	}
+
+	if vl == nil || vr == nil {
+		return nil
+	}
+	return fmt.Sprint(vl) + fmt.Sprint(vr)
+}
+
+func (f *ConcatValueFunctor) Relation() string {
+	return commonRelation(f.left, f.right)
+}
+
+func (f *ConcatValueFunctor) Attribute() []string {
+	return append(f.left.Attribute(), f.right.Attribute()...)
+}
+
+func (f ConcatValueFunctor) String() string {
+	return fmt.Sprintf("%s || %s", f.left, f.right)
+}
+
+// unnest implements unnest(array), returning one row per element
+func unnest(args []any) (any, error) {
+	if args[0] == nil {
+		return []any{}, nil
+	}
+	a, err := ToArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return []any(a), nil
+}
+
+// arrayLength implements array_length(array, dimension). Length of empty arrays is NULL.
+func arrayLength(args []any) (any, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	a, err := ToArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	dim, ok := resolveLike(args[1], int64(0)).(int64)
+	if !ok {
+		return nil, fmt.Errorf("dimension must be an integer, got %v", args[1])
+	}
+	if dim != 1 || len(a) == 0 {
+		return nil, nil
+	}
+	return int64(len(a)), nil
+}
+
+// arrayAgg implements array_agg(expression), collecting values of all rows. Aggregating no rows gives NULL.
+func arrayAgg(values []any) (any, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return Array(values), nil
+}
@@ -17,10 +17,6 @@ type ForeignKey struct {
 	attribute string
 }
 
-// Domain is the set of allowable values for an Attribute.
-type Domain struct {
-}
-
 // Attribute is a named column of a relation
 // AKA Field
 // AKA Column
@@ -29,11 +25,19 @@ type Attribute struct {
 	typeName      string
 	typeInstance  reflect.Type
 	defaultValue  Defaulter
-	domain        Domain
+	domain        *Domain
 	autoIncrement bool
 	nextValue     uint64
 	unique        bool
+	notNull       bool
 	fk            *ForeignKey
+	// declared precision and scale of numeric attribute, precision 0 if unconstrained
+	precision int
+	scale     int
+	// declared length of character attribute, 0 if unbounded
+	length int
+	// user-defined enum type of attribute, if any
+	enum *EnumType
 }
 
 func NewAttribute(name, typeName string) Attribute {
@@ -61,6 +65,9 @@ func (a Attribute) WithDefaultConst(defaultValue any) Attribute {
 		if defaultValue == nil {
 			return nil
 		}
+		if d, ok := defaultValue.(Decimal); ok && a.precision > 0 {
+			return d.Round(a.scale)
+		}
 		return reflect.ValueOf(defaultValue).Convert(a.typeInstance).Interface()
 	}
 	return a
@@ -83,35 +90,253 @@ func (a Attribute) WithUnique() Attribute {
 	return a
 }
 
+// WithNotNull rejects NULL values on insert and update
+func (a Attribute) WithNotNull() Attribute {
+	a.notNull = true
+	return a
+}
+
+// withEnum types attribute with a user-defined enum type. Default value, if any, must be a label of t.
+func (a Attribute) withEnum(t *EnumType) (Attribute, error) {
+	var v Enum
+	a.enum = t
+	a.typeInstance = reflect.TypeOf(v)
+
+	if d := a.defaultValue; d != nil {
+		if v := d(); v != nil {
+			if _, err := t.Value(v); err != nil {
+				return a, err
+			}
+		}
+		a.defaultValue = func() any {
+			v := d()
+			if v == nil {
+				return nil
+			}
+			e, _ := t.Value(v)
+			return e
+		}
+	}
+	return a, nil
+}
+
+// WithPrecision constrains a numeric attribute to precision significant digits,
+// scale of them after decimal point.
+func (a Attribute) WithPrecision(precision, scale int) Attribute {
+	a.precision = precision
+	a.scale = scale
+	return a
+}
+
+// WithLength constrains a character attribute to length characters
+func (a Attribute) WithLength(length int) Attribute {
+	a.length = length
+	return a
+}
+
+// WithForeignKey references given relation attribute, inserted and updated non NULL values having to exist in it.
+// Deleting or updating referenced rows is not checked.
+// If attribute is empty, the primary key of referenced relation is used.
+func (a Attribute) WithForeignKey(schema, relation, attribute string) Attribute {
+	a.fk = &ForeignKey{
+		schema:    schema,
+		relation:  relation,
+		attribute: attribute,
+	}
+	return a
+}
+
 func (a Attribute) Name() string {
 	return a.name
 }
 
 func (a Attribute) String() string {
 	s := a.name + " (" + a.typeName
+	if a.precision > 0 {
+		s = s + fmt.Sprintf("(%d,%d)", a.precision, a.scale)
+	}
+	if a.length > 0 {
+		s = s + fmt.Sprintf("(%d)", a.length)
+	}
 	if a.autoIncrement {
 		s = s + " AutoInc"
 	}
 	if a.unique {
 		s = s + " unique"
 	}
+	if a.notNull {
+		s = s + " not null"
+	}
+	if a.fk != nil {
+		s = s + " references " + a.fk.relation + "." + a.fk.attribute
+	}
 	s = s + ")"
 	return s
 }
 
+// checkNotNull returns an error if val is NULL and attribute attr of relation does not allow it
+func checkNotNull(relation string, attr Attribute, val any) error {
+	if val == nil && attr.notNull {
+		return fmt.Errorf("null value in column \"%s\" of relation \"%s\" violates not-null constraint", attr.name, relation)
+	}
+	return nil
+}
+
+// coerce converts value to attribute type, or returns an error if it cannot be assigned to attribute
+func coerce(relation string, attr Attribute, val any) (any, error) {
+	if attr.domain != nil {
+		return attr.domain.coerce(relation, attr, val)
+	}
+
+	if val == nil {
+		return nil, nil
+	}
+
+	if attr.enum != nil {
+		e, err := attr.enum.Value(val)
+		if err != nil {
+			return nil, fmt.Errorf("cannot assign '%v' to %s.%s: %w", val, relation, attr.name, err)
+		}
+		return e, nil
+	}
+
+	if elemType, ok := arrayElementType(attr.typeName); ok {
+		return coerceArray(relation, attr, elemType, val)
+	}
+
+	switch strings.ToLower(attr.typeName) {
+	case "uuid":
+		u, err := ToUUID(val)
+		if err != nil {
+			return nil, fmt.Errorf("cannot assign '%v' (type %T) to %s.%s (type uuid): %w", val, val, relation, attr.name, err)
+		}
+		return u, nil
+	case "json", "jsonb":
+		toJSON := ToJSONB
+		if strings.ToLower(attr.typeName) == "json" {
+			toJSON = ToRawJSON
+		}
+		j, err := toJSON(val)
+		if err != nil {
+			return nil, fmt.Errorf("cannot assign '%v' (type %T) to %s.%s (type %s): %w", val, val, relation, attr.name, attr.typeName, err)
+		}
+		return j, nil
+	case "decimal", "numeric":
+		d, err := ToDecimal(val)
+		if err != nil {
+			return nil, fmt.Errorf("cannot assign '%v' (type %T) to %s.%s (type %s): %w", val, val, relation, attr.name, attr.typeName, err)
+		}
+		if attr.precision > 0 {
+			d, err = d.Fit(attr.precision, attr.scale)
+			if err != nil {
+				return nil, fmt.Errorf("cannot assign '%v' to %s.%s: %w", val, relation, attr.name, err)
+			}
+		}
+		return d, nil
+	case "bytea", "blob":
+		b, err := ToBytea(val)
+		if err != nil {
+			return nil, fmt.Errorf("cannot assign '%v' (type %T) to %s.%s (type %s): %w", val, val, relation, attr.name, attr.typeName, err)
+		}
+		return b, nil
+	case "interval":
+		iv, err := ToInterval(val)
+		if err != nil {
+			return nil, fmt.Errorf("cannot assign '%v' (type %T) to %s.%s (type interval): %w", val, val, relation, attr.name, err)
+		}
+		return iv, nil
+	}
+
+	if min, max, ok := integerRange(attr.typeName); ok {
+		return coerceInteger(relation, attr, val, min, max)
+	}
+	if isFloatType(attr.typeName) {
+		return coerceFloat(relation, attr, val)
+	}
+	if isTextType(attr.typeName) {
+		return coerceText(relation, attr, val)
+	}
+	if isTemporalType(attr.typeName) {
+		return coerceTime(relation, attr, val)
+	}
+
+	if d, ok := val.(Decimal); ok {
+		val = d.Float64()
+	}
+
+	tof := reflect.TypeOf(val)
+	if !tof.ConvertibleTo(attr.typeInstance) {
+		return nil, fmt.Errorf("cannot assign '%v' (type %s) to %s.%s (type %s)", val, tof, relation, attr.name, attr.typeInstance)
+	}
+	return reflect.ValueOf(val).Convert(attr.typeInstance).Interface(), nil
+}
+
+// coerceArray converts val to an Array of elemType values
+func coerceArray(relation string, attr Attribute, elemType string, val any) (any, error) {
+	a, err := ToArray(val)
+	if err != nil {
+		return nil, fmt.Errorf("cannot assign '%v' (type %T) to %s.%s (type %s): %w", val, val, relation, attr.name, attr.typeName, err)
+	}
+
+	elemAttr := NewAttribute(attr.name, elemType)
+	out := make(Array, len(a))
+	for i, e := range a {
+		// elements parsed from text format are converted like literals
+		if s, ok := e.(string); ok && elemAttr.typeInstance.Kind() != reflect.String {
+			e, err = ToInstance(s, elemType)
+			if err != nil {
+				return nil, fmt.Errorf("cannot assign '%v' to %s.%s (type %s): %w", val, relation, attr.name, attr.typeName, err)
+			}
+		}
+		out[i], err = coerce(relation, elemAttr, e)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
 func typeInstanceFromName(name string) reflect.Type {
-	switch strings.ToLower(name) {
-	case "serial", "bigserial", "int", "bigint":
+	if _, ok := arrayElementType(name); ok {
+		var v Array
+		return reflect.TypeOf(v)
+	}
+
+	if _, _, ok := integerRange(name); ok {
 		var v int64
 		return reflect.TypeOf(v)
+	}
+	if isFloatType(name) {
+		var v float64
+		return reflect.TypeOf(v)
+	}
+	if isCharType(name) {
+		var v Char
+		return reflect.TypeOf(v)
+	}
+	if isTemporalType(name) {
+		var v time.Time
+		return reflect.TypeOf(v)
+	}
+
+	switch strings.ToLower(name) {
 	case "bool", "boolean":
 		var v bool
 		return reflect.TypeOf(v)
-	case "decimal", "float":
-		var v float64
+	case "decimal", "numeric":
+		var v Decimal
 		return reflect.TypeOf(v)
-	case "timestamp", "timestamptz", "date":
-		var v time.Time
+	case "bytea", "blob":
+		var v Bytea
+		return reflect.TypeOf(v)
+	case "uuid":
+		var v UUID
+		return reflect.TypeOf(v)
+	case "interval":
+		var v Interval
+		return reflect.TypeOf(v)
+	case "json", "jsonb":
+		var v JSON
 		return reflect.TypeOf(v)
 	default:
 		var v string
@@ -127,40 +352,57 @@ func ToInstance(value, typeName string) (any, error) {
 		return nil, nil
 	}
 
-	switch strings.ToLower(typeName) {
-	case "serial", "bigserial":
-		var v uint64
-		v, err := strconv.ParseUint(value, 10, 64)
+	if elemType, ok := arrayElementType(typeName); ok {
+		a, err := ParseArray(value)
 		if err != nil {
 			return nil, err
 		}
-		return v, nil
-	case "decimal", "float":
-		v, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			return nil, err
-		}
-		return v, nil
-	case "int", "bigint":
-		v, err := strconv.ParseInt(value, 10, 64)
+		return coerceArray("", NewAttribute("", typeName), elemType, a)
+	}
+
+	// serial values are parsed unsigned, so they convert to any integer attribute
+	if _, _, ok := integerRange(typeName); ok && !strings.HasSuffix(strings.ToLower(typeName), "serial") {
+		return parseInteger(value, typeName)
+	}
+	if isFloatType(typeName) {
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid input syntax for type %s: \"%s\"", sqlTypeName(typeName), value)
 		}
-		return v, nil
-	case "bool", "boolean":
-		v, err := strconv.ParseBool(value)
+		return f, nil
+	}
+	if isCharType(typeName) || isVarcharType(typeName) {
+		return value, nil
+	}
+	if isTemporalType(typeName) {
+		t, err := parseTime(strings.TrimSpace(value), typeName)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid input syntax for type %s: \"%s\"", strings.ToLower(typeName), value)
 		}
-		return v, nil
-	case "timestamp", "timestamptz", "date":
-		v, err := parseDate(value)
+		return t, nil
+	}
+
+	switch strings.ToLower(typeName) {
+	case "serial", "bigserial", "smallserial":
+		v, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid input syntax for type %s: \"%s\"", sqlTypeName(typeName), value)
 		}
 		return v, nil
-	case "json", "jsonb", "varchar":
-		return value, nil
+	case "decimal", "numeric":
+		return ParseDecimal(value)
+	case "bytea", "blob":
+		return ParseBytea(value)
+	case "bool", "boolean":
+		return parseBool(value)
+	case "uuid":
+		return ParseUUID(value)
+	case "interval":
+		return ParseInterval(value)
+	case "json":
+		return ParseRawJSON(value)
+	case "jsonb":
+		return ParseJSON(value)
 	default: // try everyting
 		if v, err := strconv.ParseUint(value, 10, 64); err == nil {
 			return v, nil
@@ -175,29 +417,31 @@ func ToInstance(value, typeName string) (any, error) {
 	}
 }
 
+// parseDate parses a date or timestamp literal. Literals without time zone are wall clock readings.
 func parseDate(data string) (time.Time, error) {
-	DateLongFormat := "2006-01-02 15:04:05.999999999 -0700 MST"
-	DateShortFormat := "2006-Jan-02"
-	DateNumberFormat := "2006-01-02"
-
-	t, err := time.Parse(DateLongFormat, data)
-	if err == nil {
-		return t, nil
+	zoned := []string{
+		"2006-01-02 15:04:05.999999999 -0700 MST",
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999Z07",
 	}
-
-	t, err = time.Parse(time.RFC3339, data)
-	if err == nil {
-		return t, nil
+	for _, layout := range zoned {
+		if t, err := time.Parse(layout, data); err == nil {
+			return t, nil
+		}
 	}
 
-	t, err = time.Parse(DateShortFormat, data)
-	if err == nil {
-		return t, nil
+	local := []string{
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04",
+		"2006-Jan-02",
+		"2006-01-02",
 	}
-
-	t, err = time.Parse(DateNumberFormat, data)
-	if err == nil {
-		return t, nil
+	for _, layout := range local {
+		if t, err := time.ParseInLocation(layout, data, wallClock); err == nil {
+			return t, nil
+		}
 	}
 
 	return time.Time{}, fmt.Errorf("cannot use '%s' as date", data)
@@ -0,0 +1,79 @@
+package agnostic
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Bytea is a binary string, as stored in BYTEA and BLOB attributes
+type Bytea []byte
+
+// ParseBytea parses a bytea literal, either in hex format ('\xDEADBEEF')
+// or in escape format, where \\ is a backslash and \nnn an octal byte value.
+func ParseBytea(s string) (Bytea, error) {
+	if strings.HasPrefix(s, `\x`) || strings.HasPrefix(s, `\X`) {
+		// whitespace is allowed between pairs of digits
+		digits := strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+				return -1
+			}
+			return r
+		}, s[2:])
+		b, err := hex.DecodeString(digits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hexadecimal data in bytea \"%s\": %w", s, err)
+		}
+		return Bytea(b), nil
+	}
+
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b = append(b, s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\\' {
+			b = append(b, '\\')
+			i++
+			continue
+		}
+		if i+3 < len(s) && isOctal(s[i+1]) && isOctal(s[i+2]) && isOctal(s[i+3]) && s[i+1] <= '3' {
+			b = append(b, (s[i+1]-'0')<<6|(s[i+2]-'0')<<3|(s[i+3]-'0'))
+			i += 3
+			continue
+		}
+		return nil, fmt.Errorf("invalid input syntax for type bytea: \"%s\"", s)
+	}
+	return Bytea(b), nil
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+// ToBytea converts given value to Bytea. Byte slices are copied as-is, strings are parsed as bytea literals.
+func ToBytea(v any) (Bytea, error) {
+	switch t := v.(type) {
+	case Bytea:
+		return t, nil
+	case []byte:
+		return Bytea(bytes.Clone(t)), nil
+	case string:
+		return ParseBytea(t)
+	}
+
+	return nil, fmt.Errorf("cannot convert %v (type %T) to bytea", v, v)
+}
+
+// String returns bytea in hex format, like \xdeadbeef
+func (b Bytea) String() string {
+	return `\x` + hex.EncodeToString(b)
+}
+
+// Value implements driver.Valuer, returning raw bytes
+func (b Bytea) Value() (driver.Value, error) {
+	return []byte(b), nil
+}
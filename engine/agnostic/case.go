@@ -0,0 +1,81 @@
+package agnostic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CaseValueFunctor implements CASE WHEN condition THEN result ... [ELSE result] END.
+// Result of the first true condition is returned, or else result, NULL without ELSE.
+// A condition being unknown is not true, so CASE x WHEN NULL never matches.
+type CaseValueFunctor struct {
+	conditions []Predicate
+	results    []ValueFunctor
+	els        ValueFunctor
+}
+
+// NewCaseValueFunctor creates a ValueFunctor returning results[i] for the first true conditions[i].
+// els may be nil.
+func NewCaseValueFunctor(conditions []Predicate, results []ValueFunctor, els ValueFunctor) (*CaseValueFunctor, error) {
+	if len(conditions) == 0 || len(conditions) != len(results) {
+		return nil, fmt.Errorf("CASE requires a THEN result for each WHEN condition")
+	}
+	if els == nil {
+		els = NewConstValueFunctor(nil)
+	}
+
+	f := &CaseValueFunctor{
+		conditions: conditions,
+		results:    results,
+		els:        els,
+	}
+	return f, nil
+}
+
+func (f *CaseValueFunctor) Eval(cols []string, t *Tuple) (any, error) {
+	for i, c := range f.conditions {
+		tr, err := evalTruth(c, cols, t)
+		if err != nil {
+			return nil, err
+		}
+		if tr == truthTrue {
+			return evalFunctor(f.results[i], cols, t)
+		}
+	}
+	return evalFunctor(f.els, cols, t)
+}
+
+func (f *CaseValueFunctor) Value(cols []string, t *Tuple) any {
+	v, err := f.Eval(cols, t)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func (f *CaseValueFunctor) Relation() string {
+	pickers := []Picker{f.els}
+	for i, c := range f.conditions {
+		pickers = append(pickers, c, f.results[i])
+	}
+	return commonRelation(pickers...)
+}
+
+func (f *CaseValueFunctor) Attribute() []string {
+	var attrs []string
+	for i, c := range f.conditions {
+		attrs = append(attrs, c.Attribute()...)
+		attrs = append(attrs, f.results[i].Attribute()...)
+	}
+	return append(attrs, f.els.Attribute()...)
+}
+
+func (f CaseValueFunctor) String() string {
+	var b strings.Builder
+	b.WriteString("CASE")
+	for i, c := range f.conditions {
+		fmt.Fprintf(&b, " WHEN %s THEN %s", c, f.results[i])
+	}
+	fmt.Fprintf(&b, " ELSE %s END", f.els)
+	return b.String()
+}
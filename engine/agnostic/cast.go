@@ -0,0 +1,400 @@
+package agnostic
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// isBuiltinType returns true if typeName is a type known without any user-defined type
+func isBuiltinType(typeName string) bool {
+	if elemType, ok := arrayElementType(typeName); ok {
+		return isBuiltinType(elemType)
+	}
+	if _, _, ok := integerRange(typeName); ok {
+		return true
+	}
+	if isFloatType(typeName) || isCharType(typeName) || isVarcharType(typeName) || isTemporalType(typeName) {
+		return true
+	}
+
+	switch strings.ToLower(typeName) {
+	case "text", "string", "bool", "boolean", "decimal", "numeric", "bytea", "blob", "uuid", "interval", "json", "jsonb":
+		return true
+	}
+	return false
+}
+
+// sqlTypeName returns the name PostgreSQL gives to typeName in error messages
+func sqlTypeName(typeName string) string {
+	switch strings.ToLower(typeName) {
+	case "int", "int4", "integer", "serial":
+		return "integer"
+	case "smallint", "int2", "smallserial":
+		return "smallint"
+	case "bigint", "int8", "bigserial":
+		return "bigint"
+	case "float", "float8", "double", "double precision":
+		return "double precision"
+	case "real", "float4":
+		return "real"
+	case "bool", "boolean":
+		return "boolean"
+	case "decimal", "numeric":
+		return "numeric"
+	case "varchar":
+		return "character varying"
+	case "char", "bpchar":
+		return "character"
+	}
+	return strings.ToLower(typeName)
+}
+
+// valueTypeName returns the SQL type name of value v
+func valueTypeName(v any) string {
+	switch v.(type) {
+	case string:
+		return "text"
+	case Char:
+		return "character"
+	case bool:
+		return "boolean"
+	case float32, float64:
+		return "double precision"
+	case Decimal:
+		return "numeric"
+	case time.Time:
+		return "timestamp"
+	case Interval:
+		return "interval"
+	case UUID:
+		return "uuid"
+	case JSON:
+		return "jsonb"
+	case Bytea, []byte:
+		return "bytea"
+	case Array:
+		return "array"
+	case Enum:
+		return "enum"
+	}
+	rv := reflect.ValueOf(v)
+	if rv.CanInt() || rv.CanUint() {
+		return "bigint"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// parseBool parses a boolean literal, accepting the same spellings as PostgreSQL:
+// true, yes, on, 1 and false, no, off, 0, or any unique prefix of those, ignoring case
+func parseBool(s string) (bool, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	switch str {
+	case "1", "on":
+		return true, nil
+	case "0", "of", "off":
+		return false, nil
+	}
+	if str != "" {
+		for _, w := range []string{"true", "yes"} {
+			if strings.HasPrefix(w, str) {
+				return true, nil
+			}
+		}
+		for _, w := range []string{"false", "no"} {
+			if strings.HasPrefix(w, str) {
+				return false, nil
+			}
+		}
+	}
+	return false, fmt.Errorf("invalid input syntax for type boolean: \"%s\"", s)
+}
+
+// parseInteger parses an integer literal, surrounding spaces being ignored
+func parseInteger(s string, typeName string) (int64, error) {
+	i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+			return 0, fmt.Errorf("%s out of range", sqlTypeName(typeName))
+		}
+		return 0, fmt.Errorf("invalid input syntax for type %s: \"%s\"", sqlTypeName(typeName), s)
+	}
+	return i, nil
+}
+
+// Cast converts v to the type of attr, like CAST(v AS type). Text is parsed as a literal of the type,
+// and any value converts to text. NULL is NULL of any type.
+func Cast(v any, attr Attribute) (any, error) {
+	return cast(v, "", attr)
+}
+
+// cast converts v, of type from if known, to the type of attr
+func cast(v any, from string, attr Attribute) (any, error) {
+	if attr.domain != nil {
+		base := attr
+		base.domain = nil
+		c, err := cast(v, from, base)
+		if err != nil {
+			return nil, err
+		}
+		return attr.domain.coerce("", attr, c)
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	if attr.enum != nil {
+		if c, ok := v.(Char); ok {
+			v = c.String()
+		}
+		return attr.enum.Value(v)
+	}
+
+	typeName := attr.typeName
+	if isTextType(typeName) {
+		return castText(v, from, attr)
+	}
+
+	// text is read as a literal, like '2024-01-01'::date
+	switch t := v.(type) {
+	case string:
+		c, err := ToInstance(t, typeName)
+		if err != nil {
+			return nil, err
+		}
+		v = c
+	case Char:
+		c, err := ToInstance(t.String(), typeName)
+		if err != nil {
+			return nil, err
+		}
+		v = c
+	}
+
+	if elemType, ok := arrayElementType(typeName); ok {
+		if _, ok := v.(Array); !ok {
+			return nil, cannotCast(v, typeName)
+		}
+		return coerceArray("", attr, elemType, v)
+	}
+	if min, max, ok := integerRange(typeName); ok {
+		return castInteger(v, typeName, min, max)
+	}
+	if isFloatType(typeName) {
+		return castFloat(v, typeName)
+	}
+	if isTemporalType(typeName) {
+		if _, ok := v.(time.Time); !ok {
+			return nil, cannotCast(v, typeName)
+		}
+		return coerceTime("", attr, v)
+	}
+
+	switch strings.ToLower(typeName) {
+	case "bool", "boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		if rv := reflect.ValueOf(v); rv.CanInt() {
+			return rv.Int() != 0, nil
+		}
+	case "decimal", "numeric":
+		if _, ok := v.(bool); ok {
+			break
+		}
+		d, err := ToDecimal(v)
+		if err != nil {
+			break
+		}
+		if attr.precision > 0 {
+			return d.Fit(attr.precision, attr.scale)
+		}
+		return d, nil
+	case "uuid":
+		if u, ok := v.(UUID); ok {
+			return u, nil
+		}
+	case "bytea", "blob":
+		if b, ok := v.(Bytea); ok {
+			return b, nil
+		}
+	case "interval":
+		if iv, ok := v.(Interval); ok {
+			return iv, nil
+		}
+	case "json":
+		return ToRawJSON(v)
+	case "jsonb":
+		return ToJSONB(v)
+	}
+
+	return nil, cannotCast(v, typeName)
+}
+
+func cannotCast(v any, typeName string) error {
+	return fmt.Errorf("cannot cast type %s to %s", valueTypeName(v), sqlTypeName(typeName))
+}
+
+// isTextType returns true if typeName is a character type
+func isTextType(typeName string) bool {
+	switch strings.ToLower(typeName) {
+	case "text", "string":
+		return true
+	}
+	return isCharType(typeName) || isVarcharType(typeName)
+}
+
+// castText converts v to its text representation. Unlike assignment, a cast to
+// CHAR(n) or VARCHAR(n) silently truncates values longer than n.
+func castText(v any, from string, attr Attribute) (any, error) {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case Char:
+		s = t.String()
+	case bool:
+		s = strconv.FormatBool(t)
+	case float64:
+		s = strconv.FormatFloat(t, 'g', -1, 64)
+	case float32:
+		s = strconv.FormatFloat(float64(t), 'g', -1, 32)
+	case time.Time:
+		s = formatTime(t, from)
+	case []byte:
+		s = Bytea(t).String()
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if attr.length > 0 && utf8.RuneCountInString(s) > attr.length {
+		s = string([]rune(s)[:attr.length])
+	}
+	if !isCharType(attr.typeName) && !isVarcharType(attr.typeName) {
+		return s, nil
+	}
+	return coerceText("", attr, s)
+}
+
+// formatTime returns t, of type typeName if known, in PostgreSQL output format.
+// Times of day and wall clock readings have no time zone, instants are displayed with their offset.
+func formatTime(t time.Time, typeName string) string {
+	switch {
+	case t.Location() != wallClock:
+		return t.Format("2006-01-02 15:04:05.999999-07")
+	case strings.EqualFold(typeName, "date"):
+		return t.Format("2006-01-02")
+	case isTimeOfDayType(typeName), typeName == "" && t.Year() == 0 && t.YearDay() == 1:
+		return t.Format("15:04:05.999999")
+	}
+	return t.Format("2006-01-02 15:04:05.999999")
+}
+
+// castInteger converts a number or a boolean to an integer in [min, max]. Fractional values are rounded.
+func castInteger(v any, typeName string, min, max int64) (any, error) {
+	outOfRange := fmt.Errorf("%s out of range", sqlTypeName(typeName))
+
+	var i int64
+	switch t := v.(type) {
+	case bool:
+		if t {
+			i = 1
+		}
+	case float32, float64:
+		f, _ := toFloat(t)
+		f = math.RoundToEven(f)
+		if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return nil, outOfRange
+		}
+		i = int64(f)
+	case Decimal:
+		r := t.Round(0).unscaled
+		if !r.IsInt64() {
+			return nil, outOfRange
+		}
+		i = r.Int64()
+	default:
+		rv := reflect.ValueOf(v)
+		switch {
+		case rv.CanInt():
+			i = rv.Int()
+		case rv.CanUint():
+			if rv.Uint() > math.MaxInt64 {
+				return nil, outOfRange
+			}
+			i = int64(rv.Uint())
+		default:
+			return nil, cannotCast(v, typeName)
+		}
+	}
+
+	if i < min || i > max {
+		return nil, outOfRange
+	}
+	return i, nil
+}
+
+// castFloat converts a number to float64, checking REAL values fit in single precision
+func castFloat(v any, typeName string) (any, error) {
+	if _, ok := v.(bool); ok {
+		return nil, cannotCast(v, typeName)
+	}
+	f, ok := toFloat(v)
+	if !ok {
+		return nil, cannotCast(v, typeName)
+	}
+
+	switch strings.ToLower(typeName) {
+	case "real", "float4":
+		if !math.IsInf(f, 0) && math.Abs(f) > math.MaxFloat32 {
+			return nil, fmt.Errorf("value out of range: overflow")
+		}
+	}
+	return f, nil
+}
+
+// CastValueFunctor implements CAST(v AS type) and v::type
+type CastValueFunctor struct {
+	v    ValueFunctor
+	from Attribute
+	attr Attribute
+}
+
+// NewCastValueFunctor creates a ValueFunctor converting values of v, typed like attribute from, to type of attr.
+// from is used to display values as text, like a DATE without time, and may be empty if type of v is unknown.
+func NewCastValueFunctor(v ValueFunctor, from Attribute, attr Attribute) *CastValueFunctor {
+	return &CastValueFunctor{v: v, from: from, attr: attr}
+}
+
+func (f *CastValueFunctor) Eval(cols []string, t *Tuple) (any, error) {
+	v, err := evalFunctor(f.v, cols, t)
+	if err != nil {
+		return nil, err
+	}
+	return cast(v, f.from.typeName, f.attr)
+}
+
+func (f *CastValueFunctor) Value(cols []string, t *Tuple) any {
+	v, err := f.Eval(cols, t)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func (f *CastValueFunctor) Relation() string {
+	return f.v.Relation()
+}
+
+func (f *CastValueFunctor) Attribute() []string {
+	return f.v.Attribute()
+}
+
+func (f CastValueFunctor) String() string {
+	return fmt.Sprintf("%s::%s", f.v, f.attr.typeName)
+}
@@ -16,6 +16,21 @@ type RelationChange struct {
 	old     *Relation
 }
 
+// TypeChange records creation, drop or alteration of a user-defined type.
+// On alteration, old holds a copy of the type before the change.
+type TypeChange struct {
+	schema  *Schema
+	current *EnumType
+	old     *EnumType
+}
+
+// DomainChange records creation or drop of a domain
+type DomainChange struct {
+	schema  *Schema
+	current *Domain
+	old     *Domain
+}
+
 type SchemaChange struct {
 	current *Schema
 	old     *Schema
@@ -74,3 +89,32 @@ func (t *Transaction) rollbackSchemaChange(c SchemaChange) {
 		c.e.schemas[c.old.name] = c.old
 	}
 }
+
+func (t *Transaction) rollbackTypeChange(c TypeChange) {
+	// revert type creation
+	if c.current != nil && c.old == nil {
+		c.schema.RemoveType(c.current.name)
+	}
+
+	// revert type drop
+	if c.current == nil && c.old != nil {
+		c.schema.AddType(c.old.name, c.old)
+	}
+
+	// revert alter, in place as values reference the type
+	if c.current != nil && c.old != nil {
+		c.current.labels = c.old.labels
+	}
+}
+
+func (t *Transaction) rollbackDomainChange(c DomainChange) {
+	// revert domain creation
+	if c.current != nil {
+		c.schema.RemoveDomain(c.current.name)
+	}
+
+	// revert domain drop
+	if c.old != nil {
+		c.schema.AddDomain(c.old.name, c.old)
+	}
+}
@@ -0,0 +1,93 @@
+package agnostic
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Char is a value of a CHAR(n) attribute, blank-padded to n characters.
+// Trailing spaces are not significant: they are ignored when comparing and concatenating.
+type Char string
+
+// String returns c without padding
+func (c Char) String() string {
+	return strings.TrimRight(string(c), " ")
+}
+
+// Value implements driver.Valuer, returning c with padding
+func (c Char) Value() (driver.Value, error) {
+	return string(c), nil
+}
+
+// isCharType returns true if typeName is a blank-padded character type
+func isCharType(typeName string) bool {
+	switch strings.ToLower(typeName) {
+	case "char", "character", "bpchar":
+		return true
+	}
+	return false
+}
+
+// isVarcharType returns true if typeName is a variable length character type
+func isVarcharType(typeName string) bool {
+	switch strings.ToLower(typeName) {
+	case "varchar", "character varying":
+		return true
+	}
+	return false
+}
+
+// fitLength checks s holds in length characters. As in SQL, exceeding characters
+// are silently truncated if they are all spaces.
+func fitLength(s string, length int) (string, error) {
+	if length <= 0 || utf8.RuneCountInString(s) <= length {
+		return s, nil
+	}
+
+	if utf8.RuneCountInString(strings.TrimRight(s, " ")) > length {
+		return "", fmt.Errorf("value too long")
+	}
+
+	// keep first length characters
+	i := 0
+	for n := 0; n < length; n++ {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return s[:i], nil
+}
+
+// coerceText converts val to a character attribute value, checking declared length
+func coerceText(relation string, attr Attribute, val any) (any, error) {
+	var s string
+	switch t := val.(type) {
+	case string:
+		s = t
+	case Char:
+		s = t.String()
+	case []byte:
+		s = string(t)
+	default:
+		s = fmt.Sprint(val)
+	}
+
+	typeName := "character varying"
+	if isCharType(attr.typeName) {
+		typeName = "character"
+	}
+
+	s, err := fitLength(s, attr.length)
+	if err != nil {
+		return nil, fmt.Errorf("cannot assign '%v' to %s.%s: %w for type %s(%d)", val, relation, attr.name, err, typeName, attr.length)
+	}
+
+	if typeName == "character" {
+		if n := utf8.RuneCountInString(s); n < attr.length {
+			s += strings.Repeat(" ", attr.length-n)
+		}
+		return Char(s), nil
+	}
+	return s, nil
+}
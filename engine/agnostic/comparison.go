@@ -0,0 +1,218 @@
+package agnostic
+
+import (
+	"fmt"
+)
+
+// DistinctPredicate implements IS DISTINCT FROM and IS NOT DISTINCT FROM: a comparison where NULL
+// equals NULL and differs from any other value, so that it is never unknown. IS NULL is IS NOT DISTINCT FROM NULL.
+type DistinctPredicate struct {
+	left  ValueFunctor
+	right ValueFunctor
+	not   bool
+}
+
+// NewDistinctPredicate creates a predicate true if left IS DISTINCT FROM right
+func NewDistinctPredicate(left, right ValueFunctor) *DistinctPredicate {
+	return &DistinctPredicate{left: left, right: right}
+}
+
+// NewNotDistinctPredicate creates a predicate true if left IS NOT DISTINCT FROM right
+func NewNotDistinctPredicate(left, right ValueFunctor) *DistinctPredicate {
+	return &DistinctPredicate{left: left, right: right, not: true}
+}
+
+// NewIsNullPredicate creates a predicate true if v IS NULL
+func NewIsNullPredicate(v ValueFunctor) *DistinctPredicate {
+	return NewNotDistinctPredicate(v, NewConstValueFunctor(nil))
+}
+
+func (p *DistinctPredicate) Type() PredicateType {
+	if p.not {
+		return NotDistinct
+	}
+	return Distinct
+}
+
+func (p DistinctPredicate) String() string {
+	if p.not {
+		return fmt.Sprintf("%s IS NOT DISTINCT FROM %s", p.left, p.right)
+	}
+	return fmt.Sprintf("%s IS DISTINCT FROM %s", p.left, p.right)
+}
+
+func (p *DistinctPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	vl, vr, err := evalOperands(p.left, p.right, cols, t)
+	if err != nil {
+		return false, err
+	}
+
+	eq, err := equal(vl, vr)
+	if err != nil {
+		return false, err
+	}
+	return eq == p.not, nil
+}
+
+func (p *DistinctPredicate) Left() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *DistinctPredicate) Right() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *DistinctPredicate) Relation() string {
+	return commonRelation(p.left, p.right)
+}
+
+func (p *DistinctPredicate) Attribute() []string {
+	return append(p.left.Attribute(), p.right.Attribute()...)
+}
+
+// BetweenPredicate implements v BETWEEN low AND high, which is low <= v AND v <= high.
+// BETWEEN SYMMETRIC also accepts bounds in reverse order.
+type BetweenPredicate struct {
+	v         ValueFunctor
+	low       ValueFunctor
+	high      ValueFunctor
+	symmetric bool
+}
+
+// NewBetweenPredicate creates a predicate true if v is between low and high, inclusive.
+// If symmetric is true, bounds are swapped when low is greater than high.
+func NewBetweenPredicate(v, low, high ValueFunctor, symmetric bool) *BetweenPredicate {
+	return &BetweenPredicate{v: v, low: low, high: high, symmetric: symmetric}
+}
+
+func (p *BetweenPredicate) Type() PredicateType {
+	return Between
+}
+
+func (p BetweenPredicate) String() string {
+	if p.symmetric {
+		return fmt.Sprintf("%s BETWEEN SYMMETRIC %s AND %s", p.v, p.low, p.high)
+	}
+	return fmt.Sprintf("%s BETWEEN %s AND %s", p.v, p.low, p.high)
+}
+
+func (p *BetweenPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
+
+func (p *BetweenPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	var values [3]any
+	for i, f := range []ValueFunctor{p.v, p.low, p.high} {
+		v, err := evalFunctor(f, cols, t)
+		if err != nil {
+			return truthFalse, err
+		}
+		values[i] = v
+	}
+	v, low, high := values[0], values[1], values[2]
+
+	tr, err := between(v, low, high)
+	if err != nil || !p.symmetric {
+		return tr, err
+	}
+	reversed, err := between(v, high, low)
+	if err != nil {
+		return truthFalse, err
+	}
+	return tr.or(reversed), nil
+}
+
+// between returns truth of low <= v AND v <= high
+func between(v, low, high any) (truth, error) {
+	ge, err := lessOrEqual(low, v)
+	if err != nil {
+		return truthFalse, err
+	}
+	le, err := lessOrEqual(v, high)
+	if err != nil {
+		return truthFalse, err
+	}
+	return ge.and(le), nil
+}
+
+// lessOrEqual returns truth of vl <= vr, unknown if any is NULL
+func lessOrEqual(vl, vr any) (truth, error) {
+	if vl == nil || vr == nil {
+		return truthUnknown, nil
+	}
+	gt, err := greater(vl, vr)
+	if err != nil {
+		return truthFalse, err
+	}
+	return truthOf(!gt), nil
+}
+
+func (p *BetweenPredicate) Left() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *BetweenPredicate) Right() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *BetweenPredicate) Relation() string {
+	return commonRelation(p.v, p.low, p.high)
+}
+
+func (p *BetweenPredicate) Attribute() []string {
+	attrs := append(p.v.Attribute(), p.low.Attribute()...)
+	return append(attrs, p.high.Attribute()...)
+}
+
+// coalesce implements coalesce(value, ...), returning its first argument which is not NULL
+func coalesce(args []any) (any, error) {
+	for _, a := range args {
+		if a != nil {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+// nullif implements nullif(value, other), returning NULL if value equals other, value otherwise
+func nullif(args []any) (any, error) {
+	if args[0] == nil || args[1] == nil {
+		return args[0], nil
+	}
+	eq, err := equal(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	if eq {
+		return nil, nil
+	}
+	return args[0], nil
+}
+
+// extremumFunction returns an implementation of greatest or least(value, ...), ignoring NULL arguments
+func extremumFunction(greatest bool) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		var res any
+		for _, a := range args {
+			if a == nil {
+				continue
+			}
+			if res == nil {
+				res = a
+				continue
+			}
+			gt, err := greater(a, res)
+			if err != nil {
+				return nil, err
+			}
+			lt, err := greater(res, a)
+			if err != nil {
+				return nil, err
+			}
+			if (greatest && gt) || (!greatest && lt) {
+				res = a
+			}
+		}
+		return res, nil
+	}
+}
@@ -0,0 +1,318 @@
+package agnostic
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var bigTen = big.NewInt(10)
+
+// Decimal is an exact numeric value, as stored in NUMERIC and DECIMAL attributes.
+// Value is unscaled * 10^-scale, so 12.50 is stored as 1250 with scale 2.
+// Decimal values are immutable.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// ParseDecimal parses a decimal number like 12, -0.5, 12.50 or 1.5e3.
+// Scale of parsed value is the number of digits after decimal point.
+func ParseDecimal(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+
+	exp := 0
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.Atoi(str[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid input syntax for type numeric: \"%s\"", s)
+		}
+		exp = e
+		str = str[:i]
+	}
+
+	neg := false
+	if len(str) > 0 && (str[0] == '-' || str[0] == '+') {
+		neg = str[0] == '-'
+		str = str[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(str, ".")
+	digits := intPart + fracPart
+	if digits == "" {
+		return Decimal{}, fmt.Errorf("invalid input syntax for type numeric: \"%s\"", s)
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Decimal{}, fmt.Errorf("invalid input syntax for type numeric: \"%s\"", s)
+		}
+	}
+
+	unscaled, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		unscaled.Neg(unscaled)
+	}
+
+	d := Decimal{unscaled: unscaled, scale: len(fracPart) - exp}
+	if d.scale < 0 {
+		d = d.rescale(0)
+	}
+	return d, nil
+}
+
+// ToDecimal converts given value to Decimal.
+// Floats are converted from their shortest representation, so 0.1 gives exactly 0.1.
+func ToDecimal(v any) (Decimal, error) {
+	switch t := v.(type) {
+	case Decimal:
+		return t, nil
+	case string:
+		return ParseDecimal(t)
+	case []byte:
+		return ParseDecimal(string(t))
+	case *big.Int:
+		return Decimal{unscaled: new(big.Int).Set(t)}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.CanInt():
+		return Decimal{unscaled: big.NewInt(rv.Int())}, nil
+	case rv.CanUint():
+		return Decimal{unscaled: new(big.Int).SetUint64(rv.Uint())}, nil
+	case rv.CanFloat():
+		return ParseDecimal(strconv.FormatFloat(rv.Float(), 'f', -1, 64))
+	}
+
+	return Decimal{}, fmt.Errorf("cannot convert %v (type %T) to numeric", v, v)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// Scale returns the number of digits after decimal point
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Sign returns -1, 0 or +1 depending on sign of d
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// rescale returns d with given scale, truncating extra digits
+func (d Decimal) rescale(scale int) Decimal {
+	u := new(big.Int).Set(d.int())
+	switch {
+	case scale > d.scale:
+		u.Mul(u, new(big.Int).Exp(bigTen, big.NewInt(int64(scale-d.scale)), nil))
+	case scale < d.scale:
+		u.Quo(u, new(big.Int).Exp(bigTen, big.NewInt(int64(d.scale-scale)), nil))
+	}
+	return Decimal{unscaled: u, scale: scale}
+}
+
+// Round returns d rounded to given scale, half away from zero like PostgreSQL does
+func (d Decimal) Round(scale int) Decimal {
+	if scale >= d.scale {
+		return d.rescale(scale)
+	}
+
+	div := new(big.Int).Exp(bigTen, big.NewInt(int64(d.scale-scale)), nil)
+	q, r := new(big.Int).QuoRem(d.int(), div, new(big.Int))
+	r.Abs(r).Mul(r, big.NewInt(2))
+	if r.Cmp(div) >= 0 {
+		if d.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return Decimal{unscaled: q, scale: scale}
+}
+
+// Fit rounds d to given scale and checks it holds in precision digits,
+// as assigning to a NUMERIC(precision, scale) attribute does.
+func (d Decimal) Fit(precision, scale int) (Decimal, error) {
+	r := d.Round(scale)
+	if len(new(big.Int).Abs(r.int()).String()) > precision && r.Sign() != 0 {
+		return Decimal{}, fmt.Errorf("numeric field overflow: a field with precision %d, scale %d must round to an absolute value less than 10^%d", precision, scale, precision-scale)
+	}
+	return r, nil
+}
+
+// Cmp compares d and o and returns -1, 0 or +1. Scale is ignored, 12.5 equals 12.50.
+func (d Decimal) Cmp(o Decimal) int {
+	switch {
+	case d.scale < o.scale:
+		return d.rescale(o.scale).int().Cmp(o.int())
+	case d.scale > o.scale:
+		return d.int().Cmp(o.rescale(d.scale).int())
+	}
+	return d.int().Cmp(o.int())
+}
+
+// Add returns d + o, with the largest scale of both
+func (d Decimal) Add(o Decimal) Decimal {
+	scale := d.scale
+	if o.scale > scale {
+		scale = o.scale
+	}
+	u := new(big.Int).Add(d.rescale(scale).int(), o.rescale(scale).int())
+	return Decimal{unscaled: u, scale: scale}
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Sub returns d - o, with the largest scale of both
+func (d Decimal) Sub(o Decimal) Decimal {
+	return d.Add(o.Neg())
+}
+
+// Mul returns d * o, with the sum of both scales
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// Quo returns d / o rounded half away from zero, with at least 16 significant digits like PostgreSQL,
+// and at least the scale of both. o must not be zero.
+func (d Decimal) Quo(o Decimal) Decimal {
+	dw, dfirst := d.weight()
+	ow, ofirst := o.weight()
+	qweight := dw - ow
+	if dfirst <= ofirst {
+		qweight--
+	}
+	scale := 16 - qweight*4
+	for _, s := range []int{d.scale, o.scale, 0} {
+		if s > scale {
+			scale = s
+		}
+	}
+	if scale > 1000 {
+		scale = 1000
+	}
+
+	// d / o = (d.unscaled * 10^(scale - d.scale + o.scale) / o.unscaled) * 10^-scale
+	num, div := new(big.Int).Set(d.int()), new(big.Int).Set(o.int())
+	if exp := scale - d.scale + o.scale; exp >= 0 {
+		num.Mul(num, new(big.Int).Exp(bigTen, big.NewInt(int64(exp)), nil))
+	} else {
+		div.Mul(div, new(big.Int).Exp(bigTen, big.NewInt(int64(-exp)), nil))
+	}
+	q, r := new(big.Int).QuoRem(num, div, new(big.Int))
+	r.Abs(r).Mul(r, big.NewInt(2))
+	if r.Cmp(new(big.Int).Abs(div)) >= 0 {
+		if d.Sign()*o.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return Decimal{unscaled: q, scale: scale}
+}
+
+// Rem returns the remainder of d / o truncated toward zero, with the largest scale of both.
+// Result has the sign of d. o must not be zero.
+func (d Decimal) Rem(o Decimal) Decimal {
+	scale := d.scale
+	if o.scale > scale {
+		scale = o.scale
+	}
+	u := new(big.Int).Rem(d.rescale(scale).int(), o.rescale(scale).int())
+	return Decimal{unscaled: u, scale: scale}
+}
+
+// weight returns the position of the most significant base 10000 digit of d, and its value,
+// as PostgreSQL stores numeric values. Zero has weight 0 and digit 0.
+func (d Decimal) weight() (int, int64) {
+	if d.Sign() == 0 {
+		return 0, 0
+	}
+	abs := Decimal{unscaled: new(big.Int).Abs(d.int()), scale: d.scale}
+	nbase := big.NewInt(10000)
+
+	i := abs.rescale(0).int()
+	if i.Sign() > 0 {
+		w := 0
+		for i.Cmp(nbase) >= 0 {
+			i = new(big.Int).Quo(i, nbase)
+			w++
+		}
+		return w, i.Int64()
+	}
+	w := -1
+	for {
+		i = abs.rescale(-w * 4).int()
+		if i.Sign() > 0 {
+			return w, i.Int64()
+		}
+		w--
+	}
+}
+
+// QuoInt returns d / n rounded to given scale, half away from zero. n must not be zero.
+func (d Decimal) QuoInt(n int64, scale int) Decimal {
+	div := big.NewInt(n)
+	q, r := new(big.Int).QuoRem(d.rescale(scale).int(), div, new(big.Int))
+	r.Abs(r).Mul(r, big.NewInt(2))
+	if r.Cmp(new(big.Int).Abs(div)) >= 0 {
+		if (d.Sign() < 0) != (n < 0) {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return Decimal{unscaled: q, scale: scale}
+}
+
+// Float64 returns the nearest float64 value of d
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// canonical returns d without trailing zeros after decimal point, so equal values share the same representation
+func (d Decimal) canonical() Decimal {
+	u := new(big.Int).Set(d.int())
+	scale := d.scale
+	r := new(big.Int)
+	for scale > 0 {
+		q, m := new(big.Int).QuoRem(u, bigTen, r)
+		if m.Sign() != 0 {
+			break
+		}
+		u = q
+		scale--
+	}
+	return Decimal{unscaled: u, scale: scale}
+}
+
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.int()).String()
+	if d.scale > 0 {
+		if len(s) <= d.scale {
+			s = strings.Repeat("0", d.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-d.scale] + "." + s[len(s)-d.scale:]
+	}
+	if d.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Value implements driver.Valuer. Like PostgreSQL drivers, value is returned as text to keep it exact.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
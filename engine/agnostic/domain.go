@@ -0,0 +1,91 @@
+package agnostic
+
+import (
+	"fmt"
+)
+
+// domainValue is the name under which the checked value is available to Domain check predicate
+const domainValue = "value"
+
+// Domain is the set of allowable values for an Attribute.
+//
+// It is a base type, restricted by nullability and a check predicate,
+// created with CREATE DOMAIN name AS type [DEFAULT value] [NOT NULL] [CHECK (condition)]
+type Domain struct {
+	name string
+	// base holds domain type, default value and nullability
+	base  Attribute
+	check Predicate
+}
+
+// NewDomain creates a domain over type of base attribute.
+// Check predicate can be nil, otherwise it must evaluate checked value with a DomainValueFunctor.
+func NewDomain(name string, base Attribute, check Predicate) *Domain {
+	base.name = name
+	return &Domain{
+		name:  name,
+		base:  base,
+		check: check,
+	}
+}
+
+func (d *Domain) Name() string {
+	return d.name
+}
+
+func (d Domain) String() string {
+	if d.check == nil {
+		return fmt.Sprintf("%s %s", d.name, d.base)
+	}
+	return fmt.Sprintf("%s %s check %s", d.name, d.base, d.check)
+}
+
+// NewDomainValueFunctor creates a ValueFunctor returning the value checked by a Domain,
+// designated as VALUE in CHECK condition
+func NewDomainValueFunctor() ValueFunctor {
+	return NewAttributeValueFunctor("", domainValue)
+}
+
+// coerce converts value to domain base type, then checks it is allowed in domain.
+// As in SQL, a NULL value always satisfies the check predicate, only NOT NULL rejects it.
+func (d *Domain) coerce(relation string, attr Attribute, val any) (any, error) {
+	if val == nil {
+		if d.base.notNull {
+			return nil, fmt.Errorf("domain %s does not allow null values", d.name)
+		}
+		return nil, nil
+	}
+
+	attr.domain = nil
+	v, err := coerce(relation, attr, val)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.check == nil {
+		return v, nil
+	}
+	// like any CHECK constraint, an unknown result is satisfied
+	tr, err := evalTruth(d.check, []string{domainValue}, &Tuple{values: []any{v}})
+	if err != nil {
+		return nil, err
+	}
+	if tr == truthFalse {
+		return nil, fmt.Errorf("value '%v' for domain %s violates check constraint", v, d.name)
+	}
+	return v, nil
+}
+
+// withDomain types attribute with a domain. Attribute takes domain base type,
+// and domain default value if it does not declare one.
+func (a Attribute) withDomain(d *Domain) Attribute {
+	a.domain = d
+	a.typeName = d.base.typeName
+	a.typeInstance = d.base.typeInstance
+	a.precision, a.scale = d.base.precision, d.base.scale
+	a.enum = d.base.enum
+	if a.defaultValue == nil {
+		a.defaultValue = d.base.defaultValue
+	}
+	return a
+}
@@ -3,6 +3,7 @@ package agnostic
 import (
 	"fmt"
 	"sync"
+	"time"
 )
 
 const (
@@ -11,12 +12,15 @@ const (
 
 type Engine struct {
 	schemas map[string]*Schema
+	random  *randomSource
 
 	sync.Mutex
 }
 
 func NewEngine() *Engine {
-	e := &Engine{}
+	e := &Engine{
+		random: newRandomSource(time.Now().UnixNano()),
+	}
 
 	// create public schema
 	e.schemas = make(map[string]*Schema)
@@ -25,6 +29,33 @@ func NewEngine() *Engine {
 	return e
 }
 
+// SeedRandom sets the random source of values generated by e, like gen_random_uuid() and random(),
+// making them deterministic across runs. Other engines are not affected.
+func (e *Engine) SeedRandom(seed int64) {
+	e.random.seed(seed)
+}
+
+// NewRandomUUID returns a version 4 UUID from the random source of e
+func (e *Engine) NewRandomUUID() UUID {
+	return e.random.uuid()
+}
+
+// NewFunctionValueFunctor creates a ValueFunctor calling given function with values of args,
+// random() and gen_random_uuid() drawing from the random source of e
+func (e *Engine) NewFunctionValueFunctor(name string, args ...ValueFunctor) (ValueFunctor, error) {
+	f, err := NewFunctionValueFunctor(name, args...)
+	if err != nil {
+		return nil, err
+	}
+	switch fn := f.(*FunctionValueFunctor); fn.name {
+	case "random":
+		fn.fn.f = e.random.random
+	case "gen_random_uuid":
+		fn.fn.f = e.random.genRandomUUID
+	}
+	return f, nil
+}
+
 func (e *Engine) Begin() (*Transaction, error) {
 	t, err := NewTransaction(e)
 	return t, err
@@ -37,6 +68,25 @@ func (e *Engine) createRelation(schema, relation string, attributes []Attribute,
 		return nil, nil, err
 	}
 
+	// attributes typed with a domain or a user-defined type
+	for i, a := range attributes {
+		attributes[i], err = s.typed(a)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for i, a := range attributes {
+		if a.fk == nil {
+			continue
+		}
+		fk, err := e.foreignKey(relation, attributes, pk, *a.fk)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid reference on %s.%s: %w", relation, a.name, err)
+		}
+		attributes[i].fk = fk
+	}
+
 	r, err := NewRelation(schema, relation, attributes, pk)
 	if err != nil {
 		return nil, nil, err
@@ -47,6 +97,64 @@ func (e *Engine) createRelation(schema, relation string, attributes []Attribute,
 	return s, r, nil
 }
 
+// foreignKey checks referenced attribute exists, resolving it to referenced primary key if not specified.
+// Relation being created can reference itself.
+func (e *Engine) foreignKey(relation string, attributes []Attribute, pk []string, fk ForeignKey) (*ForeignKey, error) {
+	if fk.schema == "" {
+		fk.schema = DefaultSchema
+	}
+
+	if fk.relation != relation {
+		s, err := e.schema(fk.schema)
+		if err != nil {
+			return nil, err
+		}
+		r, err := s.Relation(fk.relation)
+		if err != nil {
+			return nil, err
+		}
+		attributes = r.attributes
+		pk = nil
+		for _, idx := range r.pk {
+			pk = append(pk, r.attributes[idx].name)
+		}
+	}
+
+	if fk.attribute == "" {
+		if len(pk) != 1 {
+			return nil, fmt.Errorf("relation %s has no single attribute primary key", fk.relation)
+		}
+		fk.attribute = pk[0]
+	}
+
+	for _, a := range attributes {
+		if a.name == fk.attribute {
+			return &fk, nil
+		}
+	}
+
+	return nil, fmt.Errorf("attribute not defined: %s.%s", fk.relation, fk.attribute)
+}
+
+func (e *Engine) createVirtualRelation(schema, relation string, attributes []Attribute, generator func() []*Tuple) (*Schema, *Relation, error) {
+	s, err := e.schema(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := s.Relation(relation); err == nil {
+		return nil, nil, fmt.Errorf("relation '%s'.'%s' already exists", schema, relation)
+	}
+
+	r, err := NewVirtualRelation(schema, relation, attributes, generator)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.Add(relation, r)
+	return s, r, nil
+}
+
 func (e *Engine) dropRelation(schema, relation string) (*Schema, *Relation, error) {
 
 	s, err := e.schema(schema)
@@ -0,0 +1,115 @@
+package agnostic
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// EnumType is a user-defined type whose values are an ordered set of labels,
+// created with CREATE TYPE name AS ENUM ('label', ...)
+type EnumType struct {
+	name   string
+	labels []string
+}
+
+// NewEnumType creates an enum type with given labels, in declaration order
+func NewEnumType(name string, labels []string) (*EnumType, error) {
+	t := &EnumType{name: name}
+	for _, l := range labels {
+		if err := t.addValue(l, "", false); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *EnumType) Name() string {
+	return t.name
+}
+
+// Labels returns labels of enum type, in sort order
+func (t *EnumType) Labels() []string {
+	return append([]string{}, t.labels...)
+}
+
+func (t EnumType) String() string {
+	return fmt.Sprintf("%s %v", t.name, t.labels)
+}
+
+func (t *EnumType) index(label string) int {
+	for i, l := range t.labels {
+		if l == label {
+			return i
+		}
+	}
+	return -1
+}
+
+// addValue adds label to enum, at the end or before or after neighbor label
+func (t *EnumType) addValue(label, neighbor string, after bool) error {
+	if label == "" {
+		return fmt.Errorf("invalid enum label \"\"")
+	}
+	if t.index(label) >= 0 {
+		return fmt.Errorf("enum label \"%s\" already exists", label)
+	}
+
+	if neighbor == "" {
+		t.labels = append(t.labels, label)
+		return nil
+	}
+
+	i := t.index(neighbor)
+	if i < 0 {
+		return fmt.Errorf("\"%s\" is not an existing enum label", neighbor)
+	}
+	if after {
+		i++
+	}
+	t.labels = append(t.labels[:i], append([]string{label}, t.labels[i:]...)...)
+	return nil
+}
+
+// Value returns the value of label v in enum type
+func (t *EnumType) Value(v any) (Enum, error) {
+	switch l := v.(type) {
+	case Enum:
+		if l.typ == t {
+			return l, nil
+		}
+		v = l.label
+	case []byte:
+		v = string(l)
+	}
+
+	label, ok := v.(string)
+	if !ok || t.index(label) < 0 {
+		return Enum{}, fmt.Errorf("invalid input value for enum %s: \"%v\"", t.name, v)
+	}
+	return Enum{label: label, typ: t}, nil
+}
+
+// Enum is a value of an EnumType. Enums sort in the order labels were declared.
+type Enum struct {
+	label string
+	typ   *EnumType
+}
+
+// Type returns the enum type of e
+func (e Enum) Type() *EnumType {
+	return e.typ
+}
+
+func (e Enum) String() string {
+	return e.label
+}
+
+// Value implements driver.Valuer, returning the label
+func (e Enum) Value() (driver.Value, error) {
+	return e.label, nil
+}
+
+// order returns position of e in its type labels
+func (e Enum) order() int64 {
+	return int64(e.typ.index(e.label))
+}
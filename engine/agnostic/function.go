@@ -0,0 +1,266 @@
+package agnostic
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Evaluator is implemented by ValueFunctor whose computation can fail.
+// Value returns NULL on failure, Eval returns the error.
+type Evaluator interface {
+	Eval(columns []string, tuple *Tuple) (any, error)
+}
+
+// evalFunctor returns value computed by f, or its error if f is an Evaluator
+func evalFunctor(f ValueFunctor, cols []string, t *Tuple) (any, error) {
+	if e, ok := f.(Evaluator); ok {
+		return e.Eval(cols, t)
+	}
+	return f.Value(cols, t), nil
+}
+
+// evalOperands returns values computed by left and right operands of a comparison
+func evalOperands(left, right ValueFunctor, cols []string, t *Tuple) (any, any, error) {
+	vl, err := evalFunctor(left, cols, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	vr, err := evalFunctor(right, cols, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vl, vr, nil
+}
+
+type function struct {
+	// minimum and maximum number of arguments, maxArgs -1 for variadic functions
+	minArgs int
+	maxArgs int
+	// set returning function, computed value is a []any producing one row per element
+	set bool
+	f   func(args []any) (any, error)
+}
+
+var functions = map[string]function{
+	"json_build_object":         {0, -1, false, jsonBuildObject},
+	"jsonb_build_object":        {0, -1, false, jsonBuildObject},
+	"jsonb_set":                 {3, 4, false, jsonSet},
+	"json_array_elements":       {1, 1, true, jsonArrayElements},
+	"jsonb_array_elements":      {1, 1, true, jsonArrayElements},
+	"json_array_elements_text":  {1, 1, true, jsonArrayElementsText},
+	"jsonb_array_elements_text": {1, 1, true, jsonArrayElementsText},
+	"unnest":                    {1, 1, true, unnest},
+	"array_length":              {2, 2, false, arrayLength},
+	"date_trunc":                {2, 2, false, dateTrunc},
+	"date_part":                 {2, 2, false, datePart},
+	"age":                       {1, 2, false, age},
+	"to_char":                   {2, 2, false, toChar},
+	"make_interval":             {0, 7, false, makeInterval},
+	"lower":                     {1, 1, false, strict(lower)},
+	"upper":                     {1, 1, false, strict(upper)},
+	"length":                    {1, 1, false, strict(length)},
+	"char_length":               {1, 1, false, strict(length)},
+	"substring":                 {2, 3, false, strict(substring)},
+	"substr":                    {2, 3, false, strict(substring)},
+	"btrim":                     {1, 2, false, strict(trimFunction(strings.Trim))},
+	"ltrim":                     {1, 2, false, strict(trimFunction(strings.TrimLeft))},
+	"rtrim":                     {1, 2, false, strict(trimFunction(strings.TrimRight))},
+	"replace":                   {3, 3, false, strict(replace)},
+	"concat":                    {0, -1, false, concat},
+	"abs":                       {1, 1, false, strict(abs)},
+	"round":                     {1, 2, false, strict(round)},
+	"ceil":                      {1, 1, false, strict(roundingFunction(true, math.Ceil))},
+	"ceiling":                   {1, 1, false, strict(roundingFunction(true, math.Ceil))},
+	"floor":                     {1, 1, false, strict(roundingFunction(false, math.Floor))},
+	"mod":                       {2, 2, false, strict(mod)},
+	"random":                    {0, 0, false, defaultRandom.random},
+	"gen_random_uuid":           {0, 0, false, defaultRandom.genRandomUUID},
+	"coalesce":                  {1, -1, false, coalesce},
+	"nullif":                    {2, 2, false, nullif},
+	"greatest":                  {1, -1, false, extremumFunction(true)},
+	"least":                     {1, -1, false, extremumFunction(false)},
+}
+
+// strict wraps f so that it returns NULL if any argument is NULL, like most SQL functions do
+func strict(f func(args []any) (any, error)) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		for _, a := range args {
+			if a == nil {
+				return nil, nil
+			}
+		}
+		return f(args)
+	}
+}
+
+// aggregates compute a single value from the values of all rows of a group
+var aggregates = map[string]func(values []any) (any, error){
+	"array_agg": arrayAgg,
+	"count":     count,
+	"sum":       sum,
+	"avg":       avg,
+	"min":       extremum(false),
+	"max":       extremum(true),
+}
+
+// IsAggregate returns true if name is an aggregate function, like array_agg
+func IsAggregate(name string) bool {
+	_, ok := aggregates[strings.ToLower(name)]
+	return ok
+}
+
+// FunctionValueFunctor computes a SQL function call
+type FunctionValueFunctor struct {
+	name string
+	args []ValueFunctor
+	fn   function
+}
+
+// NewFunctionValueFunctor creates a ValueFunctor calling given function with values of args
+func NewFunctionValueFunctor(name string, args ...ValueFunctor) (ValueFunctor, error) {
+	name = strings.ToLower(name)
+	fn, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("function %s does not exist", name)
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("function %s does not accept %d arguments", name, len(args))
+	}
+
+	f := &FunctionValueFunctor{
+		name: name,
+		args: args,
+		fn:   fn,
+	}
+	return f, nil
+}
+
+func (f *FunctionValueFunctor) Eval(cols []string, t *Tuple) (any, error) {
+	values := make([]any, len(f.args))
+	for i, a := range f.args {
+		v, err := evalFunctor(a, cols, t)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	v, err := f.fn.f(values)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.name, err)
+	}
+	return v, nil
+}
+
+func (f *FunctionValueFunctor) Value(cols []string, t *Tuple) any {
+	v, err := f.Eval(cols, t)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// ReturnsSet returns true if function produces a set of rows
+func (f *FunctionValueFunctor) ReturnsSet() bool {
+	return f.fn.set
+}
+
+func (f *FunctionValueFunctor) Relation() string {
+	pickers := make([]Picker, 0, len(f.args))
+	for _, a := range f.args {
+		pickers = append(pickers, a)
+	}
+	return commonRelation(pickers...)
+}
+
+func (f *FunctionValueFunctor) Attribute() []string {
+	var attrs []string
+	for _, a := range f.args {
+		attrs = append(attrs, a.Attribute()...)
+	}
+	return attrs
+}
+
+func (f FunctionValueFunctor) String() string {
+	args := make([]string, len(f.args))
+	for i, a := range f.args {
+		args[i] = fmt.Sprint(a)
+	}
+	return f.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// FunctorSelector selects the value computed by a ValueFunctor, like an operator or a function call
+type FunctorSelector struct {
+	relation string
+	name     string
+	functor  ValueFunctor
+}
+
+// NewFunctorSelector creates a Selector returning values of f, in a column named name
+func NewFunctorSelector(rel string, name string, f ValueFunctor) *FunctorSelector {
+	s := &FunctorSelector{
+		relation: rel,
+		name:     name,
+		functor:  f,
+	}
+	return s
+}
+
+func (s *FunctorSelector) Attribute() []string {
+	return []string{s.name}
+}
+
+func (s *FunctorSelector) Relation() string {
+	return s.relation
+}
+
+func (s *FunctorSelector) Alias() string {
+	return ""
+}
+
+func (s *FunctorSelector) ReturnsSet() bool {
+	return returnsSet(s.functor)
+}
+
+func (s *FunctorSelector) Select(cols []string, in []*list.Element) (out []*Tuple, err error) {
+	set := s.ReturnsSet()
+
+	for _, e := range in {
+		t, ok := e.Value.(*Tuple)
+		if !ok {
+			return nil, fmt.Errorf("provided element list does not contain Tuple")
+		}
+
+		var v any
+		if ev, ok := s.functor.(Evaluator); ok {
+			v, err = ev.Eval(cols, t)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			v = s.functor.Value(cols, t)
+		}
+
+		if !set {
+			out = append(out, NewTuple(v))
+			continue
+		}
+		values, _ := v.([]any)
+		for _, sv := range values {
+			out = append(out, NewTuple(sv))
+		}
+	}
+
+	return
+}
+
+func (s FunctorSelector) String() string {
+	return fmt.Sprintf("%s AS %s", s.functor, s.name)
+}
+
+func returnsSet(v any) bool {
+	s, ok := v.(interface{ ReturnsSet() bool })
+	return ok && s.ReturnsSet()
+}
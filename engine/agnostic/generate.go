@@ -0,0 +1,363 @@
+package agnostic
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Generator produces the value of an attribute for given generated row
+type Generator func(rnd *rand.Rand, row int) any
+
+// GenerateOptions configures test data generation
+type GenerateOptions struct {
+	// Seed of random source. Same seed on same schema and data generates same rows.
+	Seed int64
+	// NullRatio is the probability for nullable attributes to be NULL, between 0 and 1
+	NullRatio float64
+	// Generators overrides value generation of attributes, by attribute name
+	Generators map[string]Generator
+}
+
+// maximum number of attempts to generate a value satisfying unique and domain check constraints
+const generateAttempts = 100
+
+var (
+	firstNames = []string{"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda", "William", "Elizabeth", "David", "Barbara", "Richard", "Susan", "Joseph", "Jessica", "Thomas", "Sarah", "Charles", "Karen"}
+	lastNames  = []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez", "Hernandez", "Lopez", "Gonzalez", "Wilson", "Anderson", "Thomas", "Taylor", "Moore", "Jackson", "Martin"}
+	cities     = []string{"Paris", "London", "Berlin", "Madrid", "Rome", "Lisbon", "Vienna", "Prague", "Dublin", "Amsterdam", "Brussels", "Oslo", "Stockholm", "Helsinki", "Warsaw"}
+	words      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do", "eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua", "enim"}
+
+	generateEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Generate inserts n rows of random data into relation.
+//
+// Generated rows respect primary key, unique, not null, domain check and foreign key constraints:
+// foreign key attributes take values from referenced relation, which must be populated first.
+// Attributes with a default value or auto increment are left to the engine unless overridden.
+func (t *Transaction) Generate(schema, relation string, n int, opts GenerateOptions) (int64, error) {
+	if err := t.aborted(); err != nil {
+		return 0, err
+	}
+
+	s, err := t.e.schema(schema)
+	if err != nil {
+		return 0, t.abort(err)
+	}
+	r, err := s.Relation(relation)
+	if err != nil {
+		return 0, t.abort(err)
+	}
+	if r.IsVirtual() {
+		return 0, t.abort(fmt.Errorf("cannot generate rows in virtual relation %s", r))
+	}
+
+	t.lock(r)
+
+	g := &generator{
+		r:    r,
+		rnd:  rand.New(rand.NewSource(opts.Seed)),
+		opts: opts,
+		seen: make(map[string]map[string]struct{}),
+		refs: make(map[string][]any),
+	}
+
+	for i, a := range r.attributes {
+		if a.unique {
+			g.seen[a.name] = g.existing([]int{i})
+		}
+		if a.fk != nil {
+			refs, err := t.referencedValues(a.fk)
+			if err != nil {
+				return 0, t.abort(err)
+			}
+			g.refs[a.name] = refs
+		}
+	}
+	if len(r.pk) > 0 && !g.autoPk() {
+		g.seen[""] = g.existing(r.pk)
+	}
+
+	for i := 0; i < n; i++ {
+		values, err := g.row(i)
+		if err != nil {
+			return int64(i), t.abort(err)
+		}
+		_, err = t.Insert(schema, relation, values)
+		if err != nil {
+			return int64(i), err
+		}
+	}
+
+	return int64(n), nil
+}
+
+// referencedValues lists values of foreign key referenced attribute
+func (t *Transaction) referencedValues(fk *ForeignKey) ([]any, error) {
+	s, err := t.e.schema(fk.schema)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.Relation(fk.relation)
+	if err != nil {
+		return nil, err
+	}
+	idx, _, err := r.Attribute(fk.attribute)
+	if err != nil {
+		return nil, err
+	}
+
+	t.lock(r)
+
+	var values []any
+	for e := r.rows.Front(); e != nil; e = e.Next() {
+		v := e.Value.(*Tuple).values[idx]
+		if v != nil {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+type generator struct {
+	r    *Relation
+	rnd  *rand.Rand
+	opts GenerateOptions
+	// already used keys of unique attributes, by attribute name. Primary key is stored with empty name.
+	seen map[string]map[string]struct{}
+	// foreign key candidates, by attribute name
+	refs map[string][]any
+}
+
+// autoPk returns true if primary key uniqueness is guaranteed by auto increment
+func (g *generator) autoPk() bool {
+	for _, idx := range g.r.pk {
+		if g.r.attributes[idx].autoIncrement {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) existing(attrs []int) map[string]struct{} {
+	m := make(map[string]struct{})
+	for e := g.r.rows.Front(); e != nil; e = e.Next() {
+		t := e.Value.(*Tuple)
+		vals := make([]any, len(attrs))
+		for i, idx := range attrs {
+			vals[i] = t.values[idx]
+		}
+		m[generateKey(vals...)] = struct{}{}
+	}
+	return m
+}
+
+func (g *generator) row(n int) (map[string]any, error) {
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		values, err := g.values(n)
+		if err != nil {
+			return nil, err
+		}
+		if g.claim(values) {
+			return values, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot generate unique values for %s after %d attempts", g.r, generateAttempts)
+}
+
+// claim marks unique values of row as used, returning false if one of them already is
+func (g *generator) claim(values map[string]any) bool {
+	keys := make(map[string]string)
+
+	for name := range g.seen {
+		var k string
+		if name == "" {
+			vals := make([]any, len(g.r.pk))
+			for i, idx := range g.r.pk {
+				vals[i] = values[g.r.attributes[idx].name]
+			}
+			k = generateKey(vals...)
+		} else {
+			v, ok := values[name]
+			if !ok || v == nil {
+				continue
+			}
+			k = generateKey(v)
+		}
+		if _, ok := g.seen[name][k]; ok {
+			return false
+		}
+		keys[name] = k
+	}
+
+	for name, k := range keys {
+		g.seen[name][k] = struct{}{}
+	}
+	return true
+}
+
+func (g *generator) values(n int) (map[string]any, error) {
+	values := make(map[string]any)
+
+	for _, a := range g.r.attributes {
+		if f, ok := g.opts.Generators[a.name]; ok {
+			values[a.name] = f(g.rnd, n)
+			continue
+		}
+		if a.autoIncrement || a.defaultValue != nil {
+			continue
+		}
+		if !a.notNull && !g.isPk(a.name) && g.rnd.Float64() < g.opts.NullRatio {
+			values[a.name] = nil
+			continue
+		}
+		if refs, ok := g.refs[a.name]; ok {
+			if len(refs) == 0 {
+				if a.notNull {
+					return nil, fmt.Errorf("cannot generate %s.%s: referenced relation %s is empty", g.r.name, a.name, a.fk.relation)
+				}
+				values[a.name] = nil
+				continue
+			}
+			values[a.name] = refs[g.rnd.Intn(len(refs))]
+			continue
+		}
+		v, err := g.checked(a)
+		if err != nil {
+			return nil, err
+		}
+		values[a.name] = v
+	}
+
+	return values, nil
+}
+
+// checked produces a random value for attribute, retried until it satisfies attribute domain check
+func (g *generator) checked(a Attribute) (any, error) {
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		v := g.value(a)
+		if a.domain == nil {
+			return v, nil
+		}
+		if _, err := a.domain.coerce(g.r.name, a, v); err == nil {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot generate %s.%s values satisfying domain %s after %d attempts", g.r.name, a.name, a.domain.name, generateAttempts)
+}
+
+func (g *generator) isPk(name string) bool {
+	for _, idx := range g.r.pk {
+		if g.r.attributes[idx].name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// value produces a plausible random value for attribute, guessing content from its type and name
+func (g *generator) value(a Attribute) any {
+	rnd := g.rnd
+
+	if elemType, ok := arrayElementType(a.typeName); ok {
+		values := make(Array, rnd.Intn(4))
+		for i := range values {
+			values[i] = g.value(NewAttribute(a.name, elemType))
+		}
+		return values
+	}
+
+	if (isCharType(a.typeName) || isVarcharType(a.typeName)) && a.length > 0 {
+		// generate text, then cut it to declared length
+		text := a
+		text.typeName, text.length = "text", 0
+		r := []rune(fmt.Sprint(g.value(text)))
+		if len(r) > a.length {
+			r = r[:a.length]
+		}
+		v, _ := coerceText("", a, string(r))
+		return v
+	}
+
+	if a.enum != nil && len(a.enum.labels) > 0 {
+		return Enum{label: a.enum.labels[rnd.Intn(len(a.enum.labels))], typ: a.enum}
+	}
+
+	switch strings.ToLower(a.typeName) {
+	case "smallint", "int2", "smallserial":
+		return int64(rnd.Intn(math.MaxInt16))
+	case "serial", "bigserial", "int", "integer", "int4", "bigint", "int8":
+		return int64(rnd.Intn(1000000))
+	case "bool", "boolean":
+		return rnd.Intn(2) == 1
+	case "float", "float8", "double", "double precision", "real", "float4":
+		return math.Round(rnd.Float64()*1000000) / 100
+	case "decimal", "numeric":
+		// stay within declared precision
+		scale, digits := 2, 8
+		if a.precision > 0 {
+			scale, digits = a.scale, a.precision
+		}
+		if digits > 18 {
+			digits = 18
+		}
+		return Decimal{unscaled: big.NewInt(rnd.Int63n(int64(math.Pow10(digits)))), scale: scale}
+	case "timestamp", "timestamptz", "timestamp without time zone", "timestamp with time zone":
+		return generateEpoch.Add(time.Duration(rnd.Int63n(5*365*24*3600)) * time.Second)
+	case "date":
+		return generateEpoch.AddDate(0, 0, rnd.Intn(5*365))
+	case "time", "time without time zone":
+		return generateEpoch.Add(time.Duration(rnd.Int63n(24*3600)) * time.Second)
+	case "interval":
+		return Interval{days: rnd.Intn(30), micros: rnd.Int63n(24*3600) * microsPerSecond}
+	case "uuid":
+		return newUUIDFrom(rnd)
+	case "bytea", "blob":
+		b := make(Bytea, 16)
+		rnd.Read(b)
+		return b
+	case "json", "jsonb":
+		return fmt.Sprintf(`{"id": %d, "tag": "%s"}`, rnd.Intn(1000000), words[rnd.Intn(len(words))])
+	}
+
+	first := firstNames[rnd.Intn(len(firstNames))]
+	last := lastNames[rnd.Intn(len(lastNames))]
+	name := a.name
+	switch {
+	case strings.Contains(name, "email"):
+		return fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(first), strings.ToLower(last), rnd.Intn(100000))
+	case strings.Contains(name, "first") && strings.Contains(name, "name"):
+		return first
+	case strings.Contains(name, "last") && strings.Contains(name, "name"), strings.Contains(name, "surname"):
+		return last
+	case strings.Contains(name, "name"):
+		return first + " " + last
+	case strings.Contains(name, "phone"):
+		return fmt.Sprintf("+1%010d", rnd.Int63n(10000000000))
+	case strings.Contains(name, "city"):
+		return cities[rnd.Intn(len(cities))]
+	case strings.Contains(name, "url"), strings.Contains(name, "website"):
+		return fmt.Sprintf("https://example.com/%s/%d", words[rnd.Intn(len(words))], rnd.Intn(100000))
+	}
+
+	n := 2 + rnd.Intn(4)
+	w := make([]string, n)
+	for i := range w {
+		w[i] = words[rnd.Intn(len(words))]
+	}
+	return strings.Join(w, " ")
+}
+
+func generateKey(values ...any) string {
+	var sb strings.Builder
+	for _, v := range values {
+		fmt.Fprintf(&sb, "%v|", v)
+	}
+	return sb.String()
+}
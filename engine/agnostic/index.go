@@ -4,6 +4,9 @@ import (
 	"container/list"
 	"fmt"
 	"hash/maphash"
+	"strconv"
+	"strings"
+	"time"
 	"unsafe"
 )
 
@@ -29,6 +32,7 @@ type HashIndex struct {
 	relAttrs  []string
 	attrs     []int
 	attrsName []string
+	attrsType []string
 	m         map[uint64]uintptr
 
 	maphash.Hash
@@ -46,6 +50,9 @@ func NewHashIndex(name string, relName string, relAttrs []Attribute, attrsName [
 	for _, a := range relAttrs {
 		h.relAttrs = append(h.relAttrs, a.name)
 	}
+	for _, idx := range attrs {
+		h.attrsType = append(h.attrsType, strings.ToLower(relAttrs[idx].typeName))
+	}
 	return h
 }
 
@@ -56,11 +63,7 @@ func (h *HashIndex) Name() string {
 func (h *HashIndex) Add(e *list.Element) {
 	t := e.Value.(*Tuple)
 	for _, idx := range h.attrs {
-		if t.values[idx] == nil {
-			h.Write([]byte("nil"))
-			continue
-		}
-		h.Write([]byte(fmt.Sprintf("%v", t.values[idx])))
+		h.write(t.values[idx])
 	}
 	sum := h.Sum64()
 	h.Reset()
@@ -70,11 +73,7 @@ func (h *HashIndex) Add(e *list.Element) {
 func (h *HashIndex) Remove(e *list.Element) {
 	t := e.Value.(*Tuple)
 	for _, idx := range h.attrs {
-		if t.values[idx] == nil {
-			h.Write([]byte("nil"))
-			continue
-		}
-		h.Write([]byte(fmt.Sprintf("%v", t.values[idx])))
+		h.write(t.values[idx])
 	}
 	sum := h.Sum64()
 	h.Reset()
@@ -82,12 +81,11 @@ func (h *HashIndex) Remove(e *list.Element) {
 }
 
 func (h *HashIndex) Get(values []any) (*list.Element, error) {
-	for _, v := range values {
-		if v == nil {
-			h.Write([]byte("nil"))
-			continue
+	for i, v := range values {
+		if i < len(h.attrsType) {
+			v = h.lookupValue(h.attrsType[i], v)
 		}
-		h.Write([]byte(fmt.Sprintf("%v", v)))
+		h.write(v)
 	}
 	sum := h.Sum64()
 	h.Reset()
@@ -103,6 +101,74 @@ func (h *HashIndex) Get(values []any) (*list.Element, error) {
 	return t, nil
 }
 
+// lookupValue converts v to the type of indexed values, so any representation
+// of a value finds it
+func (h *HashIndex) lookupValue(typeName string, v any) any {
+	if _, ok := arrayElementType(typeName); ok {
+		// lookup value may be a Go slice or an array literal
+		if a, err := ToArray(v); err == nil {
+			return a
+		}
+		return v
+	}
+
+	if isTemporalType(typeName) {
+		// lookup value may be in any time zone or text, indexed one is coerced to attribute type
+		if t, err := coerceTime(h.relName, NewAttribute("", typeName), v); err == nil {
+			return t
+		}
+		return v
+	}
+
+	switch typeName {
+	case "uuid":
+		// lookup value may be any UUID representation, indexed one is canonical
+		if u, err := ToUUID(v); err == nil {
+			return u
+		}
+	case "decimal", "numeric":
+		// lookup value may be any numeric type, 12.5 must find 12.50
+		if d, err := ToDecimal(v); err == nil {
+			return d
+		}
+	case "char", "character", "bpchar":
+		// trailing spaces are not significant, indexed value is hashed without them
+		if s, ok := v.(string); ok {
+			return Char(s)
+		}
+	case "bytea", "blob":
+		// lookup value may be []byte or a bytea literal
+		if b, err := ToBytea(v); err == nil {
+			return b
+		}
+	case "interval":
+		// lookup value may be an interval literal
+		if iv, err := ToInterval(v); err == nil {
+			return iv
+		}
+	}
+	return v
+}
+
+// write adds v to the hash being computed
+func (h *HashIndex) write(v any) {
+	switch t := v.(type) {
+	case nil:
+		h.Write([]byte("nil"))
+	case Decimal:
+		h.Write([]byte(t.canonical().String()))
+	case Char:
+		h.Write([]byte(t.String()))
+	case time.Time:
+		h.Write([]byte(strconv.FormatInt(t.UnixMicro(), 10)))
+	case Interval:
+		// equal intervals, like 1 day and 24 hours, have the same hash
+		h.Write([]byte(strconv.FormatInt(t.span(), 10)))
+	default:
+		h.Write([]byte(fmt.Sprintf("%v", v)))
+	}
+}
+
 func (h *HashIndex) Truncate() {
 	h.m = make(map[uint64]uintptr)
 }
@@ -120,6 +186,19 @@ func (h *HashIndex) CanSourceWith(p Predicate) (bool, int64) {
 		return false, 0
 	}
 
+	// index only holds attribute values, not values computed from them like data->'key'
+	eq, ok := p.(*EqPredicate)
+	if !ok {
+		return false, 0
+	}
+	if _, ok := eq.left.(*AttributeValueFunctor); !ok {
+		return false, 0
+	}
+	// looked up value must not depend on rows, like in payments.user_id = account.id
+	if eq.right.Relation() != "" || len(eq.right.Attribute()) > 0 {
+		return false, 0
+	}
+
 	var found bool
 	for _, l := range h.attrsName {
 		found = false
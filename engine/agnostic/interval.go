@@ -0,0 +1,307 @@
+package agnostic
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	microsPerSecond = int64(time.Second / time.Microsecond)
+	microsPerDay    = 24 * 3600 * microsPerSecond
+	daysPerMonth    = 30
+)
+
+// Interval is a span of time, as stored in INTERVAL attributes. Like in PostgreSQL,
+// months, days and time are kept apart since months and days do not have a fixed length.
+type Interval struct {
+	months int
+	days   int
+	micros int64
+}
+
+// NewInterval returns an interval of given months, days and microseconds
+func NewInterval(months, days int, micros int64) Interval {
+	return Interval{months: months, days: days, micros: micros}
+}
+
+// intervalUnits maps unit names to their length in months, days or microseconds
+var intervalUnits = map[string]struct {
+	months float64
+	days   float64
+	micros float64
+}{
+	"microsecond": {micros: 1},
+	"millisecond": {micros: 1000},
+	"second":      {micros: 1e6},
+	"minute":      {micros: 60e6},
+	"hour":        {micros: 3600e6},
+	"day":         {days: 1},
+	"week":        {days: 7},
+	"month":       {months: 1},
+	"year":        {months: 12},
+	"decade":      {months: 120},
+	"century":     {months: 1200},
+	"millennium":  {months: 12000},
+}
+
+// intervalUnitName returns the canonical name of unit, accepting plurals and abbreviations
+func intervalUnitName(unit string) string {
+	switch strings.ToLower(unit) {
+	case "us", "usec", "usecs", "microsecond", "microseconds":
+		return "microsecond"
+	case "ms", "msec", "msecs", "millisecond", "milliseconds":
+		return "millisecond"
+	case "s", "sec", "secs", "second", "seconds":
+		return "second"
+	case "m", "min", "mins", "minute", "minutes":
+		return "minute"
+	case "h", "hr", "hrs", "hour", "hours":
+		return "hour"
+	case "d", "day", "days":
+		return "day"
+	case "w", "week", "weeks":
+		return "week"
+	case "mon", "mons", "month", "months":
+		return "month"
+	case "y", "yr", "yrs", "year", "years":
+		return "year"
+	case "decade", "decades":
+		return "decade"
+	case "c", "cent", "century", "centuries":
+		return "century"
+	case "mil", "millennium", "millennia", "millenniums":
+		return "millennium"
+	}
+	return ""
+}
+
+// ParseInterval parses an interval literal of the form
+// 1 day
+// 1 year 2 mons 3 days 04:05:06.5
+// -2 hours 30 minutes
+// @ 3 days ago
+//
+// A number without unit is a number of seconds. Fractional months and days spill into smaller units.
+func ParseInterval(s string) (Interval, error) {
+	var iv Interval
+	invalid := fmt.Errorf("invalid input syntax for type interval: \"%s\"", s)
+
+	fields := strings.Fields(s)
+	if len(fields) > 0 && fields[0] == "@" {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return iv, invalid
+	}
+
+	ago := false
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if strings.EqualFold(field, "ago") && i == len(fields)-1 {
+			ago = true
+			continue
+		}
+		if strings.Contains(field, ":") {
+			micros, err := parseClock(field)
+			if err != nil {
+				return iv, invalid
+			}
+			iv.micros += micros
+			continue
+		}
+
+		// number may be followed by its unit, like 10min
+		end := strings.IndexFunc(field, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+		})
+		number, unit := field, ""
+		if end >= 0 {
+			number, unit = field[:end], field[end:]
+		} else if i+1 < len(fields) && intervalUnitName(fields[i+1]) != "" {
+			unit = fields[i+1]
+			i++
+		}
+		value, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return iv, invalid
+		}
+		if unit == "" {
+			unit = "second"
+		}
+		name := intervalUnitName(unit)
+		if name == "" {
+			return iv, invalid
+		}
+		iv = iv.add(value, name)
+	}
+
+	if ago {
+		iv = iv.Neg()
+	}
+	return iv, nil
+}
+
+// parseClock parses [-]hh:mm[:ss[.ffffff]] as microseconds
+func parseClock(s string) (int64, error) {
+	sign := int64(1)
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, s[1:]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %s", s)
+	}
+
+	var micros int64
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v < 0 || (i < len(parts)-1 && strings.Contains(p, ".")) {
+			return 0, fmt.Errorf("invalid time %s", s)
+		}
+		switch i {
+		case 0:
+			micros += int64(v) * 3600 * microsPerSecond
+		case 1:
+			micros += int64(v) * 60 * microsPerSecond
+		case 2:
+			micros += int64(math.Round(v * float64(microsPerSecond)))
+		}
+	}
+	return sign * micros, nil
+}
+
+// add returns iv increased by value times unit. Fractional months spill into days, fractional days into time.
+func (iv Interval) add(value float64, unit string) Interval {
+	u := intervalUnits[unit]
+
+	months := value * u.months
+	whole := math.Trunc(months)
+	iv.months += int(whole)
+
+	days := value*u.days + (months-whole)*daysPerMonth
+	whole = math.Trunc(days)
+	iv.days += int(whole)
+
+	iv.micros += int64(math.Round(value*u.micros + (days-whole)*float64(microsPerDay)))
+	return iv
+}
+
+// ToInterval converts given value to Interval. Text is parsed as an interval literal, durations are kept as time.
+func ToInterval(v any) (Interval, error) {
+	switch t := v.(type) {
+	case Interval:
+		return t, nil
+	case time.Duration:
+		return Interval{micros: t.Microseconds()}, nil
+	case string:
+		return ParseInterval(t)
+	case []byte:
+		return ParseInterval(string(t))
+	}
+
+	return Interval{}, fmt.Errorf("cannot convert %v (type %T) to interval", v, v)
+}
+
+// Neg returns the opposite of iv
+func (iv Interval) Neg() Interval {
+	return Interval{months: -iv.months, days: -iv.days, micros: -iv.micros}
+}
+
+// Add returns the sum of iv and o, field by field
+func (iv Interval) Add(o Interval) Interval {
+	return Interval{months: iv.months + o.months, days: iv.days + o.days, micros: iv.micros + o.micros}
+}
+
+// Mul returns iv multiplied by f. Fractional months spill into days, fractional days into time.
+func (iv Interval) Mul(f float64) Interval {
+	var r Interval
+	r = r.add(float64(iv.months)*f, "month")
+	r = r.add(float64(iv.days)*f, "day")
+	r.micros += int64(math.Round(float64(iv.micros) * f))
+	return r
+}
+
+// AddTo returns t moved by iv. Months are added first, clamping day to the end of month
+// so that January 31st plus 1 month is February 28th, then days, then time.
+func (iv Interval) AddTo(t time.Time) time.Time {
+	if iv.months != 0 {
+		y, m, d := t.Date()
+		first := time.Date(y, m+time.Month(iv.months), 1, 0, 0, 0, 0, time.UTC)
+		if last := first.AddDate(0, 1, -1).Day(); d > last {
+			d = last
+		}
+		t = time.Date(first.Year(), first.Month(), d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	}
+	return t.AddDate(0, 0, iv.days).Add(time.Duration(iv.micros) * time.Microsecond)
+}
+
+// span returns iv as microseconds, counting 30 days per month and 24 hours per day, which is how intervals are compared
+func (iv Interval) span() int64 {
+	return iv.micros + int64(iv.days)*microsPerDay + int64(iv.months)*daysPerMonth*microsPerDay
+}
+
+// String returns iv in PostgreSQL format, like 1 year 2 mons 3 days 04:05:06
+func (iv Interval) String() string {
+	var parts []string
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return strconv.Itoa(n) + " " + unit + "s"
+	}
+
+	if y := iv.months / 12; y != 0 {
+		parts = append(parts, plural(y, "year"))
+	}
+	if m := iv.months % 12; m != 0 {
+		parts = append(parts, plural(m, "mon"))
+	}
+	if iv.days != 0 {
+		parts = append(parts, plural(iv.days, "day"))
+	}
+
+	if iv.micros != 0 || len(parts) == 0 {
+		micros, sign := iv.micros, ""
+		if micros < 0 {
+			micros, sign = -micros, "-"
+		}
+		secs := micros / microsPerSecond
+		clock := fmt.Sprintf("%s%02d:%02d:%02d", sign, secs/3600, secs/60%60, secs%60)
+		if frac := micros % microsPerSecond; frac != 0 {
+			clock += strings.TrimRight(fmt.Sprintf(".%06d", frac), "0")
+		}
+		parts = append(parts, clock)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Value implements driver.Valuer, returning interval in text format
+func (iv Interval) Value() (driver.Value, error) {
+	return iv.String(), nil
+}
+
+// makeInterval implements make_interval(years, months, weeks, days, hours, mins, secs), all arguments defaulting to 0
+func makeInterval(args []any) (any, error) {
+	units := []string{"year", "month", "week", "day", "hour", "minute", "second"}
+
+	var iv Interval
+	for i, a := range args {
+		if a == nil {
+			return nil, nil
+		}
+		v, ok := toFloat(a)
+		if !ok {
+			return nil, fmt.Errorf("%ss must be a number, got %v", units[i], a)
+		}
+		if i < len(units)-1 && v != math.Trunc(v) {
+			return nil, fmt.Errorf("%ss must be an integer, got %v", units[i], a)
+		}
+		iv = iv.add(v, units[i])
+	}
+	return iv, nil
+}
@@ -0,0 +1,1095 @@
+package agnostic
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/proullon/ramsql/engine/log"
+)
+
+// JoinType is the kind of a join, telling which side rows are kept without match
+type JoinType int
+
+const (
+	Inner JoinType = iota
+	LeftOuter
+	RightOuter
+	FullOuter
+)
+
+func (t JoinType) String() string {
+	switch t {
+	case LeftOuter:
+		return "LEFT JOIN"
+	case RightOuter:
+		return "RIGHT JOIN"
+	case FullOuter:
+		return "FULL JOIN"
+	}
+	return "JOIN"
+}
+
+// nullable returns true if rows of left side, or else right side, may be padded with NULL values
+func (t JoinType) nullable(left bool) bool {
+	if left {
+		return t == RightOuter || t == FullOuter
+	}
+	return t == LeftOuter || t == FullOuter
+}
+
+// OuterJoin joins two relations like NaturalJoin, also keeping rows of preserved side without match,
+// padded with NULL values for the other side.
+type OuterJoin struct {
+	typ JoinType
+
+	leftr string
+	lefta string
+	left  Node
+
+	rightr string
+	righta string
+	right  Node
+}
+
+// NewLeftOuterJoin creates a Joiner keeping all rows of left relation
+func NewLeftOuterJoin(leftRel, leftAttr, rightRel, rightAttr string) *OuterJoin {
+	return newOuterJoin(LeftOuter, leftRel, leftAttr, rightRel, rightAttr)
+}
+
+// NewRightOuterJoin creates a Joiner keeping all rows of right relation
+func NewRightOuterJoin(leftRel, leftAttr, rightRel, rightAttr string) *OuterJoin {
+	return newOuterJoin(RightOuter, leftRel, leftAttr, rightRel, rightAttr)
+}
+
+// NewFullOuterJoin creates a Joiner keeping all rows of both relations
+func NewFullOuterJoin(leftRel, leftAttr, rightRel, rightAttr string) *OuterJoin {
+	return newOuterJoin(FullOuter, leftRel, leftAttr, rightRel, rightAttr)
+}
+
+func newOuterJoin(typ JoinType, leftRel, leftAttr, rightRel, rightAttr string) *OuterJoin {
+	return &OuterJoin{
+		typ:    typ,
+		leftr:  leftRel,
+		lefta:  leftAttr,
+		rightr: rightRel,
+		righta: rightAttr,
+	}
+}
+
+func (j OuterJoin) String() string {
+	return j.typ.String() + " " + j.leftr + "." + j.lefta + " >< " + j.rightr + "." + j.righta
+}
+
+func (j *OuterJoin) Left() string {
+	return j.leftr
+}
+
+func (j *OuterJoin) SetLeft(n Node) {
+	j.left = n
+}
+
+func (j *OuterJoin) Right() string {
+	return j.rightr
+}
+
+func (j *OuterJoin) SetRight(n Node) {
+	j.right = n
+}
+
+// Nullable returns true if rows of left side, or else right side, may be padded with NULL values
+func (j *OuterJoin) Nullable(left bool) bool {
+	return j.typ.nullable(left)
+}
+
+// EstimateCardinal is at least the cardinal of preserved sides
+func (j *OuterJoin) EstimateCardinal() int64 {
+	if j.left == nil || j.right == nil {
+		return 0
+	}
+
+	return equiJoinCardinal(j.typ, j.left.EstimateCardinal(), j.right.EstimateCardinal())
+}
+
+func (j *OuterJoin) Children() []Node {
+	return []Node{j.left, j.right}
+}
+
+func (j *OuterJoin) Exec() ([]string, []*list.Element, error) {
+	lcols, lefts, err := j.left.Exec()
+	if err != nil {
+		return nil, nil, err
+	}
+	lidx := joinColumn(lcols, j.leftr, j.lefta)
+	if lidx == -1 {
+		return nil, nil, fmt.Errorf("%s: columns not found in left node", j)
+	}
+
+	rcols, rights, err := j.right.Exec()
+	if err != nil {
+		return nil, nil, err
+	}
+	ridx := joinColumn(rcols, j.rightr, j.righta)
+	if ridx == -1 {
+		return nil, nil, fmt.Errorf("%s: columns not found in right node", j)
+	}
+
+	cols := joinColumns(j.leftr, lcols, j.rightr, rcols)
+	res, err := nestedLoop(j.typ, len(lcols), lefts, len(rcols), rights, equalOn([]int{lidx}, []int{ridx}))
+	if err != nil {
+		return nil, nil, err
+	}
+	return cols, res, nil
+}
+
+// NestedLoopJoin joins two relations on any condition, like multiple attributes or a range,
+// evaluating it on each pair of rows. Without condition, it is a cross join.
+type NestedLoopJoin struct {
+	typ JoinType
+	p   Predicate
+
+	leftr string
+	left  Node
+
+	rightr string
+	right  Node
+}
+
+// NewNestedLoopJoin creates a Joiner keeping pairs of rows for which p is true, and rows without match of preserved sides of typ
+func NewNestedLoopJoin(typ JoinType, leftRel, rightRel string, p Predicate) *NestedLoopJoin {
+	return &NestedLoopJoin{
+		typ:    typ,
+		p:      p,
+		leftr:  leftRel,
+		rightr: rightRel,
+	}
+}
+
+// NewCrossJoin creates a Joiner producing all pairs of rows of both relations
+func NewCrossJoin(leftRel, rightRel string) *NestedLoopJoin {
+	return NewNestedLoopJoin(Inner, leftRel, rightRel, NewTruePredicate())
+}
+
+func (j NestedLoopJoin) String() string {
+	if _, ok := j.p.(*TruePredicate); ok && j.typ == Inner {
+		return "CROSS JOIN " + j.leftr + " >< " + j.rightr
+	}
+	return fmt.Sprintf("%s %s >< %s ON %s", j.typ, j.leftr, j.rightr, j.p)
+}
+
+func (j *NestedLoopJoin) Left() string {
+	return j.leftr
+}
+
+func (j *NestedLoopJoin) SetLeft(n Node) {
+	j.left = n
+}
+
+func (j *NestedLoopJoin) Right() string {
+	return j.rightr
+}
+
+func (j *NestedLoopJoin) SetRight(n Node) {
+	j.right = n
+}
+
+// Nullable returns true if rows of left side, or else right side, may be padded with NULL values
+func (j *NestedLoopJoin) Nullable(left bool) bool {
+	return j.typ.nullable(left)
+}
+
+// EstimateCardinal is the product of both sides cardinal, condition being unknown
+func (j *NestedLoopJoin) EstimateCardinal() int64 {
+	if j.left == nil || j.right == nil {
+		return 0
+	}
+
+	return j.left.EstimateCardinal() * j.right.EstimateCardinal()
+}
+
+func (j *NestedLoopJoin) Children() []Node {
+	return []Node{j.left, j.right}
+}
+
+func (j *NestedLoopJoin) Exec() ([]string, []*list.Element, error) {
+	lcols, lefts, err := j.left.Exec()
+	if err != nil {
+		return nil, nil, err
+	}
+	rcols, rights, err := j.right.Exec()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cols := joinColumns(j.leftr, lcols, j.rightr, rcols)
+	res, err := nestedLoop(j.typ, len(lcols), lefts, len(rcols), rights, func(lt, rt *Tuple) (bool, error) {
+		t := NewTuple(lt.values...)
+		t.Append(rt.values...)
+		ok, err := j.p.Eval(cols, t)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", j, err)
+		}
+		return ok, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return cols, res, nil
+}
+
+// joinAttribute is an attribute of a relation joined on
+type joinAttribute struct {
+	relation  string
+	attribute string
+}
+
+func (a joinAttribute) String() string {
+	return a.relation + "." + a.attribute
+}
+
+// equiCondition is the condition of hash and merge joins: equality of each attribute of left side with the attribute
+// of right side at the same position, and a predicate on rows matching them, if any
+type equiCondition struct {
+	lkeys []joinAttribute
+	rkeys []joinAttribute
+	p     Predicate
+}
+
+func newEquiCondition(leftRel, leftAttr, rightRel, rightAttr string) equiCondition {
+	return equiCondition{
+		lkeys: []joinAttribute{{leftRel, leftAttr}},
+		rkeys: []joinAttribute{{rightRel, rightAttr}},
+	}
+}
+
+func (c equiCondition) String() string {
+	keys := make([]string, len(c.lkeys))
+	for i := range c.lkeys {
+		keys[i] = c.lkeys[i].String() + " >< " + c.rkeys[i].String()
+	}
+	s := strings.Join(keys, " AND ")
+	if c.p != nil {
+		s += " ON " + fmt.Sprint(c.p)
+	}
+	return s
+}
+
+// HashJoin joins two relations on equality of attributes of each, like NaturalJoin and OuterJoin,
+// building a hash table on the smaller side and probing it with the other one.
+//
+// Rows are produced in the same order as nested loop joins. If join attributes hold values
+// which cannot be hashed, or of different types, it falls back to a nested loop.
+type HashJoin struct {
+	typ  JoinType
+	cond equiCondition
+
+	leftr string
+	left  Node
+
+	rightr string
+	right  Node
+}
+
+// NewHashJoin creates a Joiner keeping rows with equal leftRel.leftAttr and rightRel.rightAttr, and rows without match of preserved sides of typ
+func NewHashJoin(typ JoinType, leftRel, leftAttr, rightRel, rightAttr string) *HashJoin {
+	return &HashJoin{
+		typ:    typ,
+		cond:   newEquiCondition(leftRel, leftAttr, rightRel, rightAttr),
+		leftr:  leftRel,
+		rightr: rightRel,
+	}
+}
+
+func (j HashJoin) String() string {
+	return "HASH " + j.typ.String() + " " + j.cond.String()
+}
+
+func (j *HashJoin) Left() string {
+	return j.leftr
+}
+
+func (j *HashJoin) SetLeft(n Node) {
+	j.left = n
+}
+
+func (j *HashJoin) Right() string {
+	return j.rightr
+}
+
+func (j *HashJoin) SetRight(n Node) {
+	j.right = n
+}
+
+// Nullable returns true if rows of left side, or else right side, may be padded with NULL values
+func (j *HashJoin) Nullable(left bool) bool {
+	return j.typ.nullable(left)
+}
+
+func (j *HashJoin) EstimateCardinal() int64 {
+	if j.left == nil || j.right == nil {
+		return 0
+	}
+
+	return equiJoinCardinal(j.typ, j.left.EstimateCardinal(), j.right.EstimateCardinal())
+}
+
+func (j *HashJoin) Children() []Node {
+	return []Node{j.left, j.right}
+}
+
+func (j *HashJoin) Exec() ([]string, []*list.Element, error) {
+	lcols, lefts, lidx, rcols, rights, ridx, err := execEquiJoin(j, j.left, j.right, j.cond)
+	if err != nil {
+		return nil, nil, err
+	}
+	cols := joinColumns(j.leftr, lcols, j.rightr, rcols)
+
+	lkeys, lclass := joinKeys(lefts, lidx)
+	rkeys, rclass := joinKeys(rights, ridx)
+	if !compatibleKeys(lclass, rclass) {
+		log.Debug("HashJoin.Exec: cannot hash %s values, using nested loop", j)
+		res, err := nestedLoop(j.typ, len(lcols), lefts, len(rcols), rights, matchOn(j, cols, lidx, ridx, j.cond.p))
+		if err != nil {
+			return nil, nil, err
+		}
+		return cols, res, nil
+	}
+
+	// matches of each left row are kept in right rows order, whichever side is hashed
+	matches := make([][]int, len(lefts))
+	if len(lkeys) < len(rkeys) {
+		table := hashKeys(lkeys)
+		for ri, k := range rkeys {
+			if k == nil {
+				continue
+			}
+			for _, li := range table[hashable(k)] {
+				matches[li] = append(matches[li], ri)
+			}
+		}
+	} else {
+		table := hashKeys(rkeys)
+		for li, k := range lkeys {
+			if k == nil {
+				continue
+			}
+			matches[li] = table[hashable(k)]
+		}
+	}
+
+	matches, err = filterMatches(j, cols, lefts, rights, matches, j.cond.p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cols, joinRows(j.typ, len(lcols), lefts, len(rcols), rights, matches), nil
+}
+
+// MergeJoin joins two relations on equality of attributes of each, like NaturalJoin and OuterJoin,
+// walking both sides sorted on join attributes. Sides already sorted, like the result of an inner MergeJoin
+// on the same attribute, are not sorted again.
+//
+// Rows are produced in join attributes order. If join attributes hold values
+// which cannot be sorted, or of different types, it falls back to a nested loop.
+type MergeJoin struct {
+	typ  JoinType
+	cond equiCondition
+
+	leftr string
+	left  Node
+
+	rightr string
+	right  Node
+}
+
+// NewMergeJoin creates a Joiner keeping rows with equal leftRel.leftAttr and rightRel.rightAttr, and rows without match of preserved sides of typ
+func NewMergeJoin(typ JoinType, leftRel, leftAttr, rightRel, rightAttr string) *MergeJoin {
+	return &MergeJoin{
+		typ:    typ,
+		cond:   newEquiCondition(leftRel, leftAttr, rightRel, rightAttr),
+		leftr:  leftRel,
+		rightr: rightRel,
+	}
+}
+
+func (j MergeJoin) String() string {
+	return "MERGE " + j.typ.String() + " " + j.cond.String()
+}
+
+func (j *MergeJoin) Left() string {
+	return j.leftr
+}
+
+func (j *MergeJoin) SetLeft(n Node) {
+	j.left = n
+}
+
+func (j *MergeJoin) Right() string {
+	return j.rightr
+}
+
+func (j *MergeJoin) SetRight(n Node) {
+	j.right = n
+}
+
+// Nullable returns true if rows of left side, or else right side, may be padded with NULL values
+func (j *MergeJoin) Nullable(left bool) bool {
+	return j.typ.nullable(left)
+}
+
+func (j *MergeJoin) EstimateCardinal() int64 {
+	if j.left == nil || j.right == nil {
+		return 0
+	}
+
+	return equiJoinCardinal(j.typ, j.left.EstimateCardinal(), j.right.EstimateCardinal())
+}
+
+func (j *MergeJoin) Children() []Node {
+	return []Node{j.left, j.right}
+}
+
+// orderedOn returns true if rows are produced sorted on attribute of relation, being the first join attribute of a side.
+// Outer joins are not, padded rows being appended.
+func (j *MergeJoin) orderedOn(rel, attr string) bool {
+	if j.typ != Inner {
+		return false
+	}
+	a := joinAttribute{rel, attr}
+	return a == j.cond.lkeys[0] || a == j.cond.rkeys[0]
+}
+
+func (j *MergeJoin) Exec() ([]string, []*list.Element, error) {
+	lcols, lefts, lidx, rcols, rights, ridx, err := execEquiJoin(j, j.left, j.right, j.cond)
+	if err != nil {
+		return nil, nil, err
+	}
+	cols := joinColumns(j.leftr, lcols, j.rightr, rcols)
+
+	lkeys, lclass := joinKeys(lefts, lidx)
+	rkeys, rclass := joinKeys(rights, ridx)
+	if !compatibleKeys(lclass, rclass) {
+		log.Debug("MergeJoin.Exec: cannot sort %s values, using nested loop", j)
+		res, err := nestedLoop(j.typ, len(lcols), lefts, len(rcols), rights, matchOn(j, cols, lidx, ridx, j.cond.p))
+		if err != nil {
+			return nil, nil, err
+		}
+		return cols, res, nil
+	}
+
+	lefts, lkeys, ln := sortByKey(lefts, lkeys)
+	rights, rkeys, rn := sortByKey(rights, rkeys)
+
+	matches := make([][]int, len(lefts))
+	i, k := 0, 0
+	for i < ln && k < rn {
+		c := compareKeys(lkeys[i], rkeys[k])
+		if c < 0 {
+			i++
+			continue
+		}
+		if c > 0 {
+			k++
+			continue
+		}
+		iend, kend := i+1, k+1
+		for iend < ln && compareKeys(lkeys[iend], lkeys[i]) == 0 {
+			iend++
+		}
+		for kend < rn && compareKeys(rkeys[kend], rkeys[k]) == 0 {
+			kend++
+		}
+		group := make([]int, 0, kend-k)
+		for ri := k; ri < kend; ri++ {
+			group = append(group, ri)
+		}
+		for li := i; li < iend; li++ {
+			matches[li] = group
+		}
+		i, k = iend, kend
+	}
+
+	matches, err = filterMatches(j, cols, lefts, rights, matches, j.cond.p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cols, joinRows(j.typ, len(lcols), lefts, len(rcols), rights, matches), nil
+}
+
+// execEquiJoin executes both children of an equality join j, returning their columns, rows and indexes of join attributes
+func execEquiJoin(j Joiner, left, right Node, cond equiCondition) (lcols []string, lefts []*list.Element, lidx []int, rcols []string, rights []*list.Element, ridx []int, err error) {
+	lcols, lefts, err = left.Exec()
+	if err != nil {
+		return
+	}
+	for _, a := range cond.lkeys {
+		idx := joinColumn(lcols, a.relation, a.attribute)
+		if idx == -1 {
+			err = fmt.Errorf("%s: columns not found in left node", j)
+			return
+		}
+		lidx = append(lidx, idx)
+	}
+
+	rcols, rights, err = right.Exec()
+	if err != nil {
+		return
+	}
+	for _, a := range cond.rkeys {
+		idx := joinColumn(rcols, a.relation, a.attribute)
+		if idx == -1 {
+			err = fmt.Errorf("%s: columns not found in right node", j)
+			return
+		}
+		ridx = append(ridx, idx)
+	}
+	return
+}
+
+// filterMatches keeps matches of each left row for which p is true on joined rows of cols, all of them if p is nil
+func filterMatches(j Joiner, cols []string, lefts, rights []*list.Element, matches [][]int, p Predicate) ([][]int, error) {
+	if p == nil {
+		return matches, nil
+	}
+
+	res := make([][]int, len(matches))
+	for li, m := range matches {
+		lt := lefts[li].Value.(*Tuple)
+		for _, ri := range m {
+			t := NewTuple(lt.values...)
+			t.Append(rights[ri].Value.(*Tuple).values...)
+			ok, err := p.Eval(cols, t)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", j, err)
+			}
+			if ok {
+				res[li] = append(res[li], ri)
+			}
+		}
+	}
+	return res, nil
+}
+
+// equiJoinCardinal estimates cardinal of an equality join of l and r rows, being at least the cardinal of preserved sides
+func equiJoinCardinal(typ JoinType, l, r int64) int64 {
+	c := (l * r) / 2
+	if typ.nullable(false) && c < l {
+		c = l
+	}
+	if typ.nullable(true) && c < r {
+		c = r
+	}
+	return c
+}
+
+// chooseJoin returns the cheapest operator for join j of left and right nodes, based on their estimated cardinal.
+// Nested loops cost the product of both cardinals, hash joins twice their sum, and merge joins their sum plus
+// the cost of sorting each side not already sorted on join attribute.
+//
+// Conditions of nested loop joins are split into equalities of an attribute of relations lrels of left side and
+// an attribute of relations rrels of right side, used as keys of hash and merge joins, and other conditions
+// evaluated on rows matching them. Joiners without such equality are returned as is.
+func chooseJoin(j Joiner, left, right Node, lrels, rrels map[string]bool) Joiner {
+	var typ JoinType
+	var cond equiCondition
+	switch n := j.(type) {
+	case *NaturalJoin:
+		typ, cond = Inner, newEquiCondition(n.leftr, n.lefta, n.rightr, n.righta)
+	case *OuterJoin:
+		typ, cond = n.typ, newEquiCondition(n.leftr, n.lefta, n.rightr, n.righta)
+	case *NestedLoopJoin:
+		typ, cond = n.typ, splitEquiCondition(n.p, lrels, rrels)
+		if len(cond.lkeys) == 0 {
+			return j
+		}
+	default:
+		return j
+	}
+
+	l, r := left.EstimateCardinal(), right.EstimateCardinal()
+	nested := l * r
+	hash := 2 * (l + r)
+	merge := sortCost(left, l, cond.lkeys) + sortCost(right, r, cond.rkeys) + l + r
+	switch {
+	case nested <= hash && nested <= merge:
+		return j
+	case merge < hash:
+		log.Debug("chooseJoin: merge join for %s (%d x %d)", j, l, r)
+		return &MergeJoin{typ: typ, cond: cond, leftr: j.Left(), rightr: j.Right()}
+	default:
+		log.Debug("chooseJoin: hash join for %s (%d x %d)", j, l, r)
+		return &HashJoin{typ: typ, cond: cond, leftr: j.Left(), rightr: j.Right()}
+	}
+}
+
+// splitEquiCondition returns conjuncts of p being equalities of an attribute of relations lrels and an attribute of relations rrels
+// as keys, others being the predicate on rows matching them
+func splitEquiCondition(p Predicate, lrels, rrels map[string]bool) equiCondition {
+	var cond equiCondition
+	var others []Predicate
+	for _, c := range conjuncts(p) {
+		if _, ok := c.(*TruePredicate); ok {
+			continue
+		}
+		if l, r, ok := attributeEquality(c); ok {
+			if lrels[r.relation] && rrels[l.relation] {
+				l, r = r, l
+			}
+			if lrels[l.relation] && rrels[r.relation] {
+				cond.lkeys = append(cond.lkeys, l)
+				cond.rkeys = append(cond.rkeys, r)
+				continue
+			}
+		}
+		others = append(others, c)
+	}
+	for _, c := range others {
+		if cond.p == nil {
+			cond.p = c
+			continue
+		}
+		cond.p = NewAndPredicate(cond.p, c)
+	}
+	return cond
+}
+
+// attributeEquality returns both attributes of p if it is an equality of two attributes
+func attributeEquality(p Predicate) (joinAttribute, joinAttribute, bool) {
+	eq, ok := p.(*EqPredicate)
+	if !ok {
+		return joinAttribute{}, joinAttribute{}, false
+	}
+	l, lok := eq.left.(*AttributeValueFunctor)
+	r, rok := eq.right.(*AttributeValueFunctor)
+	if !lok || !rok {
+		return joinAttribute{}, joinAttribute{}, false
+	}
+	return joinAttribute{l.rname, l.aname}, joinAttribute{r.rname, r.aname}, true
+}
+
+// sortCost estimates the cost of sorting n rows of node on attributes keys, nothing if already sorted on a single key
+func sortCost(n Node, card int64, keys []joinAttribute) int64 {
+	o, ok := n.(interface{ orderedOn(string, string) bool })
+	if ok && len(keys) == 1 && o.orderedOn(keys[0].relation, keys[0].attribute) {
+		return 0
+	}
+	if card <= 0 {
+		return 0
+	}
+	return card * int64(bits.Len64(uint64(card)))
+}
+
+// classes of join keys, values of a same class being equal if their keys are
+const (
+	unknownKey = iota - 1
+	nullKey
+	intKey
+	floatKey
+	stringKey
+	charKey
+	boolKey
+	uuidKey
+	decimalKey
+)
+
+// joinKey returns key of v for hash and merge joins, and its class.
+// NULL and NaN, never equal to any value, are of null class.
+func joinKey(v any) (any, int) {
+	switch k := v.(type) {
+	case nil:
+		return nil, nullKey
+	case int:
+		return int64(k), intKey
+	case int8:
+		return int64(k), intKey
+	case int16:
+		return int64(k), intKey
+	case int32:
+		return int64(k), intKey
+	case int64:
+		return k, intKey
+	case float32:
+		if math.IsNaN(float64(k)) {
+			return nil, nullKey
+		}
+		return float64(k), floatKey
+	case float64:
+		if math.IsNaN(k) {
+			return nil, nullKey
+		}
+		return k, floatKey
+	case string:
+		return k, stringKey
+	case Char:
+		return k.String(), charKey
+	case bool:
+		return k, boolKey
+	case UUID:
+		return k, uuidKey
+	case Decimal:
+		return k.canonical(), decimalKey
+	}
+	return nil, unknownKey
+}
+
+// compositeKey is the join key of several attributes
+type compositeKey []any
+
+// joinKeys returns keys of values at indexes idx in rows, being composite keys if there are several, and the class of values at each index,
+// which is unknown if rows hold values of several classes. Keys holding a NULL value are nil.
+func joinKeys(rows []*list.Element, idx []int) ([]any, []int) {
+	keys := make([]any, len(rows))
+	classes := make([]int, len(idx))
+	for i := range classes {
+		classes[i] = nullKey
+	}
+	for i, e := range rows {
+		values := e.Value.(*Tuple).values
+		key := make(compositeKey, len(idx))
+		null := false
+		for n, x := range idx {
+			k, c := joinKey(values[x])
+			switch {
+			case c == unknownKey:
+				classes[n] = unknownKey
+				return nil, classes
+			case c == nullKey:
+				null = true
+			case classes[n] == nullKey:
+				classes[n] = c
+			case classes[n] != c:
+				classes[n] = unknownKey
+				return nil, classes
+			}
+			key[n] = k
+		}
+		switch {
+		case null:
+		case len(key) == 1:
+			keys[i] = key[0]
+		default:
+			keys[i] = key
+		}
+	}
+	return keys, classes
+}
+
+// compatibleKeys returns true if keys of classes l and r, by attribute, can be hashed or sorted together
+func compatibleKeys(l, r []int) bool {
+	for i := range l {
+		if l[i] == unknownKey || r[i] == unknownKey {
+			return false
+		}
+		if l[i] != r[i] && l[i] != nullKey && r[i] != nullKey {
+			return false
+		}
+	}
+	return true
+}
+
+// hashable returns k usable as a map key
+func hashable(k any) any {
+	switch x := k.(type) {
+	case Decimal:
+		return x.String()
+	case float64:
+		// -0 and 0 are equal, but not formatted the same way
+		if x == 0 {
+			return float64(0)
+		}
+	case compositeKey:
+		// values at each position being of the same class, their Go syntax representation is unambiguous
+		var b strings.Builder
+		for _, v := range x {
+			fmt.Fprintf(&b, "%#v,", hashable(v))
+		}
+		return b.String()
+	}
+	return k
+}
+
+// hashKeys returns indexes of non NULL keys, by key
+func hashKeys(keys []any) map[any][]int {
+	table := make(map[any][]int, len(keys))
+	for i, k := range keys {
+		if k == nil {
+			continue
+		}
+		h := hashable(k)
+		table[h] = append(table[h], i)
+	}
+	return table
+}
+
+// compareKeys returns -1, 0 or 1 if join key a is lesser, equal or greater than b, both being of the same class
+func compareKeys(a, b any) int {
+	switch x := a.(type) {
+	case int64:
+		y := b.(int64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case float64:
+		y := b.(float64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case string:
+		return strings.Compare(x, b.(string))
+	case bool:
+		if y := b.(bool); x != y {
+			if y {
+				return -1
+			}
+			return 1
+		}
+	case UUID:
+		y := b.(UUID)
+		return bytes.Compare(x[:], y[:])
+	case Decimal:
+		return x.Cmp(b.(Decimal))
+	case compositeKey:
+		y := b.(compositeKey)
+		for i := range x {
+			if c := compareKeys(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// sortByKey returns rows and their keys sorted on non NULL keys, followed by rows with NULL keys in their original order,
+// and the number of non NULL keys. Rows already sorted are returned as is.
+func sortByKey(rows []*list.Element, keys []any) ([]*list.Element, []any, int) {
+	order := make([]int, 0, len(rows))
+	for i, k := range keys {
+		if k != nil {
+			order = append(order, i)
+		}
+	}
+	n := len(order)
+	for i, k := range keys {
+		if k == nil {
+			order = append(order, i)
+		}
+	}
+	less := func(a, b int) bool {
+		return compareKeys(keys[order[a]], keys[order[b]]) < 0
+	}
+	sorted := sort.SliceIsSorted(order[:n], less)
+	if sorted && n == len(rows) {
+		return rows, keys, n
+	}
+	if !sorted {
+		sort.SliceStable(order[:n], less)
+	}
+
+	srows := make([]*list.Element, len(rows))
+	skeys := make([]any, len(keys))
+	for i, o := range order {
+		srows[i] = rows[o]
+		skeys[i] = keys[o]
+	}
+	return srows, skeys, n
+}
+
+// nestedLoop joins each row of lefts with each row of rights matching it, keeping rows without match
+// of preserved sides of typ padded with NULL values
+func nestedLoop(typ JoinType, lwidth int, lefts []*list.Element, rwidth int, rights []*list.Element, match func(lt, rt *Tuple) (bool, error)) ([]*list.Element, error) {
+	matches := make([][]int, len(lefts))
+	for li, left := range lefts {
+		lt := left.Value.(*Tuple)
+		for ri, right := range rights {
+			ok, err := match(lt, right.Value.(*Tuple))
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				matches[li] = append(matches[li], ri)
+			}
+		}
+	}
+	return joinRows(typ, lwidth, lefts, rwidth, rights, matches), nil
+}
+
+// equalOn returns a match function of rows whose values at each of lidx and ridx are equal, NULL never joining
+func equalOn(lidx, ridx []int) func(lt, rt *Tuple) (bool, error) {
+	return func(lt, rt *Tuple) (bool, error) {
+		for i := range lidx {
+			lv, rv := lt.values[lidx[i]], rt.values[ridx[i]]
+			if lv == nil || rv == nil {
+				return false, nil
+			}
+			ok, err := equal(lv, rv)
+			if !ok || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// matchOn returns a match function of rows equal on values at lidx and ridx, for which p is true on joined rows of cols, if any
+func matchOn(j Joiner, cols []string, lidx, ridx []int, p Predicate) func(lt, rt *Tuple) (bool, error) {
+	eq := equalOn(lidx, ridx)
+	return func(lt, rt *Tuple) (bool, error) {
+		ok, err := eq(lt, rt)
+		if !ok || err != nil || p == nil {
+			return ok, err
+		}
+		t := NewTuple(lt.values...)
+		t.Append(rt.values...)
+		ok, err = p.Eval(cols, t)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", j, err)
+		}
+		return ok, nil
+	}
+}
+
+// joinRows joins each row of lefts with rows of rights at indexes matches[i], in order, keeping rows without match
+// of preserved sides of typ padded with NULL values
+func joinRows(typ JoinType, lwidth int, lefts []*list.Element, rwidth int, rights []*list.Element, matches [][]int) []*list.Element {
+	l := list.New()
+	matched := make([]bool, len(rights))
+	for li, left := range lefts {
+		lt := left.Value.(*Tuple)
+		for _, ri := range matches[li] {
+			matched[ri] = true
+			t := NewTuple(lt.values...)
+			t.Append(rights[ri].Value.(*Tuple).values...)
+			l.PushBack(t)
+		}
+		if len(matches[li]) == 0 && typ.nullable(false) {
+			t := NewTuple(lt.values...)
+			t.Append(make([]any, rwidth)...)
+			l.PushBack(t)
+		}
+	}
+	if typ.nullable(true) {
+		for i, right := range rights {
+			if matched[i] {
+				continue
+			}
+			t := NewTuple(make([]any, lwidth)...)
+			t.Append(right.Value.(*Tuple).values...)
+			l.PushBack(t)
+		}
+	}
+
+	res := make([]*list.Element, 0, l.Len())
+	for e := l.Front(); e != nil; e = e.Next() {
+		res = append(res, e)
+	}
+	return res
+}
+
+// nullableRelations returns relations whose rows may be padded with NULL values by outer joins, joiners being in query order
+func nullableRelations(joiners []Joiner) map[string]bool {
+	nullable := make(map[string]bool)
+	// relations joined together, by relation
+	joined := make(map[string][]string)
+	members := func(rel string) []string {
+		if m, ok := joined[rel]; ok {
+			return m
+		}
+		return []string{rel}
+	}
+
+	for _, j := range joiners {
+		left, right := members(j.Left()), members(j.Right())
+		if oj, ok := j.(interface{ Nullable(bool) bool }); ok {
+			for _, rel := range left {
+				nullable[rel] = nullable[rel] || oj.Nullable(true)
+			}
+			for _, rel := range right {
+				nullable[rel] = nullable[rel] || oj.Nullable(false)
+			}
+		}
+		all := append(append([]string{}, left...), right...)
+		for _, rel := range all {
+			joined[rel] = all
+		}
+	}
+	return nullable
+}
+
+// joinColumn returns index of attribute of relation in cols, or -1 if not found
+func joinColumn(cols []string, rel, attr string) int {
+	for i, c := range cols {
+		if c == attr || c == rel+"."+attr {
+			return i
+		}
+	}
+	return -1
+}
+
+// joinColumns returns columns of joined tuples, qualified by their relation
+func joinColumns(leftr string, lcols []string, rightr string, rcols []string) []string {
+	cols := make([]string, 0, len(lcols)+len(rcols))
+	for _, c := range lcols {
+		if !strings.Contains(c, ".") {
+			c = leftr + "." + c
+		}
+		cols = append(cols, c)
+	}
+	for _, c := range rcols {
+		if !strings.Contains(c, ".") {
+			c = rightr + "." + c
+		}
+		cols = append(cols, c)
+	}
+	return cols
+}
+
+// FilterNode keeps rows of its child for which predicate is true.
+// It evaluates conditions which cannot be pushed down to relation scanners, like those on NULL padded side of an outer join.
+type FilterNode struct {
+	p   Predicate
+	src Node
+}
+
+func NewFilterNode(p Predicate, src Node) *FilterNode {
+	return &FilterNode{p: p, src: src}
+}
+
+func (n FilterNode) String() string {
+	return fmt.Sprintf("Filter %s", n.p)
+}
+
+func (n *FilterNode) Exec() ([]string, []*list.Element, error) {
+	cols, in, err := n.src.Exec()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res []*list.Element
+	for _, e := range in {
+		ok, err := n.p.Eval(cols, e.Value.(*Tuple))
+		if err != nil {
+			return nil, nil, fmt.Errorf("FilterNode.Exec: %s : %w", n.p, err)
+		}
+		if ok {
+			res = append(res, e)
+		}
+	}
+	return cols, res, nil
+}
+
+func (n *FilterNode) EstimateCardinal() int64 {
+	return int64(n.src.EstimateCardinal()/2) + 1
+}
+
+func (n *FilterNode) Children() []Node {
+	return []Node{n.src}
+}
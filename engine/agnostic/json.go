@@ -0,0 +1,819 @@
+package agnostic
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSON is a JSON document.
+//
+// Like jsonb, values returned by ParseJSON and ToJSON are normalized: compact, with object keys sorted,
+// duplicated keys removed and numbers in exact numeric form. Two normalized JSON values hold the same
+// document if and only if their texts are equal.
+//
+// Like json, values returned by ParseRawJSON and ToRawJSON keep their input text as written.
+type JSON string
+
+// ParseJSON validates and normalizes JSON text
+func ParseJSON(s string) (JSON, error) {
+	v, err := decodeJSON(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid JSON '%s': %w", s, err)
+	}
+
+	return encodeJSON(v)
+}
+
+// ParseRawJSON validates JSON text, keeping it as written
+func ParseRawJSON(s string) (JSON, error) {
+	if _, err := decodeJSON(s); err != nil {
+		return "", fmt.Errorf("invalid JSON '%s': %w", s, err)
+	}
+
+	return JSON(s), nil
+}
+
+// ToRawJSON converts JSON text, as string or bytes, to JSON kept as written, like json type.
+// Other values are converted to their JSON representation.
+func ToRawJSON(v any) (JSON, error) {
+	switch t := v.(type) {
+	case JSON:
+		return t, nil
+	case string:
+		return ParseRawJSON(t)
+	case []byte:
+		return ParseRawJSON(string(t))
+	case json.RawMessage:
+		return ParseRawJSON(string(t))
+	}
+
+	return encodeJSON(jsonValueOf(v))
+}
+
+// ToJSONB converts v to a normalized JSON, like jsonb type. Unlike ToJSON, JSON values are normalized too.
+func ToJSONB(v any) (JSON, error) {
+	if j, ok := v.(JSON); ok {
+		return ParseJSON(string(j))
+	}
+
+	return ToJSON(v)
+}
+
+// ToJSON converts JSON text, as string or bytes, to normalized JSON.
+// Other values are converted to their JSON representation.
+func ToJSON(v any) (JSON, error) {
+	switch t := v.(type) {
+	case JSON:
+		return t, nil
+	case string:
+		return ParseJSON(t)
+	case []byte:
+		return ParseJSON(string(t))
+	case json.RawMessage:
+		return ParseJSON(string(t))
+	}
+
+	return encodeJSON(jsonValueOf(v))
+}
+
+// String returns JSON text
+func (j JSON) String() string {
+	return string(j)
+}
+
+// Value implements driver.Valuer, returning JSON text
+func (j JSON) Value() (driver.Value, error) {
+	return string(j), nil
+}
+
+// decode returns the document as map[string]any, []any, string, json.Number, bool or nil
+func (j JSON) decode() any {
+	v, err := decodeJSON(string(j))
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func decodeJSON(s string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+
+	return canonicalJSON(v), nil
+}
+
+// canonicalJSON converts decoded numbers to exact numeric form, keeping their scale like 1.50,
+// exponent being applied
+func canonicalJSON(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		d, err := ParseDecimal(string(t))
+		if err != nil {
+			return t
+		}
+		return json.Number(d.String())
+	case map[string]any:
+		for k, e := range t {
+			t[k] = canonicalJSON(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = canonicalJSON(e)
+		}
+	}
+
+	return v
+}
+
+func encodeJSON(v any) (JSON, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+
+	return JSON(bytes.TrimRight(buf.Bytes(), "\n")), nil
+}
+
+// jsonValueOf returns the JSON document representing v, like to_jsonb()
+func jsonValueOf(v any) any {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case JSON:
+		return t.decode()
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case bool:
+		return t
+	case UUID:
+		return t.String()
+	case Decimal:
+		return json.Number(t.String())
+	case Bytea:
+		return t.String()
+	case Interval:
+		return t.String()
+	case Enum:
+		return t.label
+	case Char:
+		return string(t)
+	case Array:
+		a := make([]any, len(t))
+		for i, e := range t {
+			a[i] = jsonValueOf(e)
+		}
+		return a
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.CanInt():
+		return json.Number(strconv.FormatInt(rv.Int(), 10))
+	case rv.CanUint():
+		return json.Number(strconv.FormatUint(rv.Uint(), 10))
+	case rv.CanFloat():
+		if math.IsNaN(rv.Float()) || math.IsInf(rv.Float(), 0) {
+			return strconv.FormatFloat(rv.Float(), 'g', -1, 64)
+		}
+		return canonicalJSON(json.Number(strconv.FormatFloat(rv.Float(), 'g', -1, 64)))
+	}
+
+	// structs, maps and slices go through their encoding/json representation
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	d, err := decodeJSON(string(b))
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return d
+}
+
+// jsonComparable returns comparable representations of two JSON values.
+// Numbers compare exactly, result being returned as comparable integers, other values by their normalized text.
+func jsonComparable(l, r JSON) (any, any) {
+	lv, lok := jsonNumber(l.decode())
+	rv, rok := jsonNumber(r.decode())
+	if lok && rok {
+		return int64(lv.Cmp(rv)), int64(0)
+	}
+
+	return l.comparable(), r.comparable()
+}
+
+// comparable returns document text with numbers in canonical form, so equal documents have equal texts,
+// like 1.50 and 1.5
+func (j JSON) comparable() string {
+	c, err := encodeJSON(jsonCanonical(j.decode()))
+	if err != nil {
+		return string(j)
+	}
+	return string(c)
+}
+
+// jsonCanonical returns decoded document with numbers without trailing zeros
+func jsonCanonical(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if d, ok := jsonNumber(t); ok {
+			return json.Number(d.canonical().String())
+		}
+	case map[string]any:
+		c := make(map[string]any, len(t))
+		for k, e := range t {
+			c[k] = jsonCanonical(e)
+		}
+		return c
+	case []any:
+		c := make([]any, len(t))
+		for i, e := range t {
+			c[i] = jsonCanonical(e)
+		}
+		return c
+	}
+	return v
+}
+
+func jsonNumber(v any) (Decimal, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return Decimal{}, false
+	}
+	d, err := ParseDecimal(string(n))
+	if err != nil {
+		return Decimal{}, false
+	}
+	return d, true
+}
+
+func jsonEqual(a, b any) bool {
+	if x, ok := jsonNumber(a); ok {
+		y, ok := jsonNumber(b)
+		return ok && x.Cmp(y) == 0
+	}
+
+	ja, err := encodeJSON(jsonCanonical(a))
+	if err != nil {
+		return false
+	}
+	jb, err := encodeJSON(jsonCanonical(b))
+	if err != nil {
+		return false
+	}
+	return ja == jb
+}
+
+// jsonContains returns true if document a contains document b, as @> does
+func jsonContains(a, b any) bool {
+	switch ta := a.(type) {
+	case map[string]any:
+		tb, ok := b.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, vb := range tb {
+			va, ok := ta[k]
+			if !ok || !jsonContains(va, vb) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		tb, ok := b.([]any)
+		if !ok {
+			// an array contains a primitive value if one of its elements is that value
+			switch b.(type) {
+			case map[string]any:
+				return false
+			}
+			tb = []any{b}
+		}
+		for _, vb := range tb {
+			found := false
+			for _, va := range ta {
+				if jsonContains(va, vb) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch b.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return jsonEqual(a, b)
+}
+
+// jsonField returns object field, or array element if key is an integer.
+// Negative integers count from the end of array.
+func jsonField(doc any, key any) (any, bool) {
+	switch k := key.(type) {
+	case string:
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		v, ok := obj[k]
+		return v, ok
+	case JSON:
+		return jsonField(doc, k.decode())
+	}
+
+	arr, ok := doc.([]any)
+	if !ok {
+		return nil, false
+	}
+	rv := reflect.ValueOf(key)
+	var idx int64
+	switch {
+	case rv.CanInt():
+		idx = rv.Int()
+	case rv.CanUint():
+		idx = int64(rv.Uint())
+	default:
+		return nil, false
+	}
+	if idx < 0 {
+		idx += int64(len(arr))
+	}
+	if idx < 0 || idx >= int64(len(arr)) {
+		return nil, false
+	}
+	return arr[idx], true
+}
+
+// jsonPath parses a text array path of the form {a,b,0}
+func jsonPath(v any) ([]string, error) {
+	switch t := v.(type) {
+	case []string:
+		return t, nil
+	case string:
+		s := strings.TrimSpace(t)
+		if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("malformed array literal '%s'", t)
+		}
+		s = strings.TrimSpace(s[1 : len(s)-1])
+		if s == "" {
+			return nil, nil
+		}
+		steps := strings.Split(s, ",")
+		for i := range steps {
+			steps[i] = strings.Trim(strings.TrimSpace(steps[i]), `"`)
+		}
+		return steps, nil
+	}
+
+	return nil, fmt.Errorf("cannot use '%v' (type %T) as path", v, v)
+}
+
+// jsonPathStep returns object field, or array element if step is an integer
+func jsonPathStep(doc any, step string) (any, bool) {
+	switch doc.(type) {
+	case map[string]any:
+		return jsonField(doc, step)
+	case []any:
+		idx, err := strconv.ParseInt(step, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		return jsonField(doc, idx)
+	}
+	return nil, false
+}
+
+// jsonResult returns v as JSON, or as text if asText is set.
+// Text form of JSON strings is their unquoted content and JSON null is NULL.
+func jsonResult(v any, asText bool) any {
+	if asText {
+		switch t := v.(type) {
+		case nil:
+			return nil
+		case string:
+			return t
+		}
+	}
+
+	j, err := encodeJSON(v)
+	if err != nil {
+		return nil
+	}
+	if asText {
+		return string(j)
+	}
+	return j
+}
+
+func toJSONDocument(v any) (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+	j, err := ToJSON(v)
+	if err != nil {
+		return nil, false
+	}
+	return j.decode(), true
+}
+
+// JSONFieldValueFunctor implements -> and ->> operators
+type JSONFieldValueFunctor struct {
+	src    ValueFunctor
+	key    ValueFunctor
+	asText bool
+}
+
+// NewJSONFieldValueFunctor creates a ValueFunctor returning the field of src document named by key,
+// or its element at index key if src is an array. If asText is set, the value is returned as text.
+func NewJSONFieldValueFunctor(src, key ValueFunctor, asText bool) ValueFunctor {
+	f := &JSONFieldValueFunctor{
+		src:    src,
+		key:    key,
+		asText: asText,
+	}
+	return f
+}
+
+func (f *JSONFieldValueFunctor) Value(cols []string, t *Tuple) any {
+	doc, ok := toJSONDocument(f.src.Value(cols, t))
+	if !ok {
+		return nil
+	}
+	v, ok := jsonField(doc, f.key.Value(cols, t))
+	if !ok {
+		return nil
+	}
+	return jsonResult(v, f.asText)
+}
+
+func (f *JSONFieldValueFunctor) Relation() string {
+	return commonRelation(f.src, f.key)
+}
+
+func (f *JSONFieldValueFunctor) Attribute() []string {
+	return append(f.src.Attribute(), f.key.Attribute()...)
+}
+
+func (f JSONFieldValueFunctor) String() string {
+	if f.asText {
+		return fmt.Sprintf("%s ->> %s", f.src, f.key)
+	}
+	return fmt.Sprintf("%s -> %s", f.src, f.key)
+}
+
+// JSONPathValueFunctor implements #> and #>> operators
+type JSONPathValueFunctor struct {
+	src    ValueFunctor
+	path   ValueFunctor
+	asText bool
+}
+
+// NewJSONPathValueFunctor creates a ValueFunctor returning the value of src document at path,
+// given as a text array like '{a,b,0}'. If asText is set, the value is returned as text.
+func NewJSONPathValueFunctor(src, path ValueFunctor, asText bool) ValueFunctor {
+	f := &JSONPathValueFunctor{
+		src:    src,
+		path:   path,
+		asText: asText,
+	}
+	return f
+}
+
+func (f *JSONPathValueFunctor) Value(cols []string, t *Tuple) any {
+	doc, ok := toJSONDocument(f.src.Value(cols, t))
+	if !ok {
+		return nil
+	}
+	steps, err := jsonPath(f.path.Value(cols, t))
+	if err != nil {
+		return nil
+	}
+	for _, step := range steps {
+		doc, ok = jsonPathStep(doc, step)
+		if !ok {
+			return nil
+		}
+	}
+	return jsonResult(doc, f.asText)
+}
+
+func (f *JSONPathValueFunctor) Relation() string {
+	return commonRelation(f.src, f.path)
+}
+
+func (f *JSONPathValueFunctor) Attribute() []string {
+	return append(f.src.Attribute(), f.path.Attribute()...)
+}
+
+func (f JSONPathValueFunctor) String() string {
+	if f.asText {
+		return fmt.Sprintf("%s #>> %s", f.src, f.path)
+	}
+	return fmt.Sprintf("%s #> %s", f.src, f.path)
+}
+
+// ContainsPredicate implements @> operator: left JSON document contains right one,
+// or left array contains all elements of right one
+type ContainsPredicate struct {
+	left  ValueFunctor
+	right ValueFunctor
+}
+
+func NewContainsPredicate(left, right ValueFunctor) *ContainsPredicate {
+	p := &ContainsPredicate{
+		left:  left,
+		right: right,
+	}
+
+	return p
+}
+
+func (p *ContainsPredicate) Type() PredicateType {
+	return Contains
+}
+
+func (p ContainsPredicate) String() string {
+	return fmt.Sprintf("%s @> %s", p.left, p.right)
+}
+
+func (p *ContainsPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
+
+func (p *ContainsPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	return compareTruth(p.left, p.right, cols, t, p.compare)
+}
+
+func (p *ContainsPredicate) compare(vl, vr any) (bool, error) {
+	_, lok := vl.(Array)
+	_, rok := vr.(Array)
+	if lok || rok {
+		l, err := ToArray(vl)
+		if err != nil {
+			return false, err
+		}
+		r, err := ToArray(vr)
+		if err != nil {
+			return false, err
+		}
+		return arrayContains(l, r), nil
+	}
+
+	l, err := ToJSON(vl)
+	if err != nil {
+		return false, err
+	}
+	r, err := ToJSON(vr)
+	if err != nil {
+		return false, err
+	}
+
+	return jsonContains(l.decode(), r.decode()), nil
+}
+
+func (p *ContainsPredicate) Left() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *ContainsPredicate) Right() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *ContainsPredicate) Relation() string {
+	return commonRelation(p.left, p.right)
+}
+
+func (p *ContainsPredicate) Attribute() []string {
+	return append(p.left.Attribute(), p.right.Attribute()...)
+}
+
+// HasKeyPredicate implements ? operator: right string is a key of left JSON object,
+// or an element of left JSON array
+type HasKeyPredicate struct {
+	left  ValueFunctor
+	right ValueFunctor
+}
+
+func NewHasKeyPredicate(left, right ValueFunctor) *HasKeyPredicate {
+	p := &HasKeyPredicate{
+		left:  left,
+		right: right,
+	}
+
+	return p
+}
+
+func (p *HasKeyPredicate) Type() PredicateType {
+	return HasKey
+}
+
+func (p HasKeyPredicate) String() string {
+	return fmt.Sprintf("%s ? %s", p.left, p.right)
+}
+
+func (p *HasKeyPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
+
+func (p *HasKeyPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	return compareTruth(p.left, p.right, cols, t, p.compare)
+}
+
+func (p *HasKeyPredicate) compare(vl, vr any) (bool, error) {
+	l, err := ToJSON(vl)
+	if err != nil {
+		return false, err
+	}
+	key, ok := vr.(string)
+	if !ok {
+		return false, fmt.Errorf("%s: key must be text, got %T", p, vr)
+	}
+
+	switch doc := l.decode().(type) {
+	case map[string]any:
+		_, ok := doc[key]
+		return ok, nil
+	case []any:
+		for _, e := range doc {
+			if s, ok := e.(string); ok && s == key {
+				return true, nil
+			}
+		}
+	case string:
+		return doc == key, nil
+	}
+
+	return false, nil
+}
+
+func (p *HasKeyPredicate) Left() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *HasKeyPredicate) Right() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *HasKeyPredicate) Relation() string {
+	return commonRelation(p.left, p.right)
+}
+
+func (p *HasKeyPredicate) Attribute() []string {
+	return append(p.left.Attribute(), p.right.Attribute()...)
+}
+
+// jsonBuildObject implements jsonb_build_object(key, value, ...)
+func jsonBuildObject(args []any) (any, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("argument list must have even number of elements")
+	}
+
+	obj := make(map[string]any, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		if args[i] == nil {
+			return nil, fmt.Errorf("argument %d: key must not be null", i+1)
+		}
+		key, ok := args[i].(string)
+		if !ok {
+			key = fmt.Sprint(args[i])
+		}
+		obj[key] = jsonValueOf(args[i+1])
+	}
+
+	return encodeJSON(obj)
+}
+
+// jsonSet implements jsonb_set(target, path, new_value [, create_if_missing])
+func jsonSet(args []any) (any, error) {
+	if args[0] == nil || args[1] == nil || args[2] == nil {
+		return nil, nil
+	}
+
+	target, err := ToJSON(args[0])
+	if err != nil {
+		return nil, err
+	}
+	steps, err := jsonPath(args[1])
+	if err != nil {
+		return nil, err
+	}
+	value, err := ToJSON(args[2])
+	if err != nil {
+		return nil, err
+	}
+	create := true
+	if len(args) > 3 {
+		b, ok := args[3].(bool)
+		if !ok {
+			b, err = strconv.ParseBool(fmt.Sprint(args[3]))
+			if err != nil {
+				return nil, fmt.Errorf("create_if_missing must be boolean, got '%v'", args[3])
+			}
+		}
+		create = b
+	}
+
+	doc := target.decode()
+	switch doc.(type) {
+	case map[string]any, []any:
+	default:
+		return nil, fmt.Errorf("cannot set path in scalar")
+	}
+
+	return encodeJSON(jsonSetPath(doc, steps, value.decode(), create))
+}
+
+func jsonSetPath(doc any, steps []string, value any, create bool) any {
+	if len(steps) == 0 {
+		return value
+	}
+	step, last := steps[0], len(steps) == 1
+
+	switch t := doc.(type) {
+	case map[string]any:
+		if child, ok := t[step]; ok {
+			t[step] = jsonSetPath(child, steps[1:], value, create)
+		} else if create && last {
+			t[step] = value
+		}
+		return t
+	case []any:
+		idx, err := strconv.Atoi(step)
+		if err != nil {
+			return t
+		}
+		if idx < 0 {
+			idx += len(t)
+		}
+		switch {
+		case idx >= 0 && idx < len(t):
+			t[idx] = jsonSetPath(t[idx], steps[1:], value, create)
+		case create && last && idx < 0:
+			t = append([]any{value}, t...)
+		case create && last:
+			t = append(t, value)
+		}
+		return t
+	}
+
+	return doc
+}
+
+// jsonArrayElements implements jsonb_array_elements(array), returning a set of JSON values
+func jsonArrayElements(args []any) (any, error) {
+	return jsonElements(args[0], false)
+}
+
+// jsonArrayElementsText implements jsonb_array_elements_text(array), returning a set of text values
+func jsonArrayElementsText(args []any) (any, error) {
+	return jsonElements(args[0], true)
+}
+
+func jsonElements(v any, asText bool) (any, error) {
+	if v == nil {
+		return []any{}, nil
+	}
+
+	j, err := ToJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := j.decode().([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot extract elements from non-array %s", j)
+	}
+
+	out := make([]any, len(arr))
+	for i, e := range arr {
+		out[i] = jsonResult(e, asText)
+	}
+	return out, nil
+}
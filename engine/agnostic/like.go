@@ -0,0 +1,292 @@
+package agnostic
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PatternPredicate implements pattern matching operators: LIKE, ILIKE, SIMILAR TO and POSIX regular
+// expressions ~, ~*, !~ and !~*. LIKE and SIMILAR TO patterns match the whole value, regular expressions any part of it.
+// Like every comparison, matching NULL is unknown.
+type PatternPredicate struct {
+	ptype   PredicateType
+	left    ValueFunctor
+	pattern ValueFunctor
+	escape  ValueFunctor
+	// compiled pattern, if it does not depend on evaluated row
+	re *regexp.Regexp
+}
+
+// NewLikePredicate creates a predicate true if left matches LIKE pattern, where % matches any sequence of characters
+// and _ any single character. escape is the character quoting them, backslash if escape is nil.
+// ILIKE is case insensitive.
+func NewLikePredicate(left, pattern, escape ValueFunctor, caseInsensitive bool) (*PatternPredicate, error) {
+	ptype := Like
+	if caseInsensitive {
+		ptype = ILike
+	}
+	return newPatternPredicate(ptype, left, pattern, escape)
+}
+
+// NewSimilarToPredicate creates a predicate true if left matches SQL regular expression pattern,
+// which is a LIKE pattern also supporting |, *, +, ?, {m,n}, () and [] as in POSIX regular expressions
+func NewSimilarToPredicate(left, pattern, escape ValueFunctor) (*PatternPredicate, error) {
+	return newPatternPredicate(SimilarTo, left, pattern, escape)
+}
+
+// NewRegexPredicate creates a predicate true if left contains a match of regular expression pattern, as ~ does.
+// ~* is case insensitive.
+func NewRegexPredicate(left, pattern ValueFunctor, caseInsensitive bool) (*PatternPredicate, error) {
+	ptype := Match
+	if caseInsensitive {
+		ptype = IMatch
+	}
+	return newPatternPredicate(ptype, left, pattern, nil)
+}
+
+// NewNotRegexPredicate creates a predicate true if left does not contain a match of regular expression pattern,
+// as !~ does. !~* is case insensitive.
+func NewNotRegexPredicate(left, pattern ValueFunctor, caseInsensitive bool) (*PatternPredicate, error) {
+	ptype := NotMatch
+	if caseInsensitive {
+		ptype = NotIMatch
+	}
+	return newPatternPredicate(ptype, left, pattern, nil)
+}
+
+func newPatternPredicate(ptype PredicateType, left, pattern, escape ValueFunctor) (*PatternPredicate, error) {
+	p := &PatternPredicate{
+		ptype:   ptype,
+		left:    left,
+		pattern: pattern,
+		escape:  escape,
+	}
+
+	// constant pattern is compiled once, reporting invalid ones before execution
+	_, constPattern := pattern.(*ConstValueFunctor)
+	_, constEscape := escape.(*ConstValueFunctor)
+	if constPattern && (escape == nil || constEscape) {
+		re, err := p.compile(nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		p.re = re
+	}
+
+	return p, nil
+}
+
+func (p *PatternPredicate) Type() PredicateType {
+	return p.ptype
+}
+
+func (p PatternPredicate) String() string {
+	var op string
+	switch p.ptype {
+	case Like:
+		op = "LIKE"
+	case ILike:
+		op = "ILIKE"
+	case SimilarTo:
+		op = "SIMILAR TO"
+	case Match:
+		op = "~"
+	case IMatch:
+		op = "~*"
+	case NotMatch:
+		op = "!~"
+	case NotIMatch:
+		op = "!~*"
+	}
+	if p.escape != nil {
+		return fmt.Sprintf("%s %s %s ESCAPE %s", p.left, op, p.pattern, p.escape)
+	}
+	return fmt.Sprintf("%s %s %s", p.left, op, p.pattern)
+}
+
+func (p *PatternPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
+
+func (p *PatternPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	v, err := evalFunctor(p.left, cols, t)
+	if err != nil {
+		return truthFalse, err
+	}
+	if v == nil {
+		return truthUnknown, nil
+	}
+	s, err := patternText(v)
+	if err != nil {
+		return truthFalse, err
+	}
+
+	re := p.re
+	if re == nil {
+		re, err = p.compile(cols, t)
+		if err != nil {
+			return truthFalse, err
+		}
+		if re == nil {
+			return truthUnknown, nil
+		}
+	}
+
+	if p.ptype == NotMatch || p.ptype == NotIMatch {
+		return truthOf(!re.MatchString(s)), nil
+	}
+	return truthOf(re.MatchString(s)), nil
+}
+
+// compile returns the regular expression equivalent to pattern, or nil if pattern or escape is NULL
+func (p *PatternPredicate) compile(cols []string, t *Tuple) (*regexp.Regexp, error) {
+	v, err := evalFunctor(p.pattern, cols, t)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	pattern, err := patternText(v)
+	if err != nil {
+		return nil, err
+	}
+
+	escape := `\`
+	if p.escape != nil {
+		v, err := evalFunctor(p.escape, cols, t)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		if escape, err = patternText(v); err != nil {
+			return nil, err
+		}
+		if len([]rune(escape)) > 1 {
+			return nil, fmt.Errorf("invalid escape string \"%s\": escape string must be empty or one character", escape)
+		}
+	}
+
+	var expr string
+	switch p.ptype {
+	case Like, ILike:
+		expr, err = likeToRegex(pattern, escape)
+	case SimilarTo:
+		expr, err = similarToRegex(pattern, escape)
+	default:
+		expr = pattern
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.ptype == ILike || p.ptype == IMatch || p.ptype == NotIMatch {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile("(?s)" + expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression \"%s\": %w", pattern, err)
+	}
+	return re, nil
+}
+
+// patternText returns text value matched by a pattern
+func patternText(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	case Char:
+		return t.String(), nil
+	case Enum:
+		return t.label, nil
+	case UUID:
+		return t.String(), nil
+	}
+	return "", fmt.Errorf("operator does not exist: %T ~~ text, pattern matching requires text", v)
+}
+
+// likeToRegex converts LIKE pattern to an anchored regular expression
+func likeToRegex(pattern, escape string) (string, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escape != "" && string(r) == escape:
+			if i+1 >= len(runes) {
+				return "", fmt.Errorf("LIKE pattern must not end with escape character")
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+// similarToRegex converts SIMILAR TO pattern to an anchored regular expression
+func similarToRegex(pattern, escape string) (string, error) {
+	var b strings.Builder
+	b.WriteString("^(?:")
+
+	runes := []rune(pattern)
+	inBracket := false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escape != "" && string(r) == escape:
+			if i+1 >= len(runes) {
+				return "", fmt.Errorf("SIMILAR TO pattern must not end with escape character")
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case inBracket:
+			if r == ']' {
+				inBracket = false
+			}
+			b.WriteRune(r)
+		case r == '[':
+			inBracket = true
+			b.WriteRune(r)
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		case strings.ContainsRune("|*+?{}()", r):
+			b.WriteRune(r)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString(")$")
+	return b.String(), nil
+}
+
+func (p *PatternPredicate) Left() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *PatternPredicate) Right() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *PatternPredicate) Relation() string {
+	return commonRelation(p.left, p.pattern)
+}
+
+func (p *PatternPredicate) Attribute() []string {
+	attrs := append(p.left.Attribute(), p.pattern.Attribute()...)
+	if p.escape != nil {
+		attrs = append(attrs, p.escape.Attribute()...)
+	}
+	return attrs
+}
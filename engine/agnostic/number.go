@@ -0,0 +1,291 @@
+package agnostic
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// integerRange returns bounds of values of integer type typeName
+func integerRange(typeName string) (min int64, max int64, ok bool) {
+	switch strings.ToLower(typeName) {
+	case "smallint", "int2", "smallserial":
+		return math.MinInt16, math.MaxInt16, true
+	case "int", "integer", "int4", "serial":
+		return math.MinInt32, math.MaxInt32, true
+	case "bigint", "int8", "bigserial":
+		return math.MinInt64, math.MaxInt64, true
+	}
+	return 0, 0, false
+}
+
+// isFloatType returns true if typeName is a floating point type
+func isFloatType(typeName string) bool {
+	switch strings.ToLower(typeName) {
+	case "float", "float8", "double", "double precision", "real", "float4":
+		return true
+	}
+	return false
+}
+
+// coerceInteger converts val to an int64 in range of attribute integer type.
+// Fractional values are rounded to nearest integer.
+func coerceInteger(relation string, attr Attribute, val any, min, max int64) (any, error) {
+	outOfRange := fmt.Errorf("cannot assign '%v' to %s.%s: %s out of range", val, relation, attr.name, sqlTypeName(attr.typeName))
+
+	var i int64
+	switch t := val.(type) {
+	case int:
+		i = int64(t)
+	case int8:
+		i = int64(t)
+	case int16:
+		i = int64(t)
+	case int32:
+		i = int64(t)
+	case int64:
+		i = t
+	case uint:
+		if uint64(t) > math.MaxInt64 {
+			return nil, outOfRange
+		}
+		i = int64(t)
+	case uint8:
+		i = int64(t)
+	case uint16:
+		i = int64(t)
+	case uint32:
+		i = int64(t)
+	case uint64:
+		if t > math.MaxInt64 {
+			return nil, outOfRange
+		}
+		i = int64(t)
+	case float32:
+		return coerceInteger(relation, attr, float64(t), min, max)
+	case float64:
+		f := math.Round(t)
+		if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return nil, outOfRange
+		}
+		i = int64(f)
+	case Decimal:
+		r := t.Round(0).unscaled
+		if !r.IsInt64() {
+			return nil, outOfRange
+		}
+		i = r.Int64()
+	case string:
+		v, err := strconv.ParseInt(strings.TrimSpace(t), 10, 64)
+		if err != nil {
+			if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+				return nil, outOfRange
+			}
+			return nil, fmt.Errorf("cannot assign '%v' to %s.%s: invalid input syntax for type %s: \"%v\"", val, relation, attr.name, sqlTypeName(attr.typeName), val)
+		}
+		i = v
+	default:
+		return nil, fmt.Errorf("cannot assign '%v' (type %T) to %s.%s (type %s)", val, val, relation, attr.name, attr.typeName)
+	}
+
+	if i < min || i > max {
+		return nil, outOfRange
+	}
+	return i, nil
+}
+
+// coerceFloat converts val to a float64, checking REAL values fit in single precision
+func coerceFloat(relation string, attr Attribute, val any) (any, error) {
+	var f float64
+	switch t := val.(type) {
+	case Decimal:
+		f = t.Float64()
+	case string:
+		v, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot assign '%v' to %s.%s: invalid input syntax for type %s: \"%v\"", val, relation, attr.name, sqlTypeName(attr.typeName), val)
+		}
+		f = v
+	default:
+		tof := reflect.TypeOf(val)
+		if !tof.ConvertibleTo(attr.typeInstance) {
+			return nil, fmt.Errorf("cannot assign '%v' (type %T) to %s.%s (type %s)", val, val, relation, attr.name, attr.typeName)
+		}
+		f = reflect.ValueOf(val).Convert(attr.typeInstance).Float()
+	}
+
+	switch strings.ToLower(attr.typeName) {
+	case "real", "float4":
+		if !math.IsInf(f, 0) && math.Abs(f) > math.MaxFloat32 {
+			return nil, fmt.Errorf("cannot assign '%v' to %s.%s: value out of range: overflow", val, relation, attr.name)
+		}
+	}
+	return f, nil
+}
+
+// toFloat converts any numeric value, or numeric text, to float64
+func toFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case Decimal:
+		return t.Float64(), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.CanInt():
+		return float64(rv.Int()), true
+	case rv.CanUint():
+		return float64(rv.Uint()), true
+	case rv.CanFloat():
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// numberArg returns v as int64, float64 or Decimal. Numeric text is read as float64.
+func numberArg(v any) (any, error) {
+	switch t := v.(type) {
+	case Decimal:
+		return t, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input syntax for type double precision: \"%s\"", t)
+		}
+		return f, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.CanInt():
+		return rv.Int(), nil
+	case rv.CanUint():
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("bigint out of range")
+		}
+		return int64(rv.Uint()), nil
+	case rv.CanFloat():
+		return rv.Float(), nil
+	}
+	return nil, fmt.Errorf("argument must be a number, got %v (type %T)", v, v)
+}
+
+// abs implements abs(number)
+func abs(args []any) (any, error) {
+	n, err := numberArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := n.(type) {
+	case int64:
+		if t == math.MinInt64 {
+			return nil, fmt.Errorf("bigint out of range")
+		}
+		if t < 0 {
+			return -t, nil
+		}
+		return t, nil
+	case float64:
+		return math.Abs(t), nil
+	}
+	d := n.(Decimal)
+	return Decimal{unscaled: new(big.Int).Abs(d.int()), scale: d.scale}, nil
+}
+
+// round implements round(number [, digits]), rounding half away from zero.
+// Floats rounded to digits are rounded as numeric.
+func round(args []any) (any, error) {
+	n, err := numberArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var digits int64
+	if len(args) > 1 {
+		if digits, err = intArg(args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	// rounding to tens or more leaves no digit after decimal point
+	roundDecimal := func(d Decimal) Decimal {
+		r := d.Round(int(digits))
+		if digits < 0 {
+			r = r.rescale(0)
+		}
+		return r
+	}
+
+	switch t := n.(type) {
+	case int64:
+		if digits >= 0 {
+			return t, nil
+		}
+		d, _ := ToDecimal(t)
+		return roundDecimal(d).int().Int64(), nil
+	case float64:
+		if len(args) == 1 {
+			return math.Round(t), nil
+		}
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return t, nil
+		}
+		d, err := ToDecimal(t)
+		if err != nil {
+			return nil, err
+		}
+		return roundDecimal(d).Float64(), nil
+	}
+	return roundDecimal(n.(Decimal)), nil
+}
+
+// roundingFunction returns an implementation of ceil or floor(number),
+// rounding toward positive infinity if up is true, toward negative infinity otherwise
+func roundingFunction(up bool, f func(float64) float64) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		n, err := numberArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := n.(type) {
+		case int64:
+			return t, nil
+		case float64:
+			return f(t), nil
+		}
+
+		d := n.(Decimal)
+		r := d.rescale(0)
+		if r.Cmp(d) == 0 {
+			return r, nil
+		}
+		// rescale truncates toward zero
+		if up && d.Sign() > 0 {
+			r.unscaled.Add(r.unscaled, big.NewInt(1))
+		}
+		if !up && d.Sign() < 0 {
+			r.unscaled.Sub(r.unscaled, big.NewInt(1))
+		}
+		return r, nil
+	}
+}
+
+// mod implements mod(number, number), the remainder of integer division
+func mod(args []any) (any, error) {
+	var values [2]any
+	for i := range values {
+		n, err := numberArg(args[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = n
+	}
+	return multiplicativeArithmetic('%', values[0], values[1])
+}
@@ -30,6 +30,21 @@ const (
 	Not
 	True
 	False
+	Contains
+	ContainedBy
+	HasKey
+	Overlap
+	Any
+	All
+	ILike
+	SimilarTo
+	Match
+	IMatch
+	NotMatch
+	NotIMatch
+	Between
+	Distinct
+	NotDistinct
 )
 
 var (
@@ -47,12 +62,38 @@ type Picker interface {
 	Attribute() []string
 }
 
+// commonRelation returns the relation all pickers depend on, constant ones being ignored.
+// It returns an empty string if they depend on different relations, like payments.user_id = account.id.
+func commonRelation(pickers ...Picker) string {
+	var rel string
+	for _, p := range pickers {
+		r := p.Relation()
+		if r == "" {
+			// picker depending on different relations
+			if len(p.Attribute()) > 0 {
+				return ""
+			}
+			continue
+		}
+		if rel != "" && r != rel {
+			return ""
+		}
+		rel = r
+	}
+	return rel
+}
+
 // ValueFunctor is used by Predicate to compare values
 //
 // Possible ValueFunctor implementation:
 //   - ConstValueFunctor
 //   - AttributeValueFunctor
 //   - NowValueFunctor
+//   - JSONFieldValueFunctor
+//   - JSONPathValueFunctor
+//   - ConcatValueFunctor
+//   - FunctionValueFunctor
+//   - AggregateValueFunctor
 type ValueFunctor interface {
 	Picker
 	Value(columns []string, tuple *Tuple) any
@@ -63,9 +104,8 @@ type ValueFunctor interface {
 // Possible Selector implementations:
 //   - Attribute
 //   - Star
-//   - Max
-//   - Min
-//   - Avg
+//   - Count
+//   - Functor, computing expressions and aggregates like sum(amount)
 //   - ...
 type Selector interface {
 	Picker
@@ -134,11 +174,11 @@ func (sn *SubqueryNode) Children() []Node {
 //
 // Should be able to estimate cardinality of join for cost optimization.
 //
-// Possible implementations:
-//   - NaturalJoiner
-//   - LeftOuterJoiner
-//   - RightOuterJoiner
-//   - FullOuterJoiner
+// Implementations:
+//   - NaturalJoin
+//   - OuterJoin, either LEFT, RIGHT or FULL
+//   - NestedLoopJoin, on any condition or none for CROSS JOIN
+//   - HashJoin and MergeJoin, chosen by the planner for equality joins of large relations
 type Joiner interface {
 	Node
 	Left() string
@@ -175,7 +215,7 @@ type Scanner interface {
 //
 // GroupBy (-10000) before Having (-5000) before Order (0) before Distinct (1000) before Offset (5000) before Limit (10000).
 //
-// # GroupBy must contains both selector node and last join to compute arithmetic on all groups
+// GroupBy gathers rows of each group in a single tuple, on which following sorters and selectors compute aggregates.
 //
 // Possible implementations:
 //   - OrderAscSort
@@ -251,30 +291,83 @@ func (s *OffsetSorter) SetNode(n Node) {
 	s.src = n
 }
 
+// GroupBySorter gathers rows sharing the same values of keys in a single tuple per group.
+// Aggregates, like sum(amount), are then computed on rows of each group by selectors, Having and OrderBy.
+// Without keys, all rows form a single group, even if there is none.
 type GroupBySorter struct {
-	rel      string
-	attrs    []string
-	src      Node
-	selector Node
+	rel  string
+	keys []ValueFunctor
+	src  Node
 }
 
-func NewGroupBySorter(rel string, attrs []string) *GroupBySorter {
-	return &GroupBySorter{rel: rel, attrs: attrs}
+// NewGroupBySorter creates a Sorter grouping rows on values computed by keys, like GROUP BY user_id, date_trunc('day', created_at)
+func NewGroupBySorter(rel string, keys []ValueFunctor) *GroupBySorter {
+	return &GroupBySorter{rel: rel, keys: keys}
 }
 
 func (s GroupBySorter) String() string {
-	return fmt.Sprintf("GroupBy %s.%v", s.rel, s.attrs)
+	return fmt.Sprintf("GroupBy %s.%v", s.rel, s.keys)
 }
 
 func (s *GroupBySorter) Exec() ([]string, []*list.Element, error) {
-	cols, res, err := s.src.Exec()
+	cols, in, err := s.src.Exec()
 	if err != nil {
 		return nil, nil, err
 	}
 
+	groups := make(map[string]*Tuple)
+	var res []*list.Element
+	l := list.New()
+	for _, e := range in {
+		t := e.Value.(*Tuple)
+		key, err := s.key(cols, t)
+		if err != nil {
+			return nil, nil, err
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &Tuple{values: t.values, group: []*Tuple{}}
+			groups[key] = g
+			res = append(res, l.PushBack(g))
+		}
+		g.group = append(g.group, t)
+	}
+
+	if len(s.keys) == 0 && len(res) == 0 {
+		res = append(res, l.PushBack(&Tuple{values: make([]any, len(cols)), group: []*Tuple{}}))
+	}
+
 	return cols, res, nil
 }
 
+// key returns a string identifying group of t. NULL values are grouped together.
+func (s *GroupBySorter) key(cols []string, t *Tuple) (string, error) {
+	var b strings.Builder
+	for _, k := range s.keys {
+		v, err := evalFunctor(k, cols, t)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(valueKey(v))
+		b.WriteString(";")
+	}
+	return b.String(), nil
+}
+
+// valueKey returns a string identifying v, equal numerics having the same key whatever their scale
+func valueKey(v any) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case Decimal:
+		return fmt.Sprintf("%T:%s", v, v.canonical())
+	case JSON:
+		return fmt.Sprintf("%T:%s", v, v.comparable())
+	default:
+		return fmt.Sprintf("%T:%v", v, v)
+	}
+}
+
 func (s *GroupBySorter) EstimateCardinal() int64 {
 	if s.src != nil {
 		return int64(s.src.EstimateCardinal()/2) + 1
@@ -287,15 +380,63 @@ func (s *GroupBySorter) Children() []Node {
 }
 
 func (s *GroupBySorter) Priority() int {
-	return 0
+	return -10000
 }
 
 func (s *GroupBySorter) SetNode(n Node) {
 	s.src = n
 }
 
-func (s *GroupBySorter) SetSelector(n Node) {
-	s.selector = n
+// HavingSorter keeps groups built by GroupBySorter for which predicate is true
+type HavingSorter struct {
+	p   Predicate
+	src Node
+}
+
+func NewHavingSorter(p Predicate) *HavingSorter {
+	return &HavingSorter{p: p}
+}
+
+func (s HavingSorter) String() string {
+	return fmt.Sprintf("Having %s", s.p)
+}
+
+func (s *HavingSorter) Exec() ([]string, []*list.Element, error) {
+	cols, in, err := s.src.Exec()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res []*list.Element
+	for _, e := range in {
+		tr, err := evalTruth(s.p, cols, e.Value.(*Tuple))
+		if err != nil {
+			return nil, nil, err
+		}
+		if tr == truthTrue {
+			res = append(res, e)
+		}
+	}
+	return cols, res, nil
+}
+
+func (s *HavingSorter) EstimateCardinal() int64 {
+	if s.src != nil {
+		return int64(s.src.EstimateCardinal()/2) + 1
+	}
+	return 0
+}
+
+func (s *HavingSorter) Children() []Node {
+	return []Node{s.src}
+}
+
+func (s *HavingSorter) Priority() int {
+	return -5000
+}
+
+func (s *HavingSorter) SetNode(n Node) {
+	s.src = n
 }
 
 type SortType int
@@ -307,6 +448,7 @@ const (
 
 type SortExpression struct {
 	attr      string
+	f         ValueFunctor
 	direction SortType
 }
 
@@ -314,6 +456,18 @@ func NewSortExpression(attr string, direction SortType) SortExpression {
 	return SortExpression{attr: attr, direction: direction}
 }
 
+// NewSortValueExpression creates a SortExpression ordering tuples by value computed by f, like ORDER BY price * qty
+func NewSortValueExpression(f ValueFunctor, direction SortType) SortExpression {
+	return SortExpression{attr: fmt.Sprint(f), f: f, direction: direction}
+}
+
+func (e SortExpression) String() string {
+	if e.direction == DESC {
+		return e.attr + " DESC"
+	}
+	return e.attr
+}
+
 type OrderBySorter struct {
 	rel   string
 	attrs []SortExpression
@@ -336,23 +490,44 @@ func (s *OrderBySorter) Exec() ([]string, []*list.Element, error) {
 		return nil, nil, err
 	}
 
-	var idxs []int
-	for _, a := range s.attrs {
-		for i, c := range cols {
+	idxs := make([]int, len(s.attrs))
+	for i, a := range s.attrs {
+		idxs[i] = -1
+		for j, c := range cols {
 			if c == a.attr || c == s.rel+"."+a.attr {
-				idxs = append(idxs, i)
+				idxs[i] = j
+				break
 			}
 		}
 	}
 
+	// sort keys are computed once per tuple, expressions being evaluated
+	keys := make(map[*list.Element][]any, len(res))
+	for _, e := range res {
+		t := e.Value.(*Tuple)
+		k := make([]any, len(s.attrs))
+		for i, a := range s.attrs {
+			switch {
+			case a.f != nil:
+				k[i], err = evalFunctor(a.f, cols, t)
+				if err != nil {
+					return nil, nil, err
+				}
+			case idxs[i] >= 0:
+				k[i] = t.values[idxs[i]]
+			}
+		}
+		keys[e] = k
+	}
+
 	closure := func(t1idx, t2idx int) bool {
 		var comp bool
-		t1 := res[t1idx]
-		t2 := res[t2idx]
+		k1 := keys[res[t1idx]]
+		k2 := keys[res[t2idx]]
 
-		for i, idx := range idxs {
-			v1 := t1.Value.(*Tuple).values[idx]
-			v2 := t2.Value.(*Tuple).values[idx]
+		for i := range s.attrs {
+			v1 := k1[i]
+			v2 := k2[i]
 
 			eq, err := equal(v1, v2)
 			if err != nil {
@@ -583,6 +758,11 @@ func (s *AttributeSelector) Select(cols []string, in []*list.Element) (out []*Tu
 				idx[attrIdx] = i
 				break
 			}
+			// joined columns are qualified by relation name, not its alias
+			if s.alias != "" && lc == lrelation+"."+strings.TrimPrefix(lattr, strings.ToLower(s.alias)+".") {
+				idx[attrIdx] = i
+				break
+			}
 		}
 		if idx[attrIdx] == -1 {
 			return nil, fmt.Errorf("AttributeSelector(%s) not found in %s", attr, cols)
@@ -695,6 +875,16 @@ func (s *StarSelector) Alias() string {
 func (s *StarSelector) Select(cols []string, in []*list.Element) (out []*Tuple, err error) {
 	var colIdx []int
 
+	// values of window functions, appended by WindowSorter, are not attributes
+	n := len(cols)
+	for i, c := range cols {
+		if isWindowColumn(c) {
+			n = i
+			break
+		}
+	}
+	cols = cols[:n]
+
 	// if only 1 relation, can return directly
 	for i, c := range cols {
 		if !strings.Contains(c, ".") {
@@ -704,6 +894,9 @@ func (s *StarSelector) Select(cols []string, in []*list.Element) (out []*Tuple,
 				if !ok {
 					return nil, fmt.Errorf("provided element list does not contain Tuple")
 				}
+				if len(t.values) > n {
+					t = &Tuple{values: t.values[:n]}
+				}
 				out[i] = t
 			}
 			s.cols = cols
@@ -730,12 +923,6 @@ func (s StarSelector) String() string {
 	return s.relation + ".*"
 }
 
-type AvgSelector struct {
-}
-
-type MaxSelector struct {
-}
-
 func NewComparisonPredicate(left ValueFunctor, t PredicateType, right ValueFunctor) (Predicate, error) {
 
 	switch t {
@@ -751,6 +938,26 @@ func NewComparisonPredicate(left ValueFunctor, t PredicateType, right ValueFunct
 		return NewGePredicate(left, right), nil
 	case Neq:
 		return NewNeqPredicate(left, right), nil
+	case Contains:
+		return NewContainsPredicate(left, right), nil
+	case ContainedBy:
+		return NewContainsPredicate(right, left), nil
+	case HasKey:
+		return NewHasKeyPredicate(left, right), nil
+	case Overlap:
+		return NewOverlapPredicate(left, right), nil
+	case Like, ILike:
+		return NewLikePredicate(left, right, nil, t == ILike)
+	case SimilarTo:
+		return NewSimilarToPredicate(left, right, nil)
+	case Match, IMatch:
+		return NewRegexPredicate(left, right, t == IMatch)
+	case NotMatch, NotIMatch:
+		return NewNotRegexPredicate(left, right, t == NotIMatch)
+	case Distinct:
+		return NewDistinctPredicate(left, right), nil
+	case NotDistinct:
+		return NewNotDistinctPredicate(left, right), nil
 	default:
 		return nil, fmt.Errorf("unknown predicate type %v", t)
 	}
@@ -774,13 +981,16 @@ func (p *NotPredicate) Type() PredicateType {
 }
 
 func (p *NotPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
 
-	e, err := p.src.Eval(cols, t)
+func (p *NotPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	e, err := evalTruth(p.src, cols, t)
 	if err != nil {
-		return false, err
+		return truthFalse, err
 	}
 
-	return !e, nil
+	return e.not(), nil
 }
 
 func (p *NotPredicate) Left() (Predicate, bool) {
@@ -820,11 +1030,16 @@ func (p *InPredicate) Type() PredicateType {
 }
 
 func (p *InPredicate) Eval(inCols []string, in *Tuple) (bool, error) {
+	return isTrue(p.truth(inCols, in))
+}
+
+// truth is true if value equals one of the listed values, unknown if it is NULL or if none matched but a NULL is listed
+func (p *InPredicate) truth(inCols []string, in *Tuple) (truth, error) {
 
 	if p.res == nil {
 		cols, res, err := p.src.Exec()
 		if err != nil {
-			return false, err
+			return truthFalse, err
 		}
 		p.cols = cols
 		for _, e := range res {
@@ -832,20 +1047,31 @@ func (p *InPredicate) Eval(inCols []string, in *Tuple) (bool, error) {
 		}
 	}
 
-	lv := p.v.Value(inCols, in)
+	lv, err := evalFunctor(p.v, inCols, in)
+	if err != nil {
+		return truthFalse, err
+	}
+	if lv == nil {
+		return truthUnknown, nil
+	}
 
+	result := truthFalse
 	for _, t := range p.res {
 		rv := t.values[0]
+		if rv == nil {
+			result = truthUnknown
+			continue
+		}
 		eq, err := equal(lv, rv)
 		if eq {
-			return true, nil
+			return truthTrue, nil
 		}
 		if err != nil {
-			return false, err
+			return truthFalse, err
 		}
 	}
 
-	return false, nil
+	return result, nil
 }
 
 func (p *InPredicate) Left() (Predicate, bool) {
@@ -865,12 +1091,19 @@ func (p *InPredicate) Attribute() []string {
 }
 
 type TruePredicate struct {
+	rel string
 }
 
 func NewTruePredicate() *TruePredicate {
 	return &TruePredicate{}
 }
 
+// NewRelationTruePredicate creates a TruePredicate on given relation, so that it is scanned
+// even if no other predicate or selector references it, like in UPDATE without WHERE clause
+func NewRelationTruePredicate(rel string) *TruePredicate {
+	return &TruePredicate{rel: rel}
+}
+
 func (p TruePredicate) String() string {
 	return "TRUE"
 }
@@ -892,7 +1125,7 @@ func (p *TruePredicate) Right() (Predicate, bool) {
 }
 
 func (p *TruePredicate) Relation() string {
-	return ""
+	return p.rel
 }
 
 func (p *TruePredicate) Attribute() []string {
@@ -957,22 +1190,21 @@ func (p *AndPredicate) Type() PredicateType {
 }
 
 func (p *AndPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
 
-	l, err := p.left.Eval(cols, t)
+func (p *AndPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	l, err := evalTruth(p.left, cols, t)
 	if err != nil {
-		return false, err
+		return truthFalse, err
 	}
 
-	r, err := p.right.Eval(cols, t)
+	r, err := evalTruth(p.right, cols, t)
 	if err != nil {
-		return false, err
+		return truthFalse, err
 	}
 
-	if l && r {
-		return true, nil
-	}
-
-	return false, nil
+	return l.and(r), nil
 }
 
 func (p *AndPredicate) Left() (Predicate, bool) {
@@ -1017,22 +1249,21 @@ func (p *OrPredicate) Type() PredicateType {
 }
 
 func (p *OrPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
 
-	l, err := p.left.Eval(cols, t)
+func (p *OrPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	l, err := evalTruth(p.left, cols, t)
 	if err != nil {
-		return false, err
+		return truthFalse, err
 	}
 
-	r, err := p.right.Eval(cols, t)
+	r, err := evalTruth(p.right, cols, t)
 	if err != nil {
-		return false, err
-	}
-
-	if l || r {
-		return true, nil
+		return truthFalse, err
 	}
 
-	return false, nil
+	return l.or(r), nil
 }
 
 func (p *OrPredicate) Left() (Predicate, bool) {
@@ -1044,7 +1275,17 @@ func (p *OrPredicate) Right() (Predicate, bool) {
 }
 
 func (p *OrPredicate) Relation() string {
-	if p.left != nil && p.right != nil && p.left.Relation() == p.right.Relation() {
+	if p.left == nil || p.right == nil {
+		return ""
+	}
+
+	switch {
+	case p.left.Relation() == p.right.Relation():
+		return p.left.Relation()
+	// a constant operand does not prevent filtering on the relation of the other one
+	case isConstantPredicate(p.left):
+		return p.right.Relation()
+	case isConstantPredicate(p.right):
 		return p.left.Relation()
 	}
 	return ""
@@ -1077,11 +1318,11 @@ func (p EqPredicate) String() string {
 }
 
 func (p *EqPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
 
-	vl := p.left.Value(cols, t)
-	vr := p.right.Value(cols, t)
-
-	return equal(vl, vr)
+func (p *EqPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	return compareTruth(p.left, p.right, cols, t, equal)
 }
 
 func (p *EqPredicate) Left() (Predicate, bool) {
@@ -1093,11 +1334,7 @@ func (p *EqPredicate) Right() (Predicate, bool) {
 }
 
 func (p *EqPredicate) Relation() string {
-	if p.left.Relation() != "" {
-		return p.left.Relation()
-	}
-
-	return p.right.Relation()
+	return commonRelation(p.left, p.right)
 }
 
 func (p *EqPredicate) Attribute() []string {
@@ -1168,6 +1405,11 @@ func (sn *SelectorNode) Exec() ([]string, []*list.Element, error) {
 	if len(sn.selectors) == 0 {
 		return cols, srcs, nil
 	}
+	for _, selector := range sn.selectors {
+		if returnsSet(selector) {
+			return sn.execSet(cols, srcs)
+		}
+	}
 
 	outs := make([][]*Tuple, len(sn.selectors))
 	var resc []string
@@ -1210,6 +1452,50 @@ func (sn *SelectorNode) Exec() ([]string, []*list.Element, error) {
 	return resc, res, nil
 }
 
+// execSet evaluates selectors row by row. Each source row produces as many rows
+// as the longest set returned by set returning selectors, shorter sets being padded with NULL.
+func (sn *SelectorNode) execSet(cols []string, srcs []*list.Element) ([]string, []*list.Element, error) {
+	var res []*list.Element
+	rl := list.New()
+
+	for _, src := range srcs {
+		outs := make([][]*Tuple, len(sn.selectors))
+		var n int
+		for i, selector := range sn.selectors {
+			out, err := selector.Select(cols, []*list.Element{src})
+			if err != nil {
+				return nil, nil, err
+			}
+			outs[i] = out
+			if returnsSet(selector) && len(out) > n {
+				n = len(out)
+			}
+		}
+
+		for row := 0; row < n; row++ {
+			t := &Tuple{}
+			for i, selector := range sn.selectors {
+				switch {
+				case !returnsSet(selector):
+					t.values = append(t.values, outs[i][0].values...)
+				case row < len(outs[i]):
+					t.values = append(t.values, outs[i][row].values...)
+				default:
+					t.values = append(t.values, make([]any, len(selector.Attribute()))...)
+				}
+			}
+			res = append(res, rl.PushBack(t))
+		}
+	}
+
+	var resc []string
+	for _, selector := range sn.selectors {
+		resc = append(resc, selector.Attribute()...)
+	}
+
+	return resc, res, nil
+}
+
 func (sn *SelectorNode) Columns() []string {
 	return sn.columns
 }
@@ -1280,14 +1566,7 @@ func (j *NaturalJoin) Exec() ([]string, []*list.Element, error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	var lidx int
-	lidx = -1
-	for i, c := range lcols {
-		if c == j.lefta || c == j.leftr+"."+j.lefta {
-			lidx = i
-			break
-		}
-	}
+	lidx := joinColumn(lcols, j.leftr, j.lefta)
 	if lidx == -1 {
 		return nil, nil, fmt.Errorf("%s: columns not found in left node", j)
 	}
@@ -1297,37 +1576,13 @@ func (j *NaturalJoin) Exec() ([]string, []*list.Element, error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	var ridx int
-	ridx = -1
-	for i, c := range rcols {
-		if c == j.righta || c == j.rightr+"."+j.righta {
-			ridx = i
-			break
-		}
-	}
+	ridx := joinColumn(rcols, j.rightr, j.righta)
 	if ridx == -1 {
 		return nil, nil, fmt.Errorf("%s: columns not found in right node", j)
 	}
 	log.Debug("NaturalJoin.Exec: Found right (%s) %d in %v", j.righta, ridx, rcols)
 
-	cols := make([]string, len(lcols)+len(rcols))
-	var idx int
-	for _, c := range lcols {
-		if strings.Contains(c, ".") {
-			cols[idx] = c
-		} else {
-			cols[idx] = j.leftr + "." + c
-		}
-		idx++
-	}
-	for _, c := range rcols {
-		if strings.Contains(c, ".") {
-			cols[idx] = c
-		} else {
-			cols[idx] = j.rightr + "." + c
-		}
-		idx++
-	}
+	cols := joinColumns(j.leftr, lcols, j.rightr, rcols)
 
 	log.Debug("NaturalJoin.Exec: New cols: %v", cols)
 
@@ -1335,7 +1590,12 @@ func (j *NaturalJoin) Exec() ([]string, []*list.Element, error) {
 	l := list.New()
 	for _, left := range lefts {
 		for _, right := range rights {
-			ok, err := equal(left.Value.(*Tuple).values[lidx], right.Value.(*Tuple).values[ridx])
+			lv, rv := left.Value.(*Tuple).values[lidx], right.Value.(*Tuple).values[ridx]
+			if lv == nil || rv == nil {
+				// NULL never joins
+				continue
+			}
+			ok, err := equal(lv, rv)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -1346,7 +1606,7 @@ func (j *NaturalJoin) Exec() ([]string, []*list.Element, error) {
 			}
 		}
 	}
-	idx = 0
+	idx := 0
 	res := make([]*list.Element, l.Len())
 	for e := l.Front(); e != nil; e = e.Next() {
 		res[idx] = e
@@ -1473,17 +1733,20 @@ func (p GeqPredicate) String() string {
 }
 
 func (p *GeqPredicate) Eval(cols []string, t *Tuple) (bool, error) {
-	vl := p.left.Value(cols, t)
-	l := reflect.ValueOf(vl)
-	vr := p.right.Value(cols, t)
-	r := reflect.ValueOf(vr)
+	return isTrue(p.truth(cols, t))
+}
 
-	if vl == nil && vr == nil {
-		return true, nil
-	}
-	if vl == nil || vr == nil {
-		return false, nil
+func (p *GeqPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	return compareTruth(p.left, p.right, cols, t, p.compare)
+}
+
+func (p *GeqPredicate) compare(vl, vr any) (bool, error) {
+	vl, vr, err := normalize(vl, vr)
+	if err != nil {
+		return false, err
 	}
+	l := reflect.ValueOf(vl)
+	r := reflect.ValueOf(vr)
 
 	switch l.Kind() {
 	default:
@@ -1513,7 +1776,7 @@ func (p *GeqPredicate) Eval(cols []string, t *Tuple) (bool, error) {
 			if !ok {
 				return false, fmt.Errorf("%s not comparable", p)
 			}
-			return ltime.Unix() >= rtime.Unix(), nil
+			return !ltime.Before(rtime), nil
 		default:
 			return false, fmt.Errorf("%s not comparable", p)
 		}
@@ -1529,11 +1792,7 @@ func (p *GeqPredicate) Right() (Predicate, bool) {
 }
 
 func (p *GeqPredicate) Relation() string {
-	if p.left.Relation() != "" {
-		return p.left.Relation()
-	}
-
-	return p.right.Relation()
+	return commonRelation(p.left, p.right)
 }
 
 func (p *GeqPredicate) Attribute() []string {
@@ -1563,17 +1822,20 @@ func (p LeqPredicate) String() string {
 }
 
 func (p *LeqPredicate) Eval(cols []string, t *Tuple) (bool, error) {
-	vl := p.left.Value(cols, t)
-	l := reflect.ValueOf(vl)
-	vr := p.right.Value(cols, t)
-	r := reflect.ValueOf(vr)
+	return isTrue(p.truth(cols, t))
+}
 
-	if vl == nil && vr == nil {
-		return true, nil
-	}
-	if vl == nil || vr == nil {
-		return false, nil
+func (p *LeqPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	return compareTruth(p.left, p.right, cols, t, p.compare)
+}
+
+func (p *LeqPredicate) compare(vl, vr any) (bool, error) {
+	vl, vr, err := normalize(vl, vr)
+	if err != nil {
+		return false, err
 	}
+	l := reflect.ValueOf(vl)
+	r := reflect.ValueOf(vr)
 
 	switch l.Kind() {
 	default:
@@ -1603,7 +1865,7 @@ func (p *LeqPredicate) Eval(cols []string, t *Tuple) (bool, error) {
 			if !ok {
 				return false, fmt.Errorf("%s not comparable", p)
 			}
-			return ltime.Unix() <= rtime.Unix(), nil
+			return !ltime.After(rtime), nil
 		default:
 			return false, fmt.Errorf("%s not comparable", p)
 		}
@@ -1619,11 +1881,7 @@ func (p *LeqPredicate) Right() (Predicate, bool) {
 }
 
 func (p *LeqPredicate) Relation() string {
-	if p.left.Relation() != "" {
-		return p.left.Relation()
-	}
-
-	return p.right.Relation()
+	return commonRelation(p.left, p.right)
 }
 
 func (p *LeqPredicate) Attribute() []string {
@@ -1653,17 +1911,20 @@ func (p LePredicate) String() string {
 }
 
 func (p *LePredicate) Eval(cols []string, t *Tuple) (bool, error) {
-	vl := p.left.Value(cols, t)
-	l := reflect.ValueOf(vl)
-	vr := p.right.Value(cols, t)
-	r := reflect.ValueOf(vr)
+	return isTrue(p.truth(cols, t))
+}
 
-	if vl == nil && vr == nil {
-		return false, nil
-	}
-	if vl == nil || vr == nil {
-		return false, nil
+func (p *LePredicate) truth(cols []string, t *Tuple) (truth, error) {
+	return compareTruth(p.left, p.right, cols, t, p.compare)
+}
+
+func (p *LePredicate) compare(vl, vr any) (bool, error) {
+	vl, vr, err := normalize(vl, vr)
+	if err != nil {
+		return false, err
 	}
+	l := reflect.ValueOf(vl)
+	r := reflect.ValueOf(vr)
 
 	switch l.Kind() {
 	default:
@@ -1709,11 +1970,7 @@ func (p *LePredicate) Right() (Predicate, bool) {
 }
 
 func (p *LePredicate) Relation() string {
-	if p.left.Relation() != "" {
-		return p.left.Relation()
-	}
-
-	return p.right.Relation()
+	return commonRelation(p.left, p.right)
 }
 
 func (p *LePredicate) Attribute() []string {
@@ -1743,11 +2000,14 @@ func (p GePredicate) String() string {
 }
 
 func (p *GePredicate) Eval(cols []string, t *Tuple) (bool, error) {
-	vl := p.left.Value(cols, t)
-	//	l := reflect.ValueOf(vl)
-	vr := p.right.Value(cols, t)
-	//	r := reflect.ValueOf(vr)
+	return isTrue(p.truth(cols, t))
+}
 
+func (p *GePredicate) truth(cols []string, t *Tuple) (truth, error) {
+	return compareTruth(p.left, p.right, cols, t, p.compare)
+}
+
+func (p *GePredicate) compare(vl, vr any) (bool, error) {
 	return greater(vl, vr)
 }
 
@@ -1797,11 +2057,7 @@ func (p *GePredicate) Right() (Predicate, bool) {
 }
 
 func (p *GePredicate) Relation() string {
-	if p.left.Relation() != "" {
-		return p.left.Relation()
-	}
-
-	return p.right.Relation()
+	return commonRelation(p.left, p.right)
 }
 
 func (p *GePredicate) Attribute() []string {
@@ -1831,17 +2087,20 @@ func (p NeqPredicate) String() string {
 }
 
 func (p *NeqPredicate) Eval(cols []string, t *Tuple) (bool, error) {
-	vl := p.left.Value(cols, t)
-	l := reflect.ValueOf(vl)
-	vr := p.right.Value(cols, t)
-	r := reflect.ValueOf(vr)
+	return isTrue(p.truth(cols, t))
+}
 
-	if vl == nil && vr == nil {
-		return false, nil
-	}
-	if vl == nil || vr == nil {
-		return true, nil
+func (p *NeqPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	return compareTruth(p.left, p.right, cols, t, p.compare)
+}
+
+func (p *NeqPredicate) compare(vl, vr any) (bool, error) {
+	vl, vr, err := normalize(vl, vr)
+	if err != nil {
+		return false, err
 	}
+	l := reflect.ValueOf(vl)
+	r := reflect.ValueOf(vr)
 
 	if l.Kind() == r.Kind() {
 		return !l.Equal(r), nil
@@ -1887,7 +2146,7 @@ func (p *NeqPredicate) Eval(cols []string, t *Tuple) (bool, error) {
 			if !ok {
 				return false, fmt.Errorf("%s not comparable", p)
 			}
-			return ltime.Unix() != rtime.Unix(), nil
+			return !ltime.Equal(rtime), nil
 		default:
 			return false, fmt.Errorf("%s not comparable", p)
 		}
@@ -1903,18 +2162,136 @@ func (p *NeqPredicate) Right() (Predicate, bool) {
 }
 
 func (p *NeqPredicate) Relation() string {
-	if p.left.Relation() != "" {
-		return p.left.Relation()
-	}
-
-	return p.right.Relation()
+	return commonRelation(p.left, p.right)
 }
 
 func (p *NeqPredicate) Attribute() []string {
 	return append(p.left.Attribute(), p.right.Attribute()...)
 }
 
+// normalize converts comparison operands to comparable representations.
+// UUID are compared using their canonical text form, JSON using document semantics.
+// Enums are compared in declaration order, an error being returned for text which is not a label.
+func normalize(vl, vr any) (any, any, error) {
+	if vl == nil || vr == nil {
+		return vl, vr, nil
+	}
+
+	// integers are compared to floats as floats, like extract() results
+	if l, r := reflect.ValueOf(vl), reflect.ValueOf(vr); l.CanFloat() != r.CanFloat() && (l.CanInt() || l.CanUint() || r.CanInt() || r.CanUint()) {
+		lf, lok := toFloat(vl)
+		rf, rok := toFloat(vr)
+		if lok && rok {
+			return lf, rf, nil
+		}
+	}
+
+	switch l := vl.(type) {
+	case UUID:
+		if u, err := ToUUID(vr); err == nil {
+			return l.String(), u.String(), nil
+		}
+	case JSON:
+		if j, err := ToJSON(vr); err == nil {
+			cl, cr := jsonComparable(l, j)
+			return cl, cr, nil
+		}
+	case Decimal:
+		// compared exactly, result is returned as comparable integers
+		if d, err := ToDecimal(vr); err == nil {
+			return int64(l.Cmp(d)), int64(0), nil
+		}
+	case Bytea:
+		// compared byte-wise
+		if b, err := ToBytea(vr); err == nil {
+			return string(l), string(b), nil
+		}
+	case Array:
+		// compared element-wise, result is returned as comparable integers
+		if a, err := ToArray(vr); err == nil {
+			return int64(arrayCompare(l, a)), int64(0), nil
+		}
+	case Char:
+		// trailing spaces are not significant
+		switch r := vr.(type) {
+		case Char:
+			return l.String(), r.String(), nil
+		case string:
+			return l.String(), strings.TrimRight(r, " "), nil
+		}
+	case Enum:
+		// compared in declaration order
+		e, err := l.typ.Value(vr)
+		if err != nil {
+			return nil, nil, err
+		}
+		return l.order(), e.order(), nil
+	case Interval:
+		// compared as microseconds, a month being 30 days
+		if r, err := ToInterval(vr); err == nil {
+			return l.span(), r.span(), nil
+		}
+	case time.Time:
+		// compared with microsecond precision, text may be any date or time literal
+		if r, err := toTime(vr, "time"); err == nil {
+			cl, cr := timeComparable(l, r)
+			return cl, cr, nil
+		}
+	default:
+		switch r := vr.(type) {
+		case UUID:
+			if u, err := ToUUID(vl); err == nil {
+				return u.String(), r.String(), nil
+			}
+		case JSON:
+			if j, err := ToJSON(vl); err == nil {
+				cl, cr := jsonComparable(j, r)
+				return cl, cr, nil
+			}
+		case Decimal:
+			if d, err := ToDecimal(vl); err == nil {
+				return int64(d.Cmp(r)), int64(0), nil
+			}
+		case Bytea:
+			if b, err := ToBytea(vl); err == nil {
+				return string(b), string(r), nil
+			}
+		case Array:
+			if a, err := ToArray(vl); err == nil {
+				return int64(arrayCompare(a, r)), int64(0), nil
+			}
+		case Char:
+			if l, ok := vl.(string); ok {
+				return strings.TrimRight(l, " "), r.String(), nil
+			}
+		case Enum:
+			e, err := r.typ.Value(vl)
+			if err != nil {
+				return nil, nil, err
+			}
+			return e.order(), r.order(), nil
+		case Interval:
+			if l, err := ToInterval(vl); err == nil {
+				return l.span(), r.span(), nil
+			}
+		case time.Time:
+			if l, err := toTime(vl, "time"); err == nil {
+				cl, cr := timeComparable(l, r)
+				return cl, cr, nil
+			}
+		}
+	}
+
+	return vl, vr, nil
+}
+
+// equal returns true if vl and vr are not distinct, NULL being equal to NULL.
+// Comparison predicates treat NULL operands as unknown before calling it.
 func equal(vl, vr any) (bool, error) {
+	vl, vr, err := normalize(vl, vr)
+	if err != nil {
+		return false, err
+	}
 	l := reflect.ValueOf(vl)
 	r := reflect.ValueOf(vr)
 
@@ -1960,7 +2337,7 @@ func equal(vl, vr any) (bool, error) {
 			ltime := vl.(time.Time)
 			rtime, ok := vr.(time.Time)
 			if ok {
-				return ltime.Unix() == rtime.Unix(), nil
+				return ltime.Equal(rtime), nil
 			}
 		}
 	}
@@ -1969,6 +2346,10 @@ func equal(vl, vr any) (bool, error) {
 }
 
 func greater(vl, vr any) (bool, error) {
+	vl, vr, err := normalize(vl, vr)
+	if err != nil {
+		return false, err
+	}
 	l := reflect.ValueOf(vl)
 	r := reflect.ValueOf(vr)
 
@@ -2020,6 +2401,8 @@ type Updater struct {
 	child      Node
 	attributes []Attribute
 	indexes    []Index
+	// check of updated rows, if any
+	check func(*Tuple) error
 }
 
 func NewUpdaterNode(relation *Relation, changes *list.List, values map[string]any) *Updater {
@@ -2035,6 +2418,12 @@ func NewUpdaterNode(relation *Relation, changes *list.List, values map[string]an
 	for k := range values {
 		u.attrs = append(u.attrs, strings.ToLower(k))
 	}
+	// updated keys may not be in order anymore
+	if attr, ok := relation.sortedOn(); ok {
+		if _, ok := values[attr]; ok {
+			relation.ordered = false
+		}
+	}
 	return u
 }
 
@@ -2053,6 +2442,19 @@ func (u *Updater) EstimateCardinal() int64 {
 func (u *Updater) Exec() (cols []string, out []*list.Element, err error) {
 	var in []*list.Element
 
+	for k := range u.values {
+		found := false
+		for _, attr := range u.attributes {
+			if attr.name == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("attribute %s not existing in relation %s, %s", k, u.rel, u.attributes)
+		}
+	}
+
 	cols, in, err = u.child.Exec()
 	if err != nil {
 		return nil, nil, err
@@ -2069,21 +2471,33 @@ func (u *Updater) Exec() (cols []string, out []*list.Element, err error) {
 			nv := v
 			attr := u.attributes[i]
 			if val, ok := u.values[cols[i]]; ok {
-				if val == nil {
-					newt.values[i] = nil
-					delete(u.values, cols[i])
-					continue
+				// value computed from current row, like jsonb_set(attr, ...)
+				if f, ok := val.(ValueFunctor); ok {
+					if ev, ok := f.(Evaluator); ok {
+						val, err = ev.Eval(cols, t)
+						if err != nil {
+							return nil, nil, err
+						}
+					} else {
+						val = f.Value(cols, t)
+					}
+				}
+				nv, err = coerce(u.rel, attr, val)
+				if err == nil {
+					err = checkNotNull(u.rel, attr, nv)
 				}
-				tof := reflect.TypeOf(val)
-				if !tof.ConvertibleTo(attr.typeInstance) {
-					return nil, nil, fmt.Errorf("cannot assign '%v' (type %s) to %s.%s (type %s)", val, tof, u.rel, attr.name, attr.typeInstance)
+				if err != nil {
+					return nil, nil, err
 				}
-				nv = reflect.ValueOf(val).Convert(attr.typeInstance).Interface()
 				log.Debug("Updating %s to %v", attr.name, nv)
 			}
 
 			newt.values[i] = nv
-			delete(u.values, cols[i])
+		}
+		if u.check != nil {
+			if err := u.check(newt); err != nil {
+				return nil, nil, err
+			}
 		}
 
 		newe := u.rows.InsertAfter(newt, e)
@@ -2107,9 +2521,6 @@ func (u *Updater) Exec() (cols []string, out []*list.Element, err error) {
 		u.changes.PushBack(c)
 	}
 
-	if len(u.values) > 0 {
-		return nil, nil, fmt.Errorf("attribute %s not existing in relation %s, %s", u.values, u.rel, u.attributes)
-	}
 	return cols, out, nil
 }
 
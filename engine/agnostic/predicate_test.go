@@ -98,3 +98,24 @@ func TestEqPredicate(t *testing.T) {
 	p = NewEqPredicate(b1, c4)
 	checkEval(t, p, cols, tup, false)
 }
+
+func TestThreeValuedLogic(t *testing.T) {
+	tup := &Tuple{}
+	null := NewConstValueFunctor(nil)
+	one := NewConstValueFunctor(int64(1))
+	unknown := NewEqPredicate(null, one)
+
+	checkEval(t, unknown, nil, tup, false)
+	checkEval(t, NewNotPredicate(unknown), nil, tup, false)
+	checkEval(t, NewEqPredicate(null, null), nil, tup, false)
+	checkEval(t, NewNotPredicate(NewNeqPredicate(null, one)), nil, tup, false)
+
+	checkEval(t, NewNotPredicate(NewAndPredicate(unknown, NewFalsePredicate())), nil, tup, true)
+	checkEval(t, NewNotPredicate(NewAndPredicate(unknown, NewTruePredicate())), nil, tup, false)
+	checkEval(t, NewOrPredicate(unknown, NewTruePredicate()), nil, tup, true)
+	checkEval(t, NewNotPredicate(NewOrPredicate(unknown, NewFalsePredicate())), nil, tup, false)
+
+	checkEval(t, NewIsNullPredicate(null), nil, tup, true)
+	checkEval(t, NewDistinctPredicate(null, one), nil, tup, true)
+	checkEval(t, NewNotDistinctPredicate(null, null), nil, tup, true)
+}
@@ -18,9 +18,15 @@ type Relation struct {
 
 	// list of Tuple
 	rows *list.List
+	// true while rows are stored in ascending order of a single attribute primary key,
+	// as rows with serial keys are
+	ordered bool
 
 	indexes []Index
 
+	// if set, relation is virtual and rows are generated on each scan
+	generator func() []*Tuple
+
 	sync.RWMutex
 }
 
@@ -40,6 +46,7 @@ func NewRelation(schema, name string, attributes []Attribute, pk []string) (*Rel
 	for _, k := range pk {
 		r.pk = append(r.pk, r.attrIndex[k])
 	}
+	r.ordered = len(r.pk) == 1
 
 	// if primary key is specified, create Hash index
 	if len(r.pk) != 0 {
@@ -56,6 +63,22 @@ func NewRelation(schema, name string, attributes []Attribute, pk []string) (*Rel
 	return r, nil
 }
 
+// NewVirtualRelation creates a read-only relation whose rows are produced
+// by generator each time the relation is scanned.
+func NewVirtualRelation(schema, name string, attributes []Attribute, generator func() []*Tuple) (*Relation, error) {
+	r, err := NewRelation(schema, name, attributes, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.generator = generator
+	return r, nil
+}
+
+// IsVirtual returns true if relation rows are generated instead of stored.
+func (r *Relation) IsVirtual() bool {
+	return r.generator != nil
+}
+
 func (r *Relation) CheckPrimaryKey(tuple *Tuple) (bool, error) {
 	if len(r.pk) == 0 {
 		return true, nil
@@ -97,6 +120,28 @@ func (r *Relation) Attribute(name string) (int, Attribute, error) {
 	return index, r.attributes[index], nil
 }
 
+// sortedOn returns the attribute rows are stored in ascending order of, if any
+func (r *Relation) sortedOn() (string, bool) {
+	if !r.ordered || r.generator != nil {
+		return "", false
+	}
+	return r.attributes[r.pk[0]].name, true
+}
+
+// appended keeps track of rows order after tuple was appended to rows
+func (r *Relation) appended(e *list.Element) {
+	prev := e.Prev()
+	if !r.ordered || prev == nil {
+		return
+	}
+	idx := r.pk[0]
+	k, kc := joinKey(e.Value.(*Tuple).values[idx])
+	pk, pc := joinKey(prev.Value.(*Tuple).values[idx])
+	if kc != pc || kc == nullKey || kc == unknownKey || compareKeys(pk, k) >= 0 {
+		r.ordered = false
+	}
+}
+
 func (r *Relation) createIndex(name string, t IndexType, attrs []string) error {
 
 	switch t {
@@ -131,6 +176,7 @@ func (r *Relation) Truncate() int64 {
 	}
 
 	r.rows = list.New()
+	r.ordered = len(r.pk) == 1
 
 	return int64(l)
 }
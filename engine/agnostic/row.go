@@ -0,0 +1,157 @@
+package agnostic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RowPredicate compares row values element-wise, like (created_at, id) > ($1, $2).
+// Rows are equal if all their elements are equal, and ordered by their first elements that are not.
+type RowPredicate struct {
+	ptype PredicateType
+	left  []ValueFunctor
+	right []ValueFunctor
+}
+
+// rowOperators are the comparisons supported between rows
+var rowOperators = map[PredicateType]string{
+	Eq:          "=",
+	Neq:         "<>",
+	Le:          "<",
+	Leq:         "<=",
+	Ge:          ">",
+	Geq:         ">=",
+	Distinct:    "IS DISTINCT FROM",
+	NotDistinct: "IS NOT DISTINCT FROM",
+}
+
+// NewRowComparisonPredicate creates a predicate comparing row left to row right with operator t
+func NewRowComparisonPredicate(left []ValueFunctor, t PredicateType, right []ValueFunctor) (*RowPredicate, error) {
+	if _, ok := rowOperators[t]; !ok {
+		return nil, fmt.Errorf("operator is not supported on row values")
+	}
+	if len(left) != len(right) {
+		return nil, fmt.Errorf("unequal number of entries in row expressions")
+	}
+
+	p := &RowPredicate{
+		ptype: t,
+		left:  left,
+		right: right,
+	}
+	return p, nil
+}
+
+func (p *RowPredicate) Type() PredicateType {
+	return p.ptype
+}
+
+func (p RowPredicate) String() string {
+	row := func(values []ValueFunctor) string {
+		s := make([]string, len(values))
+		for i, v := range values {
+			s[i] = fmt.Sprint(v)
+		}
+		return "(" + strings.Join(s, ", ") + ")"
+	}
+	return fmt.Sprintf("%s %s %s", row(p.left), rowOperators[p.ptype], row(p.right))
+}
+
+func (p *RowPredicate) Eval(cols []string, t *Tuple) (bool, error) {
+	return isTrue(p.truth(cols, t))
+}
+
+func (p *RowPredicate) truth(cols []string, t *Tuple) (truth, error) {
+	vl := make([]any, len(p.left))
+	vr := make([]any, len(p.right))
+	for i := range p.left {
+		l, r, err := evalOperands(p.left[i], p.right[i], cols, t)
+		if err != nil {
+			return truthFalse, err
+		}
+		vl[i], vr[i] = l, r
+	}
+
+	switch p.ptype {
+	case Distinct, NotDistinct:
+		for i := range vl {
+			eq, err := equal(vl[i], vr[i])
+			if err != nil {
+				return truthFalse, err
+			}
+			if !eq {
+				return truthOf(p.ptype == Distinct), nil
+			}
+		}
+		return truthOf(p.ptype == NotDistinct), nil
+	case Eq, Neq:
+		// rows are equal if all elements are, unknown if any element is NULL and none differ
+		result := truthTrue
+		for i := range vl {
+			if vl[i] == nil || vr[i] == nil {
+				result = truthUnknown
+				continue
+			}
+			eq, err := equal(vl[i], vr[i])
+			if err != nil {
+				return truthFalse, err
+			}
+			result = result.and(truthOf(eq))
+		}
+		if p.ptype == Neq {
+			return result.not(), nil
+		}
+		return result, nil
+	}
+
+	// ordering is decided by the first elements which are not equal
+	for i := range vl {
+		if vl[i] == nil || vr[i] == nil {
+			return truthUnknown, nil
+		}
+		eq, err := equal(vl[i], vr[i])
+		if err != nil {
+			return truthFalse, err
+		}
+		if eq {
+			continue
+		}
+		gt, err := greater(vl[i], vr[i])
+		if err != nil {
+			return truthFalse, err
+		}
+		if p.ptype == Ge || p.ptype == Geq {
+			return truthOf(gt), nil
+		}
+		return truthOf(!gt), nil
+	}
+
+	return truthOf(p.ptype == Leq || p.ptype == Geq), nil
+}
+
+func (p *RowPredicate) Left() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *RowPredicate) Right() (Predicate, bool) {
+	return nil, false
+}
+
+func (p *RowPredicate) Relation() string {
+	pickers := make([]Picker, 0, len(p.left)+len(p.right))
+	for _, f := range p.left {
+		pickers = append(pickers, f)
+	}
+	for _, f := range p.right {
+		pickers = append(pickers, f)
+	}
+	return commonRelation(pickers...)
+}
+
+func (p *RowPredicate) Attribute() []string {
+	var attrs []string
+	for _, f := range append(p.left, p.right...) {
+		attrs = append(attrs, f.Attribute()...)
+	}
+	return attrs
+}
@@ -55,6 +55,12 @@ func (s *RelationScanner) Exec() ([]string, []*list.Element, error) {
 	return cols, res, nil
 }
 
+// orderedOn returns true if rows are produced sorted on attribute of relation, filtering keeping source order
+func (s *RelationScanner) orderedOn(rel, attr string) bool {
+	o, ok := s.src.(interface{ orderedOn(string, string) bool })
+	return ok && o.orderedOn(rel, attr)
+}
+
 // No idea on how to estimate cardinal of scanner given predicates
 //
 // min: 0
@@ -2,12 +2,15 @@ package agnostic
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 )
 
 type Schema struct {
 	name      string
 	relations map[string]*Relation
+	types     map[string]*EnumType
+	domains   map[string]*Domain
 
 	sync.RWMutex
 }
@@ -16,6 +19,8 @@ func NewSchema(name string) *Schema {
 	s := &Schema{
 		name:      name,
 		relations: make(map[string]*Relation),
+		types:     make(map[string]*EnumType),
+		domains:   make(map[string]*Domain),
 	}
 
 	return s
@@ -54,3 +59,91 @@ func (s *Schema) Remove(name string) (*Relation, error) {
 	delete(s.relations, name)
 	return r, nil
 }
+
+// Type returns user-defined type name
+func (s *Schema) Type(name string) (*EnumType, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	t, ok := s.types[name]
+	if !ok {
+		return nil, fmt.Errorf("type '%s'.'%s' does not exist", s.name, name)
+	}
+
+	return t, nil
+}
+
+func (s *Schema) AddType(name string, t *EnumType) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.types[name] = t
+}
+
+func (s *Schema) RemoveType(name string) (*EnumType, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	t, ok := s.types[name]
+	if !ok {
+		return nil, fmt.Errorf("type '%s'.'%s' does not exist", s.name, name)
+	}
+
+	delete(s.types, name)
+	return t, nil
+}
+
+// Domain returns domain name
+func (s *Schema) Domain(name string) (*Domain, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	d, ok := s.domains[name]
+	if !ok {
+		return nil, fmt.Errorf("domain '%s'.'%s' does not exist", s.name, name)
+	}
+
+	return d, nil
+}
+
+func (s *Schema) AddDomain(name string, d *Domain) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.domains[name] = d
+}
+
+func (s *Schema) RemoveDomain(name string) (*Domain, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	d, ok := s.domains[name]
+	if !ok {
+		return nil, fmt.Errorf("domain '%s'.'%s' does not exist", s.name, name)
+	}
+
+	delete(s.domains, name)
+	return d, nil
+}
+
+// hasType returns true if a type or a domain is named name
+func (s *Schema) hasType(name string) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	_, isType := s.types[name]
+	_, isDomain := s.domains[name]
+	return isType || isDomain
+}
+
+// typed returns attribute a typed with the domain or the user-defined type of schema it names, if any
+func (s *Schema) typed(a Attribute) (Attribute, error) {
+	if d, err := s.Domain(strings.ToLower(a.typeName)); err == nil {
+		a = a.withDomain(d)
+	}
+	et, err := s.Type(strings.ToLower(a.typeName))
+	if err != nil {
+		return a, nil
+	}
+	return a.withEnum(et)
+}
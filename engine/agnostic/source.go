@@ -75,14 +75,28 @@ type SeqScanSrc struct {
 	card  int64
 	rname string
 	cols  []string
+
+	// relation and attribute rows are produced in ascending order of, if any
+	relation string
+	sorted   string
 }
 
 func NewSeqScan(r *Relation, alias string) *SeqScanSrc {
+	rows := r.rows
+	if r.generator != nil {
+		rows = list.New()
+		for _, t := range r.generator() {
+			rows.PushBack(t)
+		}
+	}
+
 	s := &SeqScanSrc{
-		e:     r.rows.Front(),
-		card:  int64(r.rows.Len()),
-		rname: r.name,
+		e:        rows.Front(),
+		card:     int64(rows.Len()),
+		rname:    r.name,
+		relation: r.name,
 	}
+	s.sorted, _ = r.sortedOn()
 	if alias != "" {
 		s.rname = alias
 	}
@@ -97,7 +111,7 @@ func (s SeqScanSrc) String() string {
 }
 
 func (s *SeqScanSrc) HasNext() bool {
-    return s.e != nil
+	return s.e != nil
 }
 
 func (s *SeqScanSrc) Next() *list.Element {
@@ -116,3 +130,8 @@ func (s *SeqScanSrc) EstimateCardinal() int64 {
 func (s *SeqScanSrc) Columns() []string {
 	return s.cols
 }
+
+// orderedOn returns true if rows are produced sorted on attribute of relation
+func (s *SeqScanSrc) orderedOn(rel, attr string) bool {
+	return s.sorted != "" && rel == s.relation && attr == s.sorted
+}
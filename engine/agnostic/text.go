@@ -0,0 +1,150 @@
+package agnostic
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// textArg returns v as text. CHAR values lose their padding, like when cast to TEXT.
+func textArg(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case Char:
+		return t.String(), nil
+	}
+	return "", fmt.Errorf("argument must be text, got %v (type %T)", v, v)
+}
+
+// intArg returns v as an integer, numeric text being parsed
+func intArg(v any) (int64, error) {
+	if s, ok := v.(string); ok {
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid input syntax for type integer: \"%s\"", s)
+		}
+		return i, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.CanInt():
+		return rv.Int(), nil
+	case rv.CanUint():
+		return int64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("argument must be an integer, got %v (type %T)", v, v)
+}
+
+// lower implements lower(text)
+func lower(args []any) (any, error) {
+	s, err := textArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToLower(s), nil
+}
+
+// upper implements upper(text)
+func upper(args []any) (any, error) {
+	s, err := textArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToUpper(s), nil
+}
+
+// length implements length(text), the number of characters, and length(bytea), the number of bytes
+func length(args []any) (any, error) {
+	if b, ok := args[0].(Bytea); ok {
+		return int64(len(b)), nil
+	}
+	s, err := textArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return int64(utf8.RuneCountInString(s)), nil
+}
+
+// substring implements substring(text, start [, count]). Characters are counted from 1,
+// and start may be before the first character, shortening the result.
+func substring(args []any) (any, error) {
+	s, err := textArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := intArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(s)
+	end := int64(len(runes)) + 1
+	if len(args) > 2 {
+		count, err := intArg(args[2])
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("negative substring length not allowed")
+		}
+		if start+count < end {
+			end = start + count
+		}
+	}
+	if start < 1 {
+		start = 1
+	}
+	if start >= end {
+		return "", nil
+	}
+	return string(runes[start-1 : end-1]), nil
+}
+
+// trimFunction returns an implementation of btrim, ltrim or rtrim(text [, characters]),
+// removing the longest string of given characters, spaces by default, from text
+func trimFunction(trim func(s, cutset string) string) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		s, err := textArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		cutset := " "
+		if len(args) > 1 {
+			if cutset, err = textArg(args[1]); err != nil {
+				return nil, err
+			}
+		}
+		return trim(s, cutset), nil
+	}
+}
+
+// replace implements replace(text, from, to), replacing all occurrences of from
+func replace(args []any) (any, error) {
+	var s [3]string
+	for i := range s {
+		v, err := textArg(args[i])
+		if err != nil {
+			return nil, err
+		}
+		s[i] = v
+	}
+	if s[1] == "" {
+		return s[0], nil
+	}
+	return strings.ReplaceAll(s[0], s[1], s[2]), nil
+}
+
+// concat implements concat(value, ...), concatenating text representation of arguments. NULL arguments are ignored.
+func concat(args []any) (any, error) {
+	var b strings.Builder
+	for _, a := range args {
+		if a != nil {
+			b.WriteString(fmt.Sprint(a))
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,526 @@
+package agnostic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wallClock is the location of values of temporal types without time zone: TIMESTAMP, DATE and TIME.
+// Such a value is a wall clock reading rather than an instant, it is never converted to another time zone.
+var wallClock = time.FixedZone("UTC", 0)
+
+// isTemporalType returns true if typeName is a date or time type
+func isTemporalType(typeName string) bool {
+	switch strings.ToLower(typeName) {
+	case "timestamp", "timestamp without time zone", "timestamptz", "timestamp with time zone",
+		"date", "time", "time without time zone":
+		return true
+	}
+	return false
+}
+
+// isTimeOfDayType returns true if typeName is a time of day type
+func isTimeOfDayType(typeName string) bool {
+	switch strings.ToLower(typeName) {
+	case "time", "time without time zone":
+		return true
+	}
+	return false
+}
+
+// coerceTime converts val to a value of temporal attribute, with microsecond precision.
+// TIMESTAMP, DATE and TIME values keep the wall clock reading of val in its own location,
+// TIMESTAMPTZ values are instants. A wall clock reading assigned to TIMESTAMPTZ is read as UTC.
+func coerceTime(relation string, attr Attribute, val any) (any, error) {
+	t, err := toTime(val, attr.typeName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot assign '%v' (type %T) to %s.%s (type %s): %w", val, val, relation, attr.name, attr.typeName, err)
+	}
+
+	switch strings.ToLower(attr.typeName) {
+	case "timestamptz", "timestamp with time zone":
+		return t.Round(time.Microsecond).UTC(), nil
+	case "date":
+		y, m, d := toWallClock(t).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, wallClock), nil
+	case "time", "time without time zone":
+		w := toWallClock(t).Round(time.Microsecond)
+		return time.Date(0, time.January, 1, w.Hour(), w.Minute(), w.Second(), w.Nanosecond(), wallClock), nil
+	}
+	return toWallClock(t).Round(time.Microsecond), nil
+}
+
+// toTime converts v to time.Time, parsing text as a typeName value
+func toTime(v any, typeName string) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		return parseTime(t, typeName)
+	case []byte:
+		return parseTime(string(t), typeName)
+	}
+	return time.Time{}, fmt.Errorf("cannot use '%v' as %s", v, typeName)
+}
+
+// parseTime parses a typeName literal. Literals without time zone are wall clock readings.
+func parseTime(data, typeName string) (time.Time, error) {
+	if isTimeOfDayType(typeName) {
+		for _, layout := range []string{"15:04:05.999999999", "15:04"} {
+			if t, err := time.ParseInLocation(layout, data, wallClock); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return parseDate(data)
+}
+
+// toWallClock returns the wall clock reading of t in its own location
+func toWallClock(t time.Time) time.Time {
+	if t.Location() == wallClock {
+		return t
+	}
+	y, m, d := t.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), wallClock)
+}
+
+// timeComparable returns l and r as comparable microseconds. A wall clock reading
+// is compared to the wall clock reading of an instant in its own location.
+func timeComparable(l, r time.Time) (int64, int64) {
+	if l.Location() == wallClock {
+		r = toWallClock(r)
+	} else if r.Location() == wallClock {
+		l = toWallClock(l)
+	}
+	return l.Round(time.Microsecond).UnixMicro(), r.Round(time.Microsecond).UnixMicro()
+}
+
+// TimeIn returns v as displayed in time zone loc: instants are converted to loc,
+// values of types without time zone are returned as UTC. Other values are returned unchanged.
+func TimeIn(v any, loc *time.Location) any {
+	t, ok := v.(time.Time)
+	if !ok {
+		return v
+	}
+	if t.Location() == wallClock {
+		return t.UTC()
+	}
+	return t.In(loc)
+}
+
+// ReadTimeIn returns the instant denoted in time zone loc by v, if v is a wall clock reading
+// like a literal without time zone. Other values are returned unchanged.
+func ReadTimeIn(v any, loc *time.Location) any {
+	t, ok := v.(time.Time)
+	if !ok || t.Location() != wallClock {
+		return v
+	}
+	y, m, d := t.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// TimeZoneValueFunctor returns values of a ValueFunctor with instants converted to a time zone,
+// so functions like date_trunc() work on the wall clock of the session
+type TimeZoneValueFunctor struct {
+	ValueFunctor
+	loc *time.Location
+}
+
+// NewTimeZoneValueFunctor creates a ValueFunctor returning values of f, with instants converted to loc
+func NewTimeZoneValueFunctor(f ValueFunctor, loc *time.Location) ValueFunctor {
+	return &TimeZoneValueFunctor{ValueFunctor: f, loc: loc}
+}
+
+func (f *TimeZoneValueFunctor) Eval(cols []string, t *Tuple) (any, error) {
+	v, err := evalFunctor(f.ValueFunctor, cols, t)
+	if err != nil {
+		return nil, err
+	}
+	return f.in(v), nil
+}
+
+func (f *TimeZoneValueFunctor) Value(cols []string, t *Tuple) any {
+	return f.in(f.ValueFunctor.Value(cols, t))
+}
+
+func (f *TimeZoneValueFunctor) in(v any) any {
+	if t, ok := v.(time.Time); ok && t.Location() != wallClock {
+		return t.In(f.loc)
+	}
+	return v
+}
+
+func (f TimeZoneValueFunctor) String() string {
+	return fmt.Sprint(f.ValueFunctor)
+}
+
+// timeArg converts a function argument to time.Time, parsing text as a timestamp
+func timeArg(v any) (time.Time, error) {
+	return toTime(v, "timestamp")
+}
+
+// fieldArg returns the lowercase name of the field argument of date_trunc() and date_part()
+func fieldArg(v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("field must be text, got %v", v)
+	}
+	return strings.ToLower(s), nil
+}
+
+// dateTrunc implements date_trunc(field, timestamp), truncating timestamp to given precision.
+// Weeks start on monday.
+func dateTrunc(args []any) (any, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	field, err := fieldArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	t, err := timeArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+	ns := t.Nanosecond()
+
+	switch field {
+	case "microseconds":
+		ns = ns / 1000 * 1000
+	case "milliseconds":
+		ns = ns / 1000000 * 1000000
+	case "second":
+		ns = 0
+	case "minute":
+		s, ns = 0, 0
+	case "hour":
+		mi, s, ns = 0, 0, 0
+	case "day":
+		h, mi, s, ns = 0, 0, 0, 0
+	case "week":
+		d -= (int(t.Weekday()) + 6) % 7
+		h, mi, s, ns = 0, 0, 0, 0
+	case "month":
+		d, h, mi, s, ns = 1, 0, 0, 0, 0
+	case "quarter":
+		mo = (mo-1)/3*3 + 1
+		d, h, mi, s, ns = 1, 0, 0, 0, 0
+	case "year", "decade", "century", "millennium":
+		switch field {
+		case "decade":
+			y -= y % 10
+		case "century":
+			y = (y-1)/100*100 + 1
+		case "millennium":
+			y = (y-1)/1000*1000 + 1
+		}
+		mo, d, h, mi, s, ns = time.January, 1, 0, 0, 0, 0
+	default:
+		return nil, fmt.Errorf("unit \"%s\" not recognized for type timestamp", field)
+	}
+
+	return time.Date(y, mo, d, h, mi, s, ns, t.Location()), nil
+}
+
+// datePart implements date_part(field, source) and EXTRACT(field FROM source),
+// returning a subfield of a timestamp or an interval as a double precision number
+func datePart(args []any) (any, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	field, err := fieldArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if iv, ok := args[1].(Interval); ok {
+		return intervalPart(field, iv)
+	}
+	t, err := timeArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	y := t.Year()
+	seconds := float64(t.Second()) + float64(t.Nanosecond())/1e9
+	switch field {
+	case "microseconds":
+		return seconds * 1e6, nil
+	case "milliseconds":
+		return seconds * 1e3, nil
+	case "second":
+		return seconds, nil
+	case "minute":
+		return float64(t.Minute()), nil
+	case "hour":
+		return float64(t.Hour()), nil
+	case "day":
+		return float64(t.Day()), nil
+	case "dow":
+		return float64(t.Weekday()), nil
+	case "isodow":
+		return float64((int(t.Weekday())+6)%7 + 1), nil
+	case "doy":
+		return float64(t.YearDay()), nil
+	case "week":
+		_, w := t.ISOWeek()
+		return float64(w), nil
+	case "isoyear":
+		iy, _ := t.ISOWeek()
+		return float64(iy), nil
+	case "month":
+		return float64(t.Month()), nil
+	case "quarter":
+		return float64((int(t.Month())-1)/3 + 1), nil
+	case "year":
+		return float64(y), nil
+	case "decade":
+		return float64(y / 10), nil
+	case "century":
+		return float64((y + 99) / 100), nil
+	case "millennium":
+		return float64((y + 999) / 1000), nil
+	case "epoch":
+		// wall clock readings are counted as UTC
+		return float64(t.Round(time.Microsecond).UnixMicro()) / 1e6, nil
+	case "timezone":
+		_, offset := t.Zone()
+		return float64(offset), nil
+	}
+	return nil, fmt.Errorf("unit \"%s\" not recognized for type timestamp", field)
+}
+
+// intervalPart returns a subfield of iv, like date_part('hour', interval '1 day 2 hours') which is 2
+func intervalPart(field string, iv Interval) (any, error) {
+	years := iv.months / 12
+	seconds := float64(iv.micros%(60*microsPerSecond)) / 1e6
+	switch field {
+	case "microseconds":
+		return seconds * 1e6, nil
+	case "milliseconds":
+		return seconds * 1e3, nil
+	case "second":
+		return seconds, nil
+	case "minute":
+		return float64(iv.micros / (60 * microsPerSecond) % 60), nil
+	case "hour":
+		return float64(iv.micros / (3600 * microsPerSecond)), nil
+	case "day":
+		return float64(iv.days), nil
+	case "month":
+		return float64(iv.months % 12), nil
+	case "quarter":
+		return float64(iv.months%12/3 + 1), nil
+	case "year":
+		return float64(years), nil
+	case "decade":
+		return float64(years / 10), nil
+	case "century":
+		return float64(years / 100), nil
+	case "millennium":
+		return float64(years / 1000), nil
+	case "epoch":
+		// a year is 365.25 days, a month 30 days
+		return float64(years)*365.25*86400 + float64(iv.months%12*daysPerMonth+iv.days)*86400 + float64(iv.micros)/1e6, nil
+	}
+	return nil, fmt.Errorf("unit \"%s\" not supported for type interval", field)
+}
+
+// age implements age(timestamp, timestamp), subtracting arguments to a symbolic result in years, months and days,
+// and age(timestamp), subtracting from midnight of current date
+func age(args []any) (any, error) {
+	for _, a := range args {
+		if a == nil {
+			return nil, nil
+		}
+	}
+	r, err := timeArg(args[len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	var l time.Time
+	if len(args) == 2 {
+		if l, err = timeArg(args[0]); err != nil {
+			return nil, err
+		}
+	} else {
+		y, m, d := time.Now().In(r.Location()).Date()
+		l = time.Date(y, m, d, 0, 0, 0, 0, r.Location())
+	}
+
+	// compare wall clocks in the same location
+	if l.Location() == wallClock {
+		r = toWallClock(r)
+	} else if r.Location() == wallClock {
+		l = toWallClock(l)
+	} else {
+		r = r.In(l.Location())
+	}
+
+	if l.Before(r) {
+		return symbolicDiff(r, l).Neg(), nil
+	}
+	return symbolicDiff(l, r), nil
+}
+
+// symbolicDiff returns l - r as years, months, days and time, with l after r.
+// Missing days are borrowed from the month of r.
+func symbolicDiff(l, r time.Time) Interval {
+	l, r = l.Round(time.Microsecond), r.Round(time.Microsecond)
+	ly, lm, ld := l.Date()
+	ry, rm, rd := r.Date()
+
+	clock := func(t time.Time) int64 {
+		return (int64(t.Hour())*3600+int64(t.Minute())*60+int64(t.Second()))*microsPerSecond + int64(t.Nanosecond()/1000)
+	}
+	micros := clock(l) - clock(r)
+	days := ld - rd
+	months := int(lm) - int(rm)
+	years := ly - ry
+
+	if micros < 0 {
+		micros += microsPerDay
+		days--
+	}
+	if days < 0 {
+		days += time.Date(ry, rm+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		months--
+	}
+	if months < 0 {
+		months += 12
+		years--
+	}
+	return Interval{months: years*12 + months, days: days, micros: micros}
+}
+
+// toCharPatterns are template patterns of to_char(), longest first
+var toCharPatterns = []string{
+	"HH24", "HH12", "HH", "MI", "SS", "MS", "US", "AM", "PM",
+	"YYYY", "YY", "MONTH", "MON", "MM", "DAY", "DDD", "DD", "DY", "D", "Q",
+}
+
+// toChar implements to_char(timestamp, format). Format supports patterns YYYY, YY, MM, Month, Mon, DD, DDD, D,
+// Day, Dy, HH24, HH12, HH, MI, SS, MS, US, AM, PM and Q, FM prefix suppressing padding,
+// and double quoted literal text.
+func toChar(args []any) (any, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	t, err := timeArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	format, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("format must be text, got %v", args[1])
+	}
+
+	var b strings.Builder
+	fm := false
+	for i := 0; i < len(format); {
+		if i+2 <= len(format) && strings.EqualFold(format[i:i+2], "FM") {
+			fm = true
+			i += 2
+			continue
+		}
+		if format[i] == '"' {
+			end := strings.IndexByte(format[i+1:], '"')
+			if end < 0 {
+				end = len(format) - i - 1
+			}
+			b.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+
+		matched := ""
+		for _, p := range toCharPatterns {
+			if i+len(p) <= len(format) && strings.EqualFold(format[i:i+len(p)], p) {
+				matched = format[i : i+len(p)]
+				break
+			}
+		}
+		if matched == "" {
+			b.WriteByte(format[i])
+			i++
+			continue
+		}
+		b.WriteString(toCharField(t, matched, fm))
+		fm = false
+		i += len(matched)
+	}
+
+	return b.String(), nil
+}
+
+// toCharField formats field of t matched by pattern. Names follow case of pattern, like Month or MONTH.
+func toCharField(t time.Time, pattern string, fm bool) string {
+	num := func(n, width int) string {
+		if fm {
+			return strconv.Itoa(n)
+		}
+		return fmt.Sprintf("%0*d", width, n)
+	}
+	name := func(s string, width int) string {
+		switch {
+		case pattern == strings.ToUpper(pattern):
+			s = strings.ToUpper(s)
+		case pattern == strings.ToLower(pattern):
+			s = strings.ToLower(s)
+		}
+		if fm {
+			return s
+		}
+		return fmt.Sprintf("%-*s", width, s)
+	}
+	hour12 := (t.Hour()+11)%12 + 1
+
+	switch strings.ToUpper(pattern) {
+	case "HH24":
+		return num(t.Hour(), 2)
+	case "HH12", "HH":
+		return num(hour12, 2)
+	case "MI":
+		return num(t.Minute(), 2)
+	case "SS":
+		return num(t.Second(), 2)
+	case "MS":
+		return num(t.Nanosecond()/1000000, 3)
+	case "US":
+		return num(t.Nanosecond()/1000, 6)
+	case "AM", "PM":
+		if t.Hour() < 12 {
+			return name("AM", 2)
+		}
+		return name("PM", 2)
+	case "YYYY":
+		return num(t.Year(), 4)
+	case "YY":
+		return num(t.Year()%100, 2)
+	case "MONTH":
+		return name(t.Month().String(), 9)
+	case "MON":
+		return name(t.Month().String()[:3], 3)
+	case "MM":
+		return num(int(t.Month()), 2)
+	case "DAY":
+		return name(t.Weekday().String(), 9)
+	case "DY":
+		return name(t.Weekday().String()[:3], 3)
+	case "DDD":
+		return num(t.YearDay(), 3)
+	case "DD":
+		return num(t.Day(), 2)
+	case "D":
+		return strconv.Itoa(int(t.Weekday()) + 1)
+	case "Q":
+		return strconv.Itoa((int(t.Month())-1)/3 + 1)
+	}
+	return pattern
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 
 	"github.com/proullon/ramsql/engine/log"
 )
@@ -68,6 +69,12 @@ func (t *Transaction) Rollback() {
 		case RelationChange:
 			c := b.Value.(RelationChange)
 			t.rollbackRelationChange(c)
+		case TypeChange:
+			c := b.Value.(TypeChange)
+			t.rollbackTypeChange(c)
+		case DomainChange:
+			c := b.Value.(DomainChange)
+			t.rollbackDomainChange(c)
 		}
 		t.changes.Remove(b)
 	}
@@ -79,6 +86,21 @@ func (t Transaction) Error() error {
 	return t.err
 }
 
+// Changes returns the number of changes not yet committed
+func (t *Transaction) Changes() int {
+	return t.changes.Len()
+}
+
+// Relations returns the names of relations locked by the transaction
+func (t *Transaction) Relations() []string {
+	names := make([]string, 0, len(t.locks))
+	for name := range t.locks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (t *Transaction) Truncate(schema, relation string) (int64, error) {
 	if err := t.aborted(); err != nil {
 		return 0, err
@@ -93,6 +115,9 @@ func (t *Transaction) Truncate(schema, relation string) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+	if r.IsVirtual() {
+		return 0, t.abort(fmt.Errorf("cannot truncate virtual relation %s", r))
+	}
 
 	c := r.Truncate()
 
@@ -117,6 +142,74 @@ func (t *Transaction) RelationAttribute(schName, relName, attrName string) (int,
 	return r.Attribute(attrName)
 }
 
+// PrimaryKey returns names of primary key attributes of relation, if any
+func (t *Transaction) PrimaryKey(schName, relName string) ([]string, error) {
+	if err := t.aborted(); err != nil {
+		return nil, err
+	}
+
+	s, err := t.e.schema(schName)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.Relation(relName)
+	if err != nil {
+		return nil, err
+	}
+
+	pk := make([]string, len(r.pk))
+	for i, idx := range r.pk {
+		pk[i] = r.attributes[idx].name
+	}
+	return pk, nil
+}
+
+// Attributes returns names of relation attributes, in order of declaration
+func (t *Transaction) Attributes(schName, relName string) ([]string, error) {
+	if err := t.aborted(); err != nil {
+		return nil, err
+	}
+
+	s, err := t.e.schema(schName)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.Relation(relName)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make([]string, len(r.attributes))
+	for i, a := range r.attributes {
+		attrs[i] = a.name
+	}
+	return attrs, nil
+}
+
+// TypedAttribute returns attribute a typed with the domain or the user-defined type of schema it names.
+// An error is returned if a is not of a builtin type either.
+func (t *Transaction) TypedAttribute(schName string, a Attribute) (Attribute, error) {
+	if err := t.aborted(); err != nil {
+		return Attribute{}, err
+	}
+
+	s, err := t.e.schema(schName)
+	if err != nil {
+		return Attribute{}, err
+	}
+
+	a, err = s.typed(a)
+	if err != nil {
+		return Attribute{}, err
+	}
+	if a.domain == nil && a.enum == nil && !isBuiltinType(a.typeName) {
+		return Attribute{}, fmt.Errorf("type \"%s\" does not exist", a.typeName)
+	}
+	return a, nil
+}
+
 func (t *Transaction) CheckRelation(schemaName, relName string) bool {
 	if err := t.aborted(); err != nil {
 		return false
@@ -173,6 +266,53 @@ func (t *Transaction) DropRelation(schemaName, relName string) error {
 	return nil
 }
 
+// CreateVirtualRelation registers a read-only relation whose rows are
+// produced by generator on each scan.
+func (t *Transaction) CreateVirtualRelation(schemaName, relName string, attributes []Attribute, generator func() []*Tuple) error {
+	if err := t.aborted(); err != nil {
+		return err
+	}
+
+	s, r, err := t.e.createVirtualRelation(schemaName, relName, attributes, generator)
+	if err != nil {
+		return t.abort(err)
+	}
+
+	c := RelationChange{
+		schema:  s,
+		current: r,
+		old:     nil,
+	}
+	t.changes.PushBack(c)
+	log.Debug("CreateVirtualRelation(%s,%s,%s)", schemaName, relName, attributes)
+
+	t.lock(r)
+	return nil
+}
+
+// DropVirtualRelation removes a relation registered with CreateVirtualRelation,
+// once transactions scanning it are done.
+func (t *Transaction) DropVirtualRelation(schemaName, relName string) error {
+	if err := t.aborted(); err != nil {
+		return err
+	}
+
+	s, err := t.e.schema(schemaName)
+	if err != nil {
+		return t.abort(err)
+	}
+	r, err := s.Relation(relName)
+	if err != nil {
+		return t.abort(err)
+	}
+	if !r.IsVirtual() {
+		return t.abort(fmt.Errorf("relation '%s'.'%s' is not virtual", schemaName, relName))
+	}
+	t.lock(r)
+
+	return t.DropRelation(schemaName, relName)
+}
+
 func (t *Transaction) CheckSchema(schemaName string) bool {
 	if err := t.aborted(); err != nil {
 		return false
@@ -223,6 +363,217 @@ func (t *Transaction) DropSchema(schemaName string) error {
 	return nil
 }
 
+// CreateEnumType creates type name in schema, whose values are given labels
+func (t *Transaction) CreateEnumType(schemaName, name string, labels []string) error {
+	if err := t.aborted(); err != nil {
+		return err
+	}
+
+	s, err := t.e.schema(schemaName)
+	if err != nil {
+		return t.abort(err)
+	}
+	if s.hasType(name) {
+		return t.abort(fmt.Errorf("type \"%s\" already exists", name))
+	}
+
+	et, err := NewEnumType(name, labels)
+	if err != nil {
+		return t.abort(err)
+	}
+	s.AddType(name, et)
+
+	c := TypeChange{
+		schema:  s,
+		current: et,
+	}
+	t.changes.PushBack(c)
+	log.Debug("CreateEnumType(%s,%s,%v)", schemaName, name, labels)
+
+	return nil
+}
+
+// AddEnumValue adds label to type name. If neighbor is not empty, label is placed before it, or after it if after is set.
+func (t *Transaction) AddEnumValue(schemaName, name, label, neighbor string, after bool, ifNotExists bool) error {
+	if err := t.aborted(); err != nil {
+		return err
+	}
+
+	s, err := t.e.schema(schemaName)
+	if err != nil {
+		return t.abort(err)
+	}
+	et, err := s.Type(name)
+	if err != nil {
+		return t.abort(err)
+	}
+	if ifNotExists && et.index(label) >= 0 {
+		return nil
+	}
+
+	old := &EnumType{name: et.name, labels: et.Labels()}
+	if err := et.addValue(label, neighbor, after); err != nil {
+		return t.abort(err)
+	}
+
+	c := TypeChange{
+		schema:  s,
+		current: et,
+		old:     old,
+	}
+	t.changes.PushBack(c)
+
+	return nil
+}
+
+// CheckType returns true if type name exists in schema
+func (t *Transaction) CheckType(schemaName, name string) bool {
+	if err := t.aborted(); err != nil {
+		return false
+	}
+
+	s, err := t.e.schema(schemaName)
+	if err != nil {
+		return false
+	}
+	_, err = s.Type(name)
+	return err == nil
+}
+
+// DropType removes type name from schema. Type cannot be dropped while an attribute uses it.
+func (t *Transaction) DropType(schemaName, name string) error {
+	if err := t.aborted(); err != nil {
+		return err
+	}
+
+	s, err := t.e.schema(schemaName)
+	if err != nil {
+		return t.abort(err)
+	}
+	et, err := s.Type(name)
+	if err != nil {
+		return t.abort(err)
+	}
+
+	s.RLock()
+	for _, r := range s.relations {
+		for _, a := range r.attributes {
+			if a.enum == et {
+				s.RUnlock()
+				return t.abort(fmt.Errorf("cannot drop type %s because column %s of table %s depends on it", name, a.name, r.name))
+			}
+		}
+	}
+	for _, d := range s.domains {
+		if d.base.enum == et {
+			s.RUnlock()
+			return t.abort(fmt.Errorf("cannot drop type %s because domain %s depends on it", name, d.name))
+		}
+	}
+	s.RUnlock()
+
+	if _, err := s.RemoveType(name); err != nil {
+		return t.abort(err)
+	}
+
+	c := TypeChange{
+		schema: s,
+		old:    et,
+	}
+	t.changes.PushBack(c)
+
+	return nil
+}
+
+// CreateDomain creates domain name in schema, over base attribute type, default value and nullability.
+// Check predicate can be nil.
+func (t *Transaction) CreateDomain(schemaName, name string, base Attribute, check Predicate) error {
+	if err := t.aborted(); err != nil {
+		return err
+	}
+
+	s, err := t.e.schema(schemaName)
+	if err != nil {
+		return t.abort(err)
+	}
+	if s.hasType(name) {
+		return t.abort(fmt.Errorf("type \"%s\" already exists", name))
+	}
+
+	// domain over an enum type
+	if et, err := s.Type(strings.ToLower(base.typeName)); err == nil {
+		base, err = base.withEnum(et)
+		if err != nil {
+			return t.abort(err)
+		}
+	}
+
+	d := NewDomain(name, base, check)
+	s.AddDomain(name, d)
+
+	c := DomainChange{
+		schema:  s,
+		current: d,
+	}
+	t.changes.PushBack(c)
+	log.Debug("CreateDomain(%s,%s,%s)", schemaName, name, d)
+
+	return nil
+}
+
+// CheckDomain returns true if domain name exists in schema
+func (t *Transaction) CheckDomain(schemaName, name string) bool {
+	if err := t.aborted(); err != nil {
+		return false
+	}
+
+	s, err := t.e.schema(schemaName)
+	if err != nil {
+		return false
+	}
+	_, err = s.Domain(name)
+	return err == nil
+}
+
+// DropDomain removes domain name from schema. Domain cannot be dropped while an attribute uses it.
+func (t *Transaction) DropDomain(schemaName, name string) error {
+	if err := t.aborted(); err != nil {
+		return err
+	}
+
+	s, err := t.e.schema(schemaName)
+	if err != nil {
+		return t.abort(err)
+	}
+	d, err := s.Domain(name)
+	if err != nil {
+		return t.abort(err)
+	}
+
+	s.RLock()
+	for _, r := range s.relations {
+		for _, a := range r.attributes {
+			if a.domain == d {
+				s.RUnlock()
+				return t.abort(fmt.Errorf("cannot drop domain %s because column %s of table %s depends on it", name, a.name, r.name))
+			}
+		}
+	}
+	s.RUnlock()
+
+	if _, err := s.RemoveDomain(name); err != nil {
+		return t.abort(err)
+	}
+
+	c := DomainChange{
+		schema: s,
+		old:    d,
+	}
+	t.changes.PushBack(c)
+
+	return nil
+}
+
 func (t *Transaction) CreateIndex(schema, relation, index string, it IndexType, attrs []string) error {
 	if err := t.aborted(); err != nil {
 		return err
@@ -266,6 +617,9 @@ func (t *Transaction) Delete(schema, relation string, selectors []Selector, p Pr
 	if err != nil {
 		return nil, nil, err
 	}
+	if r.IsVirtual() {
+		return nil, nil, t.abort(fmt.Errorf("cannot delete from virtual relation %s", r))
+	}
 
 	n, err := t.Plan(schema, selectors, p, nil, nil)
 	if err != nil {
@@ -315,6 +669,9 @@ func (t *Transaction) Update(schema, relation string, values map[string]any, sel
 	if err != nil {
 		return nil, nil, err
 	}
+	if r.IsVirtual() {
+		return nil, nil, t.abort(fmt.Errorf("cannot update virtual relation %s", r))
+	}
 
 	n, err := t.Plan(schema, selectors, p, nil, nil)
 	if err != nil {
@@ -327,6 +684,9 @@ func (t *Transaction) Update(schema, relation string, values map[string]any, sel
 	}
 
 	un := NewUpdaterNode(r, t.changes, values)
+	un.check = func(tuple *Tuple) error {
+		return t.checkReferences(r, tuple)
+	}
 
 	snode.child, un.child = un, snode.child
 
@@ -350,12 +710,13 @@ func (t *Transaction) Update(schema, relation string, values map[string]any, sel
 // Build tuple for given relation
 // for each column:
 // - if not specified, use default value if set
+// - check not null
 // - if specified:
 //   - check domain
 //   - check unique
-//   - check foreign key
 //
 // If tuple is valid, then
+// - check foreign keys
 // - check primary key
 // - insert into rows list
 // - update index if any
@@ -372,6 +733,9 @@ func (t *Transaction) Insert(schema, relation string, values map[string]any) (*T
 	if err != nil {
 		return nil, t.abort(err)
 	}
+	if r.IsVirtual() {
+		return nil, t.abort(fmt.Errorf("cannot insert into virtual relation %s", r))
+	}
 
 	t.lock(r)
 
@@ -382,7 +746,14 @@ func (t *Transaction) Insert(schema, relation string, values map[string]any) (*T
 		val, specified := values[attr.name]
 		if !specified {
 			if attr.defaultValue != nil {
-				tuple.Append(attr.defaultValue())
+				val, err := coerce(relation, attr, attr.defaultValue())
+				if err == nil {
+					err = checkNotNull(relation, attr, val)
+				}
+				if err != nil {
+					return nil, t.abort(err)
+				}
+				tuple.Append(val)
 				continue
 			}
 			if attr.autoIncrement {
@@ -392,18 +763,21 @@ func (t *Transaction) Insert(schema, relation string, values map[string]any) (*T
 			}
 		}
 		if specified {
+			val, err = coerce(relation, attr, val)
+			if err != nil {
+				return nil, t.abort(err)
+			}
 			if val == nil {
+				if err := checkNotNull(relation, attr, val); err != nil {
+					return nil, t.abort(err)
+				}
 				tuple.Append(val)
 				delete(values, attr.name)
 				continue
 			}
-			tof := reflect.TypeOf(val)
-			if !tof.ConvertibleTo(attr.typeInstance) {
-				return nil, t.abort(fmt.Errorf("cannot assign '%v' (type %s) to %s.%s (type %s)", val, tof, relation, attr.name, attr.typeInstance))
-			}
 			if attr.unique {
 				f := NewAttributeValueFunctor(r.name, attr.name)
-				p := NewEqPredicate(f, f)
+				p := NewEqPredicate(f, NewConstValueFunctor(val))
 				for _, index := range r.indexes {
 					if ok, _ := index.CanSourceWith(p); !ok {
 						continue
@@ -421,10 +795,7 @@ func (t *Transaction) Insert(schema, relation string, values map[string]any) (*T
 					}
 				}
 			}
-			if attr.fk != nil {
-				// TODO: predicate: equal
-			}
-			tuple.Append(reflect.ValueOf(val).Convert(attr.typeInstance).Interface())
+			tuple.Append(val)
 			delete(values, attr.name)
 			continue
 		}
@@ -436,6 +807,10 @@ func (t *Transaction) Insert(schema, relation string, values map[string]any) (*T
 		return nil, t.abort(fmt.Errorf("attribute %s does not exist in relation %s", k, relation))
 	}
 
+	if err := t.checkReferences(r, tuple); err != nil {
+		return nil, t.abort(err)
+	}
+
 	// check primary key violation
 	ok, err := r.CheckPrimaryKey(tuple)
 	if err != nil {
@@ -448,6 +823,7 @@ func (t *Transaction) Insert(schema, relation string, values map[string]any) (*T
 	// insert into row list
 	log.Debug("Inserting %v", tuple.values)
 	e := r.rows.PushBack(tuple)
+	r.appended(e)
 
 	// update indexes
 	for _, index := range r.indexes {
@@ -465,6 +841,59 @@ func (t *Transaction) Insert(schema, relation string, values map[string]any) (*T
 	return tuple, nil
 }
 
+// checkReferences returns an error if a value of tuple of relation r is not a value of the attribute it references.
+// Deleting or updating referenced rows is not checked.
+func (t *Transaction) checkReferences(r *Relation, tuple *Tuple) error {
+	for i, attr := range r.attributes {
+		v := tuple.values[i]
+		if attr.fk == nil || v == nil {
+			continue
+		}
+		ok, err := t.referenced(r, tuple, attr.fk, v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("insert or update on table \"%s\" violates foreign key constraint: key (%s)=(%v) is not present in table \"%s\"", r.name, attr.name, v, attr.fk.relation)
+		}
+	}
+	return nil
+}
+
+// referenced returns true if v is a value of attribute referenced by fk, a row of r being able to reference itself
+func (t *Transaction) referenced(r *Relation, tuple *Tuple, fk *ForeignKey, v any) (bool, error) {
+	s, err := t.e.schema(fk.schema)
+	if err != nil {
+		return false, err
+	}
+	ref, err := s.Relation(fk.relation)
+	if err != nil {
+		return false, err
+	}
+	idx, _, err := ref.Attribute(fk.attribute)
+	if err != nil {
+		return false, err
+	}
+
+	if ref == r {
+		if ok, err := equal(tuple.values[idx], v); err == nil && ok {
+			return true, nil
+		}
+	}
+	t.lock(ref)
+
+	for e := ref.rows.Front(); e != nil; e = e.Next() {
+		w := e.Value.(*Tuple).values[idx]
+		if w == nil {
+			continue
+		}
+		if ok, err := equal(w, v); err == nil && ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // Query data from relations
 //
 // cf: https://en.wikipedia.org/wiki/Query_optimization
@@ -504,18 +933,64 @@ func (t *Transaction) Query(schema string, selectors []Selector, p Predicate, jo
 	return columns, res, nil
 }
 
-func recAppendPredicates(rname string, sc Scanner, p Predicate) {
-	if p.Relation() == rname {
-		sc.Append(p)
-		return
+// conjuncts returns operands of AND predicates of p, which can be evaluated separately
+func conjuncts(p Predicate) []Predicate {
+	if and, ok := p.(*AndPredicate); ok {
+		return append(conjuncts(and.left), conjuncts(and.right)...)
 	}
+	return []Predicate{p}
+}
 
-	if lp, ok := p.Left(); ok {
-		recAppendPredicates(rname, sc, lp)
+// isConstantPredicate returns true if p does not depend on any relation, like $1 * 2 > 10.
+// Its value is the same for all tuples, so it can filter any scanner.
+func isConstantPredicate(p Predicate) bool {
+	if p.Relation() != "" || len(p.Attribute()) > 0 {
+		return false
 	}
-	if rp, ok := p.Right(); ok {
-		recAppendPredicates(rname, sc, rp)
+	_, lok := p.Left()
+	_, rok := p.Right()
+	return !lok && !rok
+}
+
+// pushEqualities returns inner join j with conditions of residual being equalities of an attribute of relations lrels
+// and an attribute of relations rrels, none of them NULL padded, and other conditions of residual
+func pushEqualities(j Joiner, residual []Predicate, lrels, rrels, nullable map[string]bool) (Joiner, []Predicate) {
+	var p Predicate
+	switch n := j.(type) {
+	case *NaturalJoin:
+		p = NewEqPredicate(NewAttributeValueFunctor(n.leftr, n.lefta), NewAttributeValueFunctor(n.rightr, n.righta))
+	case *NestedLoopJoin:
+		if n.typ != Inner {
+			return j, residual
+		}
+		if _, ok := n.p.(*TruePredicate); !ok {
+			p = n.p
+		}
+	default:
+		return j, residual
+	}
+
+	var others []Predicate
+	pushed := false
+	for _, c := range residual {
+		l, r, ok := attributeEquality(c)
+		if !ok || nullable[l.relation] || nullable[r.relation] ||
+			!(lrels[l.relation] && rrels[r.relation] || lrels[r.relation] && rrels[l.relation]) {
+			others = append(others, c)
+			continue
+		}
+		if p == nil {
+			p = c
+		} else {
+			p = NewAndPredicate(p, c)
+		}
+		pushed = true
+	}
+	if !pushed {
+		return j, residual
 	}
+	log.Debug("pushEqualities: joining %s >< %s on %s", j.Left(), j.Right(), p)
+	return NewNestedLoopJoin(Inner, j.Left(), j.Right(), p), others
 }
 
 func (t *Transaction) Plan(schema string, selectors []Selector, p Predicate, joiners []Joiner, sorters []Sorter) (Node, error) {
@@ -552,11 +1027,31 @@ func (t *Transaction) Plan(schema string, selectors []Selector, p Predicate, joi
 		t.lock(r)
 		relations[rel] = r
 	}
+	// joined relations may have no selected attribute, like in SELECT COUNT(*) FROM a JOIN b ON ...
+	for _, j := range joiners {
+		for _, rel := range []string{j.Left(), j.Right()} {
+			if _, ok := relations[rel]; ok {
+				continue
+			}
+			r, err := s.Relation(rel)
+			if err != nil {
+				return nil, t.abort(err)
+			}
+			t.lock(r)
+			relations[rel] = r
+		}
+	}
 
 	// (2)
+	// relations NULL padded by outer joins are not filtered before joining
+	nullable := nullableRelations(joiners)
 	sources := make(map[string]Source)
 	var sourceCost int64
 	for _, r := range relations {
+		if nullable[r.name] {
+			sources[r.name] = NewSeqScan(r, getAlias(r.name, aliases))
+			continue
+		}
 		for _, index := range r.indexes {
 			cost, ok, p := recCanUseIndex(r.name, index, p)
 			if ok && (sourceCost == 0 || cost < sourceCost) {
@@ -579,11 +1074,10 @@ func (t *Transaction) Plan(schema string, selectors []Selector, p Predicate, joi
 	// build nodes for each relations
 	scanners := make(map[string]Scanner)
 	for _, r := range relations {
-		sc := NewRelationScanner(sources[r.name], nil)
-		recAppendPredicates(r.name, sc, p)
-		scanners[r.name] = sc
+		scanners[r.name] = NewRelationScanner(sources[r.name], nil)
 	}
 	// assign scanner nodes to joiner nodes
+	ordered := false
 	for _, j := range joiners {
 		sc, ok := scanners[j.Left()]
 		if !ok {
@@ -595,28 +1089,68 @@ func (t *Transaction) Plan(schema string, selectors []Selector, p Predicate, joi
 			return nil, t.abort(fmt.Errorf("cannot join %s, scanner for %s not found", j, j.Right()))
 		}
 		j.SetRight(sc)
+		if _, ok := j.(*NaturalJoin); !ok {
+			ordered = true
+		}
+	}
+	// sort joins by estimated cardinal. Other joins are kept in query order, as reordering changes the meaning
+	// of outer joins, and conditions of nested loop joins may depend on any relation joined before.
+	if !ordered {
+		sort.Sort(Joiners(joiners))
+	}
+	// conditions on a single relation filter its scanner, others are evaluated after joins,
+	// like those on NULL padded relations of outer joins
+	var residual []Predicate
+	for _, c := range conjuncts(p) {
+		pushed := false
+		for _, r := range relations {
+			if !nullable[r.name] && (c.Relation() == r.name || isConstantPredicate(c)) {
+				scanners[r.name].Append(c)
+				pushed = true
+			}
+		}
+		if !pushed {
+			residual = append(residual, c)
+		}
 	}
-	// sort joins by estimated cardinal
-	sort.Sort(Joiners(joiners))
 	// now we need to build tree by replacing gradually already joined relation in bigger join
-	seen := make(map[string]Node)
-	for _, n := range joiners {
-		child, ok := seen[n.Left()]
-		if !ok {
-			seen[n.Left()] = n
-		} else {
-			n.SetLeft(child)
+	top := make(map[string]Node)
+	for name, sc := range scanners {
+		top[name] = sc
+	}
+	for i, j := range joiners {
+		left, right := top[j.Left()], top[j.Right()]
+		if left == right {
+			return nil, t.abort(fmt.Errorf("cannot join %s, relations are already joined", j))
 		}
-		child, ok = seen[n.Right()]
-		if !ok {
-			seen[n.Right()] = n
-		} else {
-			n.SetRight(child)
+		lrels, rrels := make(map[string]bool), make(map[string]bool)
+		for name, n := range top {
+			lrels[name] = n == left
+			rrels[name] = n == right
+		}
+		// equalities of attributes of both sides evaluated after joins are join conditions of inner joins,
+		// like those of relations separated by commas
+		j, residual = pushEqualities(j, residual, lrels, rrels, nullable)
+		// equality joins are executed by nested loop, hash or merge join depending on cardinal of both sides
+		j = chooseJoin(j, left, right, lrels, rrels)
+		joiners[i] = j
+		j.SetLeft(left)
+		j.SetRight(right)
+		for name, n := range top {
+			if n == left || n == right {
+				top[name] = j
+			}
 		}
 	}
+
 	var headJoin Node
 	if len(joiners) > 0 {
 		headJoin = joiners[len(joiners)-1]
+		for name, n := range top {
+			if n != headJoin {
+				return nil, t.abort(fmt.Errorf("relation %s is not joined", name))
+			}
+		}
 	} else if len(scanners) == 1 {
 		// should have only on scanner then ?
 		for _, v := range scanners {
@@ -625,28 +1159,25 @@ func (t *Transaction) Plan(schema string, selectors []Selector, p Predicate, joi
 	} else {
 		return nil, t.abort(fmt.Errorf("no join, but got %d scan", len(scanners)))
 	}
+	if len(residual) > 0 {
+		filter := residual[0]
+		for _, c := range residual[1:] {
+			filter = NewAndPredicate(filter, c)
+		}
+		headJoin = NewFilterNode(filter, headJoin)
+	}
 
 	// append selectors
 	n := NewSelectorNode(selectors, headJoin)
 
 	// append sorters
-	// GroupBy must contains both selector node and last join to compute arithmetic on all groups
 	if len(sorters) > 0 {
 		sort.Sort(Sorters(sorters))
-		var src Node
 		for i, s := range sorters {
 			if i == 0 {
-				src = headJoin
+				s.SetNode(headJoin)
 			} else {
-				src = sorters[i-1]
-			}
-
-			switch s := s.(type) {
-			case *GroupBySorter:
-				s.SetNode(src)
-				s.SetSelector(n)
-			default:
-				s.SetNode(src)
+				s.SetNode(sorters[i-1])
 			}
 		}
 		n.child = sorters[len(sorters)-1]
@@ -692,6 +1223,11 @@ func recCanUseIndex(relName string, index Index, p Predicate) (int64, bool, Pred
 		return cost, ok, p
 	}
 
+	// only conditions true for all returned rows can be used, unlike operands of OR and NOT
+	if _, ok := p.(*AndPredicate); !ok {
+		return 0, false, nil
+	}
+
 	if lp, ok := p.Left(); ok {
 		cost, ok, cp := recCanUseIndex(relName, index, lp)
 		if ok {
@@ -1,7 +1,10 @@
 package agnostic
 
 import (
+	"container/list"
+	"fmt"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -1289,3 +1292,255 @@ func TestAlias(t *testing.T) {
 	}
 
 }
+
+func TestJoinOperators(t *testing.T) {
+	e := NewEngine()
+
+	tx, err := e.Begin()
+	if err != nil {
+		t.Fatalf("cannot begin tx: %s", err)
+	}
+	defer tx.Rollback()
+
+	schema := DefaultSchema
+	attrs := []Attribute{
+		NewAttribute("id", "BIGINT").WithAutoIncrement(),
+		NewAttribute("name", "TEXT"),
+	}
+	err = tx.CreateRelation(schema, "account", attrs, []string{"id"})
+	if err != nil {
+		t.Fatalf("cannot create relation: %s", err)
+	}
+	attrs = []Attribute{
+		NewAttribute("id", "BIGINT").WithAutoIncrement(),
+		NewAttribute("user_id", "BIGINT"),
+	}
+	err = tx.CreateRelation(schema, "payments", attrs, []string{"id"})
+	if err != nil {
+		t.Fatalf("cannot create relation: %s", err)
+	}
+
+	for i := 0; i < 200; i++ {
+		_, err = tx.Insert(schema, "account", map[string]any{"name": "user"})
+		if err != nil {
+			t.Fatalf("cannot insert values: %s", err)
+		}
+	}
+	// payments of unknown users, several payments per user and payments without user
+	for i := 0; i < 300; i++ {
+		values := map[string]any{"user_id": int64((i * 7) % 250)}
+		if i%10 == 0 {
+			values = map[string]any{"user_id": nil}
+		}
+		_, err = tx.Insert(schema, "payments", values)
+		if err != nil {
+			t.Fatalf("cannot insert values: %s", err)
+		}
+	}
+
+	query := func(j Joiner) []string {
+		_, tuples, err := tx.Query(
+			schema,
+			[]Selector{
+				NewAttributeSelector("account", []string{"id"}),
+				NewAttributeSelector("payments", []string{"id", "user_id"}),
+			},
+			NewTruePredicate(),
+			[]Joiner{j},
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("cannot query %s: %s", j, err)
+		}
+		var res []string
+		for _, tuple := range tuples {
+			res = append(res, fmt.Sprint(tuple.values))
+		}
+		return res
+	}
+
+	for _, typ := range []JoinType{Inner, LeftOuter, RightOuter, FullOuter} {
+		expected := query(NewNestedLoopJoin(typ, "account", "payments", NewEqPredicate(
+			NewAttributeValueFunctor("account", "id"),
+			NewAttributeValueFunctor("payments", "user_id"),
+		)))
+		if len(expected) == 0 {
+			t.Fatalf("expected rows with %s", typ)
+		}
+
+		// hash join produces rows in nested loop order
+		res := query(NewHashJoin(typ, "account", "id", "payments", "user_id"))
+		if !reflect.DeepEqual(res, expected) {
+			t.Fatalf("expected same rows with hash %s, got %d rows instead of %d", typ, len(res), len(expected))
+		}
+		swapped := query(NewNestedLoopJoin(typ, "payments", "account", NewEqPredicate(
+			NewAttributeValueFunctor("payments", "user_id"),
+			NewAttributeValueFunctor("account", "id"),
+		)))
+		res = query(NewHashJoin(typ, "payments", "user_id", "account", "id"))
+		if !reflect.DeepEqual(res, swapped) {
+			t.Fatalf("expected same rows with hash %s on swapped relations, got %d rows instead of %d", typ, len(res), len(swapped))
+		}
+
+		res = query(NewMergeJoin(typ, "account", "id", "payments", "user_id"))
+		sort.Strings(res)
+		sorted := append([]string{}, expected...)
+		sort.Strings(sorted)
+		if !reflect.DeepEqual(res, sorted) {
+			t.Fatalf("expected same rows with merge %s, got %d rows instead of %d", typ, len(res), len(expected))
+		}
+	}
+}
+
+// cardinalNode is a Node of a given cardinal
+type cardinalNode int64
+
+func (n cardinalNode) Exec() ([]string, []*list.Element, error) { return nil, nil, nil }
+func (n cardinalNode) EstimateCardinal() int64                  { return int64(n) }
+func (n cardinalNode) Children() []Node                         { return nil }
+
+func TestChooseJoin(t *testing.T) {
+	sorted := NewMergeJoin(Inner, "a", "id", "b", "a_id")
+
+	testCases := []struct {
+		j        Joiner
+		left     Node
+		right    Node
+		expected string
+	}{
+		{NewNaturalJoin("a", "id", "b", "a_id"), cardinalNode(3), cardinalNode(4), "*agnostic.NaturalJoin"},
+		{NewNaturalJoin("a", "id", "b", "a_id"), cardinalNode(10000), cardinalNode(10000), "*agnostic.HashJoin"},
+		{NewLeftOuterJoin("a", "id", "b", "a_id"), cardinalNode(10000), cardinalNode(10000), "*agnostic.HashJoin"},
+		{NewCrossJoin("a", "b"), cardinalNode(10000), cardinalNode(10000), "*agnostic.NestedLoopJoin"},
+		{NewNaturalJoin("b", "id", "c", "b_id"), sorted, cardinalNode(10000), "*agnostic.HashJoin"},
+		{NewNaturalJoin("b", "a_id", "c", "a_id"), sorted, cardinalNode(2), "*agnostic.MergeJoin"},
+	}
+
+	sorted.SetLeft(cardinalNode(10000))
+	sorted.SetRight(cardinalNode(10000))
+	for _, tc := range testCases {
+		j := chooseJoin(tc.j, tc.left, tc.right, nil, nil)
+		if n := reflect.TypeOf(j).String(); n != tc.expected {
+			t.Fatalf("expected %s for %s, got %s", tc.expected, tc.j, n)
+		}
+	}
+
+	// equalities of attributes of both sides are keys of nested loop join conditions
+	attr := NewAttributeValueFunctor
+	lrels, rrels := map[string]bool{"a": true, "b": true}, map[string]bool{"c": true}
+	nestedCases := []struct {
+		p        Predicate
+		expected string
+	}{
+		{NewAndPredicate(NewEqPredicate(attr("c", "a_id"), attr("a", "id")), NewEqPredicate(attr("b", "id"), attr("c", "b_id"))), "HASH JOIN a.id >< c.a_id AND b.id >< c.b_id"},
+		{NewAndPredicate(NewEqPredicate(attr("a", "id"), attr("c", "a_id")), NewGePredicate(attr("c", "n"), attr("b", "n"))), "HASH JOIN a.id >< c.a_id ON c.n > b.n"},
+		{NewAndPredicate(NewEqPredicate(attr("a", "id"), attr("b", "a_id")), NewGePredicate(attr("c", "n"), attr("b", "n"))), "JOIN a >< c ON a.id = b.a_id AND c.n > b.n"},
+		{NewOrPredicate(NewEqPredicate(attr("a", "id"), attr("c", "a_id")), NewEqPredicate(attr("b", "id"), attr("c", "b_id"))), "JOIN a >< c ON a.id = c.a_id OR b.id = c.b_id"},
+	}
+	for _, tc := range nestedCases {
+		j := chooseJoin(NewNestedLoopJoin(Inner, "a", "c", tc.p), cardinalNode(10000), cardinalNode(10000), lrels, rrels)
+		if s := fmt.Sprint(j); s != tc.expected {
+			t.Fatalf("expected %s for %s, got %s", tc.expected, tc.p, s)
+		}
+	}
+}
+
+// plannedJoin returns the type of the first joiner of query plan n
+func plannedJoin(n Node) string {
+	if _, ok := n.(Joiner); ok {
+		return reflect.TypeOf(n).String()
+	}
+	for _, c := range n.Children() {
+		if c == nil {
+			continue
+		}
+		if j := plannedJoin(c); j != "" {
+			return j
+		}
+	}
+	return ""
+}
+
+func TestPlanMergeJoin(t *testing.T) {
+	schema := DefaultSchema
+
+	setup := func() *Transaction {
+		tx, err := NewEngine().Begin()
+		if err != nil {
+			t.Fatalf("cannot begin tx: %s", err)
+		}
+		for _, rel := range []string{"account", "profile"} {
+			attrs := []Attribute{
+				NewAttribute("id", "BIGINT").WithAutoIncrement(),
+				NewAttribute("name", "TEXT"),
+			}
+			err = tx.CreateRelation(schema, rel, attrs, []string{"id"})
+			if err != nil {
+				t.Fatalf("cannot create relation: %s", err)
+			}
+			for i := 0; i < 100; i++ {
+				_, err = tx.Insert(schema, rel, map[string]any{"name": "user"})
+				if err != nil {
+					t.Fatalf("cannot insert values: %s", err)
+				}
+			}
+		}
+		return tx
+	}
+
+	plan := func(tx *Transaction) string {
+		n, err := tx.Plan(
+			schema,
+			[]Selector{NewAttributeSelector("account", []string{"id"})},
+			NewTruePredicate(),
+			[]Joiner{NewNaturalJoin("account", "id", "profile", "id")},
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("cannot plan query: %s", err)
+		}
+		return plannedJoin(n)
+	}
+
+	// rows of both relations are stored in primary key order
+	tx := setup()
+	defer tx.Rollback()
+	if j := plan(tx); j != "*agnostic.MergeJoin" {
+		t.Fatalf("expected merge join on sorted relations, got %s", j)
+	}
+
+	// relations separated by commas joined by WHERE equality
+	n, err := tx.Plan(
+		schema,
+		[]Selector{NewAttributeSelector("account", []string{"id"})},
+		NewEqPredicate(NewAttributeValueFunctor("profile", "id"), NewAttributeValueFunctor("account", "id")),
+		[]Joiner{NewCrossJoin("account", "profile")},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("cannot plan query: %s", err)
+	}
+	if j := plannedJoin(n); j != "*agnostic.MergeJoin" {
+		t.Fatalf("expected merge join on cross joined relations, got %s", j)
+	}
+
+	// key inserted out of order
+	_, err = tx.Insert(schema, "profile", map[string]any{"id": int64(-1), "name": "user"})
+	if err != nil {
+		t.Fatalf("cannot insert values: %s", err)
+	}
+	if j := plan(tx); j != "*agnostic.HashJoin" {
+		t.Fatalf("expected hash join on unsorted relation, got %s", j)
+	}
+
+	// key updated
+	tx = setup()
+	defer tx.Rollback()
+	_, _, err = tx.Update(schema, "account", map[string]any{"id": int64(1000)}, nil, NewEqPredicate(NewAttributeValueFunctor("account", "id"), NewConstValueFunctor(int64(1))))
+	if err != nil {
+		t.Fatalf("cannot update values: %s", err)
+	}
+	if j := plan(tx); j != "*agnostic.HashJoin" {
+		t.Fatalf("expected hash join on updated relation, got %s", j)
+	}
+}
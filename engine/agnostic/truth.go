@@ -0,0 +1,89 @@
+package agnostic
+
+// truth is the value of a condition in SQL three-valued logic.
+// Comparing NULL is unknown, which is neither true nor false: NOT unknown is still unknown,
+// and a row is selected only if its condition is true.
+type truth int8
+
+const (
+	truthFalse truth = iota
+	truthTrue
+	truthUnknown
+)
+
+// threeValued is implemented by predicates whose result may be unknown
+type threeValued interface {
+	truth(cols []string, t *Tuple) (truth, error)
+}
+
+// evalTruth returns the three-valued result of p
+func evalTruth(p Predicate, cols []string, t *Tuple) (truth, error) {
+	if tv, ok := p.(threeValued); ok {
+		return tv.truth(cols, t)
+	}
+	ok, err := p.Eval(cols, t)
+	return truthOf(ok), err
+}
+
+// isTrue converts a three-valued result to the result of Predicate.Eval, unknown being false
+func isTrue(tr truth, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	return tr == truthTrue, nil
+}
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+func (tr truth) not() truth {
+	switch tr {
+	case truthTrue:
+		return truthFalse
+	case truthFalse:
+		return truthTrue
+	}
+	return truthUnknown
+}
+
+// and is false if any operand is false, unknown if any is unknown
+func (tr truth) and(o truth) truth {
+	if tr == truthFalse || o == truthFalse {
+		return truthFalse
+	}
+	if tr == truthUnknown || o == truthUnknown {
+		return truthUnknown
+	}
+	return truthTrue
+}
+
+// or is true if any operand is true, unknown if any is unknown
+func (tr truth) or(o truth) truth {
+	if tr == truthTrue || o == truthTrue {
+		return truthTrue
+	}
+	if tr == truthUnknown || o == truthUnknown {
+		return truthUnknown
+	}
+	return truthFalse
+}
+
+// compareTruth computes compare on values of left and right operands, unknown if any is NULL
+func compareTruth(left, right ValueFunctor, cols []string, t *Tuple, compare func(vl, vr any) (bool, error)) (truth, error) {
+	vl, vr, err := evalOperands(left, right, cols, t)
+	if err != nil {
+		return truthFalse, err
+	}
+	if vl == nil || vr == nil {
+		return truthUnknown, nil
+	}
+	ok, err := compare(vl, vr)
+	if err != nil {
+		return truthFalse, err
+	}
+	return truthOf(ok), nil
+}
@@ -3,6 +3,8 @@ package agnostic
 // Tuple is a row in a relation
 type Tuple struct {
 	values []any
+	// group holds rows gathered by GroupBySorter, values being those of the first row
+	group []*Tuple
 }
 
 // NewTuple should check that value are for the right Attribute and match domain
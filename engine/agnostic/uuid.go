@@ -0,0 +1,144 @@
+package agnostic
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UUID is a RFC 4122 universally unique identifier
+type UUID [16]byte
+
+// randomSource is the random source of values generated by an engine, like gen_random_uuid() and random()
+type randomSource struct {
+	rnd *rand.Rand
+	sync.Mutex
+}
+
+func newRandomSource(seed int64) *randomSource {
+	return &randomSource{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// defaultRandom is used by functors created outside of an engine
+var defaultRandom = newRandomSource(time.Now().UnixNano())
+
+func (s *randomSource) seed(seed int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.rnd = rand.New(rand.NewSource(seed))
+}
+
+// uuid returns a version 4 UUID
+func (s *randomSource) uuid() UUID {
+	s.Lock()
+	defer s.Unlock()
+
+	return newUUIDFrom(s.rnd)
+}
+
+// random implements random(), a float between 0 and 1
+func (s *randomSource) random(args []any) (any, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.rnd.Float64(), nil
+}
+
+// genRandomUUID implements gen_random_uuid()
+func (s *randomSource) genRandomUUID(args []any) (any, error) {
+	return s.uuid(), nil
+}
+
+func newUUIDFrom(rnd *rand.Rand) UUID {
+	var u UUID
+	rnd.Read(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // variant 10
+	return u
+}
+
+// ParseUUID parses canonical form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx,
+// as well as hexadecimal form without hyphens, with braces or urn:uuid: prefix.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+
+	str := s
+	if len(str) == 45 && strings.EqualFold(str[:9], "urn:uuid:") {
+		str = str[9:]
+	} else if len(str) == 38 && str[0] == '{' && str[37] == '}' {
+		str = str[1:37]
+	}
+
+	switch len(str) {
+	case 36:
+		if str[8] != '-' || str[13] != '-' || str[18] != '-' || str[23] != '-' {
+			return u, fmt.Errorf("invalid input syntax for type uuid: \"%s\"", s)
+		}
+		str = str[0:8] + str[9:13] + str[14:18] + str[19:23] + str[24:]
+	case 32:
+	default:
+		return u, fmt.Errorf("invalid input syntax for type uuid: \"%s\"", s)
+	}
+
+	if _, err := hex.Decode(u[:], []byte(str)); err != nil {
+		return u, fmt.Errorf("invalid input syntax for type uuid: \"%s\"", s)
+	}
+
+	return u, nil
+}
+
+// String returns canonical lowercase form of u
+func (u UUID) String() string {
+	var buf [36]byte
+
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+
+	return string(buf[:])
+}
+
+// Value implements driver.Valuer, returning canonical form of u
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// ToUUID converts UUID text form, 16 bytes arrays and slices to UUID
+func ToUUID(v any) (UUID, error) {
+	switch t := v.(type) {
+	case UUID:
+		return t, nil
+	case string:
+		return ParseUUID(t)
+	case []byte:
+		if len(t) == 16 {
+			var u UUID
+			copy(u[:], t)
+			return u, nil
+		}
+		return ParseUUID(string(t))
+	case fmt.Stringer:
+		return ParseUUID(t.String())
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Array && rv.Len() == 16 && rv.Type().Elem().Kind() == reflect.Uint8 {
+		var u UUID
+		reflect.Copy(reflect.ValueOf(u[:]), rv)
+		return u, nil
+	}
+
+	return UUID{}, fmt.Errorf("cannot use '%v' (type %T) as UUID", v, v)
+}
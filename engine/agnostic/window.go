@@ -0,0 +1,655 @@
+package agnostic
+
+import (
+	"container/list"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FrameBoundType is the kind of a window frame bound, like UNBOUNDED PRECEDING or 2 FOLLOWING
+type FrameBoundType int
+
+const (
+	UnboundedPreceding FrameBoundType = iota
+	Preceding
+	CurrentRow
+	Following
+	UnboundedFollowing
+)
+
+// FrameBound is a window frame bound, offset being the number of rows of PRECEDING and FOLLOWING bounds
+type FrameBound struct {
+	typ    FrameBoundType
+	offset int64
+}
+
+func NewFrameBound(typ FrameBoundType, offset int64) FrameBound {
+	return FrameBound{typ: typ, offset: offset}
+}
+
+func (b FrameBound) String() string {
+	switch b.typ {
+	case UnboundedPreceding:
+		return "UNBOUNDED PRECEDING"
+	case Preceding:
+		return fmt.Sprintf("%d PRECEDING", b.offset)
+	case Following:
+		return fmt.Sprintf("%d FOLLOWING", b.offset)
+	case UnboundedFollowing:
+		return "UNBOUNDED FOLLOWING"
+	}
+	return "CURRENT ROW"
+}
+
+// WindowFrame is the set of rows of a partition a window function is computed on, relative to current row.
+// ROWS frames count rows, RANGE frames include peers of current row, only UNBOUNDED and CURRENT ROW bounds being supported.
+type WindowFrame struct {
+	rows  bool
+	start FrameBound
+	end   FrameBound
+}
+
+// NewWindowFrame creates a ROWS frame if rows is true, or else a RANGE frame, like ROWS BETWEEN 2 PRECEDING AND CURRENT ROW
+func NewWindowFrame(rows bool, start, end FrameBound) (*WindowFrame, error) {
+	switch {
+	case start.typ == UnboundedFollowing:
+		return nil, fmt.Errorf("frame start cannot be UNBOUNDED FOLLOWING")
+	case end.typ == UnboundedPreceding:
+		return nil, fmt.Errorf("frame end cannot be UNBOUNDED PRECEDING")
+	case start.typ == CurrentRow && end.typ == Preceding:
+		return nil, fmt.Errorf("frame starting from current row cannot have preceding rows")
+	case start.typ == Following && (end.typ == Preceding || end.typ == CurrentRow):
+		return nil, fmt.Errorf("frame starting from following row cannot have preceding rows")
+	case start.offset < 0:
+		return nil, fmt.Errorf("frame starting offset must not be negative")
+	case end.offset < 0:
+		return nil, fmt.Errorf("frame ending offset must not be negative")
+	}
+	if !rows {
+		for _, b := range []FrameBound{start, end} {
+			if b.typ == Preceding || b.typ == Following {
+				return nil, fmt.Errorf("RANGE with offset PRECEDING/FOLLOWING is not supported")
+			}
+		}
+	}
+
+	return &WindowFrame{rows: rows, start: start, end: end}, nil
+}
+
+func (f WindowFrame) String() string {
+	mode := "RANGE"
+	if f.rows {
+		mode = "ROWS"
+	}
+	return fmt.Sprintf("%s BETWEEN %s AND %s", mode, f.start, f.end)
+}
+
+// bounds returns frame of row i of a partition of n rows as [start, end), peers of row i being [peerStart, peerEnd)
+func (f *WindowFrame) bounds(i, n, peerStart, peerEnd int) (int, int) {
+	var start, end int
+
+	switch f.start.typ {
+	case UnboundedPreceding:
+		start = 0
+	case Preceding:
+		start = i - int(f.start.offset)
+	case CurrentRow:
+		start = i
+		if !f.rows {
+			start = peerStart
+		}
+	case Following:
+		start = i + int(f.start.offset)
+	}
+
+	switch f.end.typ {
+	case UnboundedFollowing:
+		end = n
+	case Following:
+		end = i + int(f.end.offset) + 1
+	case CurrentRow:
+		end = i + 1
+		if !f.rows {
+			end = peerEnd
+		}
+	case Preceding:
+		end = i - int(f.end.offset) + 1
+	}
+
+	if start < 0 {
+		start = 0
+	}
+	if end > n {
+		end = n
+	}
+	if start > end {
+		start = end
+	}
+	return start, end
+}
+
+// windowFunctions holds minimum and maximum number of arguments of window functions
+var windowFunctions = map[string][2]int{
+	"row_number":   {0, 0},
+	"rank":         {0, 0},
+	"dense_rank":   {0, 0},
+	"percent_rank": {0, 0},
+	"cume_dist":    {0, 0},
+	"ntile":        {1, 1},
+	"lag":          {1, 3},
+	"lead":         {1, 3},
+	"first_value":  {1, 1},
+	"last_value":   {1, 1},
+	"nth_value":    {2, 2},
+}
+
+// IsWindow returns true if name is a window function, like row_number
+func IsWindow(name string) bool {
+	_, ok := windowFunctions[strings.ToLower(name)]
+	return ok
+}
+
+// WindowValueFunctor computes a window function, like rank() OVER (PARTITION BY user_id ORDER BY amount DESC),
+// or an aggregate function over a window frame. Values are computed by WindowSorter, and read from its column.
+type WindowValueFunctor struct {
+	name      string
+	args      []ValueFunctor
+	agg       *AggregateValueFunctor
+	partition []ValueFunctor
+	order     []SortExpression
+	frame     *WindowFrame
+	col       string
+}
+
+// NewWindowValueFunctor creates a ValueFunctor computing window function name with args
+func NewWindowValueFunctor(name string, args []ValueFunctor, functors ...func(*WindowValueFunctor)) (*WindowValueFunctor, error) {
+	name = strings.ToLower(name)
+	n, ok := windowFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("window function %s does not exist", name)
+	}
+	if len(args) < n[0] || len(args) > n[1] {
+		return nil, fmt.Errorf("function %s does not accept %d arguments", name, len(args))
+	}
+
+	f := &WindowValueFunctor{
+		name: name,
+		args: args,
+	}
+	for _, fn := range functors {
+		fn(f)
+	}
+	return f, nil
+}
+
+// NewWindowAggregateValueFunctor creates a ValueFunctor computing aggregate function agg over window frame of each row,
+// like sum(amount) OVER (ORDER BY created_at)
+func NewWindowAggregateValueFunctor(agg *AggregateValueFunctor, functors ...func(*WindowValueFunctor)) (*WindowValueFunctor, error) {
+	if agg.distinct {
+		return nil, fmt.Errorf("DISTINCT is not implemented for window functions")
+	}
+
+	f := &WindowValueFunctor{
+		name: agg.name,
+		agg:  agg,
+	}
+	for _, fn := range functors {
+		fn(f)
+	}
+	return f, nil
+}
+
+// WithPartition splits rows in partitions on values computed by keys, like PARTITION BY user_id
+func WithPartition(keys ...ValueFunctor) func(*WindowValueFunctor) {
+	return func(f *WindowValueFunctor) {
+		f.partition = keys
+	}
+}
+
+// WithWindowOrder orders rows of each partition, like ORDER BY created_at DESC
+func WithWindowOrder(attrs ...SortExpression) func(*WindowValueFunctor) {
+	return func(f *WindowValueFunctor) {
+		f.order = attrs
+	}
+}
+
+// WithFrame sets window frame. Default frame is the whole partition without ordering,
+// or else rows from start of partition to peers of current row.
+func WithFrame(frame *WindowFrame) func(*WindowValueFunctor) {
+	return func(f *WindowValueFunctor) {
+		f.frame = frame
+	}
+}
+
+func (f *WindowValueFunctor) Eval(cols []string, t *Tuple) (any, error) {
+	for i, c := range cols {
+		if f.col != "" && c == f.col {
+			return t.values[i], nil
+		}
+	}
+	return nil, fmt.Errorf("window function %s is not allowed here", f.name)
+}
+
+func (f *WindowValueFunctor) Value(cols []string, t *Tuple) any {
+	v, err := f.Eval(cols, t)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func (f *WindowValueFunctor) Relation() string {
+	return ""
+}
+
+func (f *WindowValueFunctor) Attribute() []string {
+	return nil
+}
+
+func (f WindowValueFunctor) String() string {
+	var b strings.Builder
+	if f.agg != nil {
+		b.WriteString(f.agg.String())
+	} else {
+		args := make([]string, len(f.args))
+		for i, a := range f.args {
+			args[i] = fmt.Sprint(a)
+		}
+		fmt.Fprintf(&b, "%s(%s)", f.name, strings.Join(args, ", "))
+	}
+	b.WriteString(" OVER (")
+	if len(f.partition) > 0 {
+		fmt.Fprintf(&b, "PARTITION BY %v ", f.partition)
+	}
+	if len(f.order) > 0 {
+		fmt.Fprintf(&b, "ORDER BY %v ", f.order)
+	}
+	if f.frame != nil {
+		fmt.Fprintf(&b, "%s", f.frame)
+	}
+	return strings.TrimSpace(b.String()) + ")"
+}
+
+// compute returns value of window function for each tuple of in
+func (f *WindowValueFunctor) compute(cols []string, in []*Tuple) ([]any, error) {
+	partitions, err := f.partitions(cols, in)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]any, len(in))
+	for _, rows := range partitions {
+		keys, err := f.sort(cols, in, rows)
+		if err != nil {
+			return nil, err
+		}
+
+		// peers are rows with the same ordering values, all rows of partition being peers without ordering
+		n := len(rows)
+		peerStart := make([]int, n)
+		peerEnd := make([]int, n)
+		denseRank := make([]int64, n)
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				eq, err := f.peers(keys[i-1], keys[i])
+				if err != nil {
+					return nil, err
+				}
+				if eq {
+					peerStart[i] = peerStart[i-1]
+					denseRank[i] = denseRank[i-1]
+					continue
+				}
+			}
+			peerStart[i] = i
+			denseRank[i] = 1
+			if i > 0 {
+				denseRank[i] = denseRank[i-1] + 1
+			}
+		}
+		for i := n - 1; i >= 0; i-- {
+			if i < n-1 && peerStart[i+1] == peerStart[i] {
+				peerEnd[i] = peerEnd[i+1]
+				continue
+			}
+			peerEnd[i] = i + 1
+		}
+
+		for i, idx := range rows {
+			w := &windowRow{
+				cols:      cols,
+				in:        in,
+				rows:      rows,
+				i:         i,
+				peerStart: peerStart[i],
+				peerEnd:   peerEnd[i],
+				denseRank: denseRank[i],
+			}
+			res[idx], err = f.eval(w)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// partitions returns indexes of tuples of each partition, in order of first appearance
+func (f *WindowValueFunctor) partitions(cols []string, in []*Tuple) ([][]int, error) {
+	if len(f.partition) == 0 {
+		rows := make([]int, len(in))
+		for i := range in {
+			rows[i] = i
+		}
+		return [][]int{rows}, nil
+	}
+
+	var partitions [][]int
+	idx := make(map[string]int)
+	for i, t := range in {
+		var b strings.Builder
+		for _, k := range f.partition {
+			v, err := evalFunctor(k, cols, t)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(valueKey(v))
+			b.WriteString(";")
+		}
+		p, ok := idx[b.String()]
+		if !ok {
+			p = len(partitions)
+			idx[b.String()] = p
+			partitions = append(partitions, nil)
+		}
+		partitions[p] = append(partitions[p], i)
+	}
+	return partitions, nil
+}
+
+// sort orders rows of a partition, keeping their order on equal values, and returns their ordering values
+func (f *WindowValueFunctor) sort(cols []string, in []*Tuple, rows []int) ([][]any, error) {
+	keys := make(map[int][]any, len(rows))
+	for _, idx := range rows {
+		k := make([]any, len(f.order))
+		for i, o := range f.order {
+			v, err := evalFunctor(o.f, cols, in[idx])
+			if err != nil {
+				return nil, err
+			}
+			k[i] = v
+		}
+		keys[idx] = k
+	}
+
+	var err error
+	sort.SliceStable(rows, func(a, b int) bool {
+		ka, kb := keys[rows[a]], keys[rows[b]]
+		for i, o := range f.order {
+			eq, e := equal(ka[i], kb[i])
+			if e != nil {
+				err = e
+				return false
+			}
+			if eq {
+				continue
+			}
+			less, e := windowBefore(o.direction, ka[i], kb[i])
+			if e != nil {
+				err = e
+			}
+			return less
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([][]any, len(rows))
+	for i, idx := range rows {
+		sorted[i] = keys[idx]
+	}
+	return sorted, nil
+}
+
+// windowBefore returns true if ordering value a comes before b in direction. As in PostgreSQL,
+// NULL sorts after any value in a window, so last with ASC and first with DESC.
+func windowBefore(direction SortType, a, b any) (bool, error) {
+	if direction == ASC {
+		a, b = b, a
+	}
+	switch {
+	case a == nil:
+		return b != nil, nil
+	case b == nil:
+		return false, nil
+	}
+	return greater(a, b)
+}
+
+// peers returns true if ordering values a and b are equal
+func (f *WindowValueFunctor) peers(a, b []any) (bool, error) {
+	for i := range a {
+		eq, err := equal(a[i], b[i])
+		if err != nil || !eq {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// windowRow is the i-th row of a sorted partition, rows being indexes of tuples of in
+type windowRow struct {
+	cols      []string
+	in        []*Tuple
+	rows      []int
+	i         int
+	peerStart int
+	peerEnd   int
+	denseRank int64
+}
+
+func (w *windowRow) tuple(i int) *Tuple {
+	return w.in[w.rows[i]]
+}
+
+// frameBounds returns rows of window frame of current row as [start, end)
+func (f *WindowValueFunctor) frameBounds(w *windowRow) (int, int) {
+	n := len(w.rows)
+	if f.frame != nil {
+		return f.frame.bounds(w.i, n, w.peerStart, w.peerEnd)
+	}
+	if len(f.order) == 0 {
+		return 0, n
+	}
+	return 0, w.peerEnd
+}
+
+// eval computes window function for current row of w
+func (f *WindowValueFunctor) eval(w *windowRow) (any, error) {
+	n := len(w.rows)
+	cur := w.tuple(w.i)
+
+	if f.agg != nil {
+		start, end := f.frameBounds(w)
+		group := make([]*Tuple, 0, end-start)
+		for i := start; i < end; i++ {
+			group = append(group, w.tuple(i))
+		}
+		return f.agg.Eval(w.cols, &Tuple{values: cur.values, group: group})
+	}
+
+	switch f.name {
+	case "row_number":
+		return int64(w.i + 1), nil
+	case "rank":
+		return int64(w.peerStart + 1), nil
+	case "dense_rank":
+		return w.denseRank, nil
+	case "percent_rank":
+		if n == 1 {
+			return float64(0), nil
+		}
+		return float64(w.peerStart) / float64(n-1), nil
+	case "cume_dist":
+		return float64(w.peerEnd) / float64(n), nil
+	case "ntile":
+		buckets, err := f.intArg(0, w, cur)
+		if err != nil || buckets == nil {
+			return nil, err
+		}
+		b := *buckets
+		if b <= 0 {
+			return nil, fmt.Errorf("argument of ntile must be greater than zero")
+		}
+		// first n % b buckets hold one more row
+		size := int64(n) / b
+		big := n % int(b)
+		if w.i < big*int(size+1) {
+			return int64(w.i)/(size+1) + 1, nil
+		}
+		return int64(big) + (int64(w.i)-int64(big)*(size+1))/size + 1, nil
+	case "lag", "lead":
+		offset := int64(1)
+		if len(f.args) > 1 {
+			o, err := f.intArg(1, w, cur)
+			if err != nil || o == nil {
+				return nil, err
+			}
+			offset = *o
+		}
+		j := int64(w.i) - offset
+		if f.name == "lead" {
+			j = int64(w.i) + offset
+		}
+		if j < 0 || j >= int64(n) {
+			if len(f.args) > 2 {
+				return evalFunctor(f.args[2], w.cols, cur)
+			}
+			return nil, nil
+		}
+		return evalFunctor(f.args[0], w.cols, w.tuple(int(j)))
+	case "first_value", "last_value", "nth_value":
+		start, end := f.frameBounds(w)
+		j := start
+		switch f.name {
+		case "last_value":
+			j = end - 1
+		case "nth_value":
+			nth, err := f.intArg(1, w, cur)
+			if err != nil || nth == nil {
+				return nil, err
+			}
+			if *nth <= 0 {
+				return nil, fmt.Errorf("argument of nth_value must be greater than zero")
+			}
+			j = start + int(*nth) - 1
+		}
+		if j < start || j >= end {
+			return nil, nil
+		}
+		return evalFunctor(f.args[0], w.cols, w.tuple(j))
+	}
+
+	return nil, fmt.Errorf("window function %s does not exist", f.name)
+}
+
+// intArg returns integer value of i-th argument computed on current row, or nil if it is NULL
+func (f *WindowValueFunctor) intArg(i int, w *windowRow, cur *Tuple) (*int64, error) {
+	v, err := evalFunctor(f.args[i], w.cols, cur)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.CanInt() {
+		return nil, fmt.Errorf("function %s does not accept %s argument", f.name, valueTypeName(v))
+	}
+	n := rv.Int()
+	return &n, nil
+}
+
+// WindowSorter computes window functions on filtered, joined and grouped rows, before ordering them.
+// Computed values are appended to each row, in a column read by their WindowValueFunctor.
+type WindowSorter struct {
+	functors []*WindowValueFunctor
+	src      Node
+}
+
+// windowColumnPrefix prefixes columns of values computed by WindowSorter
+const windowColumnPrefix = "#window."
+
+func isWindowColumn(c string) bool {
+	return strings.HasPrefix(c, windowColumnPrefix)
+}
+
+func NewWindowSorter(functors []*WindowValueFunctor) *WindowSorter {
+	for i, f := range functors {
+		f.col = fmt.Sprintf("%s%d", windowColumnPrefix, i)
+	}
+	return &WindowSorter{functors: functors}
+}
+
+func (s WindowSorter) String() string {
+	return fmt.Sprintf("Window %v", s.functors)
+}
+
+func (s *WindowSorter) Exec() ([]string, []*list.Element, error) {
+	cols, in, err := s.src.Exec()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tuples := make([]*Tuple, len(in))
+	for i, e := range in {
+		tuples[i] = e.Value.(*Tuple)
+	}
+	values := make([][]any, len(s.functors))
+	for i, f := range s.functors {
+		values[i], err = f.compute(cols, tuples)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// rows may be stored tuples, so values are appended to copies
+	outCols := make([]string, len(cols), len(cols)+len(s.functors))
+	copy(outCols, cols)
+	for _, f := range s.functors {
+		outCols = append(outCols, f.col)
+	}
+	l := list.New()
+	res := make([]*list.Element, len(tuples))
+	for i, t := range tuples {
+		out := &Tuple{values: make([]any, len(t.values), len(t.values)+len(s.functors)), group: t.group}
+		copy(out.values, t.values)
+		for j := range s.functors {
+			out.values = append(out.values, values[j][i])
+		}
+		res[i] = l.PushBack(out)
+	}
+
+	return outCols, res, nil
+}
+
+func (s *WindowSorter) EstimateCardinal() int64 {
+	if s.src != nil {
+		return s.src.EstimateCardinal()
+	}
+	return 0
+}
+
+func (s *WindowSorter) Children() []Node {
+	return []Node{s.src}
+}
+
+func (s *WindowSorter) Priority() int {
+	return -2500
+}
+
+func (s *WindowSorter) SetNode(n Node) {
+	s.src = n
+}
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,7 +10,7 @@ import (
 	"github.com/proullon/ramsql/engine/parser"
 )
 
-func parseAttribute(decl *parser.Decl) (attr agnostic.Attribute, isPk bool, err error) {
+func (t *Tx) parseAttribute(decl *parser.Decl) (attr agnostic.Attribute, isPk bool, err error) {
 	var name, typeName string
 
 	// Attribute name
@@ -24,19 +25,61 @@ func parseAttribute(decl *parser.Decl) (attr agnostic.Attribute, isPk bool, err
 	}
 	switch decl.Decl[0].Token {
 	case parser.DecimalToken:
-		typeName = "float"
+		typeName = "decimal"
 	case parser.NumberToken:
 		typeName = "int"
 	case parser.DateToken:
 		typeName = "date"
+	case parser.TimeToken:
+		typeName = "time"
 	case parser.StringToken:
 		typeName = decl.Decl[0].Lexeme
 	default:
 		return agnostic.Attribute{}, false, fmt.Errorf("engine: expected attribute type, got %v:%v", decl.Decl[0].Token, decl.Decl[0].Lexeme)
 	}
+	if _, ok := decl.Decl[0].Has(parser.ArrayToken); ok {
+		typeName += "[]"
+	}
 
 	attr = agnostic.NewAttribute(name, typeName)
 
+	// NUMERIC(precision, scale), scale defaults to 0
+	if t := strings.ToLower(typeName); (t == "decimal" || t == "numeric") && len(decl.Decl[0].Decl) > 0 {
+		precision, err := strconv.Atoi(decl.Decl[0].Decl[0].Lexeme)
+		if err != nil {
+			return agnostic.Attribute{}, false, err
+		}
+		var scale int
+		if len(decl.Decl[0].Decl) > 1 {
+			scale, err = strconv.Atoi(decl.Decl[0].Decl[1].Lexeme)
+			if err != nil {
+				return agnostic.Attribute{}, false, err
+			}
+		}
+		if precision < 1 || scale < 0 || scale > precision {
+			return agnostic.Attribute{}, false, fmt.Errorf("invalid precision %d and scale %d for attribute %s", precision, scale, name)
+		}
+		attr = attr.WithPrecision(precision, scale)
+	}
+
+	// CHAR(n), VARCHAR(n), CHAR defaults to CHAR(1)
+	if t := strings.ToLower(typeName); t == "char" || t == "character" || t == "varchar" || t == "character varying" {
+		length := 0
+		if t == "char" || t == "character" {
+			length = 1
+		}
+		if len(decl.Decl[0].Decl) > 0 && decl.Decl[0].Decl[0].Token == parser.NumberToken {
+			length, err = strconv.Atoi(decl.Decl[0].Decl[0].Lexeme)
+			if err != nil {
+				return agnostic.Attribute{}, false, err
+			}
+			if length < 1 {
+				return agnostic.Attribute{}, false, fmt.Errorf("length for type %s must be at least 1", typeName)
+			}
+		}
+		attr = attr.WithLength(length)
+	}
+
 	// Maybe domain and special thing like primary key
 	typeDecl := decl.Decl[1:]
 	for i := range typeDecl {
@@ -45,9 +88,19 @@ func parseAttribute(decl *parser.Decl) (attr agnostic.Attribute, isPk bool, err
 		}
 
 		if typeDecl[i].Token == parser.DefaultToken {
-			switch typeDecl[i].Decl[0].Token {
-			case parser.LocalTimestampToken, parser.NowToken:
+			switch d := typeDecl[i].Decl[0]; {
+			case d.Token == parser.LocalTimestampToken, d.Token == parser.NowToken:
 				attr = attr.WithDefault(func() any { return time.Now() })
+			case d.Token == parser.GenRandomUUIDToken:
+				attr = attr.WithDefault(func() any { return t.e.memstore.NewRandomUUID() })
+			case isExpression(d), d.Token == parser.IntervalToken:
+				// computed on each insert, like now() + interval '1 day'
+				var odbcIdx int64 = 1
+				f, err := t.getValueFunctor(d, "", nil, nil, nil, &odbcIdx)
+				if err != nil {
+					return agnostic.Attribute{}, false, err
+				}
+				attr = attr.WithDefault(func() any { return f.Value(nil, nil) })
 			default:
 				v, err := agnostic.ToInstance(typeDecl[i].Decl[0].Lexeme, typeName)
 				if err != nil {
@@ -61,6 +114,22 @@ func parseAttribute(decl *parser.Decl) (attr agnostic.Attribute, isPk bool, err
 		if typeDecl[i].Token == parser.UniqueToken {
 			attr = attr.WithUnique()
 		}
+		if typeDecl[i].Token == parser.NotToken {
+			if len(typeDecl[i].Decl) > 0 && typeDecl[i].Decl[0].Token == parser.NullToken {
+				attr = attr.WithNotNull()
+			}
+		}
+		if typeDecl[i].Token == parser.ReferencesToken {
+			var schema, attribute string
+			tableDecl := typeDecl[i].Decl[0]
+			if d, ok := tableDecl.Has(parser.SchemaToken); ok {
+				schema = d.Lexeme
+			}
+			if len(typeDecl[i].Decl) > 1 {
+				attribute = strings.ToLower(typeDecl[i].Decl[1].Lexeme)
+			}
+			attr = attr.WithForeignKey(schema, tableDecl.Lexeme, attribute)
+		}
 		if typeDecl[i].Token == parser.PrimaryToken {
 			if len(typeDecl[i].Decl) > 0 && typeDecl[i].Decl[0].Token == parser.KeyToken {
 				isPk = true
@@ -69,7 +138,8 @@ func parseAttribute(decl *parser.Decl) (attr agnostic.Attribute, isPk bool, err
 
 	}
 
-	if strings.ToLower(typeName) == "bigserial" {
+	switch strings.ToLower(typeName) {
+	case "smallserial", "serial", "bigserial":
 		attr = attr.WithAutoIncrement()
 	}
 
@@ -0,0 +1,292 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/proullon/ramsql/engine/agnostic"
+	"github.com/proullon/ramsql/engine/parser"
+)
+
+// Breakpoint pauses statement execution inside a transaction.
+//
+// All specified conditions must match for the breakpoint to fire:
+//   - Statement matches statement text
+//   - Table matches statements touching given relation
+//   - OnError fires after a failed parsing or execution instead of before execution.
+//     Statements failing to parse touch no relation, so they never match Table.
+//
+// When fired, Handler is called with the Break and execution resumes once it returns or calls Break.Resume().
+// If Handler is nil, the Break is sent to C and execution resumes when Break.Resume() is called.
+// If the statement context is done before the Break is handled, received or resumed, the statement fails
+// with the context error.
+type Breakpoint struct {
+	Statement *regexp.Regexp
+	Table     string
+	OnError   bool
+
+	Handler func(*Break)
+	C       chan *Break
+}
+
+func (bp *Breakpoint) match(query string, tables []string, err error) bool {
+	if bp.OnError != (err != nil) {
+		return false
+	}
+
+	if bp.Statement != nil && !bp.Statement.MatchString(query) {
+		return false
+	}
+
+	if bp.Table != "" {
+		found := false
+		for _, t := range tables {
+			if strings.EqualFold(t, bp.Table) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Break is the execution state of a paused statement
+type Break struct {
+	Breakpoint *Breakpoint
+	Query      string
+	Args       []NamedValue
+	Tables     []string
+	Err        error
+	State      *TxState
+
+	resume chan struct{}
+	once   sync.Once
+}
+
+// Resume execution of paused statement. Calling it more than once has no effect.
+func (b *Break) Resume() {
+	b.once.Do(func() {
+		close(b.resume)
+	})
+}
+
+// TxState inspects a paused transaction.
+//
+// It is only usable while the statement is paused: execution resumes once running inspections are done,
+// and further ones see no change nor relation and fail to query.
+type TxState struct {
+	t        *Tx
+	released bool
+
+	sync.Mutex
+}
+
+// ID returns paused transaction identifier
+func (s *TxState) ID() int64 {
+	return s.t.id
+}
+
+// Changes returns the number of changes not yet committed
+func (s *TxState) Changes() int {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.released {
+		return 0
+	}
+	return s.t.tx.Changes()
+}
+
+// Relations returns the relations locked by paused transaction
+func (s *TxState) Relations() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.released {
+		return nil
+	}
+	return s.t.tx.Relations()
+}
+
+// Query runs a SELECT statement inside paused transaction, seeing its uncommitted changes.
+//
+// Like any statement, a failed query aborts the transaction.
+func (s *TxState) Query(query string, args ...any) ([]string, []*agnostic.Tuple, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.released {
+		return nil, nil, fmt.Errorf("transaction %d is not paused anymore", s.t.id)
+	}
+
+	instructions, err := parser.ParseInstruction(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(instructions) != 1 || len(instructions[0].Decls) == 0 || instructions[0].Decls[0].Token != parser.SelectToken {
+		return nil, nil, fmt.Errorf("only SELECT statements are allowed in paused transaction")
+	}
+
+	nargs := make([]NamedValue, len(args))
+	for i, a := range args {
+		nargs[i] = NamedValue{Name: fmt.Sprintf("%d", i+1), Ordinal: i + 1, Value: a}
+	}
+
+	_, _, cols, res, err := selectExecutor(s.t, instructions[0].Decls[0], nargs)
+	return cols, res, err
+}
+
+// release waits for running inspections of paused transaction, forbidding further ones
+func (s *TxState) release() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.released = true
+}
+
+type breakpoints struct {
+	list []*Breakpoint
+
+	sync.RWMutex
+}
+
+func (b *breakpoints) match(query string, tables []string, err error) []*Breakpoint {
+	b.RLock()
+	defer b.RUnlock()
+
+	var bps []*Breakpoint
+	for _, bp := range b.list {
+		if bp.match(query, tables, err) {
+			bps = append(bps, bp)
+		}
+	}
+	return bps
+}
+
+// AddBreakpoint registers a breakpoint on all transactions of the engine
+func (e *Engine) AddBreakpoint(bp *Breakpoint) {
+	e.breakpoints.Lock()
+	defer e.breakpoints.Unlock()
+
+	e.breakpoints.list = append(e.breakpoints.list, bp)
+}
+
+// RemoveBreakpoint unregisters given breakpoint
+func (e *Engine) RemoveBreakpoint(bp *Breakpoint) {
+	e.breakpoints.Lock()
+	defer e.breakpoints.Unlock()
+
+	for i, b := range e.breakpoints.list {
+		if b == bp {
+			e.breakpoints.list = append(e.breakpoints.list[:i], e.breakpoints.list[i+1:]...)
+			return
+		}
+	}
+}
+
+// ClearBreakpoints unregisters all breakpoints
+func (e *Engine) ClearBreakpoints() {
+	e.breakpoints.Lock()
+	defer e.breakpoints.Unlock()
+
+	e.breakpoints.list = nil
+}
+
+// pause fires all breakpoints matching statement, blocking until each one is resumed or ctx is done
+func (t *Tx) pause(ctx context.Context, query string, decl *parser.Decl, args []NamedValue, err error) error {
+	tables := touchedRelations(decl)
+
+	for _, bp := range t.e.breakpoints.match(query, tables, err) {
+		b := &Break{
+			Breakpoint: bp,
+			Query:      query,
+			Args:       args,
+			Tables:     tables,
+			Err:        err,
+			State:      &TxState{t: t},
+			resume:     make(chan struct{}),
+		}
+
+		err := b.wait(ctx)
+		b.State.release()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// wait blocks until b is handled or resumed, or ctx is done
+func (b *Break) wait(ctx context.Context) error {
+	switch {
+	case b.Breakpoint.Handler != nil:
+		go func() {
+			b.Breakpoint.Handler(b)
+			b.Resume()
+		}()
+	case b.Breakpoint.C != nil:
+		select {
+		case b.Breakpoint.C <- b:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	default:
+		return nil
+	}
+
+	select {
+	case <-b.resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// touchedRelations lists relations named in statement declaration
+func touchedRelations(decl *parser.Decl) []string {
+	var tables []string
+
+	if decl == nil {
+		return nil
+	}
+
+	switch decl.Token {
+	case parser.UpdateToken, parser.TruncateToken:
+		if len(decl.Decl) > 0 {
+			tables = append(tables, decl.Decl[0].Lexeme)
+		}
+	case parser.FromToken, parser.IntoToken:
+		for _, d := range decl.Decl {
+			tables = append(tables, d.Lexeme)
+		}
+		return tables
+	case parser.JoinToken:
+		if len(decl.Decl) > 0 {
+			tables = append(tables, decl.Decl[0].Lexeme)
+		}
+		return tables
+	case parser.TableToken:
+		for _, d := range decl.Decl {
+			if d.Token == parser.IfToken {
+				continue
+			}
+			tables = append(tables, d.Lexeme)
+			break
+		}
+		return tables
+	}
+
+	for _, d := range decl.Decl {
+		tables = append(tables, touchedRelations(d)...)
+	}
+
+	return tables
+}
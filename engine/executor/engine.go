@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/proullon/ramsql/engine/agnostic"
 	"github.com/proullon/ramsql/engine/log"
@@ -16,7 +17,10 @@ import (
 
 // Engine is the root struct of RamSQL server
 type Engine struct {
-	memstore *agnostic.Engine
+	memstore    *agnostic.Engine
+	history     history
+	breakpoints breakpoints
+	lastTx      atomic.Int64
 }
 
 // New initialize a new RamSQL server
@@ -41,6 +45,12 @@ func (e *Engine) Begin() (*Tx, error) {
 func (e *Engine) Stop() {
 }
 
+// SeedRandom sets the random source of values generated by e, like gen_random_uuid() and random(),
+// making them deterministic across runs. Other engines are not affected.
+func (e *Engine) SeedRandom(seed int64) {
+	e.memstore.SeedRandom(seed)
+}
+
 func createExecutor(t *Tx, decl *parser.Decl, args []NamedValue) (int64, int64, []string, []*agnostic.Tuple, error) {
 
 	if len(decl.Decl) == 0 {
@@ -66,6 +76,12 @@ func dropExecutor(t *Tx, decl *parser.Decl, args []NamedValue) (int64, int64, []
 	if _, ok := decl.Has(parser.SchemaToken); ok {
 		return dropSchema(t, decl.Decl[0], args)
 	}
+	if _, ok := decl.Has(parser.TypeToken); ok {
+		return dropType(t, decl.Decl[0], args)
+	}
+	if _, ok := decl.Has(parser.DomainToken); ok {
+		return dropDomain(t, decl.Decl[0], args)
+	}
 
 	return 0, 0, nil, nil, NotImplemented
 }
@@ -131,6 +147,171 @@ func dropSchema(t *Tx, decl *parser.Decl, args []NamedValue) (int64, int64, []st
 	return 0, 1, nil, nil, nil
 }
 
+func dropType(t *Tx, decl *parser.Decl, args []NamedValue) (int64, int64, []string, []*agnostic.Tuple, error) {
+	if len(decl.Decl) == 0 {
+		return 0, 1, nil, nil, ParsingError
+	}
+	// Check if 'IF EXISTS' is present
+	ifExists := hasIfExists(decl)
+
+	rDecl := decl.Decl[0]
+	if ifExists {
+		rDecl = decl.Decl[1]
+	}
+	schema, name := typeName(rDecl)
+
+	if ifExists && !t.tx.CheckType(schema, name) {
+		return 0, 0, nil, nil, nil
+	}
+
+	err := t.tx.DropType(schema, name)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+
+	return 0, 1, nil, nil, nil
+}
+
+func dropDomain(t *Tx, decl *parser.Decl, args []NamedValue) (int64, int64, []string, []*agnostic.Tuple, error) {
+	if len(decl.Decl) == 0 {
+		return 0, 1, nil, nil, ParsingError
+	}
+	// Check if 'IF EXISTS' is present
+	ifExists := hasIfExists(decl)
+
+	rDecl := decl.Decl[0]
+	if ifExists {
+		rDecl = decl.Decl[1]
+	}
+	schema, name := typeName(rDecl)
+
+	if ifExists && !t.tx.CheckDomain(schema, name) {
+		return 0, 0, nil, nil, nil
+	}
+
+	err := t.tx.DropDomain(schema, name)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+
+	return 0, 1, nil, nil, nil
+}
+
+// typeName returns schema and name of a user-defined type
+func typeName(decl *parser.Decl) (string, string) {
+	schema := agnostic.DefaultSchema
+	if len(decl.Decl) > 0 {
+		schema = decl.Decl[0].Lexeme
+	}
+	return schema, strings.ToLower(decl.Lexeme)
+}
+
+func createTypeExecutor(t *Tx, typeDecl *parser.Decl, args []NamedValue) (int64, int64, []string, []*agnostic.Tuple, error) {
+	if len(typeDecl.Decl) != 2 {
+		return 0, 0, nil, nil, ParsingError
+	}
+
+	schema, name := typeName(typeDecl.Decl[0])
+
+	enumDecl, ok := typeDecl.Has(parser.EnumToken)
+	if !ok {
+		return 0, 0, nil, nil, NotImplemented
+	}
+	labels := make([]string, len(enumDecl.Decl))
+	for i, d := range enumDecl.Decl {
+		labels[i] = d.Lexeme
+	}
+
+	err := t.tx.CreateEnumType(schema, name, labels)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+
+	return 0, 0, nil, nil, nil
+}
+
+func createDomainExecutor(t *Tx, domainDecl *parser.Decl, args []NamedValue) (int64, int64, []string, []*agnostic.Tuple, error) {
+	if len(domainDecl.Decl) < 2 {
+		return 0, 0, nil, nil, ParsingError
+	}
+
+	schema, name := typeName(domainDecl.Decl[0])
+
+	// base type, default value and nullability are declared like an attribute
+	attrDecl := &parser.Decl{Token: parser.StringToken, Lexeme: name}
+	var checks []agnostic.Predicate
+	for _, d := range domainDecl.Decl[1:] {
+		if d.Token != parser.CheckToken {
+			attrDecl.Add(d)
+			continue
+		}
+		p, err := t.getPredicates(d.Decl, schema, "", args, nil)
+		if err != nil {
+			return 0, 0, nil, nil, fmt.Errorf("invalid check constraint for domain %s: %w", name, err)
+		}
+		checks = append(checks, p)
+	}
+	base, _, err := t.parseAttribute(attrDecl)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+
+	var check agnostic.Predicate
+	for _, p := range checks {
+		if check == nil {
+			check = p
+			continue
+		}
+		check = agnostic.NewAndPredicate(check, p)
+	}
+
+	err = t.tx.CreateDomain(schema, name, base, check)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+
+	return 0, 0, nil, nil, nil
+}
+
+// alterExecutor handles ALTER TYPE name ADD VALUE
+func alterExecutor(t *Tx, alterDecl *parser.Decl, args []NamedValue) (int64, int64, []string, []*agnostic.Tuple, error) {
+	if len(alterDecl.Decl) == 0 || alterDecl.Decl[0].Token != parser.TypeToken {
+		return 0, 0, nil, nil, NotImplemented
+	}
+	typeDecl := alterDecl.Decl[0]
+	if len(typeDecl.Decl) != 2 {
+		return 0, 0, nil, nil, ParsingError
+	}
+
+	schema, name := typeName(typeDecl.Decl[0])
+
+	valueDecl, ok := typeDecl.Has(parser.ValueToken)
+	if !ok {
+		return 0, 0, nil, nil, NotImplemented
+	}
+
+	var label, neighbor string
+	var after bool
+	for _, d := range valueDecl.Decl {
+		switch {
+		case d.Token == parser.IfToken:
+		case d.Lexeme == "before" && len(d.Decl) == 1:
+			neighbor = d.Decl[0].Lexeme
+		case d.Lexeme == "after" && len(d.Decl) == 1:
+			neighbor, after = d.Decl[0].Lexeme, true
+		default:
+			label = d.Lexeme
+		}
+	}
+
+	err := t.tx.AddEnumValue(schema, name, label, neighbor, after, hasIfNotExists(valueDecl))
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+
+	return 0, 0, nil, nil, nil
+}
+
 func grantExecutor(*Tx, *parser.Decl, []NamedValue) (int64, int64, []string, []*agnostic.Tuple, error) {
 	return 0, 1, nil, nil, nil
 }
@@ -209,7 +390,7 @@ func createTableExecutor(t *Tx, tableDecl *parser.Decl, args []NamedValue) (int6
 		if tableDecl.Decl[i].Token != parser.StringToken {
 			break
 		}
-		attr, isPk, err := parseAttribute(tableDecl.Decl[i])
+		attr, isPk, err := t.parseAttribute(tableDecl.Decl[i])
 		if err != nil {
 			return 0, 0, nil, nil, err
 		}
@@ -285,7 +466,7 @@ func insertIntoTableExecutor(t *Tx, insertDecl *parser.Decl, args []NamedValue)
 	var tuples []*agnostic.Tuple
 	valuesDecl := insertDecl.Decl[1]
 	for _, valueListDecl := range valuesDecl.Decl {
-		values, err := getValues(specifiedAttrs, valueListDecl, args)
+		values, err := t.getValues(specifiedAttrs, valueListDecl, args)
 		if err != nil {
 			return 0, 0, nil, nil, err
 		}
@@ -314,7 +495,7 @@ func insertIntoTableExecutor(t *Tx, insertDecl *parser.Decl, args []NamedValue)
 	return lastInsertedID, int64(len(tuples)), returningAttrs, tuples, nil
 }
 
-func getValues(specifiedAttrs []string, valuesDecl *parser.Decl, args []NamedValue) (map[string]any, error) {
+func (t *Tx) getValues(specifiedAttrs []string, valuesDecl *parser.Decl, args []NamedValue) (map[string]any, error) {
 	var typeName string
 	var err error
 	values := make(map[string]any)
@@ -333,7 +514,8 @@ func getValues(specifiedAttrs []string, valuesDecl *parser.Decl, args []NamedVal
 		case parser.TextToken:
 			typeName = "text"
 		case parser.FloatToken:
-			typeName = "float"
+			// kept exact until assigned, 1.50 is not 1.5 in a NUMERIC attribute
+			typeName = "decimal"
 		default:
 			typeName = "text"
 			if _, err := agnostic.ToInstance(d.Lexeme, "timestamp"); err == nil {
@@ -365,11 +547,21 @@ func getValues(specifiedAttrs []string, valuesDecl *parser.Decl, args []NamedVal
 					v = arg.Value
 				}
 			}
+		case parser.GenRandomUUIDToken:
+			v = t.e.memstore.NewRandomUUID()
+		case parser.FunctionToken, parser.ConcatToken, parser.PlusToken, parser.MinusToken, parser.MultiplyToken, parser.DivideToken,
+			parser.ModuloToken, parser.IntervalToken, parser.CaseToken, parser.CastToken:
+			v, err = t.evalExpression(d, args, &odbcIdx)
+			if err != nil {
+				return nil, err
+			}
 		default:
 			v, err = agnostic.ToInstance(d.Lexeme, typeName)
 			if err != nil {
 				return nil, err
 			}
+			// literal without time zone is read in session time zone
+			v = agnostic.ReadTimeIn(v, t.location)
 		}
 		values[strings.ToLower(specifiedAttrs[i])] = v
 	}
@@ -377,7 +569,7 @@ func getValues(specifiedAttrs []string, valuesDecl *parser.Decl, args []NamedVal
 	return values, nil
 }
 
-func getSet(specifiedAttrs []string, values map[string]any, valuesDecl *parser.Decl, args []NamedValue) (map[string]any, error) {
+func (t *Tx) getSet(specifiedAttrs []string, values map[string]any, valuesDecl *parser.Decl, args []NamedValue) (map[string]any, error) {
 	var typeName string
 	var err error
 	var odbcIdx int64 = 1
@@ -388,12 +580,10 @@ func getSet(specifiedAttrs []string, values map[string]any, valuesDecl *parser.D
 	switch valueDecl.Token {
 	case parser.IntToken, parser.NumberToken:
 		typeName = "bigint"
-	case parser.DecimalToken:
-		typeName = "float"
+	case parser.DecimalToken, parser.FloatToken:
+		typeName = "decimal"
 	case parser.DateToken:
 		typeName = "timestamp"
-	case parser.TextToken:
-		typeName = "text"
 	default:
 		typeName = "text"
 		if _, err := agnostic.ToInstance(valueDecl.Lexeme, "timestamp"); err == nil {
@@ -419,11 +609,15 @@ func getSet(specifiedAttrs []string, values map[string]any, valuesDecl *parser.D
 			return nil, fmt.Errorf("reference to $%s, but only %d argument provided", valueDecl.Lexeme, len(args))
 		}
 		v = args[idx-1].Value
+	case parser.GenRandomUUIDToken:
+		v = t.e.memstore.NewRandomUUID()
 	default:
 		v, err = agnostic.ToInstance(valueDecl.Lexeme, typeName)
 		if err != nil {
 			return nil, err
 		}
+		// literal without time zone is read in session time zone
+		v = agnostic.ReadTimeIn(v, t.location)
 	}
 	values[nameDecl.Lexeme] = v
 
@@ -478,22 +672,39 @@ func selectExecutor(t *Tx, selectDecl *parser.Decl, args []NamedValue) (int64, i
 	var tables []string
 	var err error
 	var aliases map[string]string
+	var groupDecl, havingDecl *parser.Decl
+	// relations joined so far, in query order
+	var joined []string
+
+	// window functions of subqueries are computed by their own WindowSorter
+	outerWindows := t.windows
+	t.windows = nil
+	defer func() { t.windows = outerWindows }()
 
 	for i := range selectDecl.Decl {
 		switch selectDecl.Decl[i].Token {
 		case parser.FromToken:
 			schema, tables, aliases = getSelectedTables(selectDecl.Decl[i])
+			// relations separated by commas are cross joined
+			joined = append(joined, tables...)
+			for _, rel := range tables[1:] {
+				joiners = append(joiners, agnostic.NewCrossJoin(tables[0], rel))
+			}
 		case parser.WhereToken:
+			if hasWindow(selectDecl.Decl[i]) {
+				return 0, 0, nil, nil, fmt.Errorf("window functions are not allowed in WHERE")
+			}
 			predicate, err = t.getPredicates(selectDecl.Decl[i].Decl, schema, tables[0], args, aliases)
 			if err != nil {
 				return 0, 0, nil, nil, err
 			}
 		case parser.JoinToken:
-			j, err := t.getJoin(selectDecl.Decl[i], tables[0])
+			j, err := t.getJoin(selectDecl.Decl[i], schema, joined, args, aliases)
 			if err != nil {
 				return 0, 0, nil, nil, err
 			}
 			joiners = append(joiners, j)
+			joined = append(joined, selectDecl.Decl[i].Decl[0].Lexeme)
 		case parser.OffsetToken:
 			offset, err := strconv.Atoi(selectDecl.Decl[i].Decl[0].Lexeme)
 			if err != nil {
@@ -508,7 +719,7 @@ func selectExecutor(t *Tx, selectDecl *parser.Decl, args []NamedValue) (int64, i
 			}
 			sorters = append(sorters, s)
 		case parser.OrderToken:
-			s, err := orderbyExecutor(selectDecl.Decl[i], tables)
+			s, err := t.orderbyExecutor(selectDecl.Decl[i], schema, tables, aliases, args)
 			if err != nil {
 				return 0, 0, nil, nil, err
 			}
@@ -520,6 +731,10 @@ func selectExecutor(t *Tx, selectDecl *parser.Decl, args []NamedValue) (int64, i
 			}
 			s := agnostic.NewLimitSorter(limit)
 			sorters = append(sorters, s)
+		case parser.GroupToken:
+			groupDecl = selectDecl.Decl[i]
+		case parser.HavingToken:
+			havingDecl = selectDecl.Decl[i]
 		}
 	}
 
@@ -527,10 +742,40 @@ func selectExecutor(t *Tx, selectDecl *parser.Decl, args []NamedValue) (int64, i
 		predicate = agnostic.NewTruePredicate()
 	}
 
+	// aggregates are computed on groups, all rows forming a single group without GROUP BY
+	if groupDecl != nil || havingDecl != nil || isAggregateQuery(selectDecl) {
+		s, err := t.groupExecutor(selectDecl, groupDecl, havingDecl, schema, tables, aliases, args)
+		if err != nil {
+			return 0, 0, nil, nil, err
+		}
+		sorters = append(sorters, s...)
+	}
+
 	for i := 0; i < len(selectDecl.Decl); i++ {
-		if selectDecl.Decl[i].Token != parser.StringToken &&
-			selectDecl.Decl[i].Token != parser.StarToken &&
-			selectDecl.Decl[i].Token != parser.CountToken {
+		switch selectDecl.Decl[i].Token {
+		case parser.FunctionToken, parser.ArrowToken, parser.DoubleArrowToken, parser.HashArrowToken, parser.HashDoubleArrowToken, parser.ConcatToken,
+			parser.PlusToken, parser.MinusToken, parser.MultiplyToken, parser.DivideToken, parser.ModuloToken, parser.CaseToken, parser.CastToken,
+			parser.CountToken, parser.FilterToken, parser.OverToken,
+			parser.TextToken, parser.NumberToken, parser.FloatToken, parser.NullToken, parser.FalseToken, parser.IntervalToken,
+			parser.NowToken, parser.GenRandomUUIDToken, parser.CurrentSchemaToken, parser.ArgToken, parser.NamedArgToken:
+			selector, err := t.getFunctorSelector(selectDecl.Decl[i], schema, tables, aliases, args)
+			if err != nil {
+				return 0, 0, nil, nil, err
+			}
+			selectors = append(selectors, selector)
+			continue
+		case parser.StarToken:
+			// attributes of all joined relations with JOIN ... USING, of the first relation otherwise
+			if hasUsingJoin(selectDecl) {
+				star, err := t.getStarSelectors(selectDecl, schema, tables)
+				if err != nil {
+					return 0, 0, nil, nil, err
+				}
+				selectors = append(selectors, star...)
+				continue
+			}
+		case parser.StringToken:
+		default:
 			continue
 		}
 		// get attribute to select
@@ -541,6 +786,11 @@ func selectExecutor(t *Tx, selectDecl *parser.Decl, args []NamedValue) (int64, i
 		selectors = append(selectors, selector)
 	}
 
+	// window functions are computed after grouping, before ordering
+	if len(t.windows) > 0 {
+		sorters = append(sorters, agnostic.NewWindowSorter(t.windows))
+	}
+
 	log.Debug("executing '%s' with %s, joining with %s and sorting with %s", selectors, predicate, joiners, sorters)
 	cols, res, err := t.tx.Query(schema, selectors, predicate, joiners, sorters)
 	if err != nil {
@@ -635,14 +885,25 @@ func updateExecutor(t *Tx, updateDecl *parser.Decl, args []NamedValue) (int64, i
 		return 0, 0, nil, nil, err
 	}
 
-	if predicate == nil {
-		predicate = agnostic.NewTruePredicate()
+	// WHERE 1 does not reference updated relation
+	if predicate == nil || predicate.Type() == agnostic.True {
+		predicate = agnostic.NewRelationTruePredicate(relation)
 	}
 
 	//	var tuples []*agnostic.Tuple
 	values := make(map[string]any)
 	for _, s := range setDecl.Decl {
-		_, err = getSet(specifiedAttrs, values, s, args)
+		// value computed from updated row, like jsonb_set(data, ...), tags || 'new' or another attribute
+		if len(s.Decl) > 1 && (isExpression(s.Decl[1]) || t.namesAttribute(s.Decl[1], schema, relation)) {
+			var odbcIdx int64 = 1
+			f, err := t.getValueFunctor(s.Decl[1], schema, []string{relation}, nil, args, &odbcIdx)
+			if err != nil {
+				return 0, 0, nil, nil, err
+			}
+			values[s.Lexeme] = f
+			continue
+		}
+		_, err = t.getSet(specifiedAttrs, values, s, args)
 		if err != nil {
 			return 0, 0, nil, nil, err
 		}
@@ -726,7 +987,7 @@ func truncateExecutor(t *Tx, trDecl *parser.Decl, args []NamedValue) (int64, int
 	return 0, c, nil, nil, nil
 }
 
-func orderbyExecutor(decl *parser.Decl, tables []string) (agnostic.Sorter, error) {
+func (t *Tx) orderbyExecutor(decl *parser.Decl, schema string, tables []string, aliases map[string]string, args []NamedValue) (agnostic.Sorter, error) {
 	var orderingTk int
 	var valDecl *parser.Decl
 	var attrs []agnostic.SortExpression
@@ -738,16 +999,34 @@ func orderbyExecutor(decl *parser.Decl, tables []string) (agnostic.Sorter, error
 	for i := 0; i < len(valDecl.Decl); i++ {
 		attr := valDecl.Decl[i].Lexeme
 		attrDecl := valDecl.Decl[i]
+
+		// computed value, like ORDER BY price * qty DESC
+		if attrDecl.Token == parser.AscToken || attrDecl.Token == parser.DescToken {
+			if len(attrDecl.Decl) != 1 {
+				return nil, ParsingError
+			}
+			var odbcIdx int64 = 1
+			f, err := t.getValueFunctor(attrDecl.Decl[0], schema, tables, aliases, args, &odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+			direction := agnostic.ASC
+			if attrDecl.Token == parser.DescToken {
+				direction = agnostic.DESC
+			}
+			attrs = append(attrs, agnostic.NewSortValueExpression(f, direction))
+			continue
+		}
 		if len(attrDecl.Decl) == 2 {
 			relationDecl := attrDecl.Decl[0]
 			orderingDecl := attrDecl.Decl[1]
-			relation = relationDecl.Lexeme
+			relation = getAlias(relationDecl.Lexeme, aliases)
 			orderingTk = orderingDecl.Token
 		} else if len(attrDecl.Decl) == 1 {
 			switch attrDecl.Decl[0].Token {
 			case parser.StringToken:
 				orderingTk = parser.AscToken
-				relation = attrDecl.Decl[0].Lexeme
+				relation = getAlias(attrDecl.Decl[0].Lexeme, aliases)
 			case parser.AscToken, parser.DescToken:
 				orderingTk = attrDecl.Decl[0].Token
 				relation = tables[0]
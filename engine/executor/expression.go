@@ -0,0 +1,871 @@
+package executor
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/proullon/ramsql/engine/agnostic"
+	"github.com/proullon/ramsql/engine/parser"
+)
+
+// getValueFunctor builds the ValueFunctor computing given expression.
+// Attributes are looked up in given tables.
+func (t *Tx) getValueFunctor(decl *parser.Decl, schema string, tables []string, aliases map[string]string, args []NamedValue, odbcIdx *int64) (agnostic.ValueFunctor, error) {
+	switch decl.Token {
+	case parser.ArrowToken, parser.DoubleArrowToken, parser.HashArrowToken, parser.HashDoubleArrowToken:
+		if len(decl.Decl) != 2 {
+			return nil, ParsingError
+		}
+		left, err := t.getValueFunctor(decl.Decl[0], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		right, err := t.getValueFunctor(decl.Decl[1], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		switch decl.Token {
+		case parser.ArrowToken:
+			return agnostic.NewJSONFieldValueFunctor(left, right, false), nil
+		case parser.DoubleArrowToken:
+			return agnostic.NewJSONFieldValueFunctor(left, right, true), nil
+		case parser.HashArrowToken:
+			return agnostic.NewJSONPathValueFunctor(left, right, false), nil
+		default:
+			return agnostic.NewJSONPathValueFunctor(left, right, true), nil
+		}
+	case parser.ConcatToken:
+		if len(decl.Decl) != 2 {
+			return nil, ParsingError
+		}
+		left, err := t.getValueFunctor(decl.Decl[0], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		right, err := t.getValueFunctor(decl.Decl[1], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		return agnostic.NewConcatValueFunctor(left, right), nil
+	case parser.PlusToken, parser.MinusToken, parser.MultiplyToken, parser.DivideToken, parser.ModuloToken:
+		if decl.Token == parser.MinusToken && len(decl.Decl) == 1 {
+			v, err := t.getValueFunctor(decl.Decl[0], schema, tables, aliases, args, odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+			return agnostic.NewNegValueFunctor(v), nil
+		}
+		if len(decl.Decl) != 2 {
+			return nil, ParsingError
+		}
+		// timestamps are computed on the wall clock of session time zone
+		left, err := t.getValueFunctor(decl.Decl[0], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		right, err := t.getValueFunctor(decl.Decl[1], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		return agnostic.NewArithmeticValueFunctor(decl.Lexeme[0], agnostic.NewTimeZoneValueFunctor(left, t.location), agnostic.NewTimeZoneValueFunctor(right, t.location))
+	case parser.CountToken:
+		return t.getAggregateValueFunctor(decl, schema, tables, aliases, args, odbcIdx)
+	case parser.FilterToken:
+		if len(decl.Decl) != 2 {
+			return nil, ParsingError
+		}
+		if !isAggregate(decl.Decl[0]) {
+			return nil, fmt.Errorf("FILTER specified, but %s is not an aggregate function", decl.Decl[0].Lexeme)
+		}
+		p, err := t.getSearchPredicate(decl.Decl[1], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		return t.getAggregateValueFunctor(decl.Decl[0], schema, tables, aliases, args, odbcIdx, agnostic.WithFilter(p))
+	case parser.OverToken:
+		return t.getWindowValueFunctor(decl, schema, tables, aliases, args, odbcIdx)
+	case parser.FunctionToken:
+		// aggregate function, like sum(amount), computed on rows of each group
+		if agnostic.IsAggregate(decl.Lexeme) {
+			return t.getAggregateValueFunctor(decl, schema, tables, aliases, args, odbcIdx)
+		}
+		if agnostic.IsWindow(decl.Lexeme) {
+			return nil, fmt.Errorf("window function %s requires an OVER clause", decl.Lexeme)
+		}
+		if len(decl.Decl) > 0 && decl.Decl[0].Token == parser.DistinctToken {
+			return nil, fmt.Errorf("DISTINCT specified, but %s is not an aggregate function", decl.Lexeme)
+		}
+		// timestamps are passed on the wall clock of session time zone, like date_trunc('day', ts)
+		fargs := make([]agnostic.ValueFunctor, len(decl.Decl))
+		for i, d := range decl.Decl {
+			f, err := t.getValueFunctor(d, schema, tables, aliases, args, odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+			fargs[i] = agnostic.NewTimeZoneValueFunctor(f, t.location)
+		}
+		return t.e.memstore.NewFunctionValueFunctor(decl.Lexeme, fargs...)
+	case parser.CaseToken:
+		return t.getCaseValueFunctor(decl, schema, tables, aliases, args, odbcIdx)
+	case parser.CastToken:
+		if len(decl.Decl) != 2 {
+			return nil, ParsingError
+		}
+		v, err := t.getValueFunctor(decl.Decl[0], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		attr, err := t.castAttribute(decl.Decl[1], schema)
+		if err != nil {
+			return nil, err
+		}
+		from, err := t.sourceAttribute(decl.Decl[0], v, schema)
+		if err != nil {
+			return nil, err
+		}
+		// instants are cast on the wall clock of session time zone, like ts::date
+		return agnostic.NewCastValueFunctor(agnostic.NewTimeZoneValueFunctor(v, t.location), from, attr), nil
+	case parser.TextToken:
+		return agnostic.NewConstValueFunctor(decl.Lexeme), nil
+	case parser.IntervalToken:
+		v, err := agnostic.ParseInterval(decl.Lexeme)
+		if err != nil {
+			return nil, err
+		}
+		return agnostic.NewConstValueFunctor(v), nil
+	case parser.NumberToken, parser.FloatToken:
+		v, err := agnostic.ToInstance(decl.Lexeme, parser.TypeNameFromToken(decl.Token))
+		if err != nil {
+			return nil, err
+		}
+		return agnostic.NewConstValueFunctor(v), nil
+	case parser.NullToken:
+		return agnostic.NewConstValueFunctor(nil), nil
+	case parser.FalseToken:
+		return agnostic.NewConstValueFunctor(false), nil
+	case parser.NowToken:
+		return agnostic.NewNowValueFunctor(), nil
+	case parser.GenRandomUUIDToken:
+		return t.e.memstore.NewFunctionValueFunctor("gen_random_uuid")
+	case parser.CurrentSchemaToken:
+		if schema == "" {
+			return agnostic.NewConstValueFunctor(agnostic.DefaultSchema), nil
+		}
+		return agnostic.NewConstValueFunctor(schema), nil
+	case parser.ValueToken:
+		return agnostic.NewDomainValueFunctor(), nil
+	case parser.ArgToken, parser.NamedArgToken:
+		v, err := argValue(decl, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		return agnostic.NewConstValueFunctor(v), nil
+	case parser.StringToken:
+		attribute := strings.ToLower(decl.Lexeme)
+		if len(decl.Decl) > 0 {
+			rel := getAlias(decl.Decl[0].Lexeme, aliases)
+			if _, _, err := t.tx.RelationAttribute(schema, rel, attribute); err != nil {
+				return nil, err
+			}
+			return agnostic.NewAttributeValueFunctor(rel, attribute), nil
+		}
+		// true is lexed as an identifier, but cannot name an attribute
+		if attribute == "true" {
+			return agnostic.NewConstValueFunctor(true), nil
+		}
+		err := fmt.Errorf("attribute %s does not exist", attribute)
+		for _, table := range tables {
+			rel := getAlias(table, aliases)
+			_, _, err = t.tx.RelationAttribute(schema, rel, attribute)
+			if err == nil {
+				return agnostic.NewAttributeValueFunctor(rel, attribute), nil
+			}
+		}
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("cannot handle %s in expression", decl.Lexeme)
+}
+
+// castAttribute returns an attribute of type parsed in typeDecl, like NUMERIC(10,2) or a domain
+func (t *Tx) castAttribute(typeDecl *parser.Decl, schema string) (agnostic.Attribute, error) {
+	attrDecl := &parser.Decl{Token: parser.StringToken, Lexeme: "?column?"}
+	attrDecl.Add(typeDecl)
+	attr, _, err := t.parseAttribute(attrDecl)
+	if err != nil {
+		return agnostic.Attribute{}, err
+	}
+	return t.tx.TypedAttribute(schema, attr)
+}
+
+// sourceAttribute returns the attribute typing values of f, computed by decl, if it is known:
+// when f is an attribute or a cast. Otherwise an empty attribute is returned.
+func (t *Tx) sourceAttribute(decl *parser.Decl, f agnostic.ValueFunctor, schema string) (agnostic.Attribute, error) {
+	switch decl.Token {
+	case parser.StringToken:
+		if attrs := f.Attribute(); len(attrs) == 1 && f.Relation() != "" {
+			_, attr, err := t.tx.RelationAttribute(schema, f.Relation(), attrs[0])
+			return attr, err
+		}
+	case parser.CastToken:
+		return t.castAttribute(decl.Decl[1], schema)
+	}
+	return agnostic.Attribute{}, nil
+}
+
+// getCaseValueFunctor builds the ValueFunctor computing a CASE expression. Simple CASE x WHEN v
+// is computed as searched CASE WHEN x = v.
+func (t *Tx) getCaseValueFunctor(decl *parser.Decl, schema string, tables []string, aliases map[string]string, args []NamedValue, odbcIdx *int64) (agnostic.ValueFunctor, error) {
+	var operand, els agnostic.ValueFunctor
+	var conditions []agnostic.Predicate
+	var results []agnostic.ValueFunctor
+
+	for _, d := range decl.Decl {
+		switch d.Token {
+		case parser.WhenToken:
+			if len(d.Decl) != 2 {
+				return nil, ParsingError
+			}
+			var cond agnostic.Predicate
+			var err error
+			if operand != nil {
+				var v agnostic.ValueFunctor
+				v, err = t.getValueFunctor(d.Decl[0], schema, tables, aliases, args, odbcIdx)
+				if err != nil {
+					return nil, err
+				}
+				cond, err = agnostic.NewComparisonPredicate(operand, agnostic.Eq, v)
+			} else {
+				cond, err = t.getSearchPredicate(d.Decl[0], schema, tables, aliases, args, odbcIdx)
+			}
+			if err != nil {
+				return nil, err
+			}
+			result, err := t.getValueFunctor(d.Decl[1], schema, tables, aliases, args, odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, cond)
+			results = append(results, result)
+		case parser.ElseToken:
+			if len(d.Decl) != 1 {
+				return nil, ParsingError
+			}
+			var err error
+			els, err = t.getValueFunctor(d.Decl[0], schema, tables, aliases, args, odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			var err error
+			operand, err = t.getValueFunctor(d, schema, tables, aliases, args, odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return agnostic.NewCaseValueFunctor(conditions, results, els)
+}
+
+// getSearchPredicate builds predicate of a CASE WHEN or HAVING condition, whose AND and OR links
+// hold both operands. A value alone, like WHEN active, is true if the value is.
+func (t *Tx) getSearchPredicate(cond *parser.Decl, schema string, tables []string, aliases map[string]string, args []NamedValue, odbcIdx *int64) (agnostic.Predicate, error) {
+	switch {
+	case (cond.Token == parser.AndToken || cond.Token == parser.OrToken) && len(cond.Decl) == 2:
+		left, err := t.getSearchPredicate(cond.Decl[0], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		right, err := t.getSearchPredicate(cond.Decl[1], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		if cond.Token == parser.AndToken {
+			return agnostic.NewAndPredicate(left, right), nil
+		}
+		return agnostic.NewOrPredicate(left, right), nil
+	case cond.Token == parser.NotToken && len(cond.Decl) == 1:
+		p, err := t.getSearchPredicate(cond.Decl[0], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		return agnostic.NewNotPredicate(p), nil
+	case isExpressionCondition(cond):
+		return t.getExpressionPredicate(cond, schema, tables, args, aliases, odbcIdx)
+	}
+
+	v, err := t.getValueFunctor(cond, schema, tables, aliases, args, odbcIdx)
+	if err != nil {
+		return nil, err
+	}
+	return agnostic.NewComparisonPredicate(v, agnostic.Eq, agnostic.NewConstValueFunctor(true))
+}
+
+// argValue returns value of $n, ? or :name argument
+func argValue(decl *parser.Decl, args []NamedValue, odbcIdx *int64) (any, error) {
+	if decl.Token == parser.NamedArgToken {
+		for _, arg := range args {
+			if arg.Name == decl.Lexeme {
+				return arg.Value, nil
+			}
+		}
+		return nil, fmt.Errorf("no named argument found for '%s'", decl.Lexeme)
+	}
+
+	var idx int64
+	var err error
+	if decl.Lexeme == "?" {
+		idx = *odbcIdx
+		*odbcIdx++
+	} else {
+		idx, err = strconv.ParseInt(decl.Lexeme, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if idx < 1 || len(args) <= int(idx)-1 {
+		return nil, fmt.Errorf("reference to $%s, but only %d argument provided", decl.Lexeme, len(args))
+	}
+	return args[idx-1].Value, nil
+}
+
+// isExpressionCondition returns true if condition was parsed as an operator
+// applied to expressions, instead of an attribute followed by operator and value
+func isExpressionCondition(cond *parser.Decl) bool {
+	switch cond.Token {
+	case parser.EqualityToken, parser.DistinctnessToken, parser.LeftDipleToken, parser.RightDipleToken,
+		parser.LessOrEqualToken, parser.GreaterOrEqualToken, parser.ContainsToken, parser.ContainedToken,
+		parser.KeyExistsToken, parser.OverlapToken, parser.IsToken, parser.InToken, parser.NotToken,
+		parser.LikeToken, parser.ILikeToken, parser.SimilarToken,
+		parser.RegexMatchToken, parser.RegexIMatchToken, parser.RegexNotMatchToken, parser.RegexNotIMatchToken,
+		parser.BetweenToken:
+		return true
+	}
+	return false
+}
+
+// getAggregateValueFunctor returns a ValueFunctor computing aggregate function call decl on rows of each group, parsed as
+//
+//	|-> sum
+//		|-> DISTINCT
+//			|-> amount
+func (t *Tx) getAggregateValueFunctor(decl *parser.Decl, schema string, tables []string, aliases map[string]string, args []NamedValue, odbcIdx *int64, functors ...func(*agnostic.AggregateValueFunctor)) (agnostic.ValueFunctor, error) {
+	name := strings.ToLower(decl.Lexeme)
+	if decl.Token == parser.CountToken {
+		name = "count"
+	}
+	if len(decl.Decl) != 1 {
+		return nil, fmt.Errorf("function %s does not accept %d arguments", name, len(decl.Decl))
+	}
+
+	arg := decl.Decl[0]
+	// count(*) counts rows
+	if arg.Token == parser.StarToken {
+		return agnostic.NewAggregateValueFunctor(name, nil, functors...)
+	}
+	if arg.Token == parser.DistinctToken {
+		if len(arg.Decl) != 1 {
+			return nil, ParsingError
+		}
+		arg = arg.Decl[0]
+		functors = append(functors, agnostic.WithDistinctValues())
+	}
+
+	v, err := t.getValueFunctor(arg, schema, tables, aliases, args, odbcIdx)
+	if err != nil {
+		return nil, err
+	}
+	return agnostic.NewAggregateValueFunctor(name, v, functors...)
+}
+
+// getWindowValueFunctor returns a ValueFunctor computing a window function, or an aggregate function over a window,
+// registered to be computed by WindowSorter of the query. It is parsed as
+//
+//	|-> OVER
+//		|-> rank
+//		|-> PARTITION
+//			|-> user_id
+//		|-> ORDER
+//			|-> DESC
+//				|-> amount
+//		|-> ROWS
+//			|-> UNBOUNDED PRECEDING
+//			|-> CURRENT ROW
+func (t *Tx) getWindowValueFunctor(decl *parser.Decl, schema string, tables []string, aliases map[string]string, args []NamedValue, odbcIdx *int64) (agnostic.ValueFunctor, error) {
+	if len(decl.Decl) == 0 {
+		return nil, ParsingError
+	}
+	for _, d := range decl.Decl[0].Decl {
+		if hasWindow(d) {
+			return nil, fmt.Errorf("window function calls cannot be nested")
+		}
+	}
+
+	var functors []func(*agnostic.WindowValueFunctor)
+	for _, d := range decl.Decl[1:] {
+		if hasWindow(d) {
+			return nil, fmt.Errorf("window function calls cannot be nested")
+		}
+		switch d.Token {
+		case parser.PartitionToken:
+			keys := make([]agnostic.ValueFunctor, len(d.Decl))
+			for i, k := range d.Decl {
+				f, err := t.getValueFunctor(k, schema, tables, aliases, args, odbcIdx)
+				if err != nil {
+					return nil, err
+				}
+				keys[i] = f
+			}
+			functors = append(functors, agnostic.WithPartition(keys...))
+		case parser.OrderToken:
+			attrs, err := t.getWindowOrder(d, schema, tables, aliases, args, odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+			functors = append(functors, agnostic.WithWindowOrder(attrs...))
+		case parser.FrameToken:
+			frame, err := t.getWindowFrame(d, args, odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+			functors = append(functors, agnostic.WithFrame(frame))
+		default:
+			return nil, ParsingError
+		}
+	}
+
+	var f *agnostic.WindowValueFunctor
+	fd := decl.Decl[0]
+	switch {
+	case isAggregate(fd) || fd.Token == parser.FilterToken:
+		v, err := t.getValueFunctor(fd, schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		agg, ok := v.(*agnostic.AggregateValueFunctor)
+		if !ok {
+			return nil, ParsingError
+		}
+		f, err = agnostic.NewWindowAggregateValueFunctor(agg, functors...)
+		if err != nil {
+			return nil, err
+		}
+	case fd.Token == parser.FunctionToken && agnostic.IsWindow(fd.Lexeme):
+		fargs := make([]agnostic.ValueFunctor, len(fd.Decl))
+		for i, d := range fd.Decl {
+			v, err := t.getValueFunctor(d, schema, tables, aliases, args, odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+			fargs[i] = v
+		}
+		var err error
+		f, err = agnostic.NewWindowValueFunctor(fd.Lexeme, fargs, functors...)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("OVER specified, but %s is not a window function nor an aggregate function", fd.Lexeme)
+	}
+
+	t.windows = append(t.windows, f)
+	return f, nil
+}
+
+// getWindowOrder returns sort expressions of ORDER BY clause of a window
+func (t *Tx) getWindowOrder(orderDecl *parser.Decl, schema string, tables []string, aliases map[string]string, args []NamedValue, odbcIdx *int64) ([]agnostic.SortExpression, error) {
+	attrs := make([]agnostic.SortExpression, len(orderDecl.Decl))
+	for i, d := range orderDecl.Decl {
+		direction := agnostic.ASC
+		exprDecl := d
+		switch d.Token {
+		case parser.AscToken, parser.DescToken:
+			// expression is held by its direction
+			if len(d.Decl) != 1 {
+				return nil, ParsingError
+			}
+			if d.Token == parser.DescToken {
+				direction = agnostic.DESC
+			}
+			exprDecl = d.Decl[0]
+		case parser.StringToken:
+			// attribute holds its relation and its direction
+			exprDecl = &parser.Decl{Token: d.Token, Lexeme: d.Lexeme}
+			for _, c := range d.Decl {
+				switch c.Token {
+				case parser.DescToken:
+					direction = agnostic.DESC
+				case parser.AscToken:
+				default:
+					exprDecl.Add(c)
+				}
+			}
+		}
+		f, err := t.getValueFunctor(exprDecl, schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		attrs[i] = agnostic.NewSortValueExpression(f, direction)
+	}
+	return attrs, nil
+}
+
+// getWindowFrame returns frame of a window, like ROWS BETWEEN 2 PRECEDING AND CURRENT ROW
+func (t *Tx) getWindowFrame(frameDecl *parser.Decl, args []NamedValue, odbcIdx *int64) (*agnostic.WindowFrame, error) {
+	if len(frameDecl.Decl) != 2 {
+		return nil, ParsingError
+	}
+
+	bounds := make([]agnostic.FrameBound, 2)
+	for i, d := range frameDecl.Decl {
+		var typ agnostic.FrameBoundType
+		switch d.Lexeme {
+		case "unbounded preceding":
+			typ = agnostic.UnboundedPreceding
+		case "preceding":
+			typ = agnostic.Preceding
+		case "current row":
+			typ = agnostic.CurrentRow
+		case "following":
+			typ = agnostic.Following
+		case "unbounded following":
+			typ = agnostic.UnboundedFollowing
+		default:
+			return nil, ParsingError
+		}
+
+		var offset int64
+		if typ == agnostic.Preceding || typ == agnostic.Following {
+			if len(d.Decl) != 1 {
+				return nil, ParsingError
+			}
+			v, err := t.evalExpression(d.Decl[0], args, odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+			rv := reflect.ValueOf(v)
+			if v == nil || !rv.CanInt() {
+				return nil, fmt.Errorf("frame offset must be an integer")
+			}
+			offset = rv.Int()
+		}
+		bounds[i] = agnostic.NewFrameBound(typ, offset)
+	}
+
+	return agnostic.NewWindowFrame(frameDecl.Lexeme == "rows", bounds[0], bounds[1])
+}
+
+// hasWindow returns true if decl calls a window function, like rank() OVER (ORDER BY amount)
+func hasWindow(decl *parser.Decl) bool {
+	if decl.Token == parser.OverToken {
+		return true
+	}
+	for _, d := range decl.Decl {
+		if hasWindow(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExpression returns true if decl is a value computed by an operator or a function call
+func isExpression(decl *parser.Decl) bool {
+	switch decl.Token {
+	case parser.FunctionToken, parser.ArrowToken, parser.DoubleArrowToken, parser.HashArrowToken, parser.HashDoubleArrowToken, parser.ConcatToken,
+		parser.PlusToken, parser.MinusToken, parser.MultiplyToken, parser.DivideToken, parser.ModuloToken, parser.CaseToken, parser.CastToken,
+		parser.CountToken, parser.FilterToken, parser.OverToken:
+		return true
+	}
+	return false
+}
+
+// namesAttribute returns true if decl is an unquoted identifier naming an attribute of relation, like cost
+// in price > cost. Other unquoted identifiers are read as text values.
+func (t *Tx) namesAttribute(decl *parser.Decl, schema, relation string) bool {
+	if decl.Token != parser.StringToken || len(decl.Decl) > 0 {
+		return false
+	}
+	_, _, err := t.tx.RelationAttribute(schema, relation, strings.ToLower(decl.Lexeme))
+	return err == nil
+}
+
+// getExpressionPredicate builds predicate of a condition parsed as
+//
+//	|-> =
+//		|-> ->>
+//			|-> data
+//			|-> name
+//		|-> foo
+func (t *Tx) getExpressionPredicate(cond *parser.Decl, schema string, tables []string, args []NamedValue, aliases map[string]string, odbcIdx *int64) (agnostic.Predicate, error) {
+	// NOT IN
+	if cond.Token == parser.NotToken && len(cond.Decl) == 1 {
+		p, err := t.getExpressionPredicate(cond.Decl[0], schema, tables, args, aliases, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		return agnostic.NewNotPredicate(p), nil
+	}
+
+	if len(cond.Decl) < 2 {
+		return nil, fmt.Errorf("Malformed predicate \"%s\"", cond.Lexeme)
+	}
+
+	// IS [NOT] DISTINCT FROM
+	if last := cond.Decl[len(cond.Decl)-1]; cond.Token == parser.IsToken && last.Token == parser.DistinctToken {
+		if len(last.Decl) != 1 {
+			return nil, ParsingError
+		}
+		ptype := agnostic.Distinct
+		if cond.Decl[1].Token == parser.NotToken {
+			ptype = agnostic.NotDistinct
+		}
+		return t.getComparisonPredicate(cond.Decl[0], ptype, last.Decl[0], schema, tables, args, aliases, odbcIdx)
+	}
+
+	// (a, b) > (x, y)
+	if cond.Decl[0].Token == parser.RowToken || cond.Decl[1].Token == parser.RowToken {
+		ptype, err := predicateType(cond)
+		if err != nil {
+			return nil, err
+		}
+		return t.getComparisonPredicate(cond.Decl[0], ptype, cond.Decl[1], schema, tables, args, aliases, odbcIdx)
+	}
+
+	left, err := t.getValueFunctor(cond.Decl[0], schema, tables, aliases, args, odbcIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	// IS [NOT] NULL
+	if cond.Token == parser.IsToken {
+		p := agnostic.NewIsNullPredicate(left)
+		if cond.Decl[1].Token == parser.NotToken {
+			return agnostic.NewNotPredicate(p), nil
+		}
+		return p, nil
+	}
+
+	// x BETWEEN [SYMMETRIC] low AND high
+	if cond.Token == parser.BetweenToken {
+		if len(cond.Decl) < 3 {
+			return nil, ParsingError
+		}
+		low, err := t.getValueFunctor(cond.Decl[1], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		high, err := t.getValueFunctor(cond.Decl[2], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		symmetric := len(cond.Decl) > 3 && cond.Decl[3].Token == parser.SymmetricToken
+		return agnostic.NewBetweenPredicate(left, low, high, symmetric), nil
+	}
+
+	// x IN (values)
+	if cond.Token == parser.InToken {
+		values := make([]any, len(cond.Decl)-1)
+		for i, d := range cond.Decl[1:] {
+			v, err := agnostic.ToInstance(d.Lexeme, parser.TypeNameFromToken(d.Token))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = agnostic.ReadTimeIn(v, t.location)
+		}
+		return agnostic.NewInPredicate(left, agnostic.NewListNode(values...)), nil
+	}
+
+	ptype, err := predicateType(cond)
+	if err != nil {
+		return nil, err
+	}
+
+	// x = ANY(array), x <> ALL(array)
+	if q := cond.Decl[1]; q.Token == parser.AnyToken || q.Token == parser.AllToken {
+		if len(q.Decl) != 1 {
+			return nil, ParsingError
+		}
+		right, err := t.getValueFunctor(q.Decl[0], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		if q.Token == parser.AllToken {
+			return agnostic.NewAllPredicate(left, ptype, right), nil
+		}
+		return agnostic.NewAnyPredicate(left, ptype, right), nil
+	}
+
+	right, err := t.getValueFunctor(cond.Decl[1], schema, tables, aliases, args, odbcIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	// x LIKE pattern ESCAPE escape
+	if len(cond.Decl) > 2 {
+		escape, err := t.getValueFunctor(cond.Decl[2], schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		switch cond.Token {
+		case parser.LikeToken, parser.ILikeToken:
+			return agnostic.NewLikePredicate(left, right, escape, cond.Token == parser.ILikeToken)
+		case parser.SimilarToken:
+			return agnostic.NewSimilarToPredicate(left, right, escape)
+		}
+		return nil, ParsingError
+	}
+
+	return agnostic.NewComparisonPredicate(left, ptype, right)
+}
+
+// getComparisonPredicate builds predicate comparing left and right operands,
+// which may be row constructors like (created_at, id)
+func (t *Tx) getComparisonPredicate(leftDecl *parser.Decl, ptype agnostic.PredicateType, rightDecl *parser.Decl, schema string, tables []string, args []NamedValue, aliases map[string]string, odbcIdx *int64) (agnostic.Predicate, error) {
+	if leftDecl.Token != parser.RowToken && rightDecl.Token != parser.RowToken {
+		left, err := t.getValueFunctor(leftDecl, schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		right, err := t.getValueFunctor(rightDecl, schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		return agnostic.NewComparisonPredicate(left, ptype, right)
+	}
+
+	if leftDecl.Token != parser.RowToken || rightDecl.Token != parser.RowToken {
+		return nil, fmt.Errorf("cannot compare row with a single value")
+	}
+	left := make([]agnostic.ValueFunctor, len(leftDecl.Decl))
+	for i, d := range leftDecl.Decl {
+		f, err := t.getValueFunctor(d, schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		left[i] = f
+	}
+	right := make([]agnostic.ValueFunctor, len(rightDecl.Decl))
+	for i, d := range rightDecl.Decl {
+		f, err := t.getValueFunctor(d, schema, tables, aliases, args, odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		right[i] = f
+	}
+	return agnostic.NewRowComparisonPredicate(left, ptype, right)
+}
+
+func predicateType(op *parser.Decl) (agnostic.PredicateType, error) {
+	switch op.Token {
+	case parser.EqualityToken:
+		return agnostic.Eq, nil
+	case parser.LessOrEqualToken:
+		return agnostic.Leq, nil
+	case parser.GreaterOrEqualToken:
+		return agnostic.Geq, nil
+	case parser.DistinctnessToken:
+		return agnostic.Neq, nil
+	case parser.LeftDipleToken:
+		return agnostic.Le, nil
+	case parser.RightDipleToken:
+		return agnostic.Ge, nil
+	case parser.ContainsToken:
+		return agnostic.Contains, nil
+	case parser.ContainedToken:
+		return agnostic.ContainedBy, nil
+	case parser.KeyExistsToken:
+		return agnostic.HasKey, nil
+	case parser.OverlapToken:
+		return agnostic.Overlap, nil
+	case parser.LikeToken:
+		return agnostic.Like, nil
+	case parser.ILikeToken:
+		return agnostic.ILike, nil
+	case parser.SimilarToken:
+		return agnostic.SimilarTo, nil
+	case parser.RegexMatchToken:
+		return agnostic.Match, nil
+	case parser.RegexIMatchToken:
+		return agnostic.IMatch, nil
+	case parser.RegexNotMatchToken:
+		return agnostic.NotMatch, nil
+	case parser.RegexNotIMatchToken:
+		return agnostic.NotIMatch, nil
+	}
+
+	return 0, fmt.Errorf("unknown comparison token %s", op.Lexeme)
+}
+
+// getFunctorSelector returns a Selector for an expression, like data->'key' or jsonb_build_object(...)
+func (t *Tx) getFunctorSelector(decl *parser.Decl, schema string, tables []string, aliases map[string]string, args []NamedValue) (agnostic.Selector, error) {
+	var odbcIdx int64 = 1
+
+	f, err := t.getValueFunctor(decl, schema, tables, aliases, args, &odbcIdx)
+	if err != nil {
+		return nil, err
+	}
+	rel := functorRelation(f, tables, aliases)
+
+	name := "?column?"
+	switch decl.Token {
+	case parser.FunctionToken:
+		name = decl.Lexeme
+	case parser.CountToken:
+		name = "count"
+	case parser.FilterToken, parser.OverToken:
+		// named after called function, like count(*) FILTER (WHERE paid) OVER (PARTITION BY user_id)
+		d := decl.Decl[0]
+		if d.Token == parser.FilterToken {
+			d = d.Decl[0]
+		}
+		name = d.Lexeme
+		if d.Token == parser.CountToken {
+			name = "count"
+		}
+	case parser.CaseToken:
+		name = "case"
+	case parser.FalseToken:
+		name = "bool"
+	case parser.IntervalToken:
+		name = "interval"
+	case parser.NowToken:
+		name = "now"
+	case parser.GenRandomUUIDToken:
+		name = "gen_random_uuid"
+	case parser.CurrentSchemaToken:
+		name = "current_schema"
+	case parser.CastToken:
+		// like PostgreSQL, a cast column is named after the attribute or else the type
+		if d := decl.Decl[0]; d.Token == parser.StringToken {
+			name = strings.ToLower(d.Lexeme)
+		} else {
+			name = strings.ToLower(decl.Decl[1].Lexeme)
+		}
+	}
+
+	return agnostic.NewFunctorSelector(rel, name, f), nil
+}
+
+// functorRelation returns relation of values computed by f, defaulting to first selected table
+func functorRelation(f agnostic.ValueFunctor, tables []string, aliases map[string]string) string {
+	rel := f.Relation()
+	if rel == "" && len(tables) > 0 {
+		rel = getAlias(tables[0], aliases)
+	}
+	return rel
+}
+
+// evalExpression computes value of an expression not referencing any relation
+func (t *Tx) evalExpression(decl *parser.Decl, args []NamedValue, odbcIdx *int64) (any, error) {
+	f, err := t.getValueFunctor(decl, "", nil, nil, args, odbcIdx)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := f.(agnostic.Evaluator); ok {
+		return e.Eval(nil, nil)
+	}
+	return f.Value(nil, nil), nil
+}
@@ -0,0 +1,28 @@
+package executor
+
+import (
+	"github.com/proullon/ramsql/engine/agnostic"
+)
+
+// Generate fills relation with n rows of random data respecting its constraints, in its own transaction.
+//
+// Referenced relations must be populated first.
+func (e *Engine) Generate(schema, relation string, n int, opts agnostic.GenerateOptions) (int64, error) {
+	tx, err := e.memstore.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	c, err := tx.Generate(schema, relation, n, opts)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	_, err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return c, nil
+}
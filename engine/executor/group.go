@@ -0,0 +1,285 @@
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/proullon/ramsql/engine/agnostic"
+	"github.com/proullon/ramsql/engine/parser"
+)
+
+// groupExecutor returns sorters grouping rows on GROUP BY expressions, then keeping groups for which HAVING condition is true.
+// Without GROUP BY, all rows form a single group. Selected, ordered and HAVING expressions must only reference
+// grouped attributes outside of aggregate functions.
+func (t *Tx) groupExecutor(selectDecl, groupDecl, havingDecl *parser.Decl, schema string, tables []string, aliases map[string]string, args []NamedValue) ([]agnostic.Sorter, error) {
+	var odbcIdx int64 = 1
+	var keys []agnostic.ValueFunctor
+
+	items := selectItems(selectDecl)
+	g := &groupChecker{
+		t:       t,
+		schema:  schema,
+		tables:  tables,
+		aliases: aliases,
+	}
+
+	if groupDecl != nil {
+		for _, d := range groupDecl.Decl {
+			// GROUP BY 2 groups on second selected expression
+			if d.Token == parser.NumberToken {
+				pos, err := strconv.Atoi(d.Lexeme)
+				if err != nil || pos < 1 || pos > len(items) {
+					return nil, fmt.Errorf("GROUP BY position %s is not in select list", d.Lexeme)
+				}
+				d = items[pos-1]
+			}
+			if hasAggregate(d) {
+				return nil, fmt.Errorf("aggregate functions are not allowed in GROUP BY")
+			}
+			if hasWindow(d) {
+				return nil, fmt.Errorf("window functions are not allowed in GROUP BY")
+			}
+			f, err := t.getValueFunctor(d, schema, tables, aliases, args, &odbcIdx)
+			if err != nil {
+				return nil, err
+			}
+			g.keys = append(g.keys, d)
+			keys = append(keys, f)
+		}
+	}
+
+	for _, d := range items {
+		if err := g.check(d); err != nil {
+			return nil, err
+		}
+	}
+	if orderDecl := orderClause(selectDecl); orderDecl != nil {
+		for _, d := range orderDecl.Decl {
+			if d.Token == parser.AscToken || d.Token == parser.DescToken {
+				d = d.Decl[0]
+			}
+			if err := g.check(d); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sorters := []agnostic.Sorter{agnostic.NewGroupBySorter(getAlias(tables[0], aliases), keys)}
+
+	if havingDecl != nil {
+		if len(havingDecl.Decl) != 1 {
+			return nil, ParsingError
+		}
+		if hasWindow(havingDecl) {
+			return nil, fmt.Errorf("window functions are not allowed in HAVING")
+		}
+		if err := g.check(havingDecl.Decl[0]); err != nil {
+			return nil, err
+		}
+		p, err := t.getSearchPredicate(havingDecl.Decl[0], schema, tables, aliases, args, &odbcIdx)
+		if err != nil {
+			return nil, err
+		}
+		sorters = append(sorters, agnostic.NewHavingSorter(p))
+	}
+
+	return sorters, nil
+}
+
+// selectItems returns expressions of select list
+func selectItems(selectDecl *parser.Decl) []*parser.Decl {
+	var items []*parser.Decl
+	for _, d := range selectDecl.Decl {
+		switch d.Token {
+		case parser.FromToken:
+			return items
+		case parser.DistinctToken:
+			continue
+		}
+		items = append(items, d)
+	}
+	return items
+}
+
+// isAggregateQuery returns true if selected or ordering expressions call an aggregate function
+func isAggregateQuery(selectDecl *parser.Decl) bool {
+	for _, d := range selectItems(selectDecl) {
+		if hasAggregate(d) {
+			return true
+		}
+	}
+	if orderDecl := orderClause(selectDecl); orderDecl != nil {
+		return hasAggregate(orderDecl)
+	}
+	return false
+}
+
+// orderClause returns ORDER BY clause of select, ignoring ordering of windows
+func orderClause(selectDecl *parser.Decl) *parser.Decl {
+	for _, d := range selectDecl.Decl {
+		if d.Token == parser.OrderToken {
+			return d
+		}
+	}
+	return nil
+}
+
+// hasAggregate returns true if decl calls an aggregate function, like count(*) or sum(amount).
+// Aggregate functions computed over a window, like sum(amount) OVER (), are not.
+func hasAggregate(decl *parser.Decl) bool {
+	if isAggregate(decl) {
+		return true
+	}
+	if decl.Token == parser.OverToken && len(decl.Decl) > 0 {
+		return hasAggregate(&parser.Decl{Decl: append(windowedArgs(decl.Decl[0]), decl.Decl[1:]...)})
+	}
+	for _, d := range decl.Decl {
+		if hasAggregate(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAggregate returns true if decl is an aggregate function call
+func isAggregate(decl *parser.Decl) bool {
+	return decl.Token == parser.CountToken || (decl.Token == parser.FunctionToken && agnostic.IsAggregate(decl.Lexeme))
+}
+
+// windowedArgs returns expressions computed on each row by function call of a window, like amount in
+// sum(amount) FILTER (WHERE paid) OVER (), along with FILTER condition
+func windowedArgs(decl *parser.Decl) []*parser.Decl {
+	if decl.Token == parser.FilterToken && len(decl.Decl) == 2 {
+		return append(windowedArgs(decl.Decl[0]), decl.Decl[1])
+	}
+	var args []*parser.Decl
+	for _, d := range decl.Decl {
+		// count(DISTINCT amount) holds amount
+		if d.Token == parser.DistinctToken {
+			args = append(args, d.Decl...)
+			continue
+		}
+		if d.Token != parser.StarToken {
+			args = append(args, d)
+		}
+	}
+	return args
+}
+
+// groupChecker checks expressions of a grouped query only reference attributes outside of aggregate functions
+// if they are grouped, either being a GROUP BY expression or of a relation grouped by its primary key
+type groupChecker struct {
+	t       *Tx
+	schema  string
+	tables  []string
+	aliases map[string]string
+	keys    []*parser.Decl
+}
+
+func (g *groupChecker) check(decl *parser.Decl) error {
+	for _, k := range g.keys {
+		if g.same(decl, k) {
+			return nil
+		}
+	}
+
+	// FILTER condition is evaluated on each row of the group, like aggregated values
+	if isAggregate(decl) || decl.Token == parser.FilterToken {
+		return nil
+	}
+	// window functions are computed on groups, so their arguments are checked like selected expressions
+	if decl.Token == parser.OverToken && len(decl.Decl) > 0 {
+		for _, d := range append(windowedArgs(decl.Decl[0]), decl.Decl[1:]...) {
+			if err := g.check(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch decl.Token {
+	case parser.StarToken:
+		rel := getAlias(g.tables[0], g.aliases)
+		if g.pkGrouped(rel) {
+			return nil
+		}
+		return fmt.Errorf("column \"%s.*\" must appear in the GROUP BY clause or be used in an aggregate function", rel)
+	case parser.StringToken:
+		// unknown attributes are reported when computing values
+		rel := g.relation(decl)
+		if rel == "" || g.pkGrouped(rel) {
+			return nil
+		}
+		return fmt.Errorf("column \"%s.%s\" must appear in the GROUP BY clause or be used in an aggregate function", rel, strings.ToLower(decl.Lexeme))
+	case parser.CastToken:
+		// second operand is the type
+		if len(decl.Decl) == 2 {
+			return g.check(decl.Decl[0])
+		}
+	}
+
+	for _, d := range decl.Decl {
+		if err := g.check(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// same returns true if a and b are the same expression, attributes being compared by relation
+func (g *groupChecker) same(a, b *parser.Decl) bool {
+	if a.Token == parser.StringToken && b.Token == parser.StringToken {
+		return strings.EqualFold(a.Lexeme, b.Lexeme) && g.relation(a) == g.relation(b)
+	}
+
+	if a.Token != b.Token || len(a.Decl) != len(b.Decl) {
+		return false
+	}
+	// keywords and function names are case insensitive, literals are not
+	if a.Lexeme != b.Lexeme && (a.Token == parser.TextToken || !strings.EqualFold(a.Lexeme, b.Lexeme)) {
+		return false
+	}
+	for i := range a.Decl {
+		if !g.same(a.Decl[i], b.Decl[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// relation returns relation of attribute decl, or an empty string if no selected relation has it
+func (g *groupChecker) relation(decl *parser.Decl) string {
+	if len(decl.Decl) > 0 && decl.Decl[0].Token == parser.StringToken {
+		return getAlias(decl.Decl[0].Lexeme, g.aliases)
+	}
+	for _, table := range g.tables {
+		rel := getAlias(table, g.aliases)
+		if _, _, err := g.t.tx.RelationAttribute(g.schema, rel, decl.Lexeme); err == nil {
+			return rel
+		}
+	}
+	return ""
+}
+
+// pkGrouped returns true if all primary key attributes of relation are grouped
+func (g *groupChecker) pkGrouped(rel string) bool {
+	pk, err := g.t.tx.PrimaryKey(g.schema, rel)
+	if err != nil || len(pk) == 0 {
+		return false
+	}
+
+	for _, attr := range pk {
+		grouped := false
+		for _, k := range g.keys {
+			if k.Token == parser.StringToken && strings.EqualFold(k.Lexeme, attr) && g.relation(k) == rel {
+				grouped = true
+				break
+			}
+		}
+		if !grouped {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/proullon/ramsql/engine/agnostic"
+)
+
+// HistoryRelation is the name of the virtual relation exposing query history.
+// It only exists while history is enabled.
+const HistoryRelation = "ramsql_history"
+
+// HistoryEntry is a statement executed by the engine
+type HistoryEntry struct {
+	Query        string
+	Args         []NamedValue
+	Conn         int64
+	Tx           int64
+	Start        time.Time
+	Duration     time.Duration
+	RowsAffected int64 // as reported by sql.Result, 0 for queries returning rows like SELECT
+	Err          error
+}
+
+func (h HistoryEntry) String() string {
+	if h.Err != nil {
+		return fmt.Sprintf("[conn %d tx %d] %s %v (%s): %s", h.Conn, h.Tx, h.Query, h.args(), h.Duration, h.Err)
+	}
+	return fmt.Sprintf("[conn %d tx %d] %s %v (%s): %d rows", h.Conn, h.Tx, h.Query, h.args(), h.Duration, h.RowsAffected)
+}
+
+func (h HistoryEntry) args() []any {
+	args := make([]any, len(h.Args))
+	for i, a := range h.Args {
+		args[i] = a.Value
+	}
+	return args
+}
+
+// history is a bounded log of executed statements
+type history struct {
+	size    int
+	seq     int64
+	entries []HistoryEntry
+	// ramsql_history relation is registered
+	registered bool
+	// held while registering or removing ramsql_history, without holding the lock of entries
+	// which is taken by scans of the relation
+	registration sync.Mutex
+
+	sync.Mutex
+}
+
+func (h *history) add(entry HistoryEntry) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.size == 0 {
+		return
+	}
+
+	h.seq++
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+}
+
+func (h *history) list() []HistoryEntry {
+	h.Lock()
+	defer h.Unlock()
+
+	entries := make([]HistoryEntry, len(h.entries))
+	copy(entries, h.entries)
+	return entries
+}
+
+// tuples builds ramsql_history rows, numbered from the first entry still in history
+func (h *history) tuples() []*agnostic.Tuple {
+	h.Lock()
+	defer h.Unlock()
+
+	first := h.seq - int64(len(h.entries)) + 1
+	tuples := make([]*agnostic.Tuple, len(h.entries))
+	for i, entry := range h.entries {
+		var errMsg any
+		if entry.Err != nil {
+			errMsg = entry.Err.Error()
+		}
+		tuples[i] = agnostic.NewTuple(
+			first+int64(i),
+			entry.Query,
+			fmt.Sprintf("%v", entry.args()),
+			entry.Conn,
+			entry.Tx,
+			entry.Start,
+			entry.Duration.Microseconds(),
+			entry.RowsAffected,
+			errMsg,
+		)
+	}
+	return tuples
+}
+
+func historyAttributes() []agnostic.Attribute {
+	return []agnostic.Attribute{
+		agnostic.NewAttribute("id", "bigint"),
+		agnostic.NewAttribute("query", "text"),
+		agnostic.NewAttribute("args", "text"),
+		agnostic.NewAttribute("conn", "bigint"),
+		agnostic.NewAttribute("tx", "bigint"),
+		agnostic.NewAttribute("started_at", "timestamp"),
+		agnostic.NewAttribute("duration", "bigint"),
+		agnostic.NewAttribute("rows_affected", "bigint"),
+		agnostic.NewAttribute("error", "text"),
+	}
+}
+
+// EnableHistory starts recording executed statements, keeping at most size entries.
+//
+// Recorded statements are available with History() or by querying the ramsql_history relation,
+// which cannot be registered if a relation with the same name already exists.
+func (e *Engine) EnableHistory(size int) error {
+	e.history.registration.Lock()
+	defer e.history.registration.Unlock()
+
+	if !e.history.registered {
+		err := e.historyRelation(func(tx *agnostic.Transaction) error {
+			return tx.CreateVirtualRelation(agnostic.DefaultSchema, HistoryRelation, historyAttributes(), e.history.tuples)
+		})
+		if err != nil {
+			return err
+		}
+		e.history.registered = true
+	}
+
+	e.history.Lock()
+	defer e.history.Unlock()
+
+	if size < 0 {
+		size = 0
+	}
+	e.history.size = size
+	if len(e.history.entries) > size {
+		e.history.entries = e.history.entries[len(e.history.entries)-size:]
+	}
+	return nil
+}
+
+// DisableHistory stops recording executed statements and removes the ramsql_history relation.
+// Already recorded entries are kept, and still available with History().
+func (e *Engine) DisableHistory() {
+	e.history.registration.Lock()
+	defer e.history.registration.Unlock()
+
+	e.history.Lock()
+	e.history.size = 0
+	e.history.Unlock()
+
+	if e.history.registered {
+		_ = e.historyRelation(func(tx *agnostic.Transaction) error {
+			return tx.DropVirtualRelation(agnostic.DefaultSchema, HistoryRelation)
+		})
+		e.history.registered = false
+	}
+}
+
+// historyRelation registers or removes ramsql_history with f, in its own transaction
+func (e *Engine) historyRelation(f func(tx *agnostic.Transaction) error) error {
+	tx, err := e.memstore.Begin()
+	if err != nil {
+		return err
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Commit()
+	return err
+}
+
+// History returns recorded statements, oldest first
+func (e *Engine) History() []HistoryEntry {
+	return e.history.list()
+}
+
+// ClearHistory removes all recorded statements
+func (e *Engine) ClearHistory() {
+	e.history.Lock()
+	defer e.history.Unlock()
+
+	e.history.entries = nil
+}
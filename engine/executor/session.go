@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/proullon/ramsql/engine/agnostic"
+	"github.com/proullon/ramsql/engine/parser"
+)
+
+// Session holds settings of a connection, kept across its transactions
+type Session struct {
+	location *time.Location
+}
+
+// NewSession returns session settings with defaults: time zone is the local one
+func NewSession() *Session {
+	return &Session{location: time.Local}
+}
+
+// Location returns the session time zone
+func (s *Session) Location() *time.Location {
+	return s.location
+}
+
+// setExecutor changes a session setting. As with any change, it is undone if transaction is rolled back.
+//
+// SET TIME ZONE { 'zone' | offset | LOCAL | DEFAULT }
+func setExecutor(t *Tx, setDecl *parser.Decl, args []NamedValue) (int64, int64, []string, []*agnostic.Tuple, error) {
+	if len(setDecl.Decl) == 0 || setDecl.Decl[0].Token != parser.ZoneToken || len(setDecl.Decl[0].Decl) == 0 {
+		return 0, 0, nil, nil, ParsingError
+	}
+
+	loc, err := timeZone(setDecl.Decl[0].Decl[0])
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	t.location = loc
+
+	return 0, 0, nil, nil, nil
+}
+
+// timeZone returns the location named by value decl: a time zone name,
+// an offset in hours east of UTC like 2 or '+02:00', LOCAL or DEFAULT
+func timeZone(decl *parser.Decl) (*time.Location, error) {
+	name := decl.Lexeme
+	if decl.Token == parser.DefaultToken || strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+
+	if hours, err := strconv.ParseFloat(name, 64); err == nil {
+		return time.FixedZone(name, int(hours*3600)), nil
+	}
+	for _, layout := range []string{"-07:00", "-07"} {
+		if z, err := time.Parse(layout, name); err == nil {
+			_, offset := z.Zone()
+			return time.FixedZone(name, offset), nil
+		}
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for time zone: %s", name)
+	}
+	return loc, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/proullon/ramsql/engine/agnostic"
 	"github.com/proullon/ramsql/engine/log"
@@ -30,6 +31,13 @@ type Tx struct {
 	e            *Engine
 	tx           *agnostic.Transaction
 	opsExecutors map[int]executorFunc
+	id           int64
+	conn         int64
+	session      *Session
+	// session time zone, saved to session on commit
+	location *time.Location
+	// window functions of the SELECT being planned, computed by its WindowSorter
+	windows []*agnostic.WindowValueFunctor
 }
 
 func NewTx(ctx context.Context, e *Engine, opts sql.TxOptions) (*Tx, error) {
@@ -38,9 +46,13 @@ func NewTx(ctx context.Context, e *Engine, opts sql.TxOptions) (*Tx, error) {
 		return nil, err
 	}
 
+	session := NewSession()
 	t := &Tx{
-		e:  e,
-		tx: tx,
+		e:        e,
+		tx:       tx,
+		id:       e.lastTx.Add(1),
+		session:  session,
+		location: session.location,
 	}
 
 	t.opsExecutors = map[int]executorFunc{
@@ -48,22 +60,65 @@ func NewTx(ctx context.Context, e *Engine, opts sql.TxOptions) (*Tx, error) {
 		parser.TableToken:    createTableExecutor,
 		parser.SchemaToken:   createSchemaExecutor,
 		parser.IndexToken:    createIndexExecutor,
+		parser.TypeToken:     createTypeExecutor,
+		parser.DomainToken:   createDomainExecutor,
 		parser.SelectToken:   selectExecutor,
 		parser.InsertToken:   insertIntoTableExecutor,
 		parser.DeleteToken:   deleteExecutor,
 		parser.UpdateToken:   updateExecutor,
 		parser.TruncateToken: truncateExecutor,
 		parser.DropToken:     dropExecutor,
+		parser.AlterToken:    alterExecutor,
 		parser.GrantToken:    grantExecutor,
+		parser.SetToken:      setExecutor,
 	}
 
 	return t, nil
 }
 
-func (t *Tx) QueryContext(ctx context.Context, query string, args []NamedValue) ([]string, []*agnostic.Tuple, error) {
+// ID returns the transaction identifier, unique for the engine
+func (t *Tx) ID() int64 {
+	return t.id
+}
+
+// SetConnection sets the identifier of the connection running the transaction,
+// as reported in query history.
+func (t *Tx) SetConnection(id int64) {
+	t.conn = id
+}
+
+// SetSession sets the settings of the connection running the transaction.
+// Settings changed by the transaction are saved to s on commit.
+func (t *Tx) SetSession(s *Session) {
+	t.session = s
+	t.location = s.location
+}
+
+// record adds executed statement to engine history
+func (t *Tx) record(query string, args []NamedValue, start time.Time, rows int64, err error) {
+	entry := HistoryEntry{
+		Query:        query,
+		Args:         append([]NamedValue(nil), args...),
+		Conn:         t.conn,
+		Tx:           t.id,
+		Start:        start,
+		Duration:     time.Since(start),
+		RowsAffected: rows,
+		Err:          err,
+	}
+	t.e.history.add(entry)
+}
+
+func (t *Tx) QueryContext(ctx context.Context, query string, args []NamedValue) (cols []string, res []*agnostic.Tuple, err error) {
+	start := time.Now()
+	var affected int64
+	defer func() {
+		t.record(query, args, start, affected, err)
+	}()
 
 	instructions, err := parser.ParseInstruction(query)
 	if err != nil {
+		t.pause(ctx, query, nil, args, err)
 		return nil, nil, err
 	}
 	if len(instructions) != 1 {
@@ -79,18 +134,38 @@ func (t *Tx) QueryContext(ctx context.Context, query string, args []NamedValue)
 		return nil, nil, NotImplemented
 	}
 
-	_, _, cols, res, err := t.opsExecutors[inst.Decls[0].Token](t, inst.Decls[0], args)
+	if err = t.pause(ctx, query, inst.Decls[0], args, nil); err != nil {
+		return nil, nil, err
+	}
+	_, affected, cols, res, err = t.opsExecutors[inst.Decls[0].Token](t, inst.Decls[0], args)
 	if err != nil {
+		t.pause(ctx, query, inst.Decls[0], args, err)
 		return nil, nil, err
 	}
 
-	return cols, res, nil
+	return cols, t.inTimeZone(res), nil
+}
+
+// inTimeZone returns tuples with instants displayed in session time zone
+func (t *Tx) inTimeZone(tuples []*agnostic.Tuple) []*agnostic.Tuple {
+	out := make([]*agnostic.Tuple, len(tuples))
+	for i, tuple := range tuples {
+		out[i] = agnostic.NewTuple()
+		for _, v := range tuple.Values() {
+			out[i].Append(agnostic.TimeIn(v, t.location))
+		}
+	}
+	return out
 }
 
 // Commit the transaction on server
 func (t *Tx) Commit() error {
 	_, err := t.tx.Commit()
-	return err
+	if err != nil {
+		return err
+	}
+	t.session.location = t.location
+	return nil
 }
 
 // Rollback all changes
@@ -99,18 +174,28 @@ func (t *Tx) Rollback() error {
 	return nil
 }
 
-func (t *Tx) ExecContext(ctx context.Context, query string, args []NamedValue) (int64, int64, error) {
+func (t *Tx) ExecContext(ctx context.Context, query string, args []NamedValue) (lastInsertedID int64, rowsAffected int64, err error) {
 	log.Info("ExecContext(%p, %s)", t.tx, query)
 
+	start := time.Now()
+	defer func() {
+		t.record(query, args, start, rowsAffected, err)
+	}()
+
 	instructions, err := parser.ParseInstruction(query)
 	if err != nil {
+		t.pause(ctx, query, nil, args, err)
 		return 0, 0, err
 	}
 
-	var lastInsertedID, rowsAffected, aff int64
+	var aff int64
 	for _, instruct := range instructions {
+		if err = t.pause(ctx, query, instruct.Decls[0], args, nil); err != nil {
+			return 0, 0, err
+		}
 		lastInsertedID, aff, err = t.executeQuery(instruct, args)
 		if err != nil {
+			t.pause(ctx, query, instruct.Decls[0], args, err)
 			return 0, 0, err
 		}
 		rowsAffected += aff
@@ -139,17 +224,6 @@ func (t *Tx) getSelector(attr *parser.Decl, schema string, tables []string, alia
 	switch attr.Token {
 	case parser.StarToken:
 		return agnostic.NewStarSelector(tables[0]), nil
-	case parser.CountToken:
-		for _, table := range tables {
-			if attr.Decl[0].Lexeme == "*" {
-				return agnostic.NewCountSelector(table, "*"), nil
-			}
-			_, _, err = t.tx.RelationAttribute(schema, getAlias(table, aliases), attr.Decl[0].Lexeme)
-			if err == nil {
-				return agnostic.NewCountSelector(table, attr.Decl[0].Lexeme), nil
-			}
-		}
-		return nil, err
 	case parser.StringToken:
 		attribute := attr.Lexeme
 		if len(attr.Decl) > 0 {
@@ -228,8 +302,12 @@ func (t *Tx) getPredicates(decl []*parser.Decl, schema, fromTableName string, ar
 		return agnostic.NewTruePredicate(), nil
 	}
 
+	if isExpressionCondition(cond) {
+		return t.getExpressionPredicate(cond, schema, []string{fromTableName}, args, aliases, &odbcIdx)
+	}
+
 	switch cond.Decl[0].Token {
-	case parser.IsToken, parser.InToken, parser.EqualityToken, parser.DistinctnessToken, parser.LeftDipleToken, parser.RightDipleToken, parser.LessOrEqualToken, parser.GreaterOrEqualToken:
+	case parser.IsToken, parser.InToken, parser.NotToken, parser.EqualityToken, parser.DistinctnessToken, parser.LeftDipleToken, parser.RightDipleToken, parser.LessOrEqualToken, parser.GreaterOrEqualToken:
 		break
 	default:
 		fromTableName = cond.Decl[0].Lexeme
@@ -343,29 +421,20 @@ func (t *Tx) getPredicates(decl []*parser.Decl, schema, fromTableName string, ar
 		}
 		right = agnostic.NewConstValueFunctor(args[idx-1].Value)
 	default:
+		if t.namesAttribute(rightS, schema, fromTableName) {
+			right = agnostic.NewAttributeValueFunctor(fromTableName, strings.ToLower(rightS.Lexeme))
+			break
+		}
 		v, err := agnostic.ToInstance(rightS.Lexeme, parser.TypeNameFromToken(rightS.Token))
 		if err != nil {
 			return nil, err
 		}
-		right = agnostic.NewConstValueFunctor(v)
-	}
-
-	var ptype agnostic.PredicateType
-	switch op.Token {
-	case parser.EqualityToken:
-		ptype = agnostic.Eq
-	case parser.LessOrEqualToken:
-		ptype = agnostic.Leq
-	case parser.GreaterOrEqualToken:
-		ptype = agnostic.Geq
-	case parser.DistinctnessToken:
-		ptype = agnostic.Neq
-	case parser.LeftDipleToken:
-		ptype = agnostic.Le
-	case parser.RightDipleToken:
-		ptype = agnostic.Ge
-	default:
-		return nil, fmt.Errorf("unknown comparison token %s", op.Lexeme)
+		right = agnostic.NewConstValueFunctor(agnostic.ReadTimeIn(v, t.location))
+	}
+
+	ptype, err := predicateType(op)
+	if err != nil {
+		return nil, err
 	}
 
 	return agnostic.NewComparisonPredicate(left, ptype, right)
@@ -415,44 +484,255 @@ func (t *Tx) or(left []*parser.Decl, right []*parser.Decl, schema, tableName str
 	return agnostic.NewOrPredicate(lp, rp), nil
 }
 
-func (t *Tx) getJoin(decl *parser.Decl, leftR string) (agnostic.Joiner, error) {
-	var leftA, rightA, rightR string
-
+// getJoin returns the Joiner of JOIN decl, joined relation being its right side, parsed as
+//
+//	|-> left join
+//		|-> payments
+//		|-> ON
+//			|-> user_id
+//				|-> payments
+//			|-> =
+//			|-> id
+//				|-> account
+//
+// The left side holds relations joined before, conditions not on a single pair of attributes being evaluated
+// by a nested loop join on the first of them.
+func (t *Tx) getJoin(decl *parser.Decl, schema string, joined []string, args []NamedValue, aliases map[string]string) (agnostic.Joiner, error) {
 	if decl.Decl[0].Token != parser.StringToken {
 		return nil, fmt.Errorf("expected joined relation name, got %v", decl.Decl[0])
 	}
-	rightR = decl.Decl[0].Lexeme
+	tableDecl := decl.Decl[0]
+	rightR := tableDecl.Lexeme
+	// joined relation may be referenced by its alias in conditions
+	for _, d := range tableDecl.Decl {
+		aliases[d.Lexeme] = rightR
+	}
+
+	typ := agnostic.Inner
+	switch decl.Lexeme {
+	case "cross join":
+		return agnostic.NewCrossJoin(joined[0], rightR), nil
+	case "left join":
+		typ = agnostic.LeftOuter
+	case "right join":
+		typ = agnostic.RightOuter
+	case "full join":
+		typ = agnostic.FullOuter
+	}
+
+	if len(decl.Decl) < 2 {
+		return nil, fmt.Errorf("expected join ON information")
+	}
+	cond := decl.Decl[1]
+	switch cond.Token {
+	case parser.UsingToken:
+		return t.getUsingJoin(cond, typ, schema, joined, rightR)
+	case parser.OnToken:
+	default:
+		return nil, fmt.Errorf("expected join ON information, got %v", cond)
+	}
 
-	if decl.Decl[1].Token != parser.OnToken {
-		return nil, fmt.Errorf("expected join ON information, got %v", decl.Decl[1])
+	if len(cond.Decl) == 3 && cond.Decl[1].Token == parser.EqualityToken {
+		// joined relation is the right side of the join, whatever the order of ON operands
+		left, right := cond.Decl[0], cond.Decl[2]
+		if isJoinedRelation(left, tableDecl) {
+			left, right = right, left
+		}
+		leftR := joined[0]
+		if len(left.Decl) > 0 {
+			leftR = getAlias(left.Decl[0].Lexeme, aliases)
+		}
+		if len(right.Decl) > 0 {
+			rightR = getAlias(right.Decl[0].Lexeme, aliases)
+		}
+		return newEquiJoin(typ, leftR, left.Lexeme, rightR, right.Lexeme), nil
 	}
-	on := decl.Decl[1]
 
-	if len(on.Decl) != 3 {
-		return nil, fmt.Errorf("expected JOIN ON to have pivot")
+	if hasWindow(cond) {
+		return nil, fmt.Errorf("window functions are not allowed in JOIN conditions")
+	}
+	p, err := t.getPredicates(cond.Decl, schema, joined[0], args, aliases)
+	if err != nil {
+		return nil, err
 	}
+	return agnostic.NewNestedLoopJoin(typ, joined[0], rightR, p), nil
+}
 
-	if on.Decl[0].Decl[0].Lexeme == leftR {
-		leftA = on.Decl[0].Lexeme
-		if len(on.Decl[0].Decl) > 0 {
-			leftR = on.Decl[0].Decl[0].Lexeme
+// getUsingJoin returns the Joiner of JOIN ... USING (attribute, ...), each attribute being taken
+// in the last relation joined before having it
+func (t *Tx) getUsingJoin(usingDecl *parser.Decl, typ agnostic.JoinType, schema string, joined []string, rightR string) (agnostic.Joiner, error) {
+	var p agnostic.Predicate
+	var leftR string
+	for _, attrDecl := range usingDecl.Decl {
+		attr := attrDecl.Lexeme
+
+		leftR = ""
+		for i := len(joined) - 1; i >= 0; i-- {
+			if _, _, err := t.tx.RelationAttribute(schema, joined[i], attr); err == nil {
+				leftR = joined[i]
+				break
+			}
 		}
-		rightA = on.Decl[2].Lexeme
-		if len(on.Decl[2].Decl) > 0 {
-			rightR = on.Decl[2].Decl[0].Lexeme
+		if leftR == "" {
+			return nil, fmt.Errorf("column %s specified in USING clause does not exist in left table", attr)
 		}
-	} else {
-		leftA = on.Decl[2].Lexeme
-		if len(on.Decl[2].Decl) > 0 {
-			leftR = on.Decl[2].Decl[0].Lexeme
+		if _, _, err := t.tx.RelationAttribute(schema, rightR, attr); err != nil {
+			return nil, fmt.Errorf("column %s specified in USING clause does not exist in right table", attr)
 		}
-		rightA = on.Decl[0].Lexeme
-		if len(on.Decl[0].Decl) > 0 {
-			rightR = on.Decl[0].Decl[0].Lexeme
+
+		eq := agnostic.NewEqPredicate(agnostic.NewAttributeValueFunctor(leftR, attr), agnostic.NewAttributeValueFunctor(rightR, attr))
+		if p == nil {
+			p = eq
+		} else {
+			p = agnostic.NewAndPredicate(p, eq)
 		}
 	}
 
-	return agnostic.NewNaturalJoin(leftR, leftA, rightR, rightA), nil
+	if len(usingDecl.Decl) == 1 {
+		return newEquiJoin(typ, leftR, usingDecl.Decl[0].Lexeme, rightR, usingDecl.Decl[0].Lexeme), nil
+	}
+	return agnostic.NewNestedLoopJoin(typ, joined[0], rightR, p), nil
+}
+
+// hasUsingJoin returns true if relations of selectDecl are joined with JOIN ... USING
+func hasUsingJoin(selectDecl *parser.Decl) bool {
+	for _, d := range selectDecl.Decl {
+		if d.Token == parser.JoinToken && len(d.Decl) > 1 && d.Decl[1].Token == parser.UsingToken {
+			return true
+		}
+	}
+	return false
+}
+
+// starColumn is a column of SELECT * on joined relations, attribute of relation unless computed by functor
+type starColumn struct {
+	relation string
+	name     string
+	functor  agnostic.ValueFunctor
+}
+
+func (c starColumn) valueFunctor() agnostic.ValueFunctor {
+	if c.functor != nil {
+		return c.functor
+	}
+	return agnostic.NewAttributeValueFunctor(c.relation, c.name)
+}
+
+// getStarSelectors returns the selectors of SELECT * on joined relations: attributes of each relation in query order,
+// columns of JOIN ... USING being merged in a single column listed first, like PostgreSQL does
+func (t *Tx) getStarSelectors(selectDecl *parser.Decl, schema string, tables []string) ([]agnostic.Selector, error) {
+	relationColumns := func(rel string) ([]starColumn, error) {
+		attrs, err := t.tx.Attributes(schema, rel)
+		if err != nil {
+			return nil, err
+		}
+		cols := make([]starColumn, len(attrs))
+		for i, a := range attrs {
+			cols[i] = starColumn{relation: rel, name: a}
+		}
+		return cols, nil
+	}
+
+	var cols []starColumn
+	for _, rel := range tables {
+		c, err := relationColumns(rel)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, c...)
+	}
+
+	for _, d := range selectDecl.Decl {
+		if d.Token != parser.JoinToken {
+			continue
+		}
+		rightR := d.Decl[0].Lexeme
+		right, err := relationColumns(rightR)
+		if err != nil {
+			return nil, err
+		}
+		if len(d.Decl) < 2 || d.Decl[1].Token != parser.UsingToken {
+			cols = append(cols, right...)
+			continue
+		}
+
+		using := make(map[string]bool)
+		var merged []starColumn
+		for _, attrDecl := range d.Decl[1].Decl {
+			attr := attrDecl.Lexeme
+			using[attr] = true
+			found := false
+			var c starColumn
+			for _, l := range cols {
+				if l.name == attr {
+					c, found = l, true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("column %s specified in USING clause does not exist in left table", attr)
+			}
+
+			// merged column is the value of the side keeping all its rows, the first non NULL one with FULL JOIN
+			switch d.Lexeme {
+			case "right join":
+				c = starColumn{relation: rightR, name: attr}
+			case "full join":
+				f, err := agnostic.NewFunctionValueFunctor("coalesce", c.valueFunctor(), agnostic.NewAttributeValueFunctor(rightR, attr))
+				if err != nil {
+					return nil, err
+				}
+				c = starColumn{relation: rightR, name: attr, functor: f}
+			}
+			merged = append(merged, c)
+		}
+
+		for _, c := range append(cols, right...) {
+			if !using[c.name] {
+				merged = append(merged, c)
+			}
+		}
+		cols = merged
+	}
+
+	selectors := make([]agnostic.Selector, len(cols))
+	for i, c := range cols {
+		if c.functor != nil {
+			selectors[i] = agnostic.NewFunctorSelector(c.relation, c.name, c.functor)
+			continue
+		}
+		selectors[i] = agnostic.NewAttributeSelector(c.relation, []string{c.name})
+	}
+	return selectors, nil
+}
+
+// newEquiJoin returns the Joiner of typ on equality of a single pair of attributes
+func newEquiJoin(typ agnostic.JoinType, leftR, leftA, rightR, rightA string) agnostic.Joiner {
+	switch typ {
+	case agnostic.LeftOuter:
+		return agnostic.NewLeftOuterJoin(leftR, leftA, rightR, rightA)
+	case agnostic.RightOuter:
+		return agnostic.NewRightOuterJoin(leftR, leftA, rightR, rightA)
+	case agnostic.FullOuter:
+		return agnostic.NewFullOuterJoin(leftR, leftA, rightR, rightA)
+	}
+	return agnostic.NewNaturalJoin(leftR, leftA, rightR, rightA)
+}
+
+// isJoinedRelation returns true if attribute decl is qualified by joined relation, or its alias
+func isJoinedRelation(attrDecl *parser.Decl, tableDecl *parser.Decl) bool {
+	if len(attrDecl.Decl) == 0 {
+		return false
+	}
+	rel := attrDecl.Decl[0].Lexeme
+	if rel == tableDecl.Lexeme {
+		return true
+	}
+	for _, d := range tableDecl.Decl {
+		if d.Lexeme == rel {
+			return true
+		}
+	}
+	return false
 }
 
 func (t *Tx) getDistinctSorter(rel string, decl *parser.Decl, nextAttr string) (agnostic.Sorter, error) {
@@ -495,7 +775,11 @@ func inExecutor(rname string, aname string, inDecl *parser.Decl) (agnostic.Predi
 	default:
 		var values []any
 		for _, d := range inDecl.Decl {
-			values = append(values, d.Lexeme)
+			v, err := agnostic.ToInstance(d.Lexeme, parser.TypeNameFromToken(d.Token))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
 		}
 		n = agnostic.NewListNode(values...)
 	}
@@ -507,12 +791,12 @@ func inExecutor(rname string, aname string, inDecl *parser.Decl) (agnostic.Predi
 func isExecutor(rname string, aname string, isDecl *parser.Decl) (agnostic.Predicate, error) {
 
 	if isDecl.Decl[0].Token == parser.NullToken {
-		p := agnostic.NewEqPredicate(agnostic.NewAttributeValueFunctor(rname, aname), agnostic.NewConstValueFunctor(nil))
+		p := agnostic.NewIsNullPredicate(agnostic.NewAttributeValueFunctor(rname, aname))
 		return p, nil
 	}
 
 	if isDecl.Decl[0].Token == parser.NotToken && isDecl.Decl[1].Token == parser.NullToken {
-		p := agnostic.NewEqPredicate(agnostic.NewAttributeValueFunctor(rname, aname), agnostic.NewConstValueFunctor(nil))
+		p := agnostic.NewIsNullPredicate(agnostic.NewAttributeValueFunctor(rname, aname))
 		return agnostic.NewNotPredicate(p), nil
 	}
 
@@ -2,7 +2,6 @@ package parser
 
 import (
 	"fmt"
-	"strings"
 )
 
 func (p *parser) parseCreate(tokens []Token) (*Instruction, error) {
@@ -40,6 +39,21 @@ func (p *parser) parseCreate(tokens []Token) (*Instruction, error) {
 			return nil, err
 		}
 		createDecl.Add(d)
+	case StringToken:
+		var d *Decl
+		var err error
+		switch {
+		case p.isWord("type"):
+			d, err = p.parseCreateType()
+		case p.isWord("domain"):
+			d, err = p.parseDomain()
+		default:
+			return nil, fmt.Errorf("Parsing error near <%s>", tokens[p.index].Lexeme)
+		}
+		if err != nil {
+			return nil, err
+		}
+		createDecl.Add(d)
 	case UniqueToken:
 		u, err := p.consumeToken(UniqueToken)
 		if err != nil {
@@ -303,30 +317,18 @@ func (p *parser) parseTable(tokens []Token) (*Decl, error) {
 					return nil, err
 				}
 				newAttribute.Add(autoincDecl)
-			case WithToken: // WITH TIME ZONE
-				if strings.ToLower(newAttributeType.Lexeme) == "timestamp" {
-					withDecl, err := p.consumeToken(WithToken)
-					if err != nil {
-						return nil, err
-					}
-					timeDecl, err := p.consumeToken(TimeToken)
-					if err != nil {
-						return nil, err
-					}
-					zoneDecl, err := p.consumeToken(ZoneToken)
-					if err != nil {
-						return nil, err
-					}
-					newAttributeType.Add(withDecl)
-					withDecl.Add(timeDecl)
-					timeDecl.Add(zoneDecl)
-				}
 			case DefaultToken: // DEFAULT
 				dDecl, err := p.parseDefaultClause()
 				if err != nil {
 					return nil, err
 				}
 				newAttribute.Add(dDecl)
+			case ReferencesToken: // REFERENCES table (attribute)
+				rDecl, err := p.parseReferences()
+				if err != nil {
+					return nil, err
+				}
+				newAttribute.Add(rDecl)
 			default:
 				// Unknown column constraint
 				return nil, p.syntaxError()
@@ -356,10 +358,13 @@ func (p *parser) parseDefaultClause() (*Decl, error) {
 
 	var vDecl *Decl
 
-	if p.is(SimpleQuoteToken) || p.is(DoubleQuoteToken) {
+	if p.isComputedValue() {
+		// computed default, like now() + interval '1 day'
+		vDecl, err = p.parseExpression()
+	} else if p.is(SimpleQuoteToken) || p.is(DoubleQuoteToken) {
 		vDecl, err = p.parseStringLiteral()
 	} else {
-		vDecl, err = p.consumeToken(NullToken, FloatToken, FalseToken, NumberToken, LocalTimestampToken, NowToken, ArgToken, NamedArgToken)
+		vDecl, err = p.consumeToken(NullToken, FloatToken, FalseToken, NumberToken, LocalTimestampToken, NowToken, GenRandomUUIDToken, ArgToken, NamedArgToken)
 	}
 
 	if err != nil {
@@ -369,6 +374,41 @@ func (p *parser) parseDefaultClause() (*Decl, error) {
 	return dDecl, nil
 }
 
+// REFERENCES [schema.]table [(attribute)]
+func (p *parser) parseReferences() (*Decl, error) {
+	refDecl, err := p.consumeToken(ReferencesToken)
+	if err != nil {
+		return nil, err
+	}
+
+	tableDecl, err := p.parseTableName()
+	if err != nil {
+		return nil, err
+	}
+	tableDecl.Token = TableToken
+	refDecl.Add(tableDecl)
+
+	if !p.is(BracketOpeningToken) {
+		return refDecl, nil
+	}
+
+	_, err = p.consumeToken(BracketOpeningToken)
+	if err != nil {
+		return nil, err
+	}
+	attrDecl, err := p.parseQuotedToken()
+	if err != nil {
+		return nil, err
+	}
+	refDecl.Add(attrDecl)
+	_, err = p.consumeToken(BracketClosingToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return refDecl, nil
+}
+
 func (p *parser) parsePrimaryKey() (*Decl, error) {
 	primaryDecl, err := p.consumeToken(PrimaryToken)
 	if err != nil {
@@ -27,9 +27,34 @@ func (p *parser) parseDrop(tokens []Token) (*Instruction, error) {
 		if err != nil {
 			return nil, err
 		}
+	case StringToken:
+		if p.isWord("domain") {
+			d, err = p.consumeWord("domain", DomainToken)
+		} else {
+			d, err = p.consumeWord("type", TypeToken)
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.syntaxError()
 	}
 	trDecl.Add(d)
 
+	// Maybe have "IF EXISTS" here
+	if p.is(IfToken) {
+		ifDecl, err := p.consumeToken(IfToken)
+		if err != nil {
+			return nil, err
+		}
+		existsDecl, err := p.consumeToken(ExistsToken)
+		if err != nil {
+			return nil, err
+		}
+		ifDecl.Add(existsDecl)
+		d.Add(ifDecl)
+	}
+
 	// Should be a name attribute
 	nameDecl, err := p.parseAttribute()
 	if err != nil {
@@ -0,0 +1,1205 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JSON, array and arithmetic operators, all left associative
+var expressionOperators = []int{ArrowToken, DoubleArrowToken, HashArrowToken, HashDoubleArrowToken, ConcatToken, PlusToken, MinusToken,
+	MultiplyToken, DivideToken, ModuloToken}
+
+// operatorPrecedence returns binding strength of given expression operator, higher binding tighter
+func operatorPrecedence(token int) int {
+	switch token {
+	case MultiplyToken, DivideToken, ModuloToken:
+		return 3
+	case PlusToken, MinusToken:
+		return 2
+	}
+	return 1
+}
+
+// comparison operators accepted in conditions between expressions
+var comparisonOperators = []int{EqualityToken, DistinctnessToken, LeftDipleToken, RightDipleToken, LessOrEqualToken, GreaterOrEqualToken, ContainsToken, ContainedToken, OverlapToken,
+	RegexMatchToken, RegexIMatchToken, RegexNotMatchToken, RegexNotIMatchToken}
+
+// pattern matching operators accepting an ESCAPE clause
+var patternOperators = []int{LikeToken, ILikeToken, SimilarToken}
+
+// parseExpression parses a value expression of the form
+// attribute
+// 'literal'
+// interval 'literal'
+// function(expression, ...)
+// aggregate([DISTINCT] expression) [FILTER (WHERE condition)] [OVER (window)]
+// COUNT(*) [FILTER (WHERE condition)] [OVER (window)]
+// EXTRACT(field FROM expression)
+// expression -> expression
+// expression ->> expression
+// expression #> expression
+// expression #>> expression
+// expression || expression
+// expression + expression
+// expression - expression
+// expression * expression
+// expression / expression
+// expression % expression
+// -expression
+// (expression)
+// CASE [expression] WHEN ... THEN expression [ELSE expression] END
+// CAST(expression AS type)
+// expression::type
+// ANY(expression)
+// ALL(expression)
+// (expression, expression, ...)
+//
+// Type casts bind tightest, then multiplicative operators bind tighter than additive ones,
+// which bind tighter than JSON and concatenation operators. A lone attribute is returned as parseAttribute does.
+func (p *parser) parseExpression() (*Decl, error) {
+	return p.parseBinaryExpression(1)
+}
+
+// parseBinaryExpression parses an expression whose operators bind at least as tight as precedence
+func (p *parser) parseBinaryExpression(precedence int) (*Decl, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.hasNext() && p.is(expressionOperators...) && operatorPrecedence(p.cur().Token) >= precedence {
+		opDecl, err := p.consumeToken(expressionOperators...)
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.parseBinaryExpression(operatorPrecedence(opDecl.Token) + 1)
+		if err != nil {
+			return nil, err
+		}
+		opDecl.Add(left)
+		opDecl.Add(right)
+		left = opDecl
+	}
+
+	return left, nil
+}
+
+// parseOperand parses an operand of an expression operator, followed by any number of casts
+func (p *parser) parseOperand() (*Decl, error) {
+	operandDecl, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.hasNext() && p.is(CastToken) {
+		castDecl, err := p.consumeToken(CastToken)
+		if err != nil {
+			return nil, err
+		}
+		typeDecl, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		castDecl.Add(operandDecl)
+		castDecl.Add(typeDecl)
+		operandDecl = castDecl
+	}
+
+	return operandDecl, nil
+}
+
+func (p *parser) parsePrimary() (*Decl, error) {
+	switch {
+	case p.isFunction():
+		funcDecl, err := p.parseFunction()
+		if err != nil {
+			return nil, err
+		}
+		if funcDecl, err = p.parseFilter(funcDecl); err != nil {
+			return nil, err
+		}
+		return p.parseOver(funcDecl)
+	case p.is(CountToken):
+		countDecl, err := p.parseBuiltinFunc()
+		if err != nil {
+			return nil, err
+		}
+		if countDecl, err = p.parseFilter(countDecl); err != nil {
+			return nil, err
+		}
+		return p.parseOver(countDecl)
+	case p.isInterval():
+		return p.parseInterval()
+	case p.is(AnyToken, AllToken):
+		return p.parseQuantifier()
+	case p.isRow():
+		return p.parseRow()
+	case p.is(CaseToken):
+		return p.parseCase()
+	case p.is(BracketOpeningToken):
+		return p.parseBracketExpression()
+	case p.is(MinusToken):
+		minusDecl, err := p.consumeToken(MinusToken)
+		if err != nil {
+			return nil, err
+		}
+		operandDecl, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		minusDecl.Add(operandDecl)
+		return minusDecl, nil
+	case p.is(SimpleQuoteToken):
+		valueDecl, err := p.parseStringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		valueDecl.Token = TextToken
+		return valueDecl, nil
+	case p.is(NumberToken, FloatToken, ArgToken, NamedArgToken, NullToken, FalseToken, NowToken, GenRandomUUIDToken, CurrentSchemaToken):
+		return p.consumeToken(p.cur().Token)
+	case p.checkValue && p.isWord("value"):
+		return p.consumeWord("value", ValueToken)
+	}
+
+	return p.parseAttribute()
+}
+
+// parseBracketExpression parses a parenthesized expression, returned without brackets
+func (p *parser) parseBracketExpression() (*Decl, error) {
+	if _, err := p.consumeToken(BracketOpeningToken); err != nil {
+		return nil, err
+	}
+	exprDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+	return exprDecl, nil
+}
+
+// isFunction returns true if current token is an identifier followed by an opening bracket
+func (p *parser) isFunction() bool {
+	return p.isFunctionAt(p.index)
+}
+
+func (p *parser) isFunctionAt(i int) bool {
+	return i+1 < len(p.tokens) && p.tokens[i].Token == StringToken && p.tokens[i+1].Token == BracketOpeningToken
+}
+
+// parseFunction parses a function call of the form
+// function()
+// function(expression, ...)
+func (p *parser) parseFunction() (*Decl, error) {
+	funcDecl := &Decl{
+		Token:  FunctionToken,
+		Lexeme: strings.ToLower(p.cur().Lexeme),
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if _, err := p.consumeToken(BracketOpeningToken); err != nil {
+		return nil, err
+	}
+
+	switch funcDecl.Lexeme {
+	case "extract":
+		return p.parseExtract()
+	case "substring":
+		if p.isSQLFunctionSyntax() {
+			return p.parseSubstring()
+		}
+	case "trim":
+		return p.parseTrim()
+	case "cast":
+		return p.parseCast()
+	}
+
+	for !p.is(BracketClosingToken) {
+		var argDecl *Decl
+		var err error
+		if len(funcDecl.Decl) == 0 {
+			argDecl, err = p.parseAggregateArgument()
+		} else {
+			argDecl, err = p.parseExpression()
+		}
+		if err != nil {
+			return nil, err
+		}
+		funcDecl.Add(argDecl)
+
+		if !p.is(CommaToken) {
+			break
+		}
+		if _, err := p.consumeToken(CommaToken); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+
+	return funcDecl, nil
+}
+
+// parseAggregateArgument parses first argument of a function call, which may be preceded by DISTINCT
+// for aggregate functions, like count(DISTINCT user_id)
+//
+//	|-> DISTINCT
+//		|-> expression
+func (p *parser) parseAggregateArgument() (*Decl, error) {
+	if !p.is(DistinctToken) {
+		return p.parseExpression()
+	}
+
+	distinctDecl, err := p.consumeToken(DistinctToken)
+	if err != nil {
+		return nil, err
+	}
+	if p.is(StarToken) {
+		return nil, p.syntaxError()
+	}
+	exprDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	distinctDecl.Add(exprDecl)
+	return distinctDecl, nil
+}
+
+// parseFilter parses FILTER (WHERE condition) following an aggregate function call, filter not being reserved.
+// Call is returned as is if there is no FILTER clause.
+//
+//	|-> FILTER
+//		|-> aggregate
+//		|-> condition
+func (p *parser) parseFilter(aggDecl *Decl) (*Decl, error) {
+	i := p.index
+	if !p.isWord("filter") || i+2 >= len(p.tokens) || p.tokens[i+1].Token != BracketOpeningToken || p.tokens[i+2].Token != WhereToken {
+		return aggDecl, nil
+	}
+
+	filterDecl := &Decl{Token: FilterToken, Lexeme: "filter"}
+	p.index += 3
+	condDecl, err := p.parseSearchCondition()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+
+	filterDecl.Add(aggDecl)
+	filterDecl.Add(condDecl)
+	return filterDecl, nil
+}
+
+// parseOver parses window of a function call, over not being reserved, of the form
+// OVER ([PARTITION BY expression, ...] [ORDER BY expression [ASC|DESC], ...] [ROWS|RANGE frame])
+// Call is returned as is if there is no OVER clause.
+//
+//	|-> OVER
+//		|-> function call
+//		|-> PARTITION
+//			|-> expression
+//		|-> ORDER
+//			|-> expression
+//		|-> ROWS
+//			|-> start bound
+//			|-> end bound
+func (p *parser) parseOver(funcDecl *Decl) (*Decl, error) {
+	i := p.index
+	if !p.isWord("over") || i+1 >= len(p.tokens) || p.tokens[i+1].Token != BracketOpeningToken {
+		return funcDecl, nil
+	}
+
+	overDecl := &Decl{Token: OverToken, Lexeme: "over"}
+	overDecl.Add(funcDecl)
+	p.index += 2
+
+	if p.isWord("partition") {
+		partitionDecl, err := p.consumeWord("partition", PartitionToken)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.consumeToken(ByToken); err != nil {
+			return nil, err
+		}
+		for {
+			exprDecl, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			partitionDecl.Add(exprDecl)
+			if !p.is(CommaToken) {
+				break
+			}
+			if _, err := p.consumeToken(CommaToken); err != nil {
+				return nil, err
+			}
+		}
+		overDecl.Add(partitionDecl)
+	}
+
+	if p.is(OrderToken) {
+		if err := p.parseOrderBy(overDecl); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isWord("rows") || p.isWord("range") {
+		frameDecl, err := p.parseFrame()
+		if err != nil {
+			return nil, err
+		}
+		overDecl.Add(frameDecl)
+	}
+
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+	return overDecl, nil
+}
+
+// parseFrame parses a window frame, end bound defaulting to CURRENT ROW, of the form
+// ROWS|RANGE start
+// ROWS|RANGE BETWEEN start AND end
+func (p *parser) parseFrame() (*Decl, error) {
+	frameDecl := &Decl{Token: FrameToken, Lexeme: strings.ToLower(p.cur().Lexeme)}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	between := p.is(BetweenToken)
+	if between {
+		if _, err := p.consumeToken(BetweenToken); err != nil {
+			return nil, err
+		}
+	}
+
+	startDecl, err := p.parseFrameBound()
+	if err != nil {
+		return nil, err
+	}
+	frameDecl.Add(startDecl)
+
+	if !between {
+		frameDecl.Add(&Decl{Token: FrameBoundToken, Lexeme: "current row"})
+		return frameDecl, nil
+	}
+
+	if _, err := p.consumeToken(AndToken); err != nil {
+		return nil, err
+	}
+	endDecl, err := p.parseFrameBound()
+	if err != nil {
+		return nil, err
+	}
+	frameDecl.Add(endDecl)
+	return frameDecl, nil
+}
+
+// parseFrameBound parses a window frame bound of the form
+// UNBOUNDED PRECEDING
+// offset PRECEDING
+// CURRENT ROW
+// offset FOLLOWING
+// UNBOUNDED FOLLOWING
+func (p *parser) parseFrameBound() (*Decl, error) {
+	switch {
+	case p.isWord("unbounded"):
+		p.next()
+		if !p.isWord("preceding") && !p.isWord("following") {
+			return nil, p.syntaxError()
+		}
+		boundDecl := &Decl{Token: FrameBoundToken, Lexeme: "unbounded " + strings.ToLower(p.cur().Lexeme)}
+		p.next()
+		return boundDecl, nil
+	case p.isWord("current"):
+		p.next()
+		if !p.isWord("row") {
+			return nil, p.syntaxError()
+		}
+		p.next()
+		return &Decl{Token: FrameBoundToken, Lexeme: "current row"}, nil
+	}
+
+	offsetDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isWord("preceding") && !p.isWord("following") {
+		return nil, p.syntaxError()
+	}
+	boundDecl := &Decl{Token: FrameBoundToken, Lexeme: strings.ToLower(p.cur().Lexeme)}
+	boundDecl.Add(offsetDecl)
+	p.next()
+	return boundDecl, nil
+}
+
+// parseExtract parses arguments of EXTRACT(field FROM expression), after the opening bracket.
+// It is returned as date_part('field', expression).
+func (p *parser) parseExtract() (*Decl, error) {
+	funcDecl := &Decl{
+		Token:  FunctionToken,
+		Lexeme: "date_part",
+	}
+
+	var fieldDecl *Decl
+	var err error
+	if p.is(SimpleQuoteToken) {
+		fieldDecl, err = p.parseStringLiteral()
+	} else {
+		fieldDecl, err = p.consumeToken(StringToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fieldDecl.Token = TextToken
+	funcDecl.Add(fieldDecl)
+
+	if _, err := p.consumeToken(FromToken); err != nil {
+		return nil, err
+	}
+	exprDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	funcDecl.Add(exprDecl)
+
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+
+	return funcDecl, nil
+}
+
+// isSQLFunctionSyntax returns true if arguments of function call, starting at current token,
+// are separated by keywords instead of commas, like in SUBSTRING(name FROM 2 FOR 3)
+func (p *parser) isSQLFunctionSyntax() bool {
+	depth := 0
+	for _, t := range p.tokens[p.index:] {
+		switch t.Token {
+		case BracketOpeningToken:
+			depth++
+		case BracketClosingToken:
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case FromToken, ForToken:
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseSubstring parses arguments of SUBSTRING(expression [FROM start] [FOR count]), after the opening bracket.
+// It is returned as substring(expression, start, count).
+func (p *parser) parseSubstring() (*Decl, error) {
+	funcDecl := &Decl{
+		Token:  FunctionToken,
+		Lexeme: "substring",
+	}
+
+	exprDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	funcDecl.Add(exprDecl)
+
+	startDecl := &Decl{Token: NumberToken, Lexeme: "1"}
+	if p.is(FromToken) {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if startDecl, err = p.parseExpression(); err != nil {
+			return nil, err
+		}
+	}
+	funcDecl.Add(startDecl)
+
+	if p.is(ForToken) {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		countDecl, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		funcDecl.Add(countDecl)
+	}
+
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+
+	return funcDecl, nil
+}
+
+// parseTrim parses arguments of TRIM([BOTH | LEADING | TRAILING] [characters FROM] expression), after the opening bracket.
+// It is returned as btrim(expression, characters), ltrim(...) or rtrim(...).
+func (p *parser) parseTrim() (*Decl, error) {
+	funcDecl := &Decl{
+		Token:  FunctionToken,
+		Lexeme: "btrim",
+	}
+
+	switch {
+	case p.isWord("leading"):
+		funcDecl.Lexeme = "ltrim"
+	case p.isWord("trailing"):
+		funcDecl.Lexeme = "rtrim"
+	}
+	if p.isWord("both") || p.isWord("leading") || p.isWord("trailing") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	// TRIM(characters FROM expression) or TRIM(FROM expression, characters)
+	var exprDecl, charsDecl *Decl
+	var err error
+	if !p.is(FromToken) {
+		if exprDecl, err = p.parseExpression(); err != nil {
+			return nil, err
+		}
+	}
+	if p.is(FromToken) {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		charsDecl = exprDecl
+		if exprDecl, err = p.parseExpression(); err != nil {
+			return nil, err
+		}
+	}
+	if charsDecl == nil && p.is(CommaToken) {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if charsDecl, err = p.parseExpression(); err != nil {
+			return nil, err
+		}
+	}
+
+	funcDecl.Add(exprDecl)
+	if charsDecl != nil {
+		funcDecl.Add(charsDecl)
+	}
+
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+
+	return funcDecl, nil
+}
+
+// parseCase parses a conditional expression, either searched
+//
+//	CASE WHEN condition THEN result [WHEN ...] [ELSE result] END
+//
+// or simple
+//
+//	CASE expression WHEN value THEN result [WHEN ...] [ELSE result] END
+//
+// It is returned as
+//
+//	|-> CASE
+//		|-> [expression]
+//		|-> WHEN
+//			|-> condition or value
+//			|-> result
+//		|-> [ELSE]
+//			|-> result
+func (p *parser) parseCase() (*Decl, error) {
+	caseDecl, err := p.consumeToken(CaseToken)
+	if err != nil {
+		return nil, err
+	}
+
+	simple := !p.is(WhenToken)
+	if simple {
+		exprDecl, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		caseDecl.Add(exprDecl)
+	}
+
+	for p.is(WhenToken) {
+		whenDecl, err := p.consumeToken(WhenToken)
+		if err != nil {
+			return nil, err
+		}
+		var condDecl *Decl
+		if simple {
+			condDecl, err = p.parseExpression()
+		} else {
+			condDecl, err = p.parseSearchCondition()
+		}
+		if err != nil {
+			return nil, err
+		}
+		whenDecl.Add(condDecl)
+
+		if _, err := p.consumeToken(ThenToken); err != nil {
+			return nil, err
+		}
+		resultDecl, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		whenDecl.Add(resultDecl)
+		caseDecl.Add(whenDecl)
+	}
+	if len(caseDecl.Decl) == 0 || caseDecl.Decl[len(caseDecl.Decl)-1].Token != WhenToken {
+		return nil, fmt.Errorf("CASE must have at least one WHEN clause")
+	}
+
+	if p.is(ElseToken) {
+		elseDecl, err := p.consumeToken(ElseToken)
+		if err != nil {
+			return nil, err
+		}
+		resultDecl, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		elseDecl.Add(resultDecl)
+		caseDecl.Add(elseDecl)
+	}
+
+	if _, err := p.consumeToken(EndToken); err != nil {
+		return nil, err
+	}
+	return caseDecl, nil
+}
+
+// parseSearchCondition parses condition of a searched CASE WHEN clause or of a HAVING clause.
+// AND binds tighter than OR, links being returned as AND or OR decls with both operands as children.
+func (p *parser) parseSearchCondition() (*Decl, error) {
+	left, err := p.parseSearchConjunction()
+	if err != nil {
+		return nil, err
+	}
+	for p.is(OrToken) {
+		orDecl, err := p.consumeToken(OrToken)
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.parseSearchConjunction()
+		if err != nil {
+			return nil, err
+		}
+		orDecl.Add(left)
+		orDecl.Add(right)
+		left = orDecl
+	}
+	return left, nil
+}
+
+func (p *parser) parseSearchConjunction() (*Decl, error) {
+	left, err := p.parseSearchPredicate()
+	if err != nil {
+		return nil, err
+	}
+	for p.is(AndToken) {
+		andDecl, err := p.consumeToken(AndToken)
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.parseSearchPredicate()
+		if err != nil {
+			return nil, err
+		}
+		andDecl.Add(left)
+		andDecl.Add(right)
+		left = andDecl
+	}
+	return left, nil
+}
+
+// parseSearchPredicate parses NOT condition, (condition), a comparison or a boolean expression
+func (p *parser) parseSearchPredicate() (*Decl, error) {
+	if p.is(NotToken) {
+		notDecl, err := p.consumeToken(NotToken)
+		if err != nil {
+			return nil, err
+		}
+		condDecl, err := p.parseSearchPredicate()
+		if err != nil {
+			return nil, err
+		}
+		notDecl.Add(condDecl)
+		return notDecl, nil
+	}
+
+	if p.is(BracketOpeningToken) && !p.isRow() && !p.isBracketOperand() {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		condDecl, err := p.parseSearchCondition()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.consumeToken(BracketClosingToken); err != nil {
+			return nil, err
+		}
+		return condDecl, nil
+	}
+
+	exprDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if p.is(ThenToken, AndToken, OrToken, BracketClosingToken, OrderToken, LimitToken, OffsetToken, ForToken) {
+		return exprDecl, nil
+	}
+	return p.parseExpressionCondition(exprDecl)
+}
+
+// parseCast parses arguments of CAST(expression AS type), after the opening bracket.
+// It is returned as expression::type
+//
+//	|-> ::
+//		|-> expression
+//		|-> type
+func (p *parser) parseCast() (*Decl, error) {
+	castDecl := &Decl{
+		Token:  CastToken,
+		Lexeme: "::",
+	}
+
+	exprDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	castDecl.Add(exprDecl)
+
+	if _, err := p.consumeToken(AsToken); err != nil {
+		return nil, err
+	}
+	typeDecl, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	castDecl.Add(typeDecl)
+
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+
+	return castDecl, nil
+}
+
+// isInterval returns true if current token starts an interval literal, like interval '1 day'
+func (p *parser) isInterval() bool {
+	return p.isIntervalAt(p.index)
+}
+
+func (p *parser) isIntervalAt(i int) bool {
+	return i+1 < len(p.tokens) && p.tokens[i].Token == StringToken && strings.EqualFold(p.tokens[i].Lexeme, "interval") &&
+		p.tokens[i+1].Token == SimpleQuoteToken
+}
+
+// parseInterval parses an interval literal, returned as an IntervalToken decl holding the quoted text
+func (p *parser) parseInterval() (*Decl, error) {
+	if _, err := p.consumeWord("interval", IntervalToken); err != nil {
+		return nil, err
+	}
+	valueDecl, err := p.parseStringLiteral()
+	if err != nil {
+		return nil, err
+	}
+	valueDecl.Token = IntervalToken
+	return valueDecl, nil
+}
+
+// parseQuantifier parses ANY(expression) or ALL(expression), as right operand of a comparison
+func (p *parser) parseQuantifier() (*Decl, error) {
+	quantDecl, err := p.consumeToken(AnyToken, AllToken)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consumeToken(BracketOpeningToken); err != nil {
+		return nil, err
+	}
+	exprDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	quantDecl.Add(exprDecl)
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+
+	return quantDecl, nil
+}
+
+// parseExpressionCondition parses the operator and right operand of a condition
+// whose left operand is an expression. Condition is returned as
+//
+//	|-> operator
+//		|-> left expression
+//		|-> right expression
+//
+// or for IS [NOT] NULL
+//
+//	|-> IS
+//		|-> left expression
+//		|-> [NOT]
+//		|-> NULL
+//
+// or for [NOT] IN
+//
+//	|-> [NOT]
+//		|-> IN
+//			|-> left expression
+//			|-> values...
+//
+// or for [NOT] LIKE, ILIKE and SIMILAR TO
+//
+//	|-> [NOT]
+//		|-> LIKE
+//			|-> left expression
+//			|-> pattern expression
+//			|-> [escape expression]
+//
+// or for [NOT] BETWEEN [SYMMETRIC]
+//
+//	|-> [NOT]
+//		|-> BETWEEN
+//			|-> left expression
+//			|-> low expression
+//			|-> high expression
+//			|-> [SYMMETRIC]
+//
+// or for IS [NOT] DISTINCT FROM
+//
+//	|-> IS
+//		|-> left expression
+//		|-> [NOT]
+//		|-> DISTINCT
+//			|-> right expression
+func (p *parser) parseExpressionCondition(left *Decl) (*Decl, error) {
+	var opDecl *Decl
+	var err error
+
+	switch {
+	case p.is(comparisonOperators...):
+		opDecl, err = p.consumeToken(p.cur().Token)
+		if err != nil {
+			return nil, err
+		}
+	case p.is(ArgToken) && p.cur().Lexeme == "?":
+		// lexed as ODBC placeholder, but in operator position it tests key existence
+		opDecl = &Decl{Token: KeyExistsToken, Lexeme: "?"}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	case p.is(InToken):
+		inDecl, err := p.parseIn()
+		if err != nil {
+			return nil, err
+		}
+		inDecl.Decl = append([]*Decl{left}, inDecl.Decl...)
+		return inDecl, nil
+	case p.is(patternOperators...):
+		return p.parsePatternMatch(left)
+	case p.is(BetweenToken):
+		return p.parseBetween(left)
+	case p.is(NotToken):
+		notDecl, err := p.consumeToken(NotToken)
+		if err != nil {
+			return nil, err
+		}
+		if p.is(patternOperators...) {
+			matchDecl, err := p.parsePatternMatch(left)
+			if err != nil {
+				return nil, err
+			}
+			notDecl.Add(matchDecl)
+			return notDecl, nil
+		}
+		if p.is(BetweenToken) {
+			betweenDecl, err := p.parseBetween(left)
+			if err != nil {
+				return nil, err
+			}
+			notDecl.Add(betweenDecl)
+			return notDecl, nil
+		}
+		inDecl, err := p.parseIn()
+		if err != nil {
+			return nil, err
+		}
+		inDecl.Decl = append([]*Decl{left}, inDecl.Decl...)
+		notDecl.Add(inDecl)
+		return notDecl, nil
+	case p.is(IsToken):
+		isDecl, err := p.consumeToken(IsToken)
+		if err != nil {
+			return nil, err
+		}
+		isDecl.Add(left)
+		if p.is(NotToken) {
+			notDecl, err := p.consumeToken(NotToken)
+			if err != nil {
+				return nil, err
+			}
+			isDecl.Add(notDecl)
+		}
+		if p.is(DistinctToken) {
+			distinctDecl, err := p.consumeToken(DistinctToken)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.consumeToken(FromToken); err != nil {
+				return nil, err
+			}
+			right, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			distinctDecl.Add(right)
+			isDecl.Add(distinctDecl)
+			return isDecl, nil
+		}
+		nullDecl, err := p.consumeToken(NullToken)
+		if err != nil {
+			return nil, err
+		}
+		isDecl.Add(nullDecl)
+		return isDecl, nil
+	default:
+		return nil, p.syntaxError()
+	}
+
+	right, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	opDecl.Add(left)
+	opDecl.Add(right)
+	return opDecl, nil
+}
+
+// parsePatternMatch parses pattern and optional escape character of
+// LIKE pattern [ESCAPE escape], ILIKE pattern [ESCAPE escape] or SIMILAR TO pattern [ESCAPE escape]
+func (p *parser) parsePatternMatch(left *Decl) (*Decl, error) {
+	opDecl, err := p.consumeToken(patternOperators...)
+	if err != nil {
+		return nil, err
+	}
+	if opDecl.Token == SimilarToken {
+		if _, err := p.consumeWord("to", StringToken); err != nil {
+			return nil, err
+		}
+		opDecl.Lexeme = "similar to"
+	}
+	opDecl.Add(left)
+
+	patternDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	opDecl.Add(patternDecl)
+
+	if p.isWord("escape") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		escapeDecl, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		opDecl.Add(escapeDecl)
+	}
+
+	return opDecl, nil
+}
+
+// parseBetween parses bounds of BETWEEN [SYMMETRIC] low AND high
+func (p *parser) parseBetween(left *Decl) (*Decl, error) {
+	betweenDecl, err := p.consumeToken(BetweenToken)
+	if err != nil {
+		return nil, err
+	}
+	betweenDecl.Add(left)
+
+	var symmetricDecl *Decl
+	if p.isWord("symmetric") {
+		symmetricDecl, err = p.consumeWord("symmetric", SymmetricToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lowDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	betweenDecl.Add(lowDecl)
+
+	if _, err := p.consumeToken(AndToken); err != nil {
+		return nil, err
+	}
+
+	highDecl, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	betweenDecl.Add(highDecl)
+
+	if symmetricDecl != nil {
+		betweenDecl.Add(symmetricDecl)
+	}
+	return betweenDecl, nil
+}
+
+// isRow returns true if current token opens a row constructor,
+// a parenthesized list of at least two expressions
+func (p *parser) isRow() bool {
+	if !p.hasNext() || !p.is(BracketOpeningToken) {
+		return false
+	}
+
+	depth := 0
+	for _, t := range p.tokens[p.index:] {
+		switch t.Token {
+		case BracketOpeningToken:
+			depth++
+		case BracketClosingToken:
+			depth--
+			if depth == 0 {
+				return false
+			}
+		case CommaToken:
+			if depth == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseRow parses a row constructor, like (created_at, id), returned as
+//
+//	|-> ROW
+//		|-> expression
+//		|-> expression...
+func (p *parser) parseRow() (*Decl, error) {
+	if _, err := p.consumeToken(BracketOpeningToken); err != nil {
+		return nil, err
+	}
+
+	rowDecl := &Decl{Token: RowToken, Lexeme: "row"}
+	for {
+		d, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		rowDecl.Add(d)
+
+		if !p.is(CommaToken) {
+			break
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+	return rowDecl, nil
+}
+
+// isExpressionCondition returns true if condition starting with left operand
+// cannot be represented as a simple attribute comparison
+func (p *parser) isExpressionCondition(left *Decl) bool {
+	if left.Token != StringToken {
+		return true
+	}
+
+	if p.is(ContainsToken, ContainedToken, OverlapToken) || (p.is(ArgToken) && p.cur().Lexeme == "?") {
+		return true
+	}
+
+	// pattern matching, like email LIKE '%@example.com' or name !~ '^a', and ranges
+	if p.is(patternOperators...) || p.is(RegexMatchToken, RegexIMatchToken, RegexNotMatchToken, RegexNotIMatchToken, BetweenToken) {
+		return true
+	}
+	if p.is(NotToken) && p.hasNext() {
+		for _, op := range append(patternOperators, BetweenToken) {
+			if p.tokens[p.index+1].Token == op {
+				return true
+			}
+		}
+	}
+
+	// IS [NOT] DISTINCT FROM
+	if p.is(IsToken) {
+		for i := p.index + 1; i < len(p.tokens) && i <= p.index+2; i++ {
+			if p.tokens[i].Token == DistinctToken {
+				return true
+			}
+		}
+	}
+
+	// right operand is a function call, ANY/ALL, NULL, an interval, a bracket, a negation, a value followed by an operator
+	// or an attribute of a relation, like payments.user_id = account.id
+	i := p.index + 1
+	if !p.is(comparisonOperators...) || i >= len(p.tokens) {
+		return false
+	}
+	switch p.tokens[i].Token {
+	case AnyToken, AllToken, NullToken, BracketOpeningToken, MinusToken, CaseToken:
+		return true
+	}
+	return p.isFunctionAt(i) || p.isIntervalAt(i) || p.isExpressionValueAt(i) || p.isQualifiedAttributeAt(i)
+}
+
+// isQualifiedAttributeAt returns true if tokens starting at i are an attribute qualified by its relation
+func (p *parser) isQualifiedAttributeAt(i int) bool {
+	return i+2 < len(p.tokens) && p.tokens[i].Token == StringToken && p.tokens[i+1].Token == PeriodToken && p.tokens[i+2].Token == StringToken
+}
+
+// isComputedValue returns true if value starting at current token must be parsed as an expression,
+// like now() + interval '1 day', -price, (price * qty) or CASE WHEN ... END
+func (p *parser) isComputedValue() bool {
+	return p.isFunction() || p.isInterval() || p.isExpressionValue() || p.is(MinusToken, BracketOpeningToken, CaseToken)
+}
+
+// isExpressionValue returns true if value starting at current token is followed by an operator,
+// like tags || 'new' or $1::uuid
+func (p *parser) isExpressionValue() bool {
+	return p.isExpressionValueAt(p.index)
+}
+
+func (p *parser) isExpressionValueAt(i int) bool {
+	if p.tokens[i].Token == SimpleQuoteToken {
+		i += 3
+	} else {
+		i++
+	}
+	if i >= len(p.tokens) {
+		return false
+	}
+	if p.tokens[i].Token == CastToken {
+		return true
+	}
+	for _, op := range expressionOperators {
+		if p.tokens[i].Token == op {
+			return true
+		}
+	}
+	return false
+}
@@ -135,13 +135,17 @@ func (p *parser) parseListElement() (*Decl, error) {
 		return v, nil
 	}
 
+	if p.isComputedValue() {
+		return p.parseExpression()
+	}
+
 	if p.is(SimpleQuoteToken) || p.is(DoubleQuoteToken) {
 		quoted = true
 		p.next()
 	}
 
 	var valueDecl *Decl
-	valueDecl, err := p.consumeToken(FloatToken, StringToken, NumberToken, NullToken, DateToken, NowToken, ArgToken, NamedArgToken, FalseToken)
+	valueDecl, err := p.consumeToken(FloatToken, StringToken, NumberToken, NullToken, DateToken, NowToken, GenRandomUUIDToken, ArgToken, NamedArgToken, FalseToken)
 	if err != nil {
 		return nil, err
 	}
@@ -31,6 +31,57 @@ const (
 	DistinctnessToken
 	PeriodToken
 
+	// JSON operator Token
+
+	ArrowToken
+	DoubleArrowToken
+	HashArrowToken
+	HashDoubleArrowToken
+	ContainsToken
+	ContainedToken
+	KeyExistsToken
+
+	// Array operator Token
+
+	OverlapToken
+	ConcatToken
+	SquareBracketOpeningToken
+	SquareBracketClosingToken
+
+	// Arithmetic operator Token
+
+	PlusToken
+	MinusToken
+	MultiplyToken
+	DivideToken
+	ModuloToken
+
+	// Pattern matching operator Token
+
+	LikeToken
+	ILikeToken
+	SimilarToken
+	RegexMatchToken
+	RegexIMatchToken
+	RegexNotMatchToken
+	RegexNotIMatchToken
+
+	// Type cast operator Token, also assigned by parser to CAST(expression AS type)
+
+	CastToken
+
+	// Range operator Token
+
+	BetweenToken
+
+	// Conditional expression Token
+
+	CaseToken
+	WhenToken
+	ThenToken
+	ElseToken
+	EndToken
+
 	// First order Token
 
 	CreateToken
@@ -41,6 +92,7 @@ const (
 	ExplainToken
 	TruncateToken
 	DropToken
+	AlterToken
 	GrantToken
 	DistinctToken
 
@@ -87,6 +139,11 @@ const (
 	IndexToken
 	CollateToken
 	NocaseToken
+	ReferencesToken
+	GenRandomUUIDToken
+	AnyToken
+	AllToken
+	CheckToken
 
 	// Type Token
 
@@ -102,6 +159,37 @@ const (
 
 	ArgToken
 	NamedArgToken
+
+	// FunctionToken is assigned by parser to identifiers followed by an opening bracket
+	FunctionToken
+	// ArrayToken is assigned by parser to [] following a type name
+	ArrayToken
+	// TypeToken, EnumToken and ValueToken are assigned by parser in CREATE, ALTER and DROP TYPE.
+	// They are not reserved, since "type" or "value" are common column names
+	TypeToken
+	EnumToken
+	ValueToken
+	// DomainToken is assigned by parser in CREATE and DROP DOMAIN
+	DomainToken
+	// IntervalToken is assigned by parser to interval literals, like interval '1 day'
+	IntervalToken
+	// RowToken is assigned by parser to row constructors, like (created_at, id)
+	RowToken
+	// SymmetricToken is assigned by parser in BETWEEN SYMMETRIC, symmetric not being reserved
+	SymmetricToken
+	// GroupToken and HavingToken are assigned by parser to GROUP BY and HAVING clauses.
+	// They are not reserved, since "group" is a common table name
+	GroupToken
+	HavingToken
+	// FilterToken is assigned by parser to FILTER (WHERE condition) following an aggregate function call
+	FilterToken
+	// Window Tokens are assigned by parser to OVER (PARTITION BY ... ORDER BY ... ROWS BETWEEN ...) following a function call
+	OverToken
+	PartitionToken
+	FrameToken
+	FrameBoundToken
+	// UsingToken is assigned by parser to USING (attribute, ...) join condition
+	UsingToken
 )
 
 // Token struct holds token id and it's lexeme
@@ -128,6 +216,24 @@ func (l *lexer) lex(instruction []byte) ([]Token, error) {
 	securityPos := 0
 
 	var matchers []Matcher
+	// JSON and arithmetic operators must be matched before numbers, '-' being part of them
+	matchers = append(matchers, l.genericOperatorMatcher("->>", DoubleArrowToken))
+	matchers = append(matchers, l.genericOperatorMatcher("->", ArrowToken))
+	matchers = append(matchers, l.genericOperatorMatcher("#>>", HashDoubleArrowToken))
+	matchers = append(matchers, l.genericOperatorMatcher("#>", HashArrowToken))
+	matchers = append(matchers, l.genericOperatorMatcher("@>", ContainsToken))
+	matchers = append(matchers, l.genericOperatorMatcher("<@", ContainedToken))
+	matchers = append(matchers, l.genericOperatorMatcher("&&", OverlapToken))
+	matchers = append(matchers, l.genericOperatorMatcher("||", ConcatToken))
+	matchers = append(matchers, l.genericOperatorMatcher("+", PlusToken))
+	matchers = append(matchers, l.genericOperatorMatcher("!~*", RegexNotIMatchToken))
+	matchers = append(matchers, l.genericOperatorMatcher("!~", RegexNotMatchToken))
+	matchers = append(matchers, l.genericOperatorMatcher("~*", RegexIMatchToken))
+	matchers = append(matchers, l.genericOperatorMatcher("~", RegexMatchToken))
+	matchers = append(matchers, l.MatchMinusToken)
+	matchers = append(matchers, l.genericOperatorMatcher("/", DivideToken))
+	matchers = append(matchers, l.genericOperatorMatcher("%", ModuloToken))
+	matchers = append(matchers, l.genericOperatorMatcher("::", CastToken))
 	matchers = append(matchers, l.MatchArgTokenODBC)
 	matchers = append(matchers, l.MatchNamedArgToken)
 	matchers = append(matchers, l.MatchArgToken)
@@ -138,7 +244,9 @@ func (l *lexer) lex(instruction []byte) ([]Token, error) {
 	matchers = append(matchers, l.genericByteMatcher(',', CommaToken))
 	matchers = append(matchers, l.genericByteMatcher('(', BracketOpeningToken))
 	matchers = append(matchers, l.genericByteMatcher(')', BracketClosingToken))
-	matchers = append(matchers, l.genericByteMatcher('*', StarToken))
+	matchers = append(matchers, l.genericByteMatcher('[', SquareBracketOpeningToken))
+	matchers = append(matchers, l.genericByteMatcher(']', SquareBracketClosingToken))
+	matchers = append(matchers, l.MatchStarToken)
 	matchers = append(matchers, l.MatchSimpleQuoteToken)
 	matchers = append(matchers, l.genericByteMatcher('=', EqualityToken))
 	matchers = append(matchers, l.genericStringMatcher("<>", DistinctnessToken))
@@ -158,6 +266,7 @@ func (l *lexer) lex(instruction []byte) ([]Token, error) {
 	matchers = append(matchers, l.genericStringMatcher("delete", DeleteToken))
 	matchers = append(matchers, l.genericStringMatcher("truncate", TruncateToken))
 	matchers = append(matchers, l.genericStringMatcher("drop", DropToken))
+	matchers = append(matchers, l.genericStringMatcher("alter", AlterToken))
 	matchers = append(matchers, l.genericStringMatcher("grant", GrantToken))
 	matchers = append(matchers, l.genericStringMatcher("distinct", DistinctToken))
 	// Second order Matcher
@@ -199,11 +308,25 @@ func (l *lexer) lex(instruction []byte) ([]Token, error) {
 	matchers = append(matchers, l.genericStringMatcher("false", FalseToken))
 	matchers = append(matchers, l.genericStringMatcher("unique", UniqueToken))
 	matchers = append(matchers, l.genericStringMatcher("now()", NowToken))
+	matchers = append(matchers, l.genericStringMatcher("gen_random_uuid()", GenRandomUUIDToken))
 	matchers = append(matchers, l.genericStringMatcher("offset", OffsetToken))
 	matchers = append(matchers, l.genericStringMatcher("index", IndexToken))
 	matchers = append(matchers, l.genericStringMatcher("on", OnToken))
 	matchers = append(matchers, l.genericStringMatcher("collate", CollateToken))
 	matchers = append(matchers, l.genericStringMatcher("nocase", NocaseToken))
+	matchers = append(matchers, l.genericStringMatcher("references", ReferencesToken))
+	matchers = append(matchers, l.genericStringMatcher("any", AnyToken))
+	matchers = append(matchers, l.genericStringMatcher("all", AllToken))
+	matchers = append(matchers, l.genericStringMatcher("check", CheckToken))
+	matchers = append(matchers, l.genericStringMatcher("like", LikeToken))
+	matchers = append(matchers, l.genericStringMatcher("ilike", ILikeToken))
+	matchers = append(matchers, l.genericStringMatcher("similar", SimilarToken))
+	matchers = append(matchers, l.genericStringMatcher("between", BetweenToken))
+	matchers = append(matchers, l.genericStringMatcher("case", CaseToken))
+	matchers = append(matchers, l.genericStringMatcher("when", WhenToken))
+	matchers = append(matchers, l.genericStringMatcher("then", ThenToken))
+	matchers = append(matchers, l.genericStringMatcher("else", ElseToken))
+	matchers = append(matchers, l.genericStringMatcher("end", EndToken))
 	// Type Matcher
 	matchers = append(matchers, l.genericStringMatcher("decimal", DecimalToken))
 	matchers = append(matchers, l.genericStringMatcher("primary", PrimaryToken))
@@ -305,6 +428,53 @@ func (l *lexer) MatchArgToken() bool {
 	return false
 }
 
+// MatchMinusToken matches '-' following an operand, like in now() - interval '1 day',
+// or negating an expression, like in -price. Elsewhere '-' is the sign of a number.
+func (l *lexer) MatchMinusToken() bool {
+	if l.instruction[l.pos] != '-' {
+		return false
+	}
+
+	if !l.followsOperand() && l.pos+1 < l.instructionLen && unicode.IsDigit(rune(l.instruction[l.pos+1])) {
+		return false
+	}
+
+	l.tokens = append(l.tokens, Token{Token: MinusToken, Lexeme: "-"})
+	l.pos++
+	return true
+}
+
+// MatchStarToken matches '*' as a multiplication following an operand, like in price * qty.
+// Elsewhere '*' selects all attributes.
+func (l *lexer) MatchStarToken() bool {
+	if l.instruction[l.pos] != '*' {
+		return false
+	}
+
+	if l.followsOperand() {
+		l.tokens = append(l.tokens, Token{Token: MultiplyToken, Lexeme: "*"})
+	} else {
+		l.tokens = append(l.tokens, Token{Token: StarToken, Lexeme: "*"})
+	}
+	l.pos++
+	return true
+}
+
+// followsOperand returns true if last lexed token, ignoring spaces, ends an operand
+func (l *lexer) followsOperand() bool {
+	for i := len(l.tokens) - 1; i >= 0; i-- {
+		switch l.tokens[i].Token {
+		case SpaceToken:
+			continue
+		case StringToken, NumberToken, FloatToken, BracketClosingToken, SimpleQuoteToken, DoubleQuoteToken,
+			SquareBracketClosingToken, NowToken, CurrentSchemaToken, ArgToken, NamedArgToken, EndToken, TimeToken:
+			return true
+		}
+		return false
+	}
+	return false
+}
+
 func (l *lexer) MatchSpaceToken() bool {
 
 	if unicode.IsSpace(rune(l.instruction[l.pos])) {
@@ -326,6 +496,19 @@ func (l *lexer) genericStringMatcher(str string, token int) Matcher {
 	}
 }
 
+// genericOperatorMatcher matches symbols, which unlike keywords can be followed by anything
+func (l *lexer) genericOperatorMatcher(str string, token int) Matcher {
+	return func() bool {
+		if l.pos+len(str) > l.instructionLen || string(l.instruction[l.pos:l.pos+len(str)]) != str {
+			return false
+		}
+
+		l.tokens = append(l.tokens, Token{Token: token, Lexeme: str})
+		l.pos += len(str)
+		return true
+	}
+}
+
 func (l *lexer) genericByteMatcher(r byte, token int) Matcher {
 	return func() bool {
 		return l.MatchSingle(r, token)
@@ -348,6 +531,10 @@ func (l *lexer) MatchStringToken() bool {
 			unicode.IsDigit(rune(l.instruction[i])) ||
 			l.instruction[i] == '_' ||
 			l.instruction[i] == '@' /* || l.instruction[i] == '.'*/) {
+		// foo@>bar is foo @> bar
+		if l.instruction[i] == '@' && i+1 < l.instructionLen && l.instruction[i+1] == '>' {
+			break
+		}
 		i++
 	}
 
@@ -366,10 +553,7 @@ func (l *lexer) MatchStringToken() bool {
 
 func (l *lexer) MatchFloatToken() bool {
 
-	i := l.pos
-	for i < l.instructionLen && (unicode.IsDigit(rune(l.instruction[i])) || l.instruction[i] == '-') {
-		i++
-	}
+	i := l.matchDigits(l.pos)
 	if i == l.pos || i >= l.instructionLen {
 		return false
 	}
@@ -412,10 +596,7 @@ func (l *lexer) MatchFloatToken() bool {
 
 func (l *lexer) MatchNumberToken() bool {
 
-	i := l.pos
-	for i < l.instructionLen && (unicode.IsDigit(rune(l.instruction[i])) || l.instruction[i] == '-') {
-		i++
-	}
+	i := l.matchDigits(l.pos)
 
 	if i != l.pos {
 		t := Token{
@@ -430,6 +611,23 @@ func (l *lexer) MatchNumberToken() bool {
 	return false
 }
 
+// matchDigits returns the end of an optionally signed integer starting at i,
+// or i if there is none
+func (l *lexer) matchDigits(i int) int {
+	start := i
+	if i < l.instructionLen && l.instruction[i] == '-' {
+		i++
+	}
+	digits := i
+	for i < l.instructionLen && unicode.IsDigit(rune(l.instruction[i])) {
+		i++
+	}
+	if i == digits {
+		return start
+	}
+	return i
+}
+
 // 2015-09-10 14:03:09.444695269 +0200 CEST);
 func (l *lexer) MatchDateToken() bool {
 
@@ -605,7 +803,7 @@ func (l *lexer) MatchSingle(char byte, token int) bool {
 
 func (l *lexer) Match(str []byte, token int) bool {
 
-	if l.pos+len(str)-1 > l.instructionLen {
+	if l.pos+len(str) > l.instructionLen {
 		return false
 	}
 
@@ -644,7 +842,7 @@ func TypeNameFromToken(tk int) string {
 	case TextToken, StringToken:
 		return "text"
 	case FloatToken:
-		return "float"
+		return "decimal"
 	default:
 		return "unknown"
 	}
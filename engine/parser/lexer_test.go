@@ -110,3 +110,43 @@ func Test_lexer_MatchNumberToken(t *testing.T) {
 		})
 	}
 }
+
+func TestLexerWithJSONOperators(t *testing.T) {
+	query := `SELECT data->'a'->>-1 FROM foo WHERE data#>>'{a,b}' = 'x' AND data@>'{}' AND '{}' <@ data`
+
+	lexer := lexer{}
+	decls, err := lexer.lex([]byte(query))
+	if err != nil {
+		t.Fatalf("Cannot lex <%s> string", query)
+	}
+
+	expected := []int{ArrowToken, DoubleArrowToken, HashDoubleArrowToken, ContainsToken, ContainedToken}
+	for _, d := range decls {
+		if len(expected) > 0 && d.Token == expected[0] {
+			expected = expected[1:]
+		}
+	}
+	if len(expected) != 0 {
+		t.Fatalf("Lexing failed, JSON operators not found in %v", decls)
+	}
+}
+
+func TestLexerWithArrayOperators(t *testing.T) {
+	query := `SELECT tags || 'x' FROM foo WHERE tags && '{a}' AND id = ANY($1) AND id <> ALL('{1,2}')`
+
+	lexer := lexer{}
+	decls, err := lexer.lex([]byte(query))
+	if err != nil {
+		t.Fatalf("Cannot lex <%s> string", query)
+	}
+
+	expected := []int{ConcatToken, OverlapToken, AnyToken, AllToken}
+	for _, d := range decls {
+		if len(expected) > 0 && d.Token == expected[0] {
+			expected = expected[1:]
+		}
+	}
+	if len(expected) != 0 {
+		t.Fatalf("Lexing failed, array operators not found in %v", decls)
+	}
+}
@@ -5,6 +5,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/proullon/ramsql/engine/log"
 )
@@ -15,6 +16,8 @@ type parser struct {
 	index    int
 	tokenLen int
 	tokens   []Token
+	// VALUE designates checked value while parsing a domain CHECK condition
+	checkValue bool
 }
 
 // Decl structure is the node to statement declaration tree
@@ -102,7 +105,7 @@ func (p *parser) parse(tokens []Token) ([]Instruction, error) {
 		// Now,
 		// Create a logical tree of all tokens
 		// We start with first order query
-		// CREATE, SELECT, INSERT, UPDATE, DELETE, TRUNCATE, DROP, EXPLAIN
+		// CREATE, SELECT, INSERT, UPDATE, DELETE, TRUNCATE, DROP, ALTER, SET, EXPLAIN
 		switch tokens[p.index].Token {
 		case CreateToken:
 			i, err := p.parseCreate(tokens)
@@ -146,6 +149,18 @@ func (p *parser) parse(tokens []Token) ([]Instruction, error) {
 				return nil, err
 			}
 			p.i = append(p.i, *i)
+		case AlterToken:
+			i, err := p.parseAlter()
+			if err != nil {
+				return nil, err
+			}
+			p.i = append(p.i, *i)
+		case SetToken:
+			i, err := p.parseSet()
+			if err != nil {
+				return nil, err
+			}
+			p.i = append(p.i, *i)
 		case ExplainToken:
 			break
 		case GrantToken:
@@ -204,6 +219,12 @@ func (p *parser) parseUpdate() (*Instruction, error) {
 		gotClause = true
 	}
 
+	// WHERE clause is implicit, updating all rows
+	if !p.is(WhereToken) {
+		addImplicitWhereAll(updateDecl)
+		return i, nil
+	}
+
 	err = p.parseWhere(updateDecl)
 	if err != nil {
 		return nil, err
@@ -213,11 +234,21 @@ func (p *parser) parseUpdate() (*Instruction, error) {
 }
 
 func (p *parser) parseType() (*Decl, error) {
-	typeDecl, err := p.consumeToken(FloatToken, DateToken, DecimalToken, NumberToken, StringToken)
+	typeDecl, err := p.consumeToken(FloatToken, DateToken, TimeToken, DecimalToken, NumberToken, StringToken)
 	if err != nil {
 		return nil, err
 	}
 
+	// Maybe a two words type, like DOUBLE PRECISION or CHARACTER VARYING
+	if typeDecl.Token == StringToken {
+		for _, words := range [][2]string{{"double", "precision"}, {"character", "varying"}} {
+			if strings.EqualFold(typeDecl.Lexeme, words[0]) && p.isWord(words[1]) {
+				typeDecl.Lexeme = words[0] + " " + words[1]
+				p.next()
+			}
+		}
+	}
+
 	// Maybe a complex type
 	if p.is(BracketOpeningToken) {
 		_, err = p.consumeToken(BracketOpeningToken)
@@ -229,12 +260,57 @@ func (p *parser) parseType() (*Decl, error) {
 			return nil, err
 		}
 		typeDecl.Add(sizeDecl)
+		// NUMERIC(precision, scale)
+		if p.is(CommaToken) {
+			_, err = p.consumeToken(CommaToken)
+			if err != nil {
+				return nil, err
+			}
+			scaleDecl, err := p.consumeToken(NumberToken)
+			if err != nil {
+				return nil, err
+			}
+			typeDecl.Add(scaleDecl)
+		}
 		_, err = p.consumeToken(BracketClosingToken)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// Maybe a time zone specification, like TIMESTAMP WITH TIME ZONE or TIME WITHOUT TIME ZONE
+	if typeDecl.Token == TimeToken || strings.EqualFold(typeDecl.Lexeme, "timestamp") {
+		zone := ""
+		if p.is(WithToken) && typeDecl.Token != TimeToken {
+			zone = " with time zone"
+		} else if p.isWord("without") {
+			zone = " without time zone"
+		}
+		if zone != "" {
+			p.next()
+			if _, err = p.consumeToken(TimeToken); err != nil {
+				return nil, err
+			}
+			if _, err = p.consumeToken(ZoneToken); err != nil {
+				return nil, err
+			}
+			typeDecl.Lexeme = strings.ToLower(typeDecl.Lexeme) + zone
+		}
+	}
+
+	// Maybe an array type, like TEXT[]
+	if p.is(SquareBracketOpeningToken) {
+		_, err = p.consumeToken(SquareBracketOpeningToken)
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consumeToken(SquareBracketClosingToken)
+		if err != nil {
+			return nil, err
+		}
+		typeDecl.Add(&Decl{Token: ArrayToken, Lexeme: "[]"})
+	}
+
 	return typeDecl, nil
 }
 
@@ -252,18 +328,33 @@ func (p *parser) parseOrderBy(selectDecl *Decl) error {
 
 	for {
 		// parse attribute now
-		attrDecl, err := p.parseAttribute()
+		attrDecl, err := p.parseExpression()
 		if err != nil {
 			return err
 		}
-		orderDecl.Add(attrDecl)
 
-		if p.is(AscToken, DescToken) {
-			decl, err := p.consumeToken(AscToken, DescToken)
-			if err != nil {
-				return err
+		if attrDecl.Token == StringToken {
+			orderDecl.Add(attrDecl)
+			if p.is(AscToken, DescToken) {
+				decl, err := p.consumeToken(AscToken, DescToken)
+				if err != nil {
+					return err
+				}
+				attrDecl.Add(decl)
+			}
+		} else {
+			// expression is held by its direction, like
+			// |-> DESC
+			//	|-> expression
+			directionDecl := &Decl{Token: AscToken, Lexeme: "asc"}
+			if p.is(AscToken, DescToken) {
+				directionDecl, err = p.consumeToken(AscToken, DescToken)
+				if err != nil {
+					return err
+				}
 			}
-			attrDecl.Add(decl)
+			directionDecl.Add(attrDecl)
+			orderDecl.Add(directionDecl)
 		}
 
 		if !p.is(CommaToken) {
@@ -278,12 +369,11 @@ func (p *parser) parseOrderBy(selectDecl *Decl) error {
 	return nil
 }
 
-// parseBuiltinFunc looks for COUNT,MAX,MIN
+// parseBuiltinFunc looks for COUNT(*), COUNT(expression) or COUNT(DISTINCT expression)
 func (p *parser) parseBuiltinFunc() (*Decl, error) {
 	var d *Decl
 	var err error
 
-	// COUNT(attribute)
 	if p.is(CountToken) {
 		d, err = p.consumeToken(CountToken)
 		if err != nil {
@@ -294,12 +384,17 @@ func (p *parser) parseBuiltinFunc() (*Decl, error) {
 		if err != nil {
 			return nil, err
 		}
-		// Attribute
-		attr, err := p.parseAttribute()
+		// Star or expression
+		var arg *Decl
+		if p.is(StarToken) {
+			arg, err = p.consumeToken(StarToken)
+		} else {
+			arg, err = p.parseAggregateArgument()
+		}
 		if err != nil {
 			return nil, err
 		}
-		d.Add(attr)
+		d.Add(arg)
 		// Bracket
 		_, err = p.consumeToken(BracketClosingToken)
 		if err != nil {
@@ -526,14 +621,20 @@ func (p *parser) parseAttribution() (*Decl, error) {
 	}
 
 	// Value
-	if p.cur().Token == NullToken {
+	if p.isComputedValue() {
+		valueDecl, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		attributeDecl.Add(valueDecl)
+	} else if p.cur().Token == NullToken {
 		nullDecl, err := p.consumeToken(NullToken)
 		if err != nil {
 			return nil, err
 		}
 		attributeDecl.Add(nullDecl)
 	} else {
-		valueDecl, err := p.parseValue()
+		valueDecl, err := p.parseLiteralValue()
 		if err != nil {
 			return nil, err
 		}
@@ -596,7 +697,7 @@ func (p *parser) parseValue() (*Decl, error) {
 		}
 	}
 
-	valueDecl, err := p.consumeToken(FloatToken, StringToken, NumberToken, DateToken, NowToken, CurrentSchemaToken, ArgToken, NamedArgToken)
+	valueDecl, err := p.consumeToken(FloatToken, StringToken, NumberToken, DateToken, NowToken, GenRandomUUIDToken, CurrentSchemaToken, ArgToken, NamedArgToken)
 	if err != nil {
 		return nil, err
 	}
@@ -611,6 +712,20 @@ func (p *parser) parseValue() (*Decl, error) {
 	return valueDecl, nil
 }
 
+// parseLiteralValue parses a value like parseValue, quoted strings being returned as TextToken so that
+// an unquoted identifier, returned as StringToken, can be told apart and name an attribute
+func (p *parser) parseLiteralValue() (*Decl, error) {
+	quoted := p.is(SimpleQuoteToken, DoubleQuoteToken)
+	valueDecl, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if quoted && valueDecl.Token == StringToken {
+		valueDecl.Token = TextToken
+	}
+	return valueDecl, nil
+}
+
 func (p *parser) parseStringLiteral() (*Decl, error) {
 	singleQuoted := p.is(SimpleQuoteToken)
 	_, err := p.consumeToken(SimpleQuoteToken, DoubleQuoteToken)
@@ -631,13 +746,50 @@ func (p *parser) parseStringLiteral() (*Decl, error) {
 	return valueDecl, nil
 }
 
-// parseJoin parses the JOIN keywords and all its condition
+// isJoin returns true if current token starts a join, join kinds not being reserved words, like
+// JOIN, INNER JOIN, LEFT JOIN or FULL OUTER JOIN
+func (p *parser) isJoin() bool {
+	if p.is(JoinToken) {
+		return true
+	}
+	if !p.isWord("inner") && !p.isWord("cross") && !p.isWord("left") && !p.isWord("right") && !p.isWord("full") {
+		return false
+	}
+
+	i := p.index + 1
+	if i < len(p.tokens) && !p.isWord("inner") && !p.isWord("cross") && p.tokens[i].Token == StringToken && strings.EqualFold(p.tokens[i].Lexeme, "outer") {
+		i++
+	}
+	return i < len(p.tokens) && p.tokens[i].Token == JoinToken
+}
+
+// parseJoin parses the JOIN keywords and all its condition, join lexeme being its kind:
+// join, cross join, left join, right join or full join
 // JOIN user_addresses ON address.id=user_addresses.address_id
+// LEFT OUTER JOIN user_addresses ON address.id=user_addresses.address_id AND user_addresses.active = true
+// JOIN user_addresses USING (address_id)
+// CROSS JOIN user_addresses
 func (p *parser) parseJoin() (*Decl, error) {
+	kind := "join"
+	switch {
+	case p.isWord("inner"):
+		p.next()
+	case p.isWord("cross"):
+		kind = "cross join"
+		p.next()
+	case p.isWord("left"), p.isWord("right"), p.isWord("full"):
+		kind = strings.ToLower(p.cur().Lexeme) + " join"
+		p.next()
+		if p.isWord("outer") {
+			p.next()
+		}
+	}
+
 	joinDecl, err := p.consumeToken(JoinToken)
 	if err != nil {
 		return nil, err
 	}
+	joinDecl.Lexeme = kind
 
 	// TABLE NAME
 	tableDecl, err := p.parseAttribute()
@@ -659,14 +811,48 @@ func (p *parser) parseJoin() (*Decl, error) {
 		tableDecl.Add(aliasDecl)
 	}
 
+	// CROSS JOIN has no condition
+	if kind == "cross join" {
+		return joinDecl, nil
+	}
+
+	// USING (attribute, ...)
+	if p.isWord("using") {
+		usingDecl, err := p.parseUsing()
+		if err != nil {
+			return nil, err
+		}
+		joinDecl.Add(usingDecl)
+		return joinDecl, nil
+	}
+
 	// ON
 	onDecl, err := p.consumeToken(OnToken)
 	if err != nil {
 		return nil, err
 	}
-	// onDecl := NewDecl(t)
 	joinDecl.Add(onDecl)
 
+	// any condition, like in WHERE clause
+	if !p.isJoinPivot() {
+		for {
+			condDecl, err := p.parseCondition()
+			if err != nil {
+				return nil, err
+			}
+			onDecl.Add(condDecl)
+
+			if !p.is(AndToken, OrToken) {
+				return joinDecl, nil
+			}
+			linkDecl, err := p.consumeToken(p.cur().Token)
+			if err != nil {
+				return nil, err
+			}
+			onDecl.Add(linkDecl)
+		}
+	}
+
 	// ATTRIBUTE
 	leftAttributeDecl, err := p.parseAttribute()
 	if err != nil {
@@ -691,6 +877,87 @@ func (p *parser) parseJoin() (*Decl, error) {
 	return joinDecl, nil
 }
 
+// isJoinPivot returns true if join condition is a single equality of attributes, like address.id = user_addresses.address_id
+func (p *parser) isJoinPivot() bool {
+	i, ok := p.attributeEnd(p.index)
+	if !ok || i >= len(p.tokens) || p.tokens[i].Token != EqualityToken {
+		return false
+	}
+	i, ok = p.attributeEnd(i + 1)
+	if !ok {
+		return false
+	}
+	if i >= len(p.tokens) {
+		return true
+	}
+
+	switch p.tokens[i].Token {
+	case AndToken, OrToken, CastToken, PeriodToken:
+		return false
+	}
+	for _, op := range expressionOperators {
+		if p.tokens[i].Token == op {
+			return false
+		}
+	}
+	return true
+}
+
+// attributeEnd returns index of token following attribute starting at i, like id, address.id or "address"."id"
+func (p *parser) attributeEnd(i int) (int, bool) {
+	name := func(i int) (int, bool) {
+		if i < len(p.tokens) && p.tokens[i].Token == StringToken {
+			return i + 1, true
+		}
+		if i+2 < len(p.tokens) && (p.tokens[i].Token == DoubleQuoteToken || p.tokens[i].Token == BacktickToken) &&
+			p.tokens[i+1].Token == StringToken && p.tokens[i+2].Token == p.tokens[i].Token {
+			return i + 3, true
+		}
+		return i, false
+	}
+
+	i, ok := name(i)
+	if !ok || i >= len(p.tokens) || p.tokens[i].Token != PeriodToken {
+		return i, ok
+	}
+	return name(i + 1)
+}
+
+// parseUsing parses join condition on attributes with the same name in both relations
+// USING (address_id, user_id)
+func (p *parser) parseUsing() (*Decl, error) {
+	usingDecl, err := p.consumeWord("using", UsingToken)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = p.consumeToken(BracketOpeningToken); err != nil {
+		return nil, err
+	}
+
+	for {
+		attrDecl, err := p.parseAttribute()
+		if err != nil {
+			return nil, err
+		}
+		if len(attrDecl.Decl) > 0 {
+			return nil, fmt.Errorf("USING attribute %s cannot be qualified by its relation", attrDecl.Lexeme)
+		}
+		usingDecl.Add(attrDecl)
+
+		if !p.is(CommaToken) {
+			break
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err = p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+	return usingDecl, nil
+}
+
 func (p *parser) next() error {
 	if !p.hasNext() {
 		return fmt.Errorf("Unexpected end")
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -23,6 +24,295 @@ func TestParserCreateTableWithDefaultClause(t *testing.T) {
 	parse(query, 1, t)
 }
 
+func TestParserCreateTableWithNumeric(t *testing.T) {
+	query := `CREATE TABLE invoice (id BIGSERIAL, amount NUMERIC(12, 2), rate DECIMAL(5,4), total NUMERIC(10), raw NUMERIC)`
+	parse(query, 1, t)
+}
+
+func TestParserEnumType(t *testing.T) {
+	query := `CREATE TYPE mood AS ENUM ('sad', 'ok', 'happy');
+	CREATE TABLE person (id BIGSERIAL, type TEXT, value mood DEFAULT 'ok');
+	ALTER TYPE mood ADD VALUE 'meh' BEFORE 'ok';
+	ALTER TYPE public.mood ADD VALUE IF NOT EXISTS 'ecstatic';
+	DROP TYPE IF EXISTS mood`
+	parse(query, 5, t)
+}
+
+func TestParserDomain(t *testing.T) {
+	query := `CREATE DOMAIN percent AS INT DEFAULT 100 NOT NULL CHECK (VALUE >= 0 AND VALUE <= 100);
+	CREATE DOMAIN public.amount NUMERIC(6, 2) NULL CHECK (VALUE > 0) CHECK (VALUE NOT IN (13, 42));
+	CREATE DOMAIN email TEXT;
+	DROP DOMAIN IF EXISTS email`
+	parse(query, 4, t)
+}
+
+func TestParserTimeZone(t *testing.T) {
+	query := `CREATE TABLE event (at TIMESTAMP WITH TIME ZONE, local_at TIMESTAMP WITHOUT TIME ZONE, day DATE, hour TIME WITHOUT TIME ZONE);
+	SET TIME ZONE 'Europe/Paris';
+	SET SESSION TIME ZONE LOCAL;
+	SET timezone TO DEFAULT;
+	SET timezone = 2`
+	parse(query, 5, t)
+}
+
+func TestParserInterval(t *testing.T) {
+	queries := []string{
+		`CREATE TABLE session (ttl INTERVAL DEFAULT '1 hour', expires_at TIMESTAMP DEFAULT now() + interval '1 day')`,
+		`SELECT extract(epoch FROM ttl), date_trunc('month', expires_at) - interval '1 day' FROM session WHERE expires_at > now() - interval '2 hours'`,
+		`UPDATE session SET ttl = ttl + interval '1 mon' WHERE age(expires_at) < interval '1 year'`,
+		`INSERT INTO session (ttl, expires_at) VALUES (interval '2 days', now()-interval '1 week')`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+}
+
+func TestParserLike(t *testing.T) {
+	queries := []string{
+		`SELECT email FROM account WHERE email LIKE '%@example.com' AND code NOT ILIKE 'ab!_%' ESCAPE '!'`,
+		`SELECT email FROM account WHERE code SIMILAR TO '(AB|CD)-[0-9]+' OR code NOT SIMILAR TO 'x%'`,
+		`SELECT email FROM account WHERE email ~ '^[a-z]+@' AND email !~* 'test' AND email LIKE ANY($1)`,
+		`DELETE FROM account WHERE email LIKE '%' || $1 || '%'`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+}
+
+func TestParserComparison(t *testing.T) {
+	queries := []string{
+		`SELECT name FROM product WHERE price BETWEEN 3 AND 5 AND added_at NOT BETWEEN SYMMETRIC $1 AND now() - interval '1 day'`,
+		`SELECT name FROM product WHERE name IS DISTINCT FROM 'apple' OR price IS NOT DISTINCT FROM NULL`,
+		`SELECT id FROM post WHERE (created_at, id) > ($1, $2) ORDER BY created_at, id LIMIT 10`,
+		`SELECT id FROM post WHERE ((created_at, id) <= ('2024-01-01', 3)) AND rank = NULL`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+}
+
+func TestParserArithmetic(t *testing.T) {
+	queries := []string{
+		`SELECT price * qty, (price + tax) * qty, -price, price/2, price%2 FROM item WHERE price*qty-1 > 10`,
+		`SELECT name FROM item WHERE (price + tax) * qty > 100 AND -price < -5 ORDER BY price * qty DESC, name`,
+		`UPDATE item SET counter = counter + 1, price = -(price * 2)`,
+		`INSERT INTO item (price, qty) VALUES (2*3, (1+2)*2)`,
+		`SELECT COUNT(*) FROM item WHERE (price * qty > 20)`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+
+	// multiplication binds tighter than addition, operators are left associative
+	i := parse(`SELECT 1 + 2 * 3 - 4 FROM item`, 1, t)
+	expr := i[0].Decls[0].Decl[0]
+	if expr.Token != MinusToken || expr.Decl[0].Token != PlusToken || expr.Decl[0].Decl[1].Token != MultiplyToken {
+		i[0].Decls[0].Stringy(0, t.Logf)
+		t.Fatalf("unexpected expression tree")
+	}
+}
+
+func TestParserScalarFunctions(t *testing.T) {
+	queries := []string{
+		`SELECT lower(email), coalesce(nickname, 'none'), round(ratio, 2), random() FROM account WHERE upper(trim(email)) = $1`,
+		`SELECT SUBSTRING(name FROM 2 FOR 3), substring(name FROM 2), substring(name, 2, 3) FROM account`,
+		`SELECT TRIM(BOTH 'x' FROM name), trim(LEADING FROM name), trim(TRAILING name), trim(name, 'x') FROM account`,
+		`UPDATE account SET email = lower(email), score = greatest(score, 0) WHERE nullif(email, '') IS NULL`,
+		`CREATE TABLE account (id INT, code TEXT DEFAULT upper('abc'))`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+}
+
+func TestParserCase(t *testing.T) {
+	queries := []string{
+		`SELECT CASE WHEN status = 'x' THEN 1 ELSE 0 END FROM task`,
+		`SELECT CASE status WHEN 'x' THEN 1 WHEN 'y' THEN 2 END, name FROM task WHERE id = 1`,
+		`SELECT CASE WHEN a > 1 AND (b IS NULL OR NOT c = 2) THEN CASE WHEN d THEN 'd' END END FROM task`,
+		`SELECT name FROM task WHERE CASE WHEN priority > 1 THEN 1 ELSE 0 END = 1 ORDER BY CASE status WHEN 'open' THEN 0 ELSE 1 END`,
+		`UPDATE task SET done = CASE WHEN status = 'closed' THEN true ELSE false END`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+
+	invalid := []string{
+		`SELECT CASE END FROM task`,
+		`SELECT CASE WHEN a = 1 THEN 1 FROM task`,
+		`SELECT CASE ELSE 1 END FROM task`,
+	}
+	for _, q := range invalid {
+		if _, err := ParseInstruction(q); err == nil {
+			t.Fatalf("expected error parsing %s", q)
+		}
+	}
+}
+
+func TestParserCast(t *testing.T) {
+	queries := []string{
+		`SELECT CAST(age AS BIGINT), id::text, $1::uuid, '2024-01-01'::date FROM account`,
+		`SELECT CAST(balance AS NUMERIC(10,2)), created_at::timestamp with time zone, tags::text[] FROM account`,
+		`SELECT -age::int * 2, (age + 1)::text, CAST(name AS CHARACTER VARYING(3)) FROM account WHERE id = $1::uuid`,
+		`INSERT INTO account (id, created_at) VALUES ($1::uuid, '2024-01-01 10:00:00'::timestamp)`,
+		`UPDATE account SET age = CAST($1 AS INT) WHERE created_at::date = $2::date`,
+		`SELECT id FROM account WHERE (data->>'y')::numeric > 1 AND (age * 2)::text = '6'`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+
+	invalid := []string{
+		`SELECT CAST(age) FROM account`,
+		`SELECT CAST(age BIGINT) FROM account`,
+		`SELECT age:: FROM account`,
+	}
+	for _, q := range invalid {
+		if _, err := ParseInstruction(q); err == nil {
+			t.Fatalf("expected error parsing %s", q)
+		}
+	}
+}
+
+func TestParserGroupBy(t *testing.T) {
+	queries := []string{
+		`SELECT user_id, SUM(amount) FROM payments GROUP BY user_id HAVING SUM(amount) > 100`,
+		`SELECT user_id, method, COUNT(*), AVG(amount) FROM payments WHERE amount > 10 GROUP BY user_id, method ORDER BY COUNT(*) DESC LIMIT 10`,
+		`SELECT date_trunc('day', created_at), MIN(amount), MAX(amount) FROM payments GROUP BY date_trunc('day', created_at)`,
+		`SELECT method, COUNT(amount) * 2 FROM payments GROUP BY 1 HAVING COUNT(*) > 1 AND (MAX(amount) < 10 OR method = 'card') OFFSET 1`,
+		`SELECT COUNT(*) FROM payments HAVING COUNT(*) > 1`,
+		`SELECT group.id, COUNT(*) FROM group GROUP BY group.id`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+
+	invalid := []string{
+		`SELECT user_id FROM payments GROUP user_id`,
+		`SELECT user_id FROM payments GROUP BY`,
+		`SELECT user_id FROM payments GROUP BY user_id HAVING SUM(amount) >`,
+	}
+	for _, q := range invalid {
+		if _, err := ParseInstruction(q); err == nil {
+			t.Fatalf("expected error parsing %s", q)
+		}
+	}
+}
+
+func TestParserAggregateDistinctFilter(t *testing.T) {
+	queries := []string{
+		`SELECT COUNT(DISTINCT user_id) FROM payments`,
+		`SELECT user_id, SUM(DISTINCT amount), array_agg(DISTINCT method) FROM payments GROUP BY user_id`,
+		`SELECT COUNT(*) FILTER (WHERE method = 'card'), SUM(amount) FILTER (WHERE amount > 10 AND method <> 'cash') FROM payments`,
+		`SELECT user_id FROM payments GROUP BY user_id HAVING COUNT(DISTINCT method) FILTER (WHERE amount > 10) > 1 ORDER BY user_id`,
+		`SELECT filter FROM payments WHERE filter = 1`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+
+	invalid := []string{
+		`SELECT COUNT(DISTINCT *) FROM payments`,
+		`SELECT COUNT(DISTINCT) FROM payments`,
+		`SELECT COUNT(*) FILTER (WHERE method = 'card' FROM payments`,
+		`SELECT COUNT(*) FILTER (WHERE) FROM payments`,
+	}
+	for _, q := range invalid {
+		if _, err := ParseInstruction(q); err == nil {
+			t.Fatalf("expected error parsing %s", q)
+		}
+	}
+}
+
+func TestParserWindow(t *testing.T) {
+	queries := []string{
+		`SELECT id, ROW_NUMBER() OVER (ORDER BY amount DESC) FROM payments`,
+		`SELECT id, RANK() OVER (PARTITION BY user_id, method ORDER BY amount DESC, id) FROM payments ORDER BY id`,
+		`SELECT id, LAG(amount, 1, 0) OVER (ORDER BY id), LEAD(amount) OVER (ORDER BY id) FROM payments`,
+		`SELECT id, SUM(amount) OVER (ORDER BY id ROWS BETWEEN 2 PRECEDING AND CURRENT ROW) FROM payments`,
+		`SELECT id, FIRST_VALUE(amount) OVER (RANGE BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING) FROM payments`,
+		`SELECT id, COUNT(*) FILTER (WHERE amount > 10) OVER (PARTITION BY user_id ROWS 1 FOLLOWING) FROM payments`,
+		`SELECT id FROM payments ORDER BY ROW_NUMBER() OVER () LIMIT 10`,
+		`SELECT over FROM payments WHERE over = 1`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+
+	invalid := []string{
+		`SELECT ROW_NUMBER() OVER (ORDER amount) FROM payments`,
+		`SELECT ROW_NUMBER() OVER (PARTITION user_id) FROM payments`,
+		`SELECT ROW_NUMBER() OVER (ORDER BY id FROM payments`,
+		`SELECT SUM(amount) OVER (ROWS BETWEEN UNBOUNDED AND CURRENT ROW) FROM payments`,
+		`SELECT SUM(amount) OVER (ROWS BETWEEN 1 PRECEDING CURRENT ROW) FROM payments`,
+		`SELECT SUM(amount) OVER (ROWS CURRENT) FROM payments`,
+	}
+	for _, q := range invalid {
+		if _, err := ParseInstruction(q); err == nil {
+			t.Fatalf("expected error parsing %s", q)
+		}
+	}
+}
+
+func TestParserOuterJoin(t *testing.T) {
+	queries := []string{
+		`SELECT * FROM account INNER JOIN payments ON payments.user_id = account.id`,
+		`SELECT * FROM account LEFT JOIN payments ON payments.user_id = account.id`,
+		`SELECT * FROM account LEFT OUTER JOIN payments ON payments.user_id = account.id WHERE payments.id IS NULL`,
+		`SELECT * FROM account RIGHT JOIN payments ON payments.user_id = account.id`,
+		`SELECT * FROM account FULL OUTER JOIN payments ON payments.user_id = account.id ORDER BY account.id`,
+		`SELECT * FROM account LEFT JOIN payments ON payments.user_id = account.id LEFT JOIN refunds ON refunds.payment_id = payments.id`,
+		`SELECT left, right FROM account WHERE full = 1`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+
+	invalid := []string{
+		`SELECT * FROM account LEFT OUTER payments ON payments.user_id = account.id`,
+		`SELECT * FROM account OUTER JOIN payments ON payments.user_id = account.id`,
+		`SELECT * FROM account INNER payments ON payments.user_id = account.id`,
+		`SELECT * FROM account FULL JOIN payments`,
+	}
+	for _, q := range invalid {
+		if _, err := ParseInstruction(q); err == nil {
+			t.Fatalf("expected error parsing %s", q)
+		}
+	}
+}
+
+func TestParserJoinCondition(t *testing.T) {
+	queries := []string{
+		`SELECT * FROM account JOIN payments ON payments.user_id = account.id AND payments.region = account.region`,
+		`SELECT * FROM account LEFT JOIN payments ON payments.user_id = account.id OR payments.amount > 10 WHERE account.id = 1`,
+		`SELECT * FROM payments JOIN tier ON payments.amount BETWEEN tier.low AND tier.high ORDER BY payments.id`,
+		`SELECT * FROM payments JOIN tier ON payments.amount >= tier.low + 1 JOIN account ON account.id = payments.user_id`,
+		`SELECT * FROM account JOIN payments USING (region)`,
+		`SELECT * FROM account AS a JOIN payments AS p USING (id, region) WHERE a.id = 1`,
+		`SELECT * FROM account CROSS JOIN payments`,
+		`SELECT * FROM account CROSS JOIN payments WHERE account.id = payments.user_id`,
+		`SELECT * FROM account, payments WHERE payments.user_id = account.id AND payments.amount > 10`,
+		`SELECT cross, using FROM account`,
+	}
+	for _, q := range queries {
+		parse(q, 1, t)
+	}
+
+	invalid := []string{
+		`SELECT * FROM account JOIN payments USING region`,
+		`SELECT * FROM account JOIN payments USING (payments.region)`,
+		`SELECT * FROM account JOIN payments USING (region`,
+		`SELECT * FROM account JOIN payments ON payments.user_id = account.id AND`,
+		`SELECT * FROM account CROSS OUTER JOIN payments`,
+		`SELECT * FROM account CROSS payments`,
+	}
+	for _, q := range invalid {
+		if _, err := ParseInstruction(q); err == nil {
+			t.Fatalf("expected error parsing %s", q)
+		}
+	}
+}
+
 func TestParserMultipleInstructions(t *testing.T) {
 	query := `CREATE TABLE account (id INT, email TEXT);CREATE TABLE user (id INT, email TEXT)`
 	parse(query, 2, t)
@@ -315,18 +605,28 @@ func TestReturning(t *testing.T) {
 	}
 }
 
-/*
 func TestForeignKey(t *testing.T) {
 	queries := []string{
 		`CREATE TABLE pokemon (id BIGSERIAL, name TEXT NOT NULL UNIQUE)`,
-		`CREATE TABLE pokemon_spell (id BIGINT, name VARCHAR(255), pokemon_id BIGINT REFERENCE pokemon(id))`,
+		`CREATE TABLE pokemon_spell (id BIGINT, name VARCHAR(255), pokemon_id BIGINT REFERENCES pokemon(id))`,
+		`CREATE TABLE pokemon_spell (id BIGINT, pokemon_id BIGINT NOT NULL REFERENCES public.pokemon)`,
 	}
 
 	for _, q := range queries {
-		parse(q, 1, t)
+		i := parse(q, 1, t)
+		tableD, ok := i[0].Decls[0].Has(TableToken)
+		if !ok {
+			t.Fatalf("expected TableToken")
+		}
+		if !strings.Contains(q, "REFERENCES") {
+			continue
+		}
+		if _, ok := tableD.Decl[len(tableD.Decl)-1].Has(ReferencesToken); !ok {
+			t.Errorf("expected pokemon_id to have References (%d) child", ReferencesToken)
+			tableD.Stringy(0, t.Logf)
+		}
 	}
 }
-*/
 
 func TestSchema(t *testing.T) {
 	queries := []string{
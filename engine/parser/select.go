@@ -47,23 +47,14 @@ func (p *parser) parseSelect(tokens []Token) (*Instruction, error) {
 	}
 
 	for {
-		switch {
-		case p.is(CountToken):
-			attrDecl, err := p.parseBuiltinFunc()
-			if err != nil {
-				return nil, err
-			}
+		attrDecl, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if distinctOpen {
+			distinctDecl.Add(attrDecl)
+		} else {
 			selectDecl.Add(attrDecl)
-		default:
-			attrDecl, err := p.parseAttribute()
-			if err != nil {
-				return nil, err
-			}
-			if distinctOpen {
-				distinctDecl.Add(attrDecl)
-			} else {
-				selectDecl.Add(attrDecl)
-			}
 		}
 
 		switch {
@@ -114,7 +105,7 @@ func (p *parser) parseSelect(tokens []Token) (*Instruction, error) {
 	}
 
 	// JOIN OR ...?
-	for p.is(JoinToken) {
+	for p.isJoin() {
 		joinDecl, err := p.parseJoin()
 		if err != nil {
 			return nil, err
@@ -167,6 +158,26 @@ func (p *parser) parseSelect(tokens []Token) (*Instruction, error) {
 			if err != nil {
 				return nil, err
 			}
+		case StringToken:
+			switch {
+			case p.isGroupBy():
+				if !hazWhereClause {
+					// WHERE clause is implicit
+					addImplicitWhereAll(selectDecl)
+					hazWhereClause = true
+				}
+				err := p.parseGroupBy(selectDecl)
+				if err != nil {
+					return nil, err
+				}
+			case p.hasNext() && p.isWord("having"):
+				err := p.parseHaving(selectDecl)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return i, nil
+			}
 		default:
 			return i, nil
 		}
@@ -207,3 +218,62 @@ func (p *parser) parseForUpdate(decl *Decl) error {
 	decl.Add(d)
 	return nil
 }
+
+// isGroupBy returns true if current token starts a GROUP BY clause, group not being reserved
+func (p *parser) isGroupBy() bool {
+	return p.isWord("group") && p.hasNext() && p.tokens[p.index+1].Token == ByToken
+}
+
+// parseGroupBy parses GROUP BY clause, grouping on attributes or expressions
+//
+//	|-> GROUP
+//		|-> expression
+//		|-> ...
+func (p *parser) parseGroupBy(selectDecl *Decl) error {
+	groupDecl, err := p.consumeWord("group", GroupToken)
+	if err != nil {
+		return err
+	}
+	selectDecl.Add(groupDecl)
+
+	if _, err = p.consumeToken(ByToken); err != nil {
+		return err
+	}
+
+	for {
+		exprDecl, err := p.parseExpression()
+		if err != nil {
+			return err
+		}
+		groupDecl.Add(exprDecl)
+
+		if !p.is(CommaToken) {
+			break
+		}
+		if _, err = p.consumeToken(CommaToken); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseHaving parses HAVING clause, whose condition is evaluated on groups
+//
+//	|-> HAVING
+//		|-> condition
+func (p *parser) parseHaving(selectDecl *Decl) error {
+	havingDecl, err := p.consumeWord("having", HavingToken)
+	if err != nil {
+		return err
+	}
+	selectDecl.Add(havingDecl)
+
+	condDecl, err := p.parseSearchCondition()
+	if err != nil {
+		return err
+	}
+	havingDecl.Add(condDecl)
+
+	return nil
+}
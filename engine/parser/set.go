@@ -0,0 +1,56 @@
+package parser
+
+// parseSet parses a session setting. Only time zone is supported:
+// SET [SESSION] TIME ZONE { 'zone' | offset | LOCAL | DEFAULT }
+// SET timezone { TO | = } { 'zone' | offset | LOCAL | DEFAULT }
+func (p *parser) parseSet() (*Instruction, error) {
+	i := &Instruction{}
+
+	setDecl, err := p.consumeToken(SetToken)
+	if err != nil {
+		return nil, err
+	}
+	i.Decls = append(i.Decls, setDecl)
+
+	if p.isWord("session") {
+		p.next()
+	}
+
+	var zoneDecl *Decl
+	if p.is(TimeToken) {
+		if _, err = p.consumeToken(TimeToken); err != nil {
+			return nil, err
+		}
+		zoneDecl, err = p.consumeToken(ZoneToken)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		zoneDecl, err = p.consumeWord("timezone", ZoneToken)
+		if err != nil {
+			return nil, err
+		}
+		if p.is(EqualityToken) {
+			_, err = p.consumeToken(EqualityToken)
+		} else {
+			_, err = p.consumeWord("to", StringToken)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	setDecl.Add(zoneDecl)
+
+	var valueDecl *Decl
+	if p.is(DefaultToken) {
+		valueDecl, err = p.consumeToken(DefaultToken)
+	} else {
+		valueDecl, err = p.parseValue()
+	}
+	if err != nil {
+		return nil, err
+	}
+	zoneDecl.Add(valueDecl)
+
+	return i, nil
+}
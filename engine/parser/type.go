@@ -0,0 +1,295 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isWord returns true if current token is the non reserved keyword w
+func (p *parser) isWord(w string) bool {
+	return p.is(StringToken) && strings.EqualFold(p.cur().Lexeme, w)
+}
+
+// consumeWord consumes non reserved keyword w, returning it as a decl of given token
+func (p *parser) consumeWord(w string, token int) (*Decl, error) {
+	if !p.isWord(w) {
+		return nil, p.syntaxError()
+	}
+
+	decl := &Decl{Token: token, Lexeme: w}
+	p.next()
+	return decl, nil
+}
+
+// parseTypeName parses a type name of the form
+// mood
+// schema.mood
+func (p *parser) parseTypeName() (*Decl, error) {
+	nameDecl, err := p.parseAttribute()
+	if err != nil {
+		return nil, err
+	}
+	if len(nameDecl.Decl) > 0 {
+		nameDecl.Decl[0].Token = SchemaToken
+	}
+
+	return nameDecl, nil
+}
+
+// parseLabel parses an enum label, which is a single quoted string
+func (p *parser) parseLabel() (*Decl, error) {
+	if !p.is(SimpleQuoteToken) {
+		return nil, p.syntaxError()
+	}
+
+	return p.parseStringLiteral()
+}
+
+// TYPE mood AS ENUM ('sad', 'ok', 'happy')
+//
+// |-> TYPE
+//
+//	|-> mood
+//	|-> ENUM
+//		|-> sad
+//		|-> ok
+//		|-> happy
+func (p *parser) parseCreateType() (*Decl, error) {
+	typeDecl, err := p.consumeWord("type", TypeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	nameDecl, err := p.parseTypeName()
+	if err != nil {
+		return nil, err
+	}
+	typeDecl.Add(nameDecl)
+
+	if _, err := p.consumeToken(AsToken); err != nil {
+		return nil, err
+	}
+	enumDecl, err := p.consumeWord("enum", EnumToken)
+	if err != nil {
+		return nil, fmt.Errorf("only ENUM types can be created: %s", err)
+	}
+	typeDecl.Add(enumDecl)
+
+	if _, err := p.consumeToken(BracketOpeningToken); err != nil {
+		return nil, err
+	}
+	for !p.is(BracketClosingToken) {
+		labelDecl, err := p.parseLabel()
+		if err != nil {
+			return nil, err
+		}
+		enumDecl.Add(labelDecl)
+
+		if p.is(CommaToken) {
+			p.next()
+			continue
+		}
+		if !p.is(BracketClosingToken) {
+			return nil, p.syntaxError()
+		}
+	}
+	if _, err := p.consumeToken(BracketClosingToken); err != nil {
+		return nil, err
+	}
+
+	return typeDecl, nil
+}
+
+// ALTER TYPE mood ADD VALUE [IF NOT EXISTS] 'meh' [BEFORE | AFTER 'ok']
+//
+// |-> ALTER
+//
+//	|-> TYPE
+//		|-> mood
+//		|-> VALUE
+//			|-> IF
+//				|-> NOT
+//					|-> EXISTS
+//			|-> meh
+//			|-> before
+//				|-> ok
+func (p *parser) parseAlter() (*Instruction, error) {
+	i := &Instruction{}
+
+	alterDecl, err := p.consumeToken(AlterToken)
+	if err != nil {
+		return nil, err
+	}
+	i.Decls = append(i.Decls, alterDecl)
+
+	typeDecl, err := p.consumeWord("type", TypeToken)
+	if err != nil {
+		return nil, fmt.Errorf("only ALTER TYPE is supported: %s", err)
+	}
+	alterDecl.Add(typeDecl)
+
+	nameDecl, err := p.parseTypeName()
+	if err != nil {
+		return nil, err
+	}
+	typeDecl.Add(nameDecl)
+
+	if _, err := p.consumeWord("add", StringToken); err != nil {
+		return nil, fmt.Errorf("only ALTER TYPE ... ADD VALUE is supported: %s", err)
+	}
+	valueDecl, err := p.consumeWord("value", ValueToken)
+	if err != nil {
+		return nil, err
+	}
+	typeDecl.Add(valueDecl)
+
+	// Maybe have "IF NOT EXISTS" here
+	if p.is(IfToken) {
+		ifDecl, err := p.consumeToken(IfToken)
+		if err != nil {
+			return nil, err
+		}
+		notDecl, err := p.consumeToken(NotToken)
+		if err != nil {
+			return nil, err
+		}
+		existsDecl, err := p.consumeToken(ExistsToken)
+		if err != nil {
+			return nil, err
+		}
+		notDecl.Add(existsDecl)
+		ifDecl.Add(notDecl)
+		valueDecl.Add(ifDecl)
+	}
+
+	labelDecl, err := p.parseLabel()
+	if err != nil {
+		return nil, err
+	}
+	valueDecl.Add(labelDecl)
+
+	if p.isWord("before") || p.isWord("after") {
+		posDecl := NewDecl(Token{Token: StringToken, Lexeme: strings.ToLower(p.cur().Lexeme)})
+		p.next()
+		neighborDecl, err := p.parseLabel()
+		if err != nil {
+			return nil, err
+		}
+		posDecl.Add(neighborDecl)
+		valueDecl.Add(posDecl)
+	}
+
+	return i, nil
+}
+
+// DOMAIN email AS TEXT [DEFAULT value] [NOT NULL | NULL] [CHECK (condition)]
+//
+// |-> DOMAIN
+//
+//	|-> email
+//	|-> TEXT
+//	|-> DEFAULT
+//		|-> value
+//	|-> NOT
+//		|-> NULL
+//	|-> CHECK
+//		|-> condition
+func (p *parser) parseDomain() (*Decl, error) {
+	domainDecl, err := p.consumeWord("domain", DomainToken)
+	if err != nil {
+		return nil, err
+	}
+
+	nameDecl, err := p.parseTypeName()
+	if err != nil {
+		return nil, err
+	}
+	domainDecl.Add(nameDecl)
+
+	// AS is optional
+	if p.is(AsToken) {
+		p.next()
+	}
+	typeDecl, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	domainDecl.Add(typeDecl)
+
+	// Domain constraints can be listed in any order
+	for p.is(DefaultToken, NotToken, NullToken, CheckToken) {
+		switch p.cur().Token {
+		case DefaultToken:
+			dDecl, err := p.parseDefaultClause()
+			if err != nil {
+				return nil, err
+			}
+			domainDecl.Add(dDecl)
+		case NotToken:
+			notDecl, err := p.consumeToken(NotToken)
+			if err != nil {
+				return nil, err
+			}
+			nullDecl, err := p.consumeToken(NullToken)
+			if err != nil {
+				return nil, err
+			}
+			notDecl.Add(nullDecl)
+			domainDecl.Add(notDecl)
+		case NullToken:
+			p.next()
+		case CheckToken:
+			checkDecl, err := p.parseCheck()
+			if err != nil {
+				return nil, err
+			}
+			domainDecl.Add(checkDecl)
+		}
+		if !p.hasNext() {
+			break
+		}
+	}
+	if p.hasNext() && p.isNot(SemicolonToken) {
+		return nil, p.syntaxError()
+	}
+
+	return domainDecl, nil
+}
+
+// parseCheck parses CHECK (condition), conditions being parsed like WHERE clause
+func (p *parser) parseCheck() (*Decl, error) {
+	checkDecl, err := p.consumeToken(CheckToken)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consumeToken(BracketOpeningToken); err != nil {
+		return nil, err
+	}
+
+	p.checkValue = true
+	defer func() { p.checkValue = false }()
+
+	for {
+		condDecl, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		checkDecl.Add(condDecl)
+
+		if !p.is(AndToken, OrToken) {
+			break
+		}
+		linkDecl, err := p.consumeToken(p.cur().Token)
+		if err != nil {
+			return nil, err
+		}
+		checkDecl.Add(linkDecl)
+	}
+
+	if !p.is(BracketClosingToken) {
+		return nil, p.syntaxError()
+	}
+	p.next()
+
+	return checkDecl, nil
+}
@@ -21,7 +21,7 @@ func (p *parser) parseWhere(selectDecl *Decl) error {
 			break
 		}
 
-		if p.is(OrderToken, LimitToken, ForToken) {
+		if p.is(OrderToken, LimitToken, ForToken) || p.isGroupBy() || (gotClause && p.isWord("having")) {
 			break
 		}
 
@@ -81,7 +81,7 @@ func (p *parser) parseCondition() (*Decl, error) {
 
 	// do we have brackets ?
 	hasBracket := false
-	if p.is(BracketOpeningToken) {
+	if p.is(BracketOpeningToken) && !p.isRow() && !p.isBracketOperand() {
 		_, err := p.consumeToken(BracketOpeningToken)
 		if err != nil {
 			return nil, err
@@ -90,11 +90,24 @@ func (p *parser) parseCondition() (*Decl, error) {
 	}
 
 	// Attribute
-	attributeDecl, err := p.parseAttribute()
+	attributeDecl, err := p.parseExpression()
 	if err != nil {
 		return nil, err
 	}
 
+	if p.isExpressionCondition(attributeDecl) {
+		exprDecl, err := p.parseExpressionCondition(attributeDecl)
+		if err != nil {
+			return nil, err
+		}
+		if hasBracket {
+			if _, err = p.consumeToken(BracketClosingToken); err != nil {
+				return nil, err
+			}
+		}
+		return exprDecl, nil
+	}
+
 	switch p.cur().Token {
 	case EqualityToken, DistinctnessToken, LeftDipleToken, RightDipleToken, LessOrEqualToken, GreaterOrEqualToken:
 		decl, err := p.consumeToken(p.cur().Token)
@@ -150,8 +163,8 @@ func (p *parser) parseCondition() (*Decl, error) {
 		return attributeDecl, nil
 	}
 
-	// Value
-	valueDecl, err := p.parseValue()
+	// Value, or attribute when unquoted
+	valueDecl, err := p.parseLiteralValue()
 	if err != nil {
 		return nil, err
 	}
@@ -165,3 +178,34 @@ func (p *parser) parseCondition() (*Decl, error) {
 
 	return attributeDecl, nil
 }
+
+// isBracketOperand returns true if current opening bracket encloses an operand
+// rather than a condition, like (price + tax) * qty > 100 or (price + tax)::text = '10'
+func (p *parser) isBracketOperand() bool {
+	depth := 0
+	for i := p.index; i < len(p.tokens); i++ {
+		switch p.tokens[i].Token {
+		case BracketOpeningToken:
+			depth++
+		case BracketClosingToken:
+			depth--
+		}
+		if depth > 0 {
+			continue
+		}
+
+		if i+1 >= len(p.tokens) {
+			return false
+		}
+		next := p.tokens[i+1].Token
+		for _, ops := range [][]int{expressionOperators, comparisonOperators, patternOperators} {
+			for _, op := range ops {
+				if next == op {
+					return true
+				}
+			}
+		}
+		return next == IsToken || next == InToken || next == NotToken || next == BetweenToken || next == CastToken
+	}
+	return false
+}